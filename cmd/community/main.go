@@ -1,22 +1,51 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"net"
+	"net/http"
+	"os"
 	"time"
 	"xhyovo.cn/community/cmd/community/routers"
 	"xhyovo.cn/community/pkg/cache"
 	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/delay"
 	"xhyovo.cn/community/pkg/email"
+	"xhyovo.cn/community/pkg/health"
+	"xhyovo.cn/community/pkg/lifecycle"
 	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/metrics"
+	"xhyovo.cn/community/pkg/migrate"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/oss"
 	"xhyovo.cn/community/pkg/utils"
+	services "xhyovo.cn/community/server/service"
 )
 
 func main() {
-
-	log.Init()
+	configPath := flag.String("config", "", "配置文件路径,不指定时只读取 "+config.EnvPrefix+"_ 前缀的环境变量")
+	flag.Parse()
+	if err := config.Init(*configPath); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	appConfig := config.GetInstance()
+	log.Init(appConfig.LogConfig)
+	config.OnChange(func(old, new *config.AppConfig) {
+		if err := log.SetLevel(new.LogConfig.Level); err != nil {
+			log.Warnf("热更新日志级别失败,err: %s", err.Error())
+		}
+		if err := log.SetPackageLevels(new.LogConfig.Levels); err != nil {
+			log.Warnf("热更新包日志级别失败,err: %s", err.Error())
+		}
+		log.Infof("配置已热更新")
+	})
+	constant.Token_TTl = appConfig.JwtConfig.Expire
 	// 设置程序使用中国时区
 	chinaLoc, err := time.LoadLocation("Asia/Shanghai")
 	time.Local = chinaLoc
@@ -24,24 +53,204 @@ func main() {
 		log.Errorf("Error loading China location:", err)
 		return
 	}
+	if flag.Arg(0) == "migrate" {
+		mysql.Init(appConfig.DbConfig)
+		os.Exit(runMigrate(flag.Args()[1:]))
+	}
+
 	r := gin.Default()
+	// *gin.Context 作为 context.Context 传给 service 时使用请求的 ctx,请求取消后 sql 也会取消
+	r.ContextWithFallback = true
 	r.SetFuncMap(utils.GlobalFunc())
-	config.Init()
-	appConfig := config.GetInstance()
-	db := appConfig.DbConfig
-	mysql.Init(db.Username, db.Password, db.Address, db.Database)
-	ossConfig := appConfig.OssConfig
-	oss.Init(ossConfig.Endpoint, ossConfig.AccessKey, ossConfig.SecretKey, ossConfig.Bucket)
-	emailConfig := appConfig.EmailConfig
-	email.Init(emailConfig.Address, emailConfig.Username, emailConfig.Password, emailConfig.Host, emailConfig.PollCount)
-	routers.InitFrontedRouter(r)
-	cache.Init()
-	log.Info("start web")
-	err = r.Run(appConfig.ServerBind)
-	if err != nil {
-		log.Errorln(err)
+	manager := lifecycle.GetInstance()
+	addComponents(manager, appConfig, r)
+	if err = manager.Run(appConfig.ShutdownTimeout); err != nil {
+		log.Errorf("服务异常退出,err: %s", err.Error())
+		os.Exit(1)
 	}
+	log.Info("服务已停止")
 }
+
+// 按依赖顺序注册组件,停止时顺序相反: 先停止接收请求,再等待后台任务,最后关闭连接
+func addComponents(manager *lifecycle.Manager, appConfig *config.AppConfig, r *gin.Engine) {
+	manager.Add(lifecycle.Component{
+		Name: "db",
+		Start: func() error {
+			mysql.Init(appConfig.DbConfig)
+			sqlDB, err := mysql.GetInstance().DB()
+			if err != nil {
+				return err
+			}
+			if err = metrics.RegisterDB(sqlDB); err != nil {
+				return err
+			}
+			health.Register("db", mysql.Ping)
+			if appConfig.DbConfig.AutoMigrate {
+				if _, err := migrate.Up(mysql.GetInstance()); err != nil {
+					return fmt.Errorf("自动迁移失败: %w", err)
+				}
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return mysql.Close()
+		},
+	})
+	manager.Add(lifecycle.Component{
+		Name: "cache",
+		Start: func() error {
+			cache.Init(appConfig.CacheConfig)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return cache.GetInstance().Close()
+		},
+	})
+	manager.Add(lifecycle.Component{
+		Name: "storage",
+		Start: func() error {
+			oss.Init(appConfig.OssConfig)
+			health.Register("storage", func(ctx context.Context) error {
+				return oss.Ping()
+			})
+			return nil
+		},
+	})
+	manager.Add(lifecycle.Component{
+		Name: "email",
+		Start: func() error {
+			emailConfig := appConfig.EmailConfig
+			email.Init(emailConfig.Address, emailConfig.Username, emailConfig.Password, emailConfig.Host, emailConfig.PollCount)
+			health.Register("smtp", email.Ping)
+			return nil
+		},
+		Stop: email.Close,
+	})
+	// 请求和延迟任务中通过 lifecycle.Go 启动的后台任务,在 http、延迟队列停止后等待完成
+	manager.Add(lifecycle.Component{
+		Name: "background",
+		Stop: lifecycle.Wait,
+	})
+	manager.Add(lifecycle.Component{
+		Name: "delay",
+		Start: func() error {
+			delay.Init(mysql.GetInstance())
+			services.InitMeetingTasks(context.Background())
+			services.InitArticleTasks(context.Background())
+			return metrics.RegisterGauge("delay_queue_depth", "延迟队列中待执行的任务数", func() float64 {
+				depth, err := delay.GetInstant().Depth()
+				if err != nil {
+					log.Warnf("查询延迟队列长度失败,err: %s", err.Error())
+				}
+				return float64(depth)
+			})
+		},
+		Stop: func(ctx context.Context) error {
+			return delay.GetInstant().Stop(ctx)
+		},
+	})
+	searchCtx, stopSearch := context.WithCancel(context.Background())
+	manager.Add(lifecycle.Component{
+		Name: "search",
+		Start: func() error {
+			go runSearchIndexer(searchCtx, appConfig.SearchConfig.RebuildInterval)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			stopSearch()
+			return nil
+		},
+	})
+	// 在 http 之后停止,停止时写入剩余的浏览量
+	hotCtx, stopHot := context.WithCancel(context.Background())
+	hotDone := make(chan struct{})
+	manager.Add(lifecycle.Component{
+		Name: "hot",
+		Start: func() error {
+			go func() {
+				defer close(hotDone)
+				runHotRanking(hotCtx, appConfig.HotConfig)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			stopHot()
+			<-hotDone
+			var hotS services.HotService
+			return hotS.FlushViews(ctx)
+		},
+	})
+
+	server := &http.Server{Addr: appConfig.ServerBind, Handler: r}
+	manager.Add(lifecycle.Component{
+		Name: "http",
+		Start: func() error {
+			routers.InitFrontedRouter(r)
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+					log.Errorf("http 服务异常,err: %s", err.Error())
+				}
+			}()
+			log.Infof("start web,listen: %s", server.Addr)
+			return nil
+		},
+		Stop: server.Shutdown,
+	})
+}
+
+// runSearchIndexer 启动时构建搜索索引,之后按间隔全量重建,修正增量更新遗漏的数据
+func runSearchIndexer(ctx context.Context, interval time.Duration) {
+	var searchS services.SearchService
+	if err := searchS.Rebuild(ctx); err != nil {
+		log.Errorf("构建搜索索引失败,err: %s", err.Error())
+	}
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := searchS.Rebuild(ctx); err != nil {
+				log.Errorf("重建搜索索引失败,err: %s", err.Error())
+			}
+		}
+	}
+}
+
+// runHotRanking 定时写入浏览量、重新计算热门排行
+func runHotRanking(ctx context.Context, hotConfig config.HotConfig) {
+	var hotS services.HotService
+	if err := hotS.Refresh(ctx); err != nil {
+		log.Errorf("计算热门排行失败,err: %s", err.Error())
+	}
+	flush := time.NewTicker(hotConfig.FlushInterval)
+	defer flush.Stop()
+	refresh := time.NewTicker(hotConfig.RefreshInterval)
+	defer refresh.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-flush.C:
+			if err := hotS.FlushViews(ctx); err != nil {
+				log.Warnf("写入文章浏览量失败,err: %s", err.Error())
+			}
+		case <-refresh.C:
+			if err := hotS.Refresh(ctx); err != nil {
+				log.Errorf("计算热门排行失败,err: %s", err.Error())
+			}
+		}
+	}
+}
+
 func GetPwd(pwd string) ([]byte, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.DefaultCost)
 	return hash, err
@@ -2,22 +2,44 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/result"
 	services "xhyovo.cn/community/server/service"
 )
 
+// AdminAuth 拥有任意角色的用户才能访问后台,具体接口再通过 Permission 校验权限
 func AdminAuth(ctx *gin.Context) {
 	userId := GetUserId(ctx)
-	var uS services.UserService
-	flag, err := uS.IsAdmin(userId)
+	var roleS services.RoleService
+	flag, err := roleS.IsStaff(ctx, userId)
 	if err != nil {
-		result.Err("判断 admin 失败").Json(ctx)
+		result.Error(errs.Internal.WithMsg("判断权限失败").Wrap(err)).Json(ctx)
 		ctx.Abort()
 		return
 	}
 	if !flag {
-		result.Err("无权限").Json(ctx)
+		result.Error(errs.Forbidden.WithMsg("无权限")).Json(ctx)
 		ctx.Abort()
+		return
 	}
 	ctx.Next()
 }
+
+// Permission 校验当前用户是否拥有权限
+func Permission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var roleS services.RoleService
+		flag, err := roleS.HasPermission(ctx, GetUserId(ctx), permission)
+		if err != nil {
+			result.Error(errs.Internal.WithMsg("判断权限失败").Wrap(err)).Json(ctx)
+			ctx.Abort()
+			return
+		}
+		if !flag {
+			result.Error(errs.Forbidden.WithMsg("无权限")).Json(ctx)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
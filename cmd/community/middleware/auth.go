@@ -1,19 +1,22 @@
 package middleware
 
 import (
-	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/spf13/viper"
 	"time"
+	"xhyovo.cn/community/pkg/config"
 	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/result"
 	services "xhyovo.cn/community/server/service"
 )
 
 const AUTHORIZATION = "Authorization"
 
-var stStringKey = []byte(viper.GetString("jwt.StringKey"))
+// jwt 签名密钥,来自 jwt.secret 配置
+func signingKey() []byte {
+	return []byte(config.GetInstance().JwtConfig.Secret)
+}
 
 type JwtCustomClaims struct {
 	ID   int
@@ -33,12 +36,12 @@ func Auth(ctx *gin.Context) {
 	}
 	claims, err := ParseToken(token)
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		ctx.Abort()
 		return
 	}
 	if claims.ID < 1 {
-		result.Err("id 不正确").Json(ctx)
+		result.Error(errs.TokenInvalid.WithMsg("id 不正确")).Json(ctx)
 		ctx.Abort()
 		return
 	}
@@ -46,17 +49,21 @@ func Auth(ctx *gin.Context) {
 	var blackService = services.BlacklistService{}
 
 	// 判断token 黑名单
-	exist := blackService.ExistToken(token)
+	exist := blackService.ExistToken(ctx, token)
 	if exist {
-		result.Err("你已涉嫌违规社区文化，token 失效，请重新登陆").Json(ctx)
+		result.Error(errs.TokenInvalid.WithMsg("你已涉嫌违规社区文化，token 失效，请重新登陆")).Json(ctx)
 		ctx.Abort()
 		return
 	}
 
-	// 判断用户黑名单
+	// 判断用户黑名单,同时取出用户设置的语言
 	var userService = services.UserService{}
-	if userService.IsBlack(claims.ID) {
-		result.Err("你已涉嫌违规社区文化，已被纳入小黑屋，如误封请联系我：xhyQAQ250").Json(ctx)
+	black, locale := userService.GetAuthState(ctx, claims.ID)
+	if locale != "" && ctx.Query(LocaleQuery) == "" {
+		setLocale(ctx, locale)
+	}
+	if black {
+		result.Error(errs.Banned.WithMsg("你已涉嫌违规社区文化，已被纳入小黑屋，如误封请联系我：xhyQAQ250")).Json(ctx)
 		ctx.Abort()
 		return
 	}
@@ -81,7 +88,7 @@ func GenerateToken(id int, name string) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, iJwtCustomClaims)
 
-	return token.SignedString(stStringKey)
+	return token.SignedString(signingKey())
 }
 
 // ParseToken 解析token
@@ -89,14 +96,14 @@ func ParseToken(tokenStr string) (JwtCustomClaims, error) {
 
 	iJwtCustomClaims := JwtCustomClaims{}
 	if tokenStr == "" {
-		return iJwtCustomClaims, errors.New("token为空")
+		return iJwtCustomClaims, errs.Unauthorized.WithMsg("token为空")
 	}
 	token, err := jwt.ParseWithClaims(tokenStr, &iJwtCustomClaims, func(token *jwt.Token) (interface{}, error) {
-		return stStringKey, nil
+		return signingKey(), nil
 	})
 
 	if err != nil || !token.Valid {
-		err = errors.New("invalid Token")
+		err = errs.TokenInvalid.WithMsg("invalid Token")
 	}
 	return iJwtCustomClaims, err
 }
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/i18n"
+)
+
+const (
+	LocaleQuery  = "lang"
+	LocaleCookie = "locale"
+)
+
+// Locale 协商请求的语言: 请求参数 lang > cookie locale > Accept-Language,登录后 Auth 会使用用户设置的语言
+func Locale(c *gin.Context) {
+	locale := i18n.Normalize(c.Query(LocaleQuery))
+	if locale == "" {
+		cookie, _ := c.Cookie(LocaleCookie)
+		locale = i18n.Normalize(cookie)
+	}
+	if locale == "" {
+		locale = i18n.Negotiate(c.GetHeader("Accept-Language"))
+	}
+	setLocale(c, locale)
+	c.Next()
+}
+
+func setLocale(c *gin.Context, locale string) {
+	c.Header("Content-Language", locale)
+	c.Request = c.Request.WithContext(i18n.NewContext(c.Request.Context(), locale))
+}
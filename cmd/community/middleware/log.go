@@ -26,6 +26,8 @@ func (w responseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
+// OperLogger 记录操作日志,会缓存整个请求体和响应体并写入数据库;
+// 上传下载文件、流式响应和返回密钥的接口需要注册在 group.Use(OperLogger()) 之前
 func OperLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		t := time.Now()
@@ -64,8 +66,9 @@ func OperLogger() gin.HandlerFunc {
 			Platform:      c.GetHeader("sec-ch-ua-platform"),
 			UserAgent:     c.GetHeader("user-agent"),
 			ResponseData:  body,
+			RequestId:     GetRequestId(c),
 		}
 
-		log.InsertOperLog(logs)
+		log.InsertOperLog(c, logs)
 	}
 }
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/metrics"
+)
+
+// Metrics 按路由统计请求耗时和状态码,使用注册的路由而不是实际路径,避免 id 等参数导致指标过多
+func Metrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.HttpDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+		Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	logger "xhyovo.cn/community/pkg/log"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+// 上游传入的请求 id 只接受常见字符,避免日志注入
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestId 生成请求 id 写入响应头,并放入请求的 ctx 中,service 中通过 log.Ctx(ctx) 打印的日志会带上请求 id
+// 需要 gin.Engine 开启 ContextWithFallback,*gin.Context 作为 context.Context 传递时才能取到
+func RequestId(c *gin.Context) {
+	id := c.GetHeader(RequestIdHeader)
+	if !requestIdPattern.MatchString(id) {
+		id = uuid.NewString()
+	}
+	c.Header(RequestIdHeader, id)
+	c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), id))
+	c.Next()
+}
+
+func GetRequestId(c *gin.Context) string {
+	return logger.RequestId(c.Request.Context())
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"xhyovo.cn/community/pkg/migrate"
+	"xhyovo.cn/community/pkg/mysql"
+)
+
+const migrateUsage = `用法: community [-config 配置文件] migrate <command>
+  up        执行所有未执行的迁移
+  down [n]  回滚最近的 n 个迁移,默认 1
+  status    查看迁移执行情况`
+
+// runMigrate 执行 migrate 子命令,返回进程退出码
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	db := mysql.GetInstance()
+	switch args[0] {
+	case "up":
+		count, err := migrate.Up(db)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		fmt.Printf("执行了 %d 个迁移\n", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				fmt.Fprintln(os.Stderr, "回滚数量必须是正整数")
+				return 2
+			}
+			steps = n
+		}
+		count, err := migrate.Down(db, steps)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		fmt.Printf("回滚了 %d 个迁移\n", count)
+	case "status":
+		status, err := migrate.GetStatus(db)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		for _, s := range status {
+			appliedAt := "未执行"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s  %s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
@@ -1,12 +1,16 @@
 package backend
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"strconv"
+	"time"
 	"xhyovo.cn/community/cmd/community/middleware"
 	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
 	"xhyovo.cn/community/pkg/utils/page"
 	"xhyovo.cn/community/server/request"
 	services "xhyovo.cn/community/server/service"
@@ -14,32 +18,53 @@ import (
 
 func InitArticleRouters(r *gin.Engine) {
 	group := r.Group("/community/admin/article")
-	group.GET("/page", listArticles)
-	group.GET("/states", listStates)
+	group.GET("/page", middleware.Permission(constant.PermArticleView), listArticles)
+	group.GET("/states", middleware.Permission(constant.PermArticleView), listStates)
+	// 历史版本包括已删除文章的版本
+	group.GET("/:id/revisions", middleware.Permission(constant.PermArticleView), listArticleRevisions)
+	group.GET("/:id/revisions/:version", middleware.Permission(constant.PermArticleView), getArticleRevision)
+	group.GET("/:id/diff", middleware.Permission(constant.PermArticleView), diffArticleRevisions)
+	group.GET("/export", middleware.Permission(constant.PermArticleExport), exportArticles)
 	group.Use(middleware.OperLogger())
-	group.DELETE("/:id", deleteArticle)
-	group.POST("/state", articleState)
-	group.POST("/topNumber", updateTopNumber)
+	group.DELETE("/:id", middleware.Permission(constant.PermArticleDelete), deleteArticle)
+	group.POST("/state", middleware.Permission(constant.PermArticleState), articleState)
+	group.POST("/topNumber", middleware.Permission(constant.PermArticleState), updateTopNumber)
+}
+
+// 导出文章为 zip,可以按用户、分类筛选,都不传时导出所有文章
+func exportArticles(ctx *gin.Context) {
+	userId, _ := strconv.Atoi(ctx.Query("userId"))
+	typeId, _ := strconv.Atoi(ctx.Query("typeId"))
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=articles-%s.zip", time.Now().Format("20060102150405")))
+	var exportS services.ExportService
+	count, err := exportS.Export(ctx, ctx.Writer, services.ExportOptions{UserId: userId, TypeId: typeId})
+	if err != nil {
+		// 已经开始写入响应,只能记录日志
+		log.Ctx(ctx).Warnf("导出文章失败,用户id: %d,分类id: %d,err: %s", userId, typeId, err.Error())
+		return
+	}
+	log.Ctx(ctx).Infof("用户id: %d 导出文章,用户id: %d,分类id: %d,文章数: %d", middleware.GetUserId(ctx), userId, typeId, count)
 }
 
 func listArticles(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	var a services.ArticleService
-	articles, count := a.PageArticles(p, limit)
+	articles, count := a.PageArticles(ctx, p, limit)
 	result.Page(articles, count, nil).Json(ctx)
 }
 
 func deleteArticle(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		log.Warnf("删除文章时参数解析失败,err: %s", err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("删除文章时参数解析失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	var a services.ArticleService
-	if err := a.Delete(id); err != nil {
-		log.Warnf("删除文章失败,err: %s", err.Error())
-		result.Err(err.Error()).Json(ctx)
+	if err := a.Delete(ctx, id); err != nil {
+		log.Ctx(ctx).Warnf("删除文章失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
@@ -48,31 +73,69 @@ func deleteArticle(ctx *gin.Context) {
 func articleState(ctx *gin.Context) {
 	var topArticle request.TopArticle
 	if err := ctx.ShouldBindJSON(&topArticle); err != nil {
-		log.Warnf("修改文章状态时参数解析失败,err: %s", err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("修改文章状态时参数解析失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	var a services.ArticleService
-	if err := a.UpdateArticleState(topArticle); err != nil {
-		log.Warnf("修改文章状态失败,err: %s", err.Error())
-		result.Err(err.Error()).Json(ctx)
+	if err := a.UpdateArticleState(ctx, topArticle); err != nil {
+		log.Ctx(ctx).Warnf("修改文章状态失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "修改成功").Json(ctx)
 }
 
 func listStates(ctx *gin.Context) {
-	result.Ok(constant.ListState(), "").Json(ctx)
+	result.Ok(constant.ListState(ctx), "").Json(ctx)
 }
 
 func updateTopNumber(ctx *gin.Context) {
 	var topArticle request.TopArticle
 	if err := ctx.ShouldBindJSON(&topArticle); err != nil {
-		log.Warnf("修改文章置顶失败,err: %s", err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("修改文章置顶失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	var a services.ArticleService
-	a.UpdateTopNumber(topArticle)
+	a.UpdateTopNumber(ctx, topArticle)
 	result.OkWithMsg(nil, "修改成功").Json(ctx)
 }
+
+func listArticleRevisions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("文章id错误")).Json(ctx)
+		return
+	}
+	p, limit := page.GetPage(ctx)
+	var r services.ArticleRevisionService
+	revisions, count := r.PageRevisions(ctx, id, p, limit)
+	result.Page(revisions, count, nil).Json(ctx)
+}
+
+func getArticleRevision(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("文章id错误")).Json(ctx)
+		return
+	}
+	version, _ := strconv.Atoi(ctx.Param("version"))
+	var r services.ArticleRevisionService
+	result.Auto(r.GetRevision(ctx, id, version)).Json(ctx)
+}
+
+func diffArticleRevisions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("文章id错误")).Json(ctx)
+		return
+	}
+	var req request.ReqRevisionDiff
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	var r services.ArticleRevisionService
+	result.Auto(r.Diff(ctx, id, req.From, req.To)).Json(ctx)
+}
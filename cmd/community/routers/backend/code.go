@@ -4,6 +4,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"strconv"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils"
@@ -13,7 +15,7 @@ import (
 )
 
 func InitCodeRouters(r *gin.Engine) {
-	group := r.Group("/community/admin/code")
+	group := r.Group("/community/admin/code", middleware.Permission(constant.PermCodeManage))
 	group.GET("", listCode)
 	group.POST("/generate", generate, middleware.OperLogger())
 	group.DELETE("/:code", deleteCode, middleware.OperLogger())
@@ -23,7 +25,7 @@ func listCode(ctx *gin.Context) {
 	var c services.CodeService
 	p, limit := page.GetPage(ctx)
 	code := ctx.Query("code")
-	codes, count := c.PageCodes(p, limit, code)
+	codes, count := c.PageCodes(ctx, p, limit, code)
 	result.Page(codes, count, nil).Json(ctx)
 }
 
@@ -31,14 +33,14 @@ func generate(ctx *gin.Context) {
 	var c services.CodeService
 	var v model.GenerateCode
 	if err := ctx.ShouldBindJSON(&v); err != nil {
-		log.Warnf("用户id: %d 生成邀请码解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(utils.GetValidateErr(v, err)).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 生成邀请码解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(utils.ValidateErr(v, err)).Json(ctx)
 		return
 	}
 	v.Creator = middleware.GetUserId(ctx)
-	if err := c.GenerateCode(v); err != nil {
-		log.Warn("用户id: %d 生成邀请码失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+	if err := c.GenerateCode(ctx, v); err != nil {
+		log.Ctx(ctx).Warn("用户id: %d 生成邀请码失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "生成成功").Json(ctx)
@@ -48,17 +50,17 @@ func deleteCode(ctx *gin.Context) {
 	code := ctx.Param("code")
 
 	if code == "" {
-		log.Warnf("用户id: %d 删除邀请码不存在: %s", middleware.GetUserId(ctx), code)
-		result.Err("删除的code不存在").Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 删除邀请码不存在: %s", middleware.GetUserId(ctx), code)
+		result.Error(errs.NotFound.WithMsg("删除的code不存在")).Json(ctx)
 		return
 	}
 
 	var c services.CodeService
 
 	code1, _ := strconv.Atoi(code)
-	if err := c.DestroyCode(code1); err != nil {
-		log.Warnf("用户id: %d 删除邀请码失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+	if err := c.DestroyCode(ctx, code1); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 删除邀请码失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
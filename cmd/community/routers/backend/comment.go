@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"strconv"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils/page"
@@ -12,14 +13,14 @@ import (
 
 func InitCommentRouters(r *gin.Engine) {
 	group := r.Group("/community/admin/comment")
-	group.GET("", listComment)
-	group.DELETE("/:id", deleteComment)
+	group.GET("", middleware.Permission(constant.PermCommentView), listComment)
+	group.DELETE("/:id", middleware.Permission(constant.PermCommentDelete), deleteComment)
 }
 
 func listComment(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	var c services.CommentsService
-	comments, count := c.PageComment(p, limit)
+	comments, count := c.PageComment(ctx, p, limit)
 	result.Page(comments, count, nil).Json(ctx)
 }
 
@@ -28,8 +29,8 @@ func deleteComment(ctx *gin.Context) {
 	id, _ := strconv.Atoi(ctx.Param("id"))
 
 	var c services.CommentsService
-	if !c.DeleteComment(id, 0) {
-		log.Warnf("用户id: %d 删除评论失败", middleware.GetUserId(ctx))
+	if !c.DeleteComment(ctx, id, 0) {
+		log.Ctx(ctx).Warnf("用户id: %d 删除评论失败", middleware.GetUserId(ctx))
 		result.Err("删除失败").Json(ctx)
 		return
 	}
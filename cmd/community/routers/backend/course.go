@@ -4,6 +4,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"strconv"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils"
@@ -14,7 +16,7 @@ import (
 var courseService services.CourseService
 
 func InitCourseRouters(r *gin.Engine) {
-	group := r.Group("/community/admin/courses")
+	group := r.Group("/community/admin/courses", middleware.Permission(constant.PermCourseManage))
 	group.GET("/tree", ListCourseTree)
 	group.GET("/map", ListCourseTitle)
 	group.POST("", PublishCourse)
@@ -26,14 +28,14 @@ func InitCourseRouters(r *gin.Engine) {
 func PublishCourse(ctx *gin.Context) {
 	var course model.Courses
 	if err := ctx.ShouldBindJSON(&course); err != nil {
-		msg := utils.GetValidateErr(course, err)
-		log.Warnf("发布课程时参数解析失败,err: %s", msg)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(course, err)
+		log.Ctx(ctx).Warnf("发布课程时参数解析失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	userId := middleware.GetUserId(ctx)
 	course.UserId = userId
-	courseService.Publish(course)
+	courseService.Publish(ctx, course)
 	result.OkWithMsg(nil, "发布成功").Json(ctx)
 }
 
@@ -42,11 +44,11 @@ func DeleteCourse(ctx *gin.Context) {
 	courseId, err := strconv.Atoi(ctx.Param("id"))
 	userId := middleware.GetUserId(ctx)
 	if err != nil {
-		log.Warnf("用户: %d ,删除课程失败,err: %s", userId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户: %d ,删除课程失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
-	courseService.DeleteCourse(courseId)
+	courseService.DeleteCourse(ctx, courseId)
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
 }
 
@@ -56,15 +58,15 @@ func PublishSection(ctx *gin.Context) {
 	userId := middleware.GetUserId(ctx)
 
 	if err := ctx.ShouldBindJSON(&sections); err != nil {
-		msg := utils.GetValidateErr(sections, err)
-		log.Warnf("用户: % d ,发布章节时参数解析失败,err: %s", userId, msg)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(sections, err)
+		log.Ctx(ctx).Warnf("用户: % d ,发布章节时参数解析失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	sections.UserId = userId
-	if err := courseService.PublishSection(sections); err != nil {
-		log.Warnf("用户: %d 发布章节时对应文章不存在,课程 id : %s", userId, sections.CourseId)
-		result.Err("对应课程不存在").Json(ctx)
+	if err := courseService.PublishSection(ctx, sections); err != nil {
+		log.Ctx(ctx).Warnf("用户: %d 发布章节时对应文章不存在,课程 id : %s", userId, sections.CourseId)
+		result.Error(errs.NotFound.WithMsg("对应课程不存在")).Json(ctx)
 		return
 	}
 
@@ -76,21 +78,21 @@ func DeleteCourseSection(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	userId := middleware.GetUserId(ctx)
 	if err != nil {
-		log.Warnf("用户: %d,删除课程失败,err: %s", userId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户: %d,删除课程失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
-	courseService.DeleteCourseSection(id)
+	courseService.DeleteCourseSection(ctx, id)
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
 }
 
 // 获取所有的课程以及章节为树形
 func ListCourseTree(ctx *gin.Context) {
 
-	result.Ok(courseService.ListCourseTree(), "").Json(ctx)
+	result.Ok(courseService.ListCourseTree(ctx), "").Json(ctx)
 }
 
 func ListCourseTitle(ctx *gin.Context) {
 
-	result.Ok(courseService.ListCourseTitle(), "").Json(ctx)
+	result.Ok(courseService.ListCourseTitle(ctx), "").Json(ctx)
 }
@@ -2,6 +2,8 @@ package backend
 
 import (
 	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/server/model"
 	services "xhyovo.cn/community/server/service"
@@ -14,28 +16,28 @@ type Dashboard struct {
 }
 
 func InitDashboardRouters(r *gin.Engine) {
-	group := r.Group("/community/admin/dashboard")
+	group := r.Group("/community/admin/dashboard", middleware.Permission(constant.PermDashboardView))
 	group.GET("", dashboard)
 }
 func dashboard(ctx *gin.Context) {
 	// 查出用户数量
 
 	var codes []model.InviteCodes
-	model.InviteCode().Where("state = 1").Select("id", "member_id").Find(&codes)
+	model.InviteCode(ctx).Where("state = ?", true).Select("id", "member_id").Find(&codes)
 	var userCount int64
 
-	model.User().Count(&userCount)
+	model.User(ctx).Count(&userCount)
 
 	// 查出文章数量
 	var articleCount int64
-	model.Article().Count(&articleCount)
+	model.Article(ctx).Count(&articleCount)
 	// 查出盈利
 	var orderService = services.OrderServices{}
 
 	d := Dashboard{
 		UserCount:    userCount,
 		ArticleCount: articleCount,
-		Profit:       orderService.CalculateProfit(),
+		Profit:       orderService.CalculateProfit(ctx),
 	}
 	result.Ok(d, "").Json(ctx)
 }
@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/delay"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils/page"
+)
+
+func InitDelayJobRouters(r *gin.Engine) {
+	group := r.Group("/community/admin/delay/job", middleware.Permission(constant.PermDelayJob))
+	group.GET("", listDelayJobs)
+	group.GET("/history", listDelayJobHistories)
+	group.DELETE("", cancelDelayJob)
+}
+
+// 待执行的延迟任务
+func listDelayJobs(ctx *gin.Context) {
+	p, limit := page.GetPage(ctx)
+	jobs, count, err := delay.GetInstant().Page(p, limit)
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Page(jobs, count, nil).Json(ctx)
+}
+
+// 延迟任务执行记录,可按 key 过滤
+func listDelayJobHistories(ctx *gin.Context) {
+	p, limit := page.GetPage(ctx)
+	histories, count, err := delay.GetInstant().PageHistory(ctx.Query("key"), p, limit)
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Page(histories, count, nil).Json(ctx)
+}
+
+func cancelDelayJob(ctx *gin.Context) {
+	key := ctx.Query("key")
+	if key == "" {
+		result.Error(errs.BadRequest.WithMsg("key 不能为空")).Json(ctx)
+		return
+	}
+	if err := delay.GetInstant().Cancel(key); err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "取消成功").Json(ctx)
+}
@@ -2,20 +2,22 @@ package backend
 
 import (
 	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils/page"
 	services "xhyovo.cn/community/server/service"
 )
 
 func InitFileRouters(r *gin.Engine) {
-	group := r.Group("/community/admin/file")
+	group := r.Group("/community/admin/file", middleware.Permission(constant.PermFileView))
 	group.GET("", listFiles)
 }
 
 func listFiles(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	var fileS services.FileService
-	files, count := fileS.PageFiles(p, limit, 0)
+	files, count := fileS.PageFiles(ctx, p, limit, 0)
 
 	result.Page(files, count, nil).Json(ctx)
 }
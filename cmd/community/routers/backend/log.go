@@ -2,9 +2,14 @@ package backend
 
 import (
 	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils/page"
 	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
 	services "xhyovo.cn/community/server/service"
 )
 
@@ -12,24 +17,55 @@ var logsS services.LogServices
 
 func InitLogRouters(r *gin.Engine) {
 	group := r.Group("/community/admin")
-	group.GET("/oper/log", listOperLogs)
-	group.GET("/login/log", listLoginLogs)
-	group.GET("/file/log", listFileLogs)
+	group.GET("/oper/log", middleware.Permission(constant.PermLogView), listOperLogs)
+	group.GET("/login/log", middleware.Permission(constant.PermLogView), listLoginLogs)
+	group.GET("/file/log", middleware.Permission(constant.PermLogView), listFileLogs)
+	group.GET("/log/level", middleware.Permission(constant.PermLogLevel), getLogLevel)
+	group.PUT("/log/level", middleware.Permission(constant.PermLogLevel), setLogLevel)
+}
+
+// 当前的日志级别
+func getLogLevel(ctx *gin.Context) {
+	result.Ok(map[string]interface{}{
+		"level":  log.GetLevel(),
+		"levels": log.GetPackageLevels(),
+	}, "").Json(ctx)
+}
+
+// 运行时修改日志级别,重启或配置文件热更新后恢复为配置中的级别
+func setLogLevel(ctx *gin.Context) {
+	var req request.ReqLogLevel
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	var err error
+	if req.Package == "" {
+		err = log.SetLevel(req.Level)
+	} else {
+		err = log.SetPackageLevel(req.Package, req.Level)
+	}
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	log.Ctx(ctx).Infof("日志级别已修改,package: %s,level: %s", req.Package, req.Level)
+	getLogLevel(ctx)
 }
 
 func listOperLogs(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	logSearch := model.LogSearch{}
 	if err := ctx.ShouldBindQuery(&logSearch); err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	if (logSearch.StartTime != "" && logSearch.EndTime == "") || (logSearch.StartTime == "" && logSearch.EndTime != "") {
-		result.Err("选择范围时间，开始时间和结束时间必须同时有值").Json(ctx)
+		result.Error(errs.BadRequest.WithMsg("选择范围时间，开始时间和结束时间必须同时有值")).Json(ctx)
 		return
 	}
 
-	logs, count := logsS.GetPageOperLog(p, limit, logSearch, true)
+	logs, count := logsS.GetPageOperLog(ctx, p, limit, logSearch, true)
 	result.Page(logs, count, nil).Json(ctx)
 }
 
@@ -37,14 +73,14 @@ func listLoginLogs(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	logSearch := model.LogSearch{}
 	if err := ctx.ShouldBindQuery(&logSearch); err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	if (logSearch.StartTime != "" && logSearch.EndTime == "") || (logSearch.StartTime == "" && logSearch.EndTime != "") {
-		result.Err("选择范围时间，开始时间和结束时间必须同时有值").Json(ctx)
+		result.Error(errs.BadRequest.WithMsg("选择范围时间，开始时间和结束时间必须同时有值")).Json(ctx)
 		return
 	}
-	logs, count := logsS.GetPageLoginPage(p, limit, logSearch)
+	logs, count := logsS.GetPageLoginPage(ctx, p, limit, logSearch)
 	result.Page(logs, count, nil).Json(ctx)
 	return
 }
@@ -53,14 +89,14 @@ func listFileLogs(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	logSearch := model.LogSearch{}
 	if err := ctx.ShouldBindQuery(&logSearch); err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	if (logSearch.StartTime != "" && logSearch.EndTime == "") || (logSearch.StartTime == "" && logSearch.EndTime != "") {
-		result.Err("选择范围时间，开始时间和结束时间必须同时有值").Json(ctx)
+		result.Error(errs.BadRequest.WithMsg("选择范围时间，开始时间和结束时间必须同时有值")).Json(ctx)
 		return
 	}
-	logs, count := logsS.GetPageOperLog(p, limit, logSearch, false)
+	logs, count := logsS.GetPageOperLog(ctx, p, limit, logSearch, false)
 	result.Page(logs, count, nil).Json(ctx)
 	return
 }
@@ -4,6 +4,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"strconv"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/i18n"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils"
 	"xhyovo.cn/community/server/request"
@@ -13,24 +15,24 @@ import (
 func InitMeetingRouters(r *gin.Engine) {
 	group := r.Group("/community/admin/meeting")
 	group.Use(middleware.OperLogger())
-	group.POST("/approve", approve)
-	group.POST("/pass", pass)
-	group.POST("/record", record)
-	group.DELETE("/:id", deleteMeeting)
-	group.POST("/sendMsgToJoinMeeting", sendMsg)
+	group.POST("/approve", middleware.Permission(constant.PermMeetingApprove), approve)
+	group.POST("/pass", middleware.Permission(constant.PermMeetingApprove), pass)
+	group.POST("/record", middleware.Permission(constant.PermMeetingManage), record)
+	group.DELETE("/:id", middleware.Permission(constant.PermMeetingManage), deleteMeeting)
+	group.POST("/sendMsgToJoinMeeting", middleware.Permission(constant.PermMeetingManage), sendMsg)
 }
 
 func approve(ctx *gin.Context) {
 	var reqProveMeeting request.ReqApproveMeeting
 	if err := ctx.ShouldBindJSON(&reqProveMeeting); err != nil {
-		msg := utils.GetValidateErr(reqProveMeeting, err)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(reqProveMeeting, err)
+		result.Error(err).Json(ctx)
 		return
 	}
 
 	var meetingService services.MeetingService
-	if err := meetingService.Approve(reqProveMeeting); err != nil {
-		result.Err(err.Error()).Json(ctx)
+	if err := meetingService.Approve(ctx, reqProveMeeting); err != nil {
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "审核通过").Json(ctx)
@@ -39,13 +41,13 @@ func approve(ctx *gin.Context) {
 func pass(ctx *gin.Context) {
 	var reqPassMeeting request.ReqPassMeeting
 	if err := ctx.ShouldBindJSON(&reqPassMeeting); err != nil {
-		msg := utils.GetValidateErr(reqPassMeeting, err)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(reqPassMeeting, err)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
-	if err := meetingService.Pass(reqPassMeeting); err != nil {
-		result.Err(err.Error()).Json(ctx)
+	if err := meetingService.Pass(ctx, reqPassMeeting); err != nil {
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "已PASS").Json(ctx)
@@ -54,13 +56,13 @@ func pass(ctx *gin.Context) {
 func record(ctx *gin.Context) {
 	var reqRecordMeeting request.ReqRecordMeeting
 	if err := ctx.ShouldBindJSON(&reqRecordMeeting); err != nil {
-		msg := utils.GetValidateErr(&reqRecordMeeting, err)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(&reqRecordMeeting, err)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
-	if err := meetingService.Record(reqRecordMeeting); err != nil {
-		result.Err(err.Error()).Json(ctx)
+	if err := meetingService.Record(ctx, reqRecordMeeting); err != nil {
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "已记录").Json(ctx)
@@ -69,12 +71,12 @@ func record(ctx *gin.Context) {
 func deleteMeeting(ctx *gin.Context) {
 	idInt, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
-	if err = meetingService.DeleteById(idInt, 0); err != nil {
-		result.Err(err.Error()).Json(ctx)
+	if err = meetingService.DeleteById(ctx, idInt, 0); err != nil {
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
@@ -89,11 +91,11 @@ func sendMsg(ctx *gin.Context) {
 	}
 	meetingMsgObject := meetingMsg{}
 	if err := ctx.ShouldBindJSON(&meetingMsgObject); err != nil {
-		msg := utils.GetValidateErr(&meetingMsgObject, err)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(&meetingMsgObject, err)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
-	meetingService.SendMsgToJoinMeeting(meetingMsgObject.Id, meetingMsgObject.MsgContent)
+	meetingService.SendMsgToJoinMeeting(ctx, meetingMsgObject.Id, i18n.Raw(meetingMsgObject.MsgContent))
 	result.OkWithMsg(nil, "发送成功").Json(ctx)
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"strconv"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils/page"
@@ -12,7 +13,7 @@ import (
 )
 
 func InitMemberRouters(r *gin.Engine) {
-	group := r.Group("/community/admin/member")
+	group := r.Group("/community/admin/member", middleware.Permission(constant.PermMemberManage))
 	group.GET("", listMembers)
 	group.POST("", saveMember, middleware.OperLogger())
 	group.DELETE("/:id", deleteMember, middleware.OperLogger())
@@ -21,7 +22,7 @@ func InitMemberRouters(r *gin.Engine) {
 func listMembers(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	var m services.MemberInfoService
-	members, count := m.ListMembers(p, limit)
+	members, count := m.ListMembers(ctx, p, limit)
 	result.Page(members, count, nil).Json(ctx)
 }
 
@@ -29,11 +30,11 @@ func saveMember(ctx *gin.Context) {
 	var m services.MemberInfoService
 	var member model.MemberInfos
 	if err := ctx.ShouldBindJSON(&member); err != nil {
-		log.Warnf("用户id: %d 添加等级参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 添加等级参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
-	m.SaveMember(&member)
+	m.SaveMember(ctx, &member)
 	result.OkWithMsg(nil, "操作成功").Json(ctx)
 }
 
@@ -41,9 +42,9 @@ func deleteMember(ctx *gin.Context) {
 	var m services.MemberInfoService
 	id := ctx.Param("id")
 	atoi, _ := strconv.Atoi(id)
-	if err := m.DeleteMember(atoi); err != nil {
-		log.Warnf("用户id: %d 删除等级参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+	if err := m.DeleteMember(ctx, atoi); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 删除等级参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "操作成功").Json(ctx)
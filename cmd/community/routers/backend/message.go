@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"strconv"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils/page"
@@ -13,7 +14,7 @@ import (
 )
 
 func InitMessageRouters(r *gin.Engine) {
-	group := r.Group("/community/admin/message/template")
+	group := r.Group("/community/admin/message/template", middleware.Permission(constant.PermMessageManage))
 	group.GET("/var", listMsgVar)
 	group.GET("/event", listEvent)
 	group.GET("", listMsgTemp)
@@ -23,7 +24,7 @@ func InitMessageRouters(r *gin.Engine) {
 }
 
 func listEvent(ctx *gin.Context) {
-	result.Ok(event.List(), "").Json(ctx)
+	result.Ok(event.List(ctx), "").Json(ctx)
 }
 
 // 获取消息模板中的变量
@@ -36,7 +37,8 @@ func listMsgVar(ctx *gin.Context) {
 
 func listMsgTemp(ctx *gin.Context) {
 	var mS services.MessageService
-	template, count := mS.ListMessageTemplate(page.GetPage(ctx))
+	p, limit := page.GetPage(ctx)
+	template, count := mS.ListMessageTemplate(ctx, p, limit)
 	result.Page(template, count, nil).Json(ctx)
 }
 
@@ -44,13 +46,13 @@ func saveMsgTemp(ctx *gin.Context) {
 	var mS services.MessageService
 	var template model.MessageTemplates
 	if err := ctx.ShouldBindJSON(&template); err != nil {
-		log.Warnf("用户id: %d 保存消息模板参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 保存消息模板参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
-	if err := mS.SaveMessageTemplate(template); err != nil {
-		result.Err(err.Error()).Json(ctx)
-		log.Warnf("用户id: %d 保存消息模板失败,err: %s", middleware.GetUserId(ctx), err.Error())
+	if err := mS.SaveMessageTemplate(ctx, template); err != nil {
+		result.Error(err).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 保存消息模板失败,err: %s", middleware.GetUserId(ctx), err.Error())
 		return
 	}
 	result.OkWithMsg(nil, "保存成功").Json(ctx)
@@ -61,10 +63,10 @@ func deleteMsgTemp(ctx *gin.Context) {
 	id := ctx.Param("id")
 	atoi, err := strconv.Atoi(id)
 	if err != nil {
-		log.Warnf("用户id: %d 删除消息模板参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 删除消息模板参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
-	mS.DeleteMessageTemplate(atoi)
+	mS.DeleteMessageTemplate(ctx, atoi)
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
 }
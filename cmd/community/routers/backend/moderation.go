@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/pkg/utils/page"
+	"xhyovo.cn/community/server/model"
+	services "xhyovo.cn/community/server/service"
+)
+
+var moderationS services.ModerationService
+
+func InitModerationRouters(r *gin.Engine) {
+	group := r.Group("/community/admin/moderation", middleware.Permission(constant.PermModerationManage))
+	group.GET("/word", listSensitiveWords)
+	group.GET("/record", listModerationRecords)
+	group.Use(middleware.OperLogger())
+	group.POST("/word", saveSensitiveWord)
+	group.DELETE("/word/:id", deleteSensitiveWord)
+	group.POST("/record/:id/approve", approveModerationRecord)
+	group.POST("/record/:id/reject", rejectModerationRecord)
+}
+
+func listSensitiveWords(ctx *gin.Context) {
+	p, limit := page.GetPage(ctx)
+	words, count := moderationS.PageWords(ctx, ctx.Query("word"), p, limit)
+	result.Page(words, count, nil).Json(ctx)
+}
+
+func saveSensitiveWord(ctx *gin.Context) {
+	var word model.SensitiveWords
+	if err := ctx.ShouldBindJSON(&word); err != nil {
+		err = utils.ValidateErr(word, err)
+		log.Ctx(ctx).Warnf("用户id: %d 保存敏感词参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	if err := moderationS.SaveWord(ctx, word); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 保存敏感词失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "保存成功").Json(ctx)
+}
+
+func deleteSensitiveWord(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("敏感词id错误")).Json(ctx)
+		return
+	}
+	if err = moderationS.DeleteWord(ctx, id); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 删除敏感词失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "删除成功").Json(ctx)
+}
+
+// 命中记录,state=pending 为待审核的内容
+func listModerationRecords(ctx *gin.Context) {
+	p, limit := page.GetPage(ctx)
+	records, count := moderationS.PageRecords(ctx, ctx.Query("state"), ctx.Query("action"), p, limit)
+	result.Page(records, count, nil).Json(ctx)
+}
+
+func approveModerationRecord(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("审核记录id错误")).Json(ctx)
+		return
+	}
+	if err = moderationS.Approve(ctx, id, middleware.GetUserId(ctx)); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 审核通过失败,记录id: %d,err: %s", middleware.GetUserId(ctx), id, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "审核通过").Json(ctx)
+}
+
+func rejectModerationRecord(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("审核记录id错误")).Json(ctx)
+		return
+	}
+	if err = moderationS.Reject(ctx, id, middleware.GetUserId(ctx)); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 审核不通过失败,记录id: %d,err: %s", middleware.GetUserId(ctx), id, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "审核不通过").Json(ctx)
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"regexp"
 	"strconv"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils/page"
@@ -12,7 +14,7 @@ import (
 )
 
 func InitMonitRouters(r *gin.Engine) {
-	group := r.Group("/community/admin/monit")
+	group := r.Group("/community/admin/monit", middleware.Permission(constant.PermMonitView))
 	group.GET("", listMonitUser)
 	group.GET("/ip/:userId", getMonitUserIpDetails)
 	group.GET("/section/:userId", getMonitUserSectionDetails)
@@ -29,7 +31,7 @@ func listMonitUser(ctx *gin.Context) {
 
 	var results []Result
 	var count int64
-	tx := mysql.GetInstance().Table("oper_logs").
+	tx := mysql.GetInstance().WithContext(ctx).Table("oper_logs").
 		Select("user_id, COUNT(DISTINCT ip) as count").
 		Where("ip <> ?", "127.0.0.1").
 		Where("user_id <> ?", 13).
@@ -47,7 +49,7 @@ func listMonitUser(ctx *gin.Context) {
 	}
 
 	var userService services.UserService
-	nameMap := userService.ListByIdsToMap(userIds)
+	nameMap := userService.ListByIdsToMap(ctx, userIds)
 
 	for i, _ := range results {
 		results[i].Name = nameMap[results[i].UserID].Name
@@ -59,7 +61,7 @@ func listMonitUser(ctx *gin.Context) {
 // 获取监控用户的操作
 func getMonitUserIpDetails(ctx *gin.Context) {
 
-	userId := ctx.Param("userId")
+	userId, _ := strconv.Atoi(ctx.Param("userId"))
 	p, limit := page.GetPage(ctx)
 
 	type ipMonit struct {
@@ -73,7 +75,7 @@ func getMonitUserIpDetails(ctx *gin.Context) {
 	var count int64
 
 	// 查询去重后的IP，不包括 127.0.0.1
-	tx := mysql.GetInstance().Table("oper_logs").
+	tx := mysql.GetInstance().WithContext(ctx).Table("oper_logs").
 		Select("ip, platform, user_agent, COUNT(ip) as count").
 		Where("ip <> ?", "127.0.0.1").
 		Where("user_id = ?", userId).
@@ -89,7 +91,7 @@ func getMonitUserIpDetails(ctx *gin.Context) {
 }
 
 func getMonitUserSectionDetails(ctx *gin.Context) {
-	userId := ctx.Param("userId")
+	userId, _ := strconv.Atoi(ctx.Param("userId"))
 	p, limit := page.GetPage(ctx)
 	// 收集章节观看次数
 	type sectionMonit struct {
@@ -102,9 +104,9 @@ func getMonitUserSectionDetails(ctx *gin.Context) {
 
 	var sectionResult []sectionMonit
 	var count int64
-	tx := mysql.GetInstance().Table("oper_logs").
+	tx := mysql.GetInstance().WithContext(ctx).Table("oper_logs").
 		Select("user_id, request_info, COUNT(*) as count").
-		Where("request_info REGEXP ?", `^/community/courses/section/[0-9]+$`).
+		Where(mysql.Regexp("request_info"), `^/community/courses/section/[0-9]+$`).
 		Where("user_id = ?", userId).
 		Group("user_id, request_info")
 
@@ -129,7 +131,7 @@ func getMonitUserSectionDetails(ctx *gin.Context) {
 	}
 
 	var sectionService services.CourseService
-	selectIdTitleMap := sectionService.ListSectionByIds(sectionIds)
+	selectIdTitleMap := sectionService.ListSectionByIds(ctx, sectionIds)
 	for i := range sectionResult {
 		sectionResult[i].SectionTitle = selectIdTitleMap[sectionResult[i].SectionId]
 	}
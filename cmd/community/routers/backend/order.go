@@ -2,6 +2,8 @@ package backend
 
 import (
 	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils/page"
 	services "xhyovo.cn/community/server/service"
@@ -9,7 +11,7 @@ import (
 
 func InitOrderRouters(r *gin.Engine) {
 
-	group := r.Group("/community/admin/order")
+	group := r.Group("/community/admin/order", middleware.Permission(constant.PermOrderView))
 	group.GET("", listOrder)
 
 }
@@ -17,7 +19,7 @@ func InitOrderRouters(r *gin.Engine) {
 func listOrder(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	var orderSer = services.OrderServices{}
-	orders, count := orderSer.Page(p, limit)
+	orders, count := orderSer.Page(ctx, p, limit)
 	result.Page(orders, count, nil).Json(ctx)
 	return
 }
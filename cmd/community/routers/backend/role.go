@@ -0,0 +1,110 @@
+package backend
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/server/request"
+	services "xhyovo.cn/community/server/service"
+)
+
+var roleS services.RoleService
+
+func InitRoleRouters(r *gin.Engine) {
+	group := r.Group("/community/admin/role", middleware.Permission(constant.PermRoleManage))
+	group.GET("", listRoles)
+	group.GET("/permissions", listPermissions)
+	group.GET("/user/:userId", getUserRoles)
+	group.Use(middleware.OperLogger())
+	group.POST("", saveRole)
+	group.DELETE("/:id", deleteRole)
+	group.PUT("/user", setUserRoles)
+}
+
+func listRoles(ctx *gin.Context) {
+	roles, err := roleS.List(ctx)
+	if err != nil {
+		log.Ctx(ctx).Warnf("查询角色失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Ok(roles, "").Json(ctx)
+}
+
+// 可分配的权限,名称按请求的语言翻译
+func listPermissions(ctx *gin.Context) {
+	permissions := make([]constant.Permission, len(constant.Permissions))
+	for i, p := range constant.Permissions {
+		permissions[i] = constant.Permission{Code: p.Code, Name: i18n.T(ctx, p.Name)}
+	}
+	result.Ok(permissions, "").Json(ctx)
+}
+
+func saveRole(ctx *gin.Context) {
+	var req request.ReqRole
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		err = utils.ValidateErr(req, err)
+		log.Ctx(ctx).Warnf("用户id: %d 保存角色参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	id, err := roleS.Save(ctx, req)
+	if err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 保存角色失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(id, "保存成功").Json(ctx)
+}
+
+func deleteRole(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("角色id错误")).Json(ctx)
+		return
+	}
+	if err = roleS.Delete(ctx, id); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 删除角色失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "删除成功").Json(ctx)
+}
+
+func getUserRoles(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("用户id错误")).Json(ctx)
+		return
+	}
+	roles, err := roleS.UserRoles(ctx, userId)
+	if err != nil {
+		log.Ctx(ctx).Warnf("查询用户角色失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Ok(roles, "").Json(ctx)
+}
+
+func setUserRoles(ctx *gin.Context) {
+	var req request.ReqUserRoles
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		err = utils.ValidateErr(req, err)
+		log.Ctx(ctx).Warnf("用户id: %d 分配角色参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	if err := roleS.SetUserRoles(ctx, req.UserId, req.RoleIds); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 分配角色失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "分配成功").Json(ctx)
+}
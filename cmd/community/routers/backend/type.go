@@ -3,6 +3,7 @@ package backend
 import (
 	"strconv"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/utils/page"
 
@@ -14,7 +15,7 @@ import (
 )
 
 func InitTypeRouters(r *gin.Engine) {
-	group := r.Group("/community/admin/type")
+	group := r.Group("/community/admin/type", middleware.Permission(constant.PermTypeManage))
 	group.GET("/parent", listParentTypes)
 	group.GET("", listType)
 	group.POST("", saveType, middleware.OperLogger())
@@ -23,7 +24,7 @@ func InitTypeRouters(r *gin.Engine) {
 
 func listParentTypes(ctx *gin.Context) {
 	var typeService services.TypeService
-	types := typeService.ListParentTypes()
+	types := typeService.ListParentTypes(ctx)
 	result.Ok(types, "").Json(ctx)
 }
 
@@ -31,7 +32,7 @@ func listType(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 
 	var typeService services.TypeService
-	types, count := typeService.PageTypes(p, limit)
+	types, count := typeService.PageTypes(ctx, p, limit)
 	result.Page(types, count, nil).Json(ctx)
 }
 
@@ -39,15 +40,15 @@ func saveType(ctx *gin.Context) {
 
 	var types model.Types
 	if err := ctx.ShouldBindJSON(&types); err != nil {
-		log.Warnf("用户id: %d 添加分类参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(utils.GetValidateErr(types, err)).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 添加分类参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(utils.ValidateErr(types, err)).Json(ctx)
 		return
 	}
 	var typeService services.TypeService
-	u, err := typeService.Save(&types)
+	u, err := typeService.Save(ctx, &types)
 	if err != nil {
-		log.Warnf("用户id: %d 添加分类失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(utils.GetValidateErr(types, err)).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 添加分类失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(utils.ValidateErr(types, err)).Json(ctx)
 		return
 	}
 	result.OkWithMsg(u, "保存成功").Json(ctx)
@@ -56,15 +57,15 @@ func saveType(ctx *gin.Context) {
 func UpdateType(ctx *gin.Context) {
 	var types model.Types
 	if err := ctx.ShouldBindJSON(&types); err != nil {
-		log.Warnf("用户id: %d ,修改分类参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(utils.GetValidateErr(types, err)).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d ,修改分类参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(utils.ValidateErr(types, err)).Json(ctx)
 		return
 	}
 	var typeService services.TypeService
-	err := typeService.Update(&types)
+	err := typeService.Update(ctx, &types)
 	if err != nil {
-		log.Warnf("用户id: %d 修改分类失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 修改分类失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "分类更新成功").Json(ctx)
@@ -74,23 +75,23 @@ func deleteType(ctx *gin.Context) {
 
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		log.Warnf("用户id: %d 删除分类失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 删除分类失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	var typeService services.TypeService
 	var articleService services.ArticleService
 	// 如果有文章
-	count := articleService.CountByTypeId(id)
+	count := articleService.CountByTypeId(ctx, id)
 	if count > 0 {
 		var msg = "删除失败,该分类下有文章"
-		log.Warnf("用户id: %d 删除分类失败,err: %s", middleware.GetUserId(ctx), msg)
+		log.Ctx(ctx).Warnf("用户id: %d 删除分类失败,err: %s", middleware.GetUserId(ctx), msg)
 		result.Err(msg).Json(ctx)
 		return
 	}
-	err = typeService.Delete(id)
+	err = typeService.Delete(ctx, id)
 	if err != nil {
-		log.Warnf("用户id: %d 删除分类失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		log.Ctx(ctx).Warnf("用户id: %d 删除分类失败,err: %s", middleware.GetUserId(ctx), err.Error())
 	}
 	result.Auto(nil, err).Json(ctx)
 }
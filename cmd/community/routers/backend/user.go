@@ -5,10 +5,12 @@ import (
 	"strconv"
 	"xhyovo.cn/community/pkg/cache"
 	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/i18n"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils/page"
@@ -19,15 +21,15 @@ import (
 func InitUserRouters(r *gin.Engine) {
 
 	group := r.Group("/community/admin/user")
-	group.GET("", listUser)
-	group.GET("/black", blackListUser)
+	group.GET("", middleware.Permission(constant.PermUserView), listUser)
+	group.GET("/black", middleware.Permission(constant.PermUserView), blackListUser)
 	group.Use(middleware.OperLogger())
-	group.POST("", updateUser)
-	group.DELETE("/:id", deleteUser)
-	group.PUT("/reset/pwd", setRangePassword)
+	group.POST("", middleware.Permission(constant.PermUserEdit), updateUser)
+	group.DELETE("/:id", middleware.Permission(constant.PermUserDelete), deleteUser)
+	group.PUT("/reset/pwd", middleware.Permission(constant.PermUserEdit), setRangePassword)
 
-	group.DELETE("/black/ban", banUser)
-	group.POST("/black/unBan", unBanUser)
+	group.DELETE("/black/ban", middleware.Permission(constant.PermUserBan), banUser)
+	group.POST("/black/unBan", middleware.Permission(constant.PermUserBan), unBanUser)
 }
 
 func listUser(ctx *gin.Context) {
@@ -39,7 +41,7 @@ func listUser(ctx *gin.Context) {
 
 	var u services.UserService
 
-	users, count := u.PageUsers(p, limit, conditionUser)
+	users, count := u.PageUsers(ctx, p, limit, conditionUser)
 	result.Page(users, count, nil).Json(ctx)
 }
 
@@ -53,13 +55,13 @@ func updateUser(ctx *gin.Context) {
 	user := updateUserInfo{}
 
 	if err := ctx.ShouldBindBodyWith(&user, binding.JSON); err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 
 	var u services.UserService
-	u.UpdateUser(&user.Users)
-	user.Tags = userTagS.AssignUserLabel(user.ID, user.Tags)
+	u.UpdateUser(ctx, &user.Users)
+	user.Tags = userTagS.AssignUserLabel(ctx, user.ID, user.Tags)
 
 	result.OkWithMsg(user, "修改成功").Json(ctx)
 }
@@ -68,11 +70,11 @@ func updateUser(ctx *gin.Context) {
 func setRangePassword(ctx *gin.Context) {
 	account := ctx.Query("account")
 	if account == "" {
-		result.Err("用户名不能为空").Json(ctx)
+		result.Error(errs.BadRequest.WithMsg("用户名不能为空")).Json(ctx)
 		return
 	}
 	var u services.UserService
-	if u.ResetPwd(account) {
+	if u.ResetPwd(ctx, account) {
 		result.OkWithMsg(nil, "重置成功, 密码已发送至邮箱").Json(ctx)
 		return
 	}
@@ -82,17 +84,17 @@ func setRangePassword(ctx *gin.Context) {
 func deleteUser(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	userId := middleware.GetUserId(ctx)
 	if userId == id {
-		result.Err("不能删除自己").Json(ctx)
+		result.Error(errs.BadRequest.WithMsg("不能删除自己")).Json(ctx)
 		return
 	}
 	var u services.UserService
-	u.DeleteUser(id)
-	log.Infof("用户id: %d,删除用户: %d", userId, id)
+	u.DeleteUser(ctx, id)
+	log.Ctx(ctx).Infof("用户id: %d,删除用户: %d", userId, id)
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
 }
 
@@ -100,7 +102,7 @@ func deleteUser(ctx *gin.Context) {
 func blackListUser(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	var u services.UserService
-	user, count := u.ListBlackUser(p, limit)
+	user, count := u.ListBlackUser(ctx, p, limit)
 	result.Page(user, count, nil).Json(ctx)
 }
 
@@ -109,12 +111,12 @@ func banUser(ctx *gin.Context) {
 	account := ctx.Query("account")
 
 	var u services.UserService
-	if !u.ExistUserByAccount(account) {
-		result.Err("用户不存在").Json(ctx)
+	if !u.ExistUserByAccount(ctx, account) {
+		result.Error(errs.NotFound.WithMsg("用户不存在")).Json(ctx)
 		return
 	}
-	u.BanByUserAccount(account)
-	result.OkWithMsg(nil, "已 ban 掉用户："+account).Json(ctx)
+	u.BanByUserAccount(ctx, account)
+	result.OkWithMsg(nil, i18n.T(ctx, "已 ban 掉用户：%s", account)).Json(ctx)
 }
 
 // 解封用户
@@ -122,15 +124,14 @@ func unBanUser(ctx *gin.Context) {
 	id := ctx.Query("id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var u services.UserService
-	u.UnBanByUserId(idInt)
-	cache := cache.GetInstance()
-	cache.Delete(constant.HEARTBEAT + id)
-	cache.Delete(constant.BLACK_LIST + id)
-	cache.Delete(constant.BLACK_LIST_COUNT + id)
+	u.UnBanByUserId(ctx, idInt)
+	if err = cache.GetInstance().Delete(constant.HEARTBEAT+id, constant.BLACK_LIST+id, constant.BLACK_LIST_COUNT+id); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %s 清除黑名单缓存失败,err: %s", id, err.Error())
+	}
 
-	result.OkWithMsg(nil, "已解封用户："+id).Json(ctx)
+	result.OkWithMsg(nil, i18n.T(ctx, "已解封用户：%s", id)).Json(ctx)
 }
@@ -3,6 +3,7 @@ package frontend
 import (
 	"strconv"
 
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/utils/page"
 
 	"xhyovo.cn/community/pkg/constant"
@@ -23,6 +24,7 @@ import (
 
 var (
 	articleService = new(services.ArticleService)
+	hotService     = new(services.HotService)
 )
 
 type SearchArticle struct {
@@ -42,12 +44,24 @@ func InitArticleRouter(r *gin.Engine) {
 	group.GET("/like/state/:articleId", articleLikeState)
 	group.GET("/list", articlesByTypeId)
 	group.GET("/latest", articleLatest)
+	group.GET("/trending", articleTrending)
+	group.GET("/hot", articleHot)
+	group.GET("/scheduled", articleScheduled)
+	// 操作日志会缓存整个请求和响应,导入、导出不经过 OperLogger
+	group.GET("/export", articleExport)
+	group.POST("/import", articleImport)
 	group.Use(middleware.OperLogger())
 	group.GET("/:id", articleGet)
 	group.POST("/update", articleSave)
 	group.POST("/publish", publish)
 	group.DELETE("/:id", articleDeleted)
 	group.POST("/like", articleLike)
+	group.GET("/:id/revisions", articleRevisions)
+	group.GET("/:id/revisions/:version", articleRevision)
+	group.GET("/:id/diff", articleRevisionDiff)
+	group.POST("/:id/revisions/:version/restore", articleRevisionRestore)
+	group.PUT("/:id/schedule", articleReschedule)
+	group.DELETE("/:id/schedule", articleCancelSchedule)
 
 }
 
@@ -55,14 +69,14 @@ func articlePageBySearch(ctx *gin.Context) {
 	// 获取所有分类
 	searchArticle := new(SearchArticle)
 	if err := ctx.ShouldBindBodyWith(searchArticle, binding.JSON); err != nil {
-		log.Warnf("用户id: %d 分页获取文章参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 分页获取文章参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	state := searchArticle.State
 	if state < 1 || state > 6 {
-		log.Warnf("用户id: %d 搜索文章状态参数错误,当前状态: %d", middleware.GetUserId(ctx), state)
-		result.Err("文章状态非法").Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 搜索文章状态参数错误,当前状态: %d", middleware.GetUserId(ctx), state)
+		result.Error(errs.BadRequest.WithMsg("文章状态非法")).Json(ctx)
 		return
 	}
 
@@ -70,31 +84,31 @@ func articlePageBySearch(ctx *gin.Context) {
 	currentUserId := middleware.GetUserId(ctx)
 
 	if (state == constant.Draft || state == constant.QADraft || state == constant.PrivateQuestion) && searchUserId != 0 && searchUserId != currentUserId {
-		log.Warnf("用户id: %d 搜索文章状态不可选择草稿以及私密提问", middleware.GetUserId(ctx))
-		result.Err("搜索文章状态不可选择草稿以及私密提问").Json(ctx) //
+		log.Ctx(ctx).Warnf("用户id: %d 搜索文章状态不可选择草稿以及私密提问", middleware.GetUserId(ctx))
+		result.Error(errs.BadRequest.WithMsg("搜索文章状态不可选择草稿以及私密提问")).Json(ctx) //
 		return
 	}
 	if state == 0 {
-		log.Warnf("用户id: %d 查询文章必须带上文章状态", middleware.GetUserId(ctx))
-		result.Err("查询文章必须带上文章状态").Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 查询文章必须带上文章状态", middleware.GetUserId(ctx))
+		result.Error(errs.BadRequest.WithMsg("查询文章必须带上文章状态")).Json(ctx)
 		return
 	}
-	var userS services.UserService
-	flag, err := userS.IsAdmin(currentUserId)
+	var roleS services.RoleService
+	flag, err := roleS.HasPermission(ctx, currentUserId, constant.PermArticleView)
 	if err != nil {
-		log.Warnf("用户id: %d 校验身份出现错误: %s", middleware.GetUserId(ctx), err)
+		log.Ctx(ctx).Warnf("用户id: %d 校验身份出现错误: %s", middleware.GetUserId(ctx), err)
 		result.Err("校验身份出现错误").Json(ctx)
 		return
 	}
 
-	// TA 用户并且 不是管理员
+	// TA 用户并且 没有查看文章的权限
 	if searchUserId != currentUserId && !flag && (state == constant.Draft || state == constant.QADraft || state == constant.PrivateQuestion) {
-		log.Warnf("用户id: %d 非法查询文章,查询文章状态: %s", middleware.GetUserId(ctx), state)
-		result.Err("你没有权限查询该状态文章").Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 非法查询文章,查询文章状态: %s", middleware.GetUserId(ctx), state)
+		result.Error(errs.Forbidden.WithMsg("你没有权限查询该状态文章")).Json(ctx)
 		return
 	}
 
-	result.Page(articleService.PageByClassfily(searchArticle.Type, searchArticle.Tags, &model.Articles{
+	result.Page(articleService.PageByClassfily(ctx, searchArticle.Type, searchArticle.Tags, &model.Articles{
 		Title:   searchArticle.Context,
 		Content: searchArticle.Context,
 		UserId:  searchUserId,
@@ -105,19 +119,24 @@ func articlePageBySearch(ctx *gin.Context) {
 func articleGet(c *gin.Context) {
 	articleId, err := strconv.Atoi(c.Params.ByName("id"))
 	if err != nil || articleId < 1 {
-		log.Warnf("用户id: %d 未找到相关文章,文章id: %d err: %s", middleware.GetUserId(c), articleId, err.Error())
-		result.Err("未找到相关文章").Json(c)
+		log.Ctx(c).Warnf("用户id: %d 未找到相关文章,文章id: %d err: %s", middleware.GetUserId(c), articleId, err.Error())
+		result.Error(errs.NotFound.WithMsg("未找到相关文章")).Json(c)
 		return
 	}
-	result.Auto(articleService.GetArticleData(articleId, middleware.GetUserId(c))).ErrMsg("未找到相关文章").Json(c)
+	userId := middleware.GetUserId(c)
+	data, err := articleService.GetArticleData(c, articleId, userId)
+	if err == nil && data.ID != 0 {
+		hotService.RecordView(c, articleId, userId, c.ClientIP())
+	}
+	result.Auto(data, err).ErrMsg("未找到相关文章").Json(c)
 }
 
 func articleDeleted(c *gin.Context) {
 	id := c.Param("id")
 	articleId, _ := strconv.Atoi(id)
-	if err := articleService.DeleteByUserId(articleId, middleware.GetUserId(c)); err != nil {
-		log.Warnf("用户id: %d 删除文章失败,文章id: %d ,err: %s", middleware.GetUserId(c), articleId, err.Error())
-		result.Err(err.Error()).Json(c)
+	if err := articleService.DeleteByUserId(c, articleId, middleware.GetUserId(c)); err != nil {
+		log.Ctx(c).Warnf("用户id: %d 删除文章失败,文章id: %d ,err: %s", middleware.GetUserId(c), articleId, err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 	result.OkWithMsg(nil, "删除成功").Json(c)
@@ -126,22 +145,22 @@ func articleDeleted(c *gin.Context) {
 func articleSave(c *gin.Context) {
 	var o request.ReqArticle
 	if err := c.ShouldBindJSON(&o); err != nil {
-		msg := utils.GetValidateErr(o, err)
-		log.Warnf("用户id: %d 保存文章解析文章失败 ,err: %s", middleware.GetUserId(c), msg)
-		result.Err(msg).Json(c)
+		err = utils.ValidateErr(o, err)
+		log.Ctx(c).Warnf("用户id: %d 保存文章解析文章失败 ,err: %s", middleware.GetUserId(c), err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 	o.UserId = middleware.GetUserId(c)
-	article, err := articleService.SaveArticle(o)
+	article, err := articleService.SaveArticle(c, o)
 	if err != nil {
-		log.Warnf("用户id: %d 保存文章失败,err: %s", middleware.GetUserId(c), err.Error())
-		result.Err(utils.GetValidateErr(o, err)).Json(c)
+		log.Ctx(c).Warnf("用户id: %d 保存文章失败,err: %s", middleware.GetUserId(c), err.Error())
+		result.Error(utils.ValidateErr(o, err)).Json(c)
 		return
 	}
-	articleData, err := articleService.GetArticleData(article.ID, o.UserId)
+	articleData, err := articleService.GetArticleData(c, article.ID, o.UserId)
 	if err != nil {
-		log.Warnf("用户id: %d 获取文章失败,文章id: %d ,err: %s", middleware.GetUserId(c), article.ID, err.Error())
-		result.Err(err.Error()).Json(c)
+		log.Ctx(c).Warnf("用户id: %d 获取文章失败,文章id: %d ,err: %s", middleware.GetUserId(c), article.ID, err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 	result.OkWithMsg(articleData, "保存成功").Json(c)
@@ -151,14 +170,14 @@ func articleLike(c *gin.Context) {
 	v := c.Query("articleId")
 	articleId, err := strconv.Atoi(v)
 	if err != nil {
-		log.Warnf("用户id: %d 点赞文章失败,文章id: %d ,err: %s", middleware.GetUserId(c), articleId, err.Error())
-		result.Err(err.Error()).Json(c)
+		log.Ctx(c).Warnf("用户id: %d 点赞文章失败,文章id: %d ,err: %s", middleware.GetUserId(c), articleId, err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 	userId := middleware.GetUserId(c)
 	var msg string = "取消点赞"
 	var likeState bool = false
-	if articleService.Like(articleId, userId) {
+	if articleService.Like(c, articleId, userId) {
 		msg = "点赞"
 		likeState = true
 	}
@@ -169,24 +188,24 @@ func articleLikeState(c *gin.Context) {
 	v := c.Param("articleId")
 	articleId, err := strconv.Atoi(v)
 	if err != nil {
-		log.Warnf("用户id: %d 获取文章点赞状态解析id失败,文章id: %d ,err: %s", middleware.GetUserId(c), articleId, err.Error())
-		result.Err(err.Error()).Json(c)
+		log.Ctx(c).Warnf("用户id: %d 获取文章点赞状态解析id失败,文章id: %d ,err: %s", middleware.GetUserId(c), articleId, err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 	userId := middleware.GetUserId(c)
-	state := articleService.GetLikeState(articleId, userId)
+	state := articleService.GetLikeState(c, articleId, userId)
 	result.Ok(state, "").Json(c)
 }
 
 func articleTop(ctx *gin.Context) {
 	types := ctx.Query("type")
 	p, limit := page.GetPage(ctx)
-	articles, count := articleService.PageTopArticle(types, p, limit)
+	articles, count := articleService.PageTopArticle(ctx, types, p, limit)
 	result.Page(articles, count, nil).Json(ctx)
 }
 
 func articleLatest(ctx *gin.Context) {
-	articles := articleService.LatestArticle()
+	articles := articleService.LatestArticle(ctx)
 	result.Ok(articles, "").Json(ctx)
 }
 
@@ -200,8 +219,8 @@ func articlesByTypeId(ctx *gin.Context) {
 	title := ctx.Query("title")
 	userId := middleware.GetUserId(ctx)
 	if err != nil {
-		log.Warnf("用户id: %d 获取文章解析分类 id 失败, ,err: %s", userId, typeId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 获取文章解析分类 id 失败, ,err: %s", userId, typeId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	searchUserId, _ := strconv.Atoi(ctx.DefaultQuery("userId", "0"))
@@ -209,25 +228,25 @@ func articlesByTypeId(ctx *gin.Context) {
 	if userId != searchUserId {
 		userId = 0
 	}
-	articles, count := articleService.ListByTypeId(typeId, searchUserId, userId, p, limit, title)
+	articles, count := articleService.ListByTypeId(ctx, typeId, searchUserId, userId, p, limit, title)
 	result.Page(articles, count, nil).Json(ctx)
 	return
 }
 func publish(c *gin.Context) {
 	var o request.ReqArticle
 	if err := c.ShouldBindJSON(&o); err != nil {
-		msg := utils.GetValidateErr(o, err)
-		log.Warnf("用户id: %d 保存文章解析文章失败 ,err: %s", middleware.GetUserId(c), msg)
-		result.Err(msg).Json(c)
+		err = utils.ValidateErr(o, err)
+		log.Ctx(c).Warnf("用户id: %d 保存文章解析文章失败 ,err: %s", middleware.GetUserId(c), err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 	o.UserId = middleware.GetUserId(c)
 
-	article, err := articleService.PublishArticle(o)
+	article, err := articleService.PublishArticle(c, o)
 	if err != nil {
-		log.Warnf("用户id: %d 保存文章失败 ,err: %s", middleware.GetUserId(c), err.Error())
-		result.Err(err.Error()).Json(c)
+		log.Ctx(c).Warnf("用户id: %d 保存文章失败 ,err: %s", middleware.GetUserId(c), err.Error())
+		result.Error(err).Json(c)
 		return
 	}
-	result.OkWithMsg(nil, constant.GetArticleMsg(article.State)).Json(c)
+	result.OkWithMsg(nil, constant.GetArticleMsg(c, article.State)).Json(c)
 }
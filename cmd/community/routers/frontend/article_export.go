@@ -0,0 +1,26 @@
+package frontend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/log"
+	services "xhyovo.cn/community/server/service"
+)
+
+// 导出自己的文章、问答为 zip,包含 markdown、评论和引用的文件
+func articleExport(ctx *gin.Context) {
+	userId := middleware.GetUserId(ctx)
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=articles-%d-%s.zip", userId, time.Now().Format("20060102")))
+	var exportS services.ExportService
+	count, err := exportS.Export(ctx, ctx.Writer, services.ExportOptions{UserId: userId})
+	if err != nil {
+		// 已经开始写入响应,只能记录日志
+		log.Ctx(ctx).Warnf("用户id: %d 导出文章失败,err: %s", userId, err.Error())
+		return
+	}
+	log.Ctx(ctx).Infof("用户id: %d 导出文章,文章数: %d", userId, count)
+}
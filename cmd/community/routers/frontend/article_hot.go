@@ -0,0 +1,34 @@
+package frontend
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils/page"
+	services "xhyovo.cn/community/server/service"
+)
+
+// 热门文章,可按分类、标签筛选
+func articleTrending(ctx *gin.Context) {
+	p, limit := page.GetPage(ctx)
+	typeId, _ := strconv.Atoi(ctx.Query("typeId"))
+	tagId, _ := strconv.Atoi(ctx.Query("tagId"))
+	articles, count, err := hotService.Trending(ctx, typeId, tagId, p, limit)
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Page(articles, count, nil).Json(ctx)
+}
+
+// 首页的每日、每周热门
+func articleHot(ctx *gin.Context) {
+	_, limit := page.GetPage(ctx)
+	articles, err := hotService.Hot(ctx, ctx.DefaultQuery("period", services.HotDay), limit)
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Ok(articles, "").Json(ctx)
+}
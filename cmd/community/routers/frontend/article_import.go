@@ -0,0 +1,49 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/server/request"
+	services "xhyovo.cn/community/server/service"
+)
+
+// 从 markdown 压缩包导入文章,dryRun=true 时只返回冲突、未匹配分类等报告
+func articleImport(ctx *gin.Context) {
+	var req request.ReqArticleImport
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, services.ImportMaxSize+1<<20)
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("请上传 zip 文件")).Json(ctx)
+		return
+	}
+	if header.Size > services.ImportMaxSize {
+		result.Error(errs.BadRequest.WithMsg("压缩包过大")).Json(ctx)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	defer file.Close()
+
+	userId := middleware.GetUserId(ctx)
+	var importS services.ImportService
+	report, err := importS.Import(ctx, file, header.Size, services.ImportOptions{UserId: userId, TypeId: req.TypeId, DryRun: req.DryRun})
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	log.Ctx(ctx).Infof("用户id: %d 导入文章,dryRun: %t,文章数: %d,导入: %d,跳过: %d", userId, req.DryRun, report.Total, report.Imported, report.Skipped)
+	result.Ok(report, "").Json(ctx)
+}
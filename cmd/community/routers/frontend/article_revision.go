@@ -0,0 +1,80 @@
+package frontend
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/pkg/utils/page"
+	"xhyovo.cn/community/server/request"
+	services "xhyovo.cn/community/server/service"
+)
+
+var revisionS services.ArticleRevisionService
+
+// 解析文章 id 并校验当前用户能否查看该文章的历史版本
+func revisionArticleId(ctx *gin.Context) (int, bool) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || articleId < 1 {
+		result.Error(errs.BadRequest.WithMsg("文章id错误")).Json(ctx)
+		return 0, false
+	}
+	if err = revisionS.CheckAccess(ctx, articleId, middleware.GetUserId(ctx)); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 查看文章历史版本失败,文章id: %d,err: %s", middleware.GetUserId(ctx), articleId, err.Error())
+		result.Error(err).Json(ctx)
+		return 0, false
+	}
+	return articleId, true
+}
+
+func articleRevisions(ctx *gin.Context) {
+	articleId, ok := revisionArticleId(ctx)
+	if !ok {
+		return
+	}
+	p, limit := page.GetPage(ctx)
+	revisions, count := revisionS.PageRevisions(ctx, articleId, p, limit)
+	result.Page(revisions, count, nil).Json(ctx)
+}
+
+func articleRevision(ctx *gin.Context) {
+	articleId, ok := revisionArticleId(ctx)
+	if !ok {
+		return
+	}
+	version, _ := strconv.Atoi(ctx.Param("version"))
+	result.Auto(revisionS.GetRevision(ctx, articleId, version)).Json(ctx)
+}
+
+func articleRevisionDiff(ctx *gin.Context) {
+	articleId, ok := revisionArticleId(ctx)
+	if !ok {
+		return
+	}
+	var req request.ReqRevisionDiff
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	result.Auto(revisionS.Diff(ctx, articleId, req.From, req.To)).Json(ctx)
+}
+
+func articleRevisionRestore(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || articleId < 1 {
+		result.Error(errs.BadRequest.WithMsg("文章id错误")).Json(ctx)
+		return
+	}
+	version, _ := strconv.Atoi(ctx.Param("version"))
+	userId := middleware.GetUserId(ctx)
+	if err = revisionS.Restore(ctx, articleId, version, userId); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 恢复文章版本失败,文章id: %d,版本: %d,err: %s", userId, articleId, version, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "恢复成功").Json(ctx)
+}
@@ -0,0 +1,49 @@
+package frontend
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/pkg/utils/page"
+	"xhyovo.cn/community/server/request"
+)
+
+// 当前用户定时发布的文章
+func articleScheduled(ctx *gin.Context) {
+	p, limit := page.GetPage(ctx)
+	articles, count := articleService.PageScheduled(ctx, middleware.GetUserId(ctx), p, limit)
+	result.Page(articles, count, nil).Json(ctx)
+}
+
+// 修改定时发布时间
+func articleReschedule(ctx *gin.Context) {
+	articleId, _ := strconv.Atoi(ctx.Param("id"))
+	var req request.ReqSchedule
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	userId := middleware.GetUserId(ctx)
+	if err := articleService.Reschedule(ctx, articleId, userId, req.PublishAt); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 修改定时发布时间失败,文章id: %d,err: %s", userId, articleId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "修改成功").Json(ctx)
+}
+
+// 取消定时发布,文章转为草稿
+func articleCancelSchedule(ctx *gin.Context) {
+	articleId, _ := strconv.Atoi(ctx.Param("id"))
+	userId := middleware.GetUserId(ctx)
+	if err := articleService.CancelSchedule(ctx, articleId, userId); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 取消定时发布失败,文章id: %d,err: %s", userId, articleId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "已取消定时发布").Json(ctx)
+}
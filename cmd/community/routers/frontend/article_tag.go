@@ -29,26 +29,26 @@ func InitArticleTagRouter(r *gin.Engine) {
 func getArticleTags(r *gin.Context) {
 	qp := ginutils.GetPage(r)
 	title := r.DefaultQuery("title", "")
-	result.Auto(articleTagService.QueryList(qp.Page, qp.Limit, title)).Json(r)
+	result.Auto(articleTagService.QueryList(r, qp.Page, qp.Limit, title)).Json(r)
 }
 
 func getHotTags(r *gin.Context) {
 	qp := ginutils.GetPage(r)
-	result.Auto(articleTagService.QueryHotTags(qp.Limit)).Json(r)
+	result.Auto(articleTagService.QueryHotTags(r, qp.Limit)).Json(r)
 }
 
 func saveArticleTags(c *gin.Context) {
 	var articleTag model.ArticleTags
 	if err := c.ShouldBindJSON(&articleTag); err != nil {
-		log.Warnf("用户id: %d 添加文章标签参数解析解析失败 ,err: %s", middleware.GetUserId(c), err.Error())
-		result.Err(utils.GetValidateErr(articleTag, err)).Json(c)
+		log.Ctx(c).Warnf("用户id: %d 添加文章标签参数解析解析失败 ,err: %s", middleware.GetUserId(c), err.Error())
+		result.Error(utils.ValidateErr(articleTag, err)).Json(c)
 		return
 	}
 	articleTag.UserId = middleware.GetUserId(c)
-	tag, err := articleTagService.CreateTag(articleTag)
+	tag, err := articleTagService.CreateTag(c, articleTag)
 	if err != nil {
-		log.Warnf("用户id: %d 添加文章标签失败,err: %s", middleware.GetUserId(c), err.Error())
-		result.Err(err.Error()).Json(c)
+		log.Ctx(c).Warnf("用户id: %d 添加文章标签失败,err: %s", middleware.GetUserId(c), err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 	result.Ok(tag, "创建成功").Json(c)
@@ -59,9 +59,9 @@ func deleteArticleTags(c *gin.Context) {
 	atoi, _ := strconv.Atoi(tagId)
 	userId := middleware.GetUserId(c)
 
-	if err := articleTagService.DeleteTag(atoi, userId); err != nil {
-		log.Warnf("用户id: %d 删除文章标签失败,标签id: %d,err: %s", userId, atoi, err.Error())
-		result.Err(err.Error()).Json(c)
+	if err := articleTagService.DeleteTag(c, atoi, userId); err != nil {
+		log.Ctx(c).Warnf("用户id: %d 删除文章标签失败,标签id: %d,err: %s", userId, atoi, err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 	result.OkWithMsg(nil, "删除成功").Json(c)
@@ -77,6 +77,6 @@ func getTagArticleCount(c *gin.Context) {
 		userId, _ = strconv.Atoi(param)
 	}
 
-	tagArticleCount := articleTagService.GetTagArticleCount(userId)
+	tagArticleCount := articleTagService.GetTagArticleCount(c, userId)
 	result.Ok(tagArticleCount, "").Json(c)
 }
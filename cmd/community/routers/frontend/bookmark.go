@@ -0,0 +1,155 @@
+package frontend
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/pkg/utils/page"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+	services "xhyovo.cn/community/server/service"
+)
+
+var bookmarkS services.BookmarkService
+
+func InitBookmarkRouters(r *gin.Engine) {
+	group := r.Group("/community/bookmarks")
+	group.GET("", pageBookmarks)
+	group.GET("/state", bookmarkState)
+	group.GET("/folders", listBookmarkFolders)
+	group.GET("/folders/:id", getBookmarkFolder)
+
+	group.Use(middleware.OperLogger())
+	group.POST("", addBookmark)
+	group.PUT("/:id", moveBookmark)
+	group.DELETE("/:id", removeBookmark)
+	group.POST("/folders", saveBookmarkFolder)
+	group.DELETE("/folders/:id", deleteBookmarkFolder)
+}
+
+// 我的收藏,可以按收藏夹、类型、标题筛选
+func pageBookmarks(ctx *gin.Context) {
+	var req request.ReqBookmarkSearch
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	p, limit := page.GetPage(ctx)
+	items, count := bookmarkS.Page(ctx, middleware.GetUserId(ctx), req, p, limit)
+	result.Page(items, count, nil).Json(ctx)
+}
+
+// 内容被当前用户收藏到了哪些收藏夹
+func bookmarkState(ctx *gin.Context) {
+	businessType, _ := strconv.Atoi(ctx.Query("businessType"))
+	businessId, _ := strconv.Atoi(ctx.Query("businessId"))
+	result.Ok(bookmarkS.FolderIds(ctx, middleware.GetUserId(ctx), businessType, businessId), "").Json(ctx)
+}
+
+// 用户的收藏夹,不传 userId 时查询自己的
+func listBookmarkFolders(ctx *gin.Context) {
+	viewerId := middleware.GetUserId(ctx)
+	ownerId, _ := strconv.Atoi(ctx.Query("userId"))
+	if ownerId == 0 {
+		ownerId = viewerId
+	}
+	result.Ok(bookmarkS.ListFolders(ctx, ownerId, viewerId), "").Json(ctx)
+}
+
+// 收藏夹及其中的收藏
+func getBookmarkFolder(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	p, limit := page.GetPage(ctx)
+	folder, items, count, err := bookmarkS.FolderItems(ctx, id, middleware.GetUserId(ctx), p, limit)
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Ok(map[string]any{
+		"folder": folder,
+		"list":   items,
+		"total":  count,
+	}, "").Json(ctx)
+}
+
+// 收藏文章、问答或课程章节
+func addBookmark(ctx *gin.Context) {
+	var req request.ReqBookmark
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	userId := middleware.GetUserId(ctx)
+	bookmark, err := bookmarkS.Add(ctx, userId, req)
+	if err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 收藏失败,类型: %d,id: %d,err: %s", userId, req.BusinessType, req.BusinessId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(bookmark, "收藏成功").Json(ctx)
+}
+
+// 移动收藏到其他收藏夹
+func moveBookmark(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	var req request.ReqBookmarkMove
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	userId := middleware.GetUserId(ctx)
+	if err := bookmarkS.Move(ctx, id, userId, req.FolderId); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 移动收藏失败,收藏id: %d,err: %s", userId, id, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "修改成功").Json(ctx)
+}
+
+// 取消收藏
+func removeBookmark(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	userId := middleware.GetUserId(ctx)
+	if err := bookmarkS.Remove(ctx, id, userId); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 取消收藏失败,收藏id: %d,err: %s", userId, id, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "已取消收藏").Json(ctx)
+}
+
+// 创建、修改收藏夹
+func saveBookmarkFolder(ctx *gin.Context) {
+	var folder model.BookmarkFolders
+	if err := ctx.ShouldBindJSON(&folder); err != nil {
+		result.Error(utils.ValidateErr(folder, err)).Json(ctx)
+		return
+	}
+	folder.UserId = middleware.GetUserId(ctx)
+	msg := "创建成功"
+	if folder.ID != 0 {
+		msg = "修改成功"
+	}
+	if err := bookmarkS.SaveFolder(ctx, &folder); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 保存收藏夹失败,err: %s", folder.UserId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(folder.ID, msg).Json(ctx)
+}
+
+// 删除收藏夹及其中的收藏
+func deleteBookmarkFolder(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	userId := middleware.GetUserId(ctx)
+	if err := bookmarkS.DeleteFolder(ctx, id, userId); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 删除收藏夹失败,收藏夹id: %d,err: %s", userId, id, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "删除成功").Json(ctx)
+}
@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/server/constants"
 	"xhyovo.cn/community/server/service/event"
@@ -15,6 +17,7 @@ import (
 	"xhyovo.cn/community/pkg/utils"
 	"xhyovo.cn/community/pkg/utils/page"
 	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
 	services "xhyovo.cn/community/server/service"
 )
 
@@ -25,10 +28,12 @@ func InitCommentRouters(g *gin.Engine) {
 	group.GET("/allCommentsByArticleId/:articleId", listAllCommentsByArticleId)
 	group.GET("/adaptions", adaptions)
 	group.GET("/byArticleId", listCommentsByArticleIdNoTree)
+	group.GET("/:id/reactions", listCommentReactions)
 	group.Use(middleware.OperLogger())
 	group.POST("/comment", comment)
 	group.DELETE("/:id", deleteComment)
 	group.POST("/adoption", adoption)
+	group.POST("/:id/reactions", reactComment)
 
 }
 
@@ -37,14 +42,14 @@ func adaptions(ctx *gin.Context) {
 	articleId, err := strconv.Atoi(ctx.Query("articleId"))
 	userId := middleware.GetUserId(ctx)
 	if err != nil {
-		log.Warnf("用户id: %d 获取采纳评论解析参数失败,err: %s", userId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 获取采纳评论解析参数失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	p, limit := page.GetPage(ctx)
 	commentsService := services.NewCommentService(ctx)
 
-	comments, count := commentsService.ListAdoptionsByArticleId(articleId, p, limit)
+	comments, count := commentsService.ListAdoptionsByArticleId(ctx, articleId, p, limit)
 	result.Page(comments, count, nil).Json(ctx)
 }
 
@@ -53,19 +58,19 @@ func comment(ctx *gin.Context) {
 	var comment model.Comments
 	userId := middleware.GetUserId(ctx)
 	if err := ctx.ShouldBindJSON(&comment); err != nil {
-		msg := utils.GetValidateErr(comment, err)
-		log.Warnf("用户id: %d 发布评论失败,err: %s", userId, msg)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(comment, err)
+		log.Ctx(ctx).Warnf("用户id: %d 发布评论失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	comment.FromUserId = userId
 
 	commentsService := services.NewCommentService(ctx)
-	err := commentsService.Comment(&comment)
+	err := commentsService.Comment(ctx, &comment)
 	msg := "评论成功"
 	if err != nil {
-		log.Warnf("用户id: %d 保存评论失败,err: %s", userId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 保存评论失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, msg).Json(ctx)
@@ -77,45 +82,49 @@ func deleteComment(ctx *gin.Context) {
 
 	userId := middleware.GetUserId(ctx)
 	if commentId == "" {
-		log.Warnf("用户id: %d 删除评论失败,err: %s", userId, "评论id为空")
-		result.Err("删除评论id不能为空").Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 删除评论失败,err: %s", userId, "评论id为空")
+		result.Error(errs.BadRequest.WithMsg("删除评论id不能为空")).Json(ctx)
 		return
 	}
 	commentIdInt, _ := strconv.Atoi(commentId)
 	var commentsService services.CommentsService
-	if !commentsService.DeleteComment(commentIdInt, userId) {
-		log.Warnf("用户id: %d 删除评论失败", userId)
+	if !commentsService.DeleteComment(ctx, commentIdInt, userId) {
+		log.Ctx(ctx).Warnf("用户id: %d 删除评论失败", userId)
 		result.Err("删除失败").Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
 }
 
-// 返回文章下的评论(文章页面展示)
+// 返回文章下的评论(文章页面展示),sort=likes 时按点赞数排序
 func listCommentsByArticleId(ctx *gin.Context) {
 	articleId, err := strconv.Atoi(ctx.Param("articleId"))
 	p, limit := page.GetPage(ctx)
 
 	if err != nil {
-		log.Warnf("用户id: %d 获取文章下的评论失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 获取文章下的评论失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	var commentsService services.CommentsService
-	comments, count := commentsService.GetCommentsByArticleID(p, limit, articleId)
+	comments, count := commentsService.GetCommentsByArticleID(ctx, p, limit, articleId, ctx.Query("sort"))
 	var adS services.QAAdoption
-	adS.SetAdoptionComment(comments)
+	adS.SetAdoptionComment(ctx, comments)
+	var reactionS services.CommentReactionService
+	reactionS.SetUserReactions(ctx, comments, middleware.GetUserId(ctx))
 	result.Ok(page.New(comments, count), "").Json(ctx)
 }
 
-// 查询根评论下的评论
+// 查询根评论下的评论,sort=likes 时按点赞数排序
 func listCommentsByRootId(ctx *gin.Context) {
 	rootId, _ := strconv.Atoi(ctx.Param("rootId"))
 	p, limit := page.GetPage(ctx)
 	var commentsService services.CommentsService
-	comments, count := commentsService.GetCommentsByRootID(p, limit, rootId)
+	comments, count := commentsService.GetCommentsByRootID(ctx, p, limit, rootId, ctx.Query("sort"))
 	var adS services.QAAdoption
-	adS.SetAdoptionComment(comments)
+	adS.SetAdoptionComment(ctx, comments)
+	var reactionS services.CommentReactionService
+	reactionS.SetUserReactions(ctx, comments, middleware.GetUserId(ctx))
 	result.Ok(page.New(comments, count), "").Json(ctx)
 
 }
@@ -127,9 +136,9 @@ func listAllCommentsByArticleId(ctx *gin.Context) {
 
 	userId := middleware.GetUserId(ctx)
 	var commentsService services.CommentsService
-	comments, count := commentsService.GetAllCommentsByArticleID(p, limit, userId, 0, 0)
+	comments, count := commentsService.GetAllCommentsByArticleID(ctx, p, limit, userId, 0, 0)
 	var adS services.QAAdoption
-	adS.SetAdoptionComment(comments)
+	adS.SetAdoptionComment(ctx, comments)
 	result.Ok(page.New(comments, count), "").Json(ctx)
 }
 
@@ -138,25 +147,25 @@ func adoption(ctx *gin.Context) {
 	var adoption model.QaAdoptions
 	userId := middleware.GetUserId(ctx)
 	if err := ctx.ShouldBindJSON(&adoption); err != nil {
-		msg := utils.GetValidateErr(adoption, err)
-		log.Warnf("用户id: %d 采纳评论参数解析失败,err :%s", userId, msg)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(adoption, err)
+		log.Ctx(ctx).Warnf("用户id: %d 采纳评论参数解析失败,err :%s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	var cS services.CommentsService
 	commentId := adoption.CommentId
-	comment := cS.GetById(commentId)
+	comment := cS.GetById(ctx, commentId)
 	articleId := comment.BusinessId
 	adoption.ArticleId = articleId
 	var msg string
 	// 采纳权限，文章得是本人,评论得存在
 	var aS services.ArticleService
-	article := aS.GetById(articleId)
+	article := aS.GetById(ctx, articleId)
 	state := article.State
 	if article.UserId != userId {
 		msg = fmt.Sprintf("用户id: %d 采纳评论无权限,文章id: %d", userId, articleId)
-		log.Warnln(msg)
-		result.Err("只有发布者运行采纳").Json(ctx)
+		log.Ctx(ctx).Warnln(msg)
+		result.Error(errs.Forbidden.WithMsg("只有发布者运行采纳")).Json(ctx)
 		return
 	}
 	if state != constant.PrivateQuestion {
@@ -166,32 +175,32 @@ func adoption(ctx *gin.Context) {
 		}
 	}
 
-	if !aS.Auth(userId, articleId) {
+	if !aS.Auth(ctx, userId, articleId) {
 		msg = fmt.Sprintf("用户id: %d 采纳评论无权限,文章id: %d", userId, articleId)
-		log.Warnln(msg)
+		log.Ctx(ctx).Warnln(msg)
 		result.Err(msg).Json(ctx)
 		return
 	}
 
 	if comment.ID == 0 {
 		msg = fmt.Sprintf("用户id: %d 采纳评论对应的评论不存在,评论id: %d", userId, commentId)
-		log.Warnln(msg)
+		log.Ctx(ctx).Warnln(msg)
 		result.Err(msg).Json(ctx)
 		return
 	}
 	var adptionS services.QAAdoption
 	msg = "取消采纳"
-	if adptionS.Adopt(articleId, commentId) {
+	if adptionS.Adopt(ctx, articleId, commentId) {
 		var suS services.SubscriptionService
-		suS.Send(event.Adoption, constant.NOTICE, userId, comment.FromUserId, services.SubscribeData{CommentId: commentId, ArticleId: articleId, UserId: userId, CurrentBusinessId: articleId})
+		suS.Send(ctx, event.Adoption, constant.NOTICE, userId, comment.FromUserId, services.SubscribeData{CommentId: commentId, ArticleId: articleId, UserId: userId, CurrentBusinessId: articleId})
 		msg = "已采纳"
 	}
 	if state != constant.PrivateQuestion {
 		// 采纳了,但是状态为未解决,则改为已解决
-		if adptionS.QAAdoptState(articleId) && state == constants.Pending {
-			aS.UpdateState(articleId, constants.Resolved)
-		} else if !adptionS.QAAdoptState(articleId) && state == constants.Resolved {
-			aS.UpdateState(articleId, constants.Pending)
+		if adptionS.QAAdoptState(ctx, articleId) && state == constants.Pending {
+			aS.UpdateState(ctx, articleId, constants.Resolved)
+		} else if !adptionS.QAAdoptState(ctx, articleId) && state == constants.Resolved {
+			aS.UpdateState(ctx, articleId, constants.Pending)
 		}
 	} else {
 		msg = "已采纳,当前版本私密提问采纳后无法变更为解决,请等待"
@@ -204,19 +213,66 @@ func adoption(ctx *gin.Context) {
 func listCommentsByArticleIdNoTree(ctx *gin.Context) {
 	businessId, err := strconv.Atoi(ctx.Query("businessId"))
 	if err != nil {
-		result.Err("获取文章下的所有评论,文章 id 解析失败, err:" + err.Error()).Json(ctx)
+		result.Err(i18n.T(ctx, "获取文章下的所有评论,文章 id 解析失败, err: %s", err.Error())).Json(ctx)
 		return
 	}
 	tenantId, err := strconv.Atoi(ctx.Query("tenantId"))
 	if err != nil {
-		log.Warnf("用户id: %d 查询用户文章下的所有评论失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err("查询对应模块id不可为空").Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 查询用户文章下的所有评论失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(errs.BadRequest.WithMsg("查询对应模块id不可为空")).Json(ctx)
 		return
 	}
 
 	var cS services.CommentsService
-	comments := cS.ListCommentsByArticleIdNoTree(businessId, tenantId)
+	comments := cS.ListCommentsByArticleIdNoTree(ctx, businessId, tenantId)
 	var adS services.QAAdoption
-	adS.SetAdoptionComment(comments)
+	adS.SetAdoptionComment(ctx, comments)
 	result.Ok(comments, "").Json(ctx)
 }
+
+// 对评论点赞或表态,再次提交时取消
+func reactComment(ctx *gin.Context) {
+	userId := middleware.GetUserId(ctx)
+	commentId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 评论表态解析评论id失败,err: %s", userId, err.Error())
+		result.Error(errs.BadRequest.WithMsg("评论id不合法")).Json(ctx)
+		return
+	}
+	var req request.ReqCommentReaction
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		err = utils.ValidateErr(req, err)
+		log.Ctx(ctx).Warnf("用户id: %d 评论表态参数解析失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	var reactionS services.CommentReactionService
+	added, err := reactionS.React(ctx, commentId, userId, req.Reaction)
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	msg := "取消表态"
+	if added {
+		msg = "已表态"
+	}
+	result.OkWithMsg(added, msg).Json(ctx)
+}
+
+// 对评论表态的用户,reaction 为空时返回所有表态
+func listCommentReactions(ctx *gin.Context) {
+	commentId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 查询评论表态解析评论id失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(errs.BadRequest.WithMsg("评论id不合法")).Json(ctx)
+		return
+	}
+	p, limit := page.GetPage(ctx)
+	var reactionS services.CommentReactionService
+	users, count, err := reactionS.ListUsers(ctx, commentId, middleware.GetUserId(ctx), ctx.Query("reaction"), p, limit)
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Page(users, count, nil).Json(ctx)
+}
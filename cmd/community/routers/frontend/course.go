@@ -27,18 +27,18 @@ func GetCourseDetail(ctx *gin.Context) {
 	courseId, err := strconv.Atoi(ctx.Param("id"))
 	userId := middleware.GetUserId(ctx)
 	if err != nil {
-		log.Warnf("用户: %d ,获取课程详细信息失败,err: %s", userId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户: %d ,获取课程详细信息失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 
-	result.Ok(courseService.GetCourseDetail(courseId), "").Json(ctx)
+	result.Ok(courseService.GetCourseDetail(ctx, courseId), "").Json(ctx)
 }
 
 // 获取课程列表
 func ListCourse(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
-	courses, count := courseService.PageCourse(p, limit)
+	courses, count := courseService.PageCourse(ctx, p, limit)
 	result.Page(courses, count, nil).Json(ctx)
 }
 
@@ -47,11 +47,11 @@ func GetCourseSectionDetail(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	userId := middleware.GetUserId(ctx)
 	if err != nil {
-		log.Warnf("用户: %d,获取课程详细信息失败,err: %s", userId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户: %d,获取课程详细信息失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
-	detail := courseService.GetCourseSectionDetail(id)
+	detail := courseService.GetCourseSectionDetail(ctx, id)
 	result.Ok(detail, "").Json(ctx)
 }
 
@@ -61,11 +61,11 @@ func ListCourseSection(ctx *gin.Context) {
 	courseId, err := strconv.Atoi(ctx.Query("courseId"))
 	userId := middleware.GetUserId(ctx)
 	if err != nil {
-		log.Warnf("用户: %d,获取课程列表信息失败,err: %s", userId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户: %d,获取课程列表信息失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
-	courses, count := courseService.PageCourseSection(p, limit, courseId)
+	courses, count := courseService.PageCourseSection(ctx, p, limit, courseId)
 	result.Page(courses, count, nil).Json(ctx)
 	return
 }
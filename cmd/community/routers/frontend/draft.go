@@ -20,20 +20,20 @@ func initDraft(ctx *gin.Context) {
 
 	// 获取所有用户
 	var userIds []int
-	model.User().Select("id").Find(&userIds)
+	model.User(ctx).Select("id").Find(&userIds)
 
 	var drafts = make([]model.Drafts, 0, len(userIds))
 	for i := range userIds {
 		drafts = append(drafts, model.Drafts{UserId: userIds[i], State: 2})
 	}
-	model.Draft().Create(drafts)
+	model.Draft(ctx).Create(drafts)
 	result.Ok(nil, "").Json(ctx)
 }
 
 func getDraft(ctx *gin.Context) {
 	var d services.Draft
 	userId := middleware.GetUserId(ctx)
-	draft := d.Get(userId)
+	draft := d.Get(ctx, userId)
 	result.Ok(draft, "").Json(ctx)
 }
 
@@ -42,13 +42,13 @@ func saveDraft(ctx *gin.Context) {
 	var draft model.Drafts
 	userId := middleware.GetUserId(ctx)
 	if err := ctx.ShouldBindJSON(&draft); err != nil {
-		log.Warnf("用户id: %d,临时存储文章参数解析错误,err %s", userId, err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d,临时存储文章参数解析错误,err %s", userId, err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	draft.UserId = userId
 	var d services.Draft
-	d.Save(draft)
+	d.Save(ctx, draft)
 	result.Ok(nil, "").Json(ctx)
 
 }
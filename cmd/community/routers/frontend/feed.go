@@ -0,0 +1,124 @@
+package frontend
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/feed"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	services "xhyovo.cn/community/server/service"
+)
+
+// 订阅源的缓存时间,单位秒
+const feedMaxAge = "300"
+
+var feedService services.FeedService
+
+// InitFeedRouters 订阅源给阅读器使用,不需要登录,会员分类通过 token 参数访问
+func InitFeedRouters(r *gin.Engine) {
+	group := r.Group("/community/feed")
+	group.GET("/cover/:id", feedCover)
+	group.GET("/:format", feedLatest)
+	group.GET("/:format/type/:flag", feedByType)
+	group.GET("/:format/tag/:id", feedByTag)
+	group.GET("/:format/author/:id", feedByAuthor)
+}
+
+func feedLatest(ctx *gin.Context) {
+	renderFeed(ctx, services.FeedOptions{})
+}
+
+func feedByType(ctx *gin.Context) {
+	renderFeed(ctx, services.FeedOptions{TypeFlag: ctx.Param("flag")})
+}
+
+func feedByTag(ctx *gin.Context) {
+	tagId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("标签id不正确")).Json(ctx)
+		return
+	}
+	renderFeed(ctx, services.FeedOptions{TagId: tagId})
+}
+
+func feedByAuthor(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("用户id不正确")).Json(ctx)
+		return
+	}
+	renderFeed(ctx, services.FeedOptions{AuthorId: userId})
+}
+
+func renderFeed(ctx *gin.Context, opts services.FeedOptions) {
+	format := ctx.Param("format")
+	contentType, ok := feed.ContentTypes[format]
+	if !ok {
+		result.Error(errs.NotFound.WithMsg("不支持的订阅格式")).Json(ctx)
+		return
+	}
+	opts.Token = ctx.Query("token")
+	f, err := feedService.Feed(ctx, opts)
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	f.FeedLink = strings.TrimRight(config.GetInstance().SiteConfig.Url, "/") + ctx.Request.URL.RequestURI()
+	b, _, err := f.Render(format)
+	if err != nil {
+		log.Ctx(ctx).Warnf("生成订阅源失败,format: %s,err: %s", format, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	// 会员订阅的内容不能被共享缓存
+	if opts.Token != "" {
+		ctx.Header("Cache-Control", "private, max-age="+feedMaxAge)
+	} else {
+		ctx.Header("Cache-Control", "public, max-age="+feedMaxAge)
+	}
+	ctx.Data(http.StatusOK, contentType, b)
+}
+
+// 订阅源中的封面,跳转到签名地址
+func feedCover(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		result.Error(errs.BadRequest.WithMsg("文章id不正确")).Json(ctx)
+		return
+	}
+	u, err := feedService.Cover(ctx, articleId, ctx.Query("token"))
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	ctx.Redirect(http.StatusFound, u)
+}
+
+// 获取订阅 token,没有时生成
+func getFeedToken(ctx *gin.Context) {
+	token, err := feedService.Token(ctx, middleware.GetUserId(ctx))
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Ok(map[string]string{"token": token}, "").Json(ctx)
+}
+
+// 重新生成订阅 token,旧的订阅地址失效
+func resetFeedToken(ctx *gin.Context) {
+	userId := middleware.GetUserId(ctx)
+	token, err := feedService.ResetToken(ctx, userId)
+	if err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 重置订阅 token 失败,err: %s", userId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	log.Ctx(ctx).Infof("用户id: %d 重置订阅 token", userId)
+	result.OkWithMsg(map[string]string{"token": token}, "重置成功").Json(ctx)
+}
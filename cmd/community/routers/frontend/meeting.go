@@ -32,7 +32,7 @@ func managerMeeting(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	userId := middleware.GetUserId(ctx)
 	var meetingService services.MeetingService
-	meetings, count := meetingService.Page(p, limit, userId)
+	meetings, count := meetingService.Page(ctx, p, limit, userId)
 	result.Page(meetings, count, nil).Json(ctx)
 }
 
@@ -40,8 +40,8 @@ func managerMeeting(ctx *gin.Context) {
 func applyMeeting(ctx *gin.Context) {
 	var reqMeeting request.ReqMeeting
 	if err := ctx.ShouldBindJSON(&reqMeeting); err != nil {
-		msg := utils.GetValidateErr(&reqMeeting, err)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(&reqMeeting, err)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
@@ -51,8 +51,8 @@ func applyMeeting(ctx *gin.Context) {
 	meeting.Description = reqMeeting.Description
 	meeting.InitiatorId = middleware.GetUserId(ctx)
 	meeting.InitiatorTime = reqMeeting.InitiatorTime
-	if err := meetingService.Save(meeting); err != nil {
-		result.Err(err.Error()).Json(ctx)
+	if err := meetingService.Save(ctx, meeting); err != nil {
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "申请成功,等待管理员审核").Json(ctx)
@@ -61,18 +61,18 @@ func applyMeeting(ctx *gin.Context) {
 func pageMeeting(ctx *gin.Context) {
 	p, limit := page.GetPage(ctx)
 	var meetingService services.MeetingService
-	meetings, count := meetingService.Page(p, limit, 0)
+	meetings, count := meetingService.Page(ctx, p, limit, 0)
 	result.Page(meetings, count, nil).Json(ctx)
 }
 func getMeeting(ctx *gin.Context) {
 	id := ctx.Param("id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
-	meeting := meetingService.GetById(idInt)
+	meeting := meetingService.GetById(ctx, idInt)
 	result.Ok(meeting, "").Json(ctx)
 
 }
@@ -81,13 +81,13 @@ func deleteMeeting(ctx *gin.Context) {
 	id := ctx.Param("id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	userId := middleware.GetUserId(ctx)
 	var meetingService services.MeetingService
-	if err = meetingService.DeleteById(idInt, userId); err != nil {
-		result.Err(err.Error()).Json(ctx)
+	if err = meetingService.DeleteById(ctx, idInt, userId); err != nil {
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "删除成功").Json(ctx)
@@ -97,13 +97,13 @@ func deleteMeeting(ctx *gin.Context) {
 func joinMeeting(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
 	userId := middleware.GetUserId(ctx)
-	if err = meetingService.JoinMeeting(id, userId); err != nil {
-		result.Err(err.Error()).Json(ctx)
+	if err = meetingService.JoinMeeting(ctx, id, userId); err != nil {
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "加入成功").Json(ctx)
@@ -112,13 +112,13 @@ func joinMeeting(ctx *gin.Context) {
 func quitMeeting(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
 	userId := middleware.GetUserId(ctx)
-	if err = meetingService.QuitJoinMeeting(id, userId); err != nil {
-		result.Err(err.Error()).Json(ctx)
+	if err = meetingService.QuitJoinMeeting(ctx, id, userId); err != nil {
+		result.Error(err).Json(ctx)
 		return
 	}
 	result.OkWithMsg(nil, "退出成功").Json(ctx)
@@ -128,14 +128,14 @@ func inMeetingState(ctx *gin.Context) {
 
 	idInt, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	var meetingService services.MeetingService
 	userId := middleware.GetUserId(ctx)
-	state, err := meetingService.InMeetingState(idInt, userId)
+	state, err := meetingService.InMeetingState(ctx, idInt, userId)
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 
@@ -145,11 +145,11 @@ func inMeetingState(ctx *gin.Context) {
 func getJoinMeetingUsers(ctx *gin.Context) {
 	idInt, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		result.Err(err.Error())
+		result.Error(err)
 		return
 	}
 	var meetingService services.MeetingService
-	avatars := meetingService.GetJoinMeetingUserSelectAvatar(idInt)
+	avatars := meetingService.GetJoinMeetingUserSelectAvatar(ctx, idInt)
 
 	result.Ok(avatars, "").Json(ctx)
 	return
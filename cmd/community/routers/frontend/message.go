@@ -31,7 +31,7 @@ func pageName(ctx *gin.Context) {
 func getUnReadMsgCount(ctx *gin.Context) {
 	userId := middleware.GetUserId(ctx)
 	var msgService services.MessageService
-	count := msgService.GetUnReadMessageCountByUserId(userId)
+	count := msgService.GetUnReadMessageCountByUserId(ctx, userId)
 	result.Ok(count, "").Json(ctx)
 }
 
@@ -44,7 +44,7 @@ func listMsg(ctx *gin.Context) {
 	atoi2, _ := strconv.Atoi(states)
 	userId := middleware.GetUserId(ctx)
 	var msgService services.MessageService
-	message, count := msgService.PageMessage(p, limit, userId, atoi, atoi2)
+	message, count := msgService.PageMessage(ctx, p, limit, userId, atoi, atoi2)
 	result.Ok(page.New(message, count), "").Json(ctx)
 }
 
@@ -52,12 +52,12 @@ func listMsg(ctx *gin.Context) {
 func readMsg(ctx *gin.Context) {
 	var ids []int
 	if err := ctx.ShouldBindJSON(&ids); err != nil && len(ids) > 0 {
-		log.Warnf("用户id: %d 阅读消息参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 阅读消息参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	var msgService services.MessageService
-	number := msgService.ReadMessage(ids, middleware.GetUserId(ctx))
+	number := msgService.ReadMessage(ctx, ids, middleware.GetUserId(ctx))
 	result.OkWithMsg(nil, fmt.Sprintf("已读%d消息", number)).Json(ctx)
 }
 
@@ -67,7 +67,7 @@ func readMsg2(ctx *gin.Context) {
 	businessId, _ := strconv.Atoi("businessId")
 
 	var msgService services.MessageService
-	number := msgService.ReadMessage2(typee, eventId, businessId, middleware.GetUserId(ctx))
+	number := msgService.ReadMessage2(ctx, typee, eventId, businessId, middleware.GetUserId(ctx))
 	result.OkWithMsg(nil, fmt.Sprintf("已读%d消息", number)).Json(ctx)
 }
 
@@ -75,11 +75,11 @@ func readMsg2(ctx *gin.Context) {
 func clearUnReadMsg(ctx *gin.Context) {
 	msgType, err := strconv.Atoi(ctx.Param("type"))
 	if err != nil {
-		log.Warnf("用户id: %d 清除未读消息参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 清除未读消息参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	var msgService services.MessageService
-	msgService.ClearUnReadMessage(msgType, middleware.GetUserId(ctx))
+	msgService.ClearUnReadMessage(ctx, msgType, middleware.GetUserId(ctx))
 	result.OkWithMsg(nil, "已清空").Json(ctx)
 }
@@ -24,29 +24,33 @@ func InitNoteRouters(r *gin.Engine) {
 func getRate(ctx *gin.Context) {
 	userId := middleware.GetUserId(ctx)
 
-	result.Ok(noteService.GetById(userId), "").Json(ctx)
+	result.Ok(noteService.GetById(ctx, userId), "").Json(ctx)
 }
 
 func commentRate(ctx *gin.Context) {
 	var note model.Rates
 	if err := ctx.ShouldBindJSON(&note); err != nil {
-		msg := utils.GetValidateErr(note, err)
-		log.Warnf("用户id: %d 保存留言解析失败 ,err: %s", middleware.GetUserId(ctx), msg)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(note, err)
+		log.Ctx(ctx).Warnf("用户id: %d 保存留言解析失败 ,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	note.UserId = middleware.GetUserId(ctx)
-	noteService.Comment(note)
+	if err := noteService.Comment(ctx, note); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 保存留言失败 ,err: %s", note.UserId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
 	result.OkWithMsg(nil, "保存成功").Json(ctx)
 }
 
 func deleteRate(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		result.Err(err.Error()).Json(ctx)
+		result.Error(err).Json(ctx)
 		return
 	}
 	userId := middleware.GetUserId(ctx)
-	noteService.Delete(id, userId)
+	noteService.Delete(ctx, id, userId)
 	result.OkWithMsg(nil, "删除成功")
 }
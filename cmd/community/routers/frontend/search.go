@@ -0,0 +1,34 @@
+package frontend
+
+import (
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/pkg/utils/page"
+	"xhyovo.cn/community/server/request"
+	services "xhyovo.cn/community/server/service"
+)
+
+var searchS services.SearchService
+
+func InitSearchRouters(r *gin.Engine) {
+	r.GET("/community/search", search)
+}
+
+// 搜索文章、问答、评论、课程章节
+func search(ctx *gin.Context) {
+	var req request.ReqSearch
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	p, limit := page.GetPage(ctx)
+	results, count, err := searchS.Search(ctx, req, p, limit)
+	if err != nil {
+		log.Ctx(ctx).Warnf("搜索失败,q: %s,err: %s", req.Keyword, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Page(results, count, nil).Json(ctx)
+}
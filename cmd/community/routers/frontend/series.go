@@ -0,0 +1,128 @@
+package frontend
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/result"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/pkg/utils/page"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+	services "xhyovo.cn/community/server/service"
+)
+
+var seriesS services.SeriesService
+
+func InitSeriesRouters(r *gin.Engine) {
+	group := r.Group("/community/series")
+	group.GET("", pageSeries)
+	group.GET("/:id", getSeries)
+
+	group.Use(middleware.OperLogger())
+	group.POST("", saveSeries)
+	group.DELETE("/:id", deleteSeries)
+	group.POST("/:id/articles", addSeriesArticle)
+	group.DELETE("/:id/articles/:articleId", removeSeriesArticle)
+	group.PUT("/:id/articles", sortSeriesArticles)
+}
+
+// 系列列表,传 userId 时只查询该用户的系列
+func pageSeries(ctx *gin.Context) {
+	p, limit := page.GetPage(ctx)
+	userId, _ := strconv.Atoi(ctx.Query("userId"))
+	list, count := seriesS.Page(ctx, userId, p, limit)
+	result.Page(list, count, nil).Json(ctx)
+}
+
+// 系列详情及按顺序排列的文章
+func getSeries(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	detail, err := seriesS.Detail(ctx, id, middleware.GetUserId(ctx))
+	if err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.Ok(detail, "").Json(ctx)
+}
+
+// 创建、修改系列
+func saveSeries(ctx *gin.Context) {
+	var series model.ArticleSeries
+	if err := ctx.ShouldBindJSON(&series); err != nil {
+		result.Error(utils.ValidateErr(series, err)).Json(ctx)
+		return
+	}
+	series.UserId = middleware.GetUserId(ctx)
+	msg := "创建成功"
+	if series.ID != 0 {
+		msg = "修改成功"
+	}
+	if err := seriesS.Save(ctx, &series); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 保存系列失败,err: %s", series.UserId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(series.ID, msg).Json(ctx)
+}
+
+// 删除系列,系列中的文章保留
+func deleteSeries(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	userId := middleware.GetUserId(ctx)
+	if err := seriesS.Delete(ctx, id, userId); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 删除系列失败,系列id: %d,err: %s", userId, id, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "删除成功").Json(ctx)
+}
+
+// 把文章加到系列末尾
+func addSeriesArticle(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	var req request.ReqSeriesArticle
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	userId := middleware.GetUserId(ctx)
+	if err := seriesS.AddArticle(ctx, id, req.ArticleId, userId); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 文章加入系列失败,系列id: %d,文章id: %d,err: %s", userId, id, req.ArticleId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "添加成功").Json(ctx)
+}
+
+// 把文章移出系列
+func removeSeriesArticle(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	articleId, _ := strconv.Atoi(ctx.Param("articleId"))
+	userId := middleware.GetUserId(ctx)
+	if err := seriesS.RemoveArticle(ctx, id, articleId, userId); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 文章移出系列失败,系列id: %d,文章id: %d,err: %s", userId, id, articleId, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "移除成功").Json(ctx)
+}
+
+// 调整系列中文章的顺序
+func sortSeriesArticles(ctx *gin.Context) {
+	id, _ := strconv.Atoi(ctx.Param("id"))
+	var req request.ReqSeriesSort
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		result.Error(utils.ValidateErr(req, err)).Json(ctx)
+		return
+	}
+	userId := middleware.GetUserId(ctx)
+	if err := seriesS.Sort(ctx, id, userId, req.ArticleIds); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 调整系列顺序失败,系列id: %d,err: %s", userId, id, err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "修改成功").Json(ctx)
+}
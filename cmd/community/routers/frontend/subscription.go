@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"strconv"
 	"xhyovo.cn/community/cmd/community/middleware"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	"xhyovo.cn/community/pkg/utils"
@@ -28,7 +29,7 @@ func listSubscription(ctx *gin.Context) {
 	userId := middleware.GetUserId(ctx)
 	eventId, _ := strconv.Atoi(ctx.DefaultQuery("eventId", "0"))
 	page, limit := page2.GetPage(ctx)
-	subscriptions, count := su.ListSubscription(userId, eventId, page, limit)
+	subscriptions, count := su.ListSubscription(ctx, userId, eventId, page, limit)
 	result.Page(subscriptions, count, nil).Json(ctx)
 }
 
@@ -37,13 +38,13 @@ func subscriptionState(ctx *gin.Context) {
 	var su services.SubscriptionService
 	var subscription model.SubscriptionState
 	if err := ctx.ShouldBindJSON(&subscription); err != nil {
-		log.Warnf("用户id: %d 查看事件订阅状态参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
-		result.Err(utils.GetValidateErr(subscription, err)).Json(ctx)
+		log.Ctx(ctx).Warnf("用户id: %d 查看事件订阅状态参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(utils.ValidateErr(subscription, err)).Json(ctx)
 		return
 	}
 	userId := middleware.GetUserId(ctx)
 	subscription.SubscriberId = userId
-	result.Ok(su.SubscriptionState(&subscription), "").Json(ctx)
+	result.Ok(su.SubscriptionState(ctx, &subscription), "").Json(ctx)
 }
 
 // 订阅/取消订阅
@@ -51,9 +52,9 @@ func subscribe(ctx *gin.Context) {
 
 	var subscription model.Subscriptions
 	if err := ctx.ShouldBindJSON(&subscription); err != nil {
-		msg := utils.GetValidateErr(comment, err)
-		log.Warnf("用户id: %d 订阅事件参数解析失败,err: %s", middleware.GetUserId(ctx), msg)
-		result.Err(msg).Json(ctx)
+		err = utils.ValidateErr(comment, err)
+		log.Ctx(ctx).Warnf("用户id: %d 订阅事件参数解析失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
 	userId := middleware.GetUserId(ctx)
@@ -64,23 +65,23 @@ func subscribe(ctx *gin.Context) {
 	var a services.ArticleService
 	var flag bool = false
 	if subscription.EventId == event.CommentUpdateEvent {
-		if a.GetById(businessId).UserId == userId {
+		if a.GetById(ctx, businessId).UserId == userId {
 			flag = true
 		}
 	}
 
 	if subscription.EventId == event.UserFollowingEvent && businessId == userId {
-		result.Err("关注用户不能是自己").Json(ctx)
+		result.Error(errs.BadRequest.WithMsg("关注用户不能是自己")).Json(ctx)
 		return
 	} else if flag {
-		result.Err("订阅文章不能是自己所发布").Json(ctx)
+		result.Error(errs.BadRequest.WithMsg("订阅文章不能是自己所发布")).Json(ctx)
 		return
 	}
 
 	var su services.SubscriptionService
 	msg := "取消订阅"
 	flag = false
-	if su.Subscribe(&subscription) {
+	if su.Subscribe(ctx, &subscription) {
 		msg = "订阅成功"
 		flag = true
 	}
@@ -88,5 +89,5 @@ func subscribe(ctx *gin.Context) {
 }
 
 func eventList(ctx *gin.Context) {
-	result.Ok(event.List(), "").Json(ctx)
+	result.Ok(event.List(ctx), "").Json(ctx)
 }
@@ -20,11 +20,11 @@ func list(ctx *gin.Context) {
 		parentId = 0
 	}
 	var typeService services.TypeService
-	result.Ok(typeService.List(parentId), "").Json(ctx)
+	result.Ok(typeService.List(ctx, parentId), "").Json(ctx)
 }
 
 func treeTypes(ctx *gin.Context) {
 	var typeService services.TypeService
-	types, _ := typeService.PageTypes(1, 99)
+	types, _ := typeService.PageTypes(ctx, 1, 99)
 	result.Ok(types, "").Json(ctx)
 }
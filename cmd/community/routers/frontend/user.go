@@ -34,29 +34,68 @@ type editPasswordForm struct {
 	ConfirmPassword string `form:"confirmPassword" binding:"required" msg:"确认密码不能为空"`
 }
 
+type editLocaleForm struct {
+	Locale string `json:"locale"` // 为空时按请求头协商
+}
+
 func InitUserRouters(r *gin.Engine) {
 	group := r.Group("/community/user")
 	group.GET("/info", getUserInfo)
 	group.GET("/menu", getUserMenu)
+	group.GET("/permissions", getUserPermissions)
 	group.GET("/statistics", statistics)
 	group.GET("", listUsers)
 	group.GET("/tags/:userId", getTagsByUserId)
 	group.GET("/active", activeUsers)
 	group.GET("/all", listAllUsers)
 	group.GET("/heart", heart)
+	// 操作日志会记录响应,订阅 token 不经过 OperLogger
+	group.GET("/feed-token", getFeedToken)
+	group.POST("/feed-token", resetFeedToken)
 	group.Use(middleware.OperLogger())
 	group.POST("/edit/:tab", updateUser)
+	group.PUT("/locale", updateLocale)
+}
+
+// 修改语言设置
+func updateLocale(ctx *gin.Context) {
+	var form editLocaleForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		result.Error(utils.ValidateErr(form, err)).Json(ctx)
+		return
+	}
+	if err := userService.UpdateLocale(ctx, middleware.GetUserId(ctx), form.Locale); err != nil {
+		result.Error(err).Json(ctx)
+		return
+	}
+	result.OkWithMsg(nil, "修改成功").Json(ctx)
 }
+
 func activeUsers(ctx *gin.Context) {
 	var u services.UserService
 	p, limit := page.GetPage(ctx)
-	users, count := u.ActiveUsers(p, limit)
+	users, count := u.ActiveUsers(ctx, p, limit)
 	result.Page(users, count, nil).Json(ctx)
 
 }
 
 func getUserMenu(ctx *gin.Context) {
-	result.Ok(userService.GetUserMenu(), "ok").Json(ctx)
+	result.Ok(userService.GetUserMenu(ctx), "ok").Json(ctx)
+}
+
+// 当前用户的后台权限,前端据此展示后台菜单
+func getUserPermissions(ctx *gin.Context) {
+	var roleS services.RoleService
+	permissions, err := roleS.Permissions(ctx, middleware.GetUserId(ctx))
+	if err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 查询权限失败,err: %s", middleware.GetUserId(ctx), err.Error())
+		result.Error(err).Json(ctx)
+		return
+	}
+	if permissions == nil {
+		permissions = []string{}
+	}
+	result.Ok(permissions, "").Json(ctx)
 }
 
 // 获取用户信息
@@ -68,7 +107,7 @@ func getUserInfo(ctx *gin.Context) {
 	if err != nil {
 		uId = middleware.GetUserId(ctx)
 	}
-	user := userService.GetUserSimpleById(uId)
+	user := userService.GetUserSimpleById(ctx, uId)
 
 	result.Ok(user, "").Json(ctx)
 }
@@ -83,60 +122,60 @@ func updateUser(ctx *gin.Context) {
 		err := ctx.ShouldBind(&form)
 
 		if err != nil {
-			log.Warnf("用户id: %d 修改信息参数解析失败,err: %s", userId, err.Error())
-			result.Err(utils.GetValidateErr(form, err)).Json(ctx)
+			log.Ctx(ctx).Warnf("用户id: %d 修改信息参数解析失败,err: %s", userId, err.Error())
+			result.Error(utils.ValidateErr(form, err)).Json(ctx)
 			return
 		}
 		if len(form.Desc) > 200 {
 			var msg = "描述长度不可超过200字"
-			log.Warnf("用户id: %d 修改信息失败,err: %s", userId, msg)
+			log.Ctx(ctx).Warnf("用户id: %d 修改信息失败,err: %s", userId, msg)
 			result.Err(msg).Json(ctx)
 			return
 		}
-		userService.UpdateUser(&model.Users{Name: form.Name, Desc: form.Desc, ID: userId, Subscribe: form.Subscribe})
+		userService.UpdateUser(ctx, &model.Users{Name: form.Name, Desc: form.Desc, ID: userId, Subscribe: form.Subscribe})
 	case "pass":
 		form := editPasswordForm{}
 		err := ctx.ShouldBind(&form)
 		if err != nil {
-			log.Warnf("用户id: %d 修改密码参数解析失败,err: %s", userId, err.Error())
-			result.Err(utils.GetValidateErr(form, err)).Json(ctx)
+			log.Ctx(ctx).Warnf("用户id: %d 修改密码参数解析失败,err: %s", userId, err.Error())
+			result.Error(utils.ValidateErr(form, err)).Json(ctx)
 			return
 		}
 		// check 旧密码
 
-		if !services.ComparePswd(userService.GetUserById(userId).Password, form.OldPassword) {
+		if !services.ComparePswd(userService.GetUserById(ctx, userId).Password, form.OldPassword) {
 			var msg = "旧密码不一致"
-			log.Warnf("用户id: %d 修改密码失败,err: %s", userId, msg)
+			log.Ctx(ctx).Warnf("用户id: %d 修改密码失败,err: %s", userId, msg)
 			result.Err(msg).Json(ctx)
 			return
 		}
 		// check 新密码
 		if form.NewPassword != form.ConfirmPassword {
 			var msg = "两次新密码不一致"
-			log.Warnf("用户id: %d 修改密码失败,err: %s", userId, msg)
+			log.Ctx(ctx).Warnf("用户id: %d 修改密码失败,err: %s", userId, msg)
 			result.Err(msg).Json(ctx)
 			return
 		}
 		pwd, err := services.GetPwd(form.ConfirmPassword)
 		if err != nil {
 			var msg = "加密密码错误"
-			log.Warnf("用户id: %d 修改密码失败,err: %s", userId, msg)
+			log.Ctx(ctx).Warnf("用户id: %d 修改密码失败,err: %s", userId, msg)
 			result.Err(msg).Json(ctx)
 			return
 		}
-		userService.UpdateUser(&model.Users{Password: string(pwd), ID: userId})
+		userService.UpdateUser(ctx, &model.Users{Password: string(pwd), ID: userId})
 	case "avatar":
 		type avatar struct {
 			Avatar string `json:"avatar" binding:"required" msg:"头像不能为空"`
 		}
 		object := &avatar{}
 		if err := ctx.ShouldBindJSON(&object); err != nil {
-			log.Warnf("用户id: %d 修改头像参数解析失败,err: %s", userId, err.Error())
-			result.Err(utils.GetValidateErr(object, err)).Json(ctx)
+			log.Ctx(ctx).Warnf("用户id: %d 修改头像参数解析失败,err: %s", userId, err.Error())
+			result.Error(utils.ValidateErr(object, err)).Json(ctx)
 			return
 		}
 		// 更改用户信息
-		userService.UpdateUser(&model.Users{ID: userId, Avatar: object.Avatar})
+		userService.UpdateUser(ctx, &model.Users{ID: userId, Avatar: object.Avatar})
 	}
 	result.OkWithMsg(nil, "修改成功").Json(ctx)
 }
@@ -148,13 +187,13 @@ func statistics(ctx *gin.Context) {
 		types = 1
 	}
 	userId := middleware.GetUserId(ctx)
-	m := userService.Statistics(userId, types)
+	m := userService.Statistics(ctx, userId, types)
 	result.Ok(m, "").Json(ctx)
 }
 
 func listUsers(ctx *gin.Context) {
 	name := ctx.Query("name")
-	users := userService.ListUsers(name)
+	users := userService.ListUsers(ctx, name)
 	result.Ok(users, "").Json(ctx)
 }
 
@@ -163,18 +202,18 @@ var userTagS services.UserTag
 func getTagsByUserId(ctx *gin.Context) {
 	userId, err := strconv.Atoi(ctx.Param("userId"))
 	if err != nil {
-		log.Warnf("获取用户标签参数解析失败,err: %s", err.Error())
-		result.Err(err.Error()).Json(ctx)
+		log.Ctx(ctx).Warnf("获取用户标签参数解析失败,err: %s", err.Error())
+		result.Error(err).Json(ctx)
 		return
 	}
-	tagNames := userTagS.GetTagsByUserId(userId)
+	tagNames := userTagS.GetTagsByUserId(ctx, userId)
 	result.Ok(tagNames, "").Json(ctx)
 }
 
 // 查询所有用户
 func listAllUsers(ctx *gin.Context) {
 	var data []model.Users
-	model.User().Select("id", "name").Find(&data)
+	model.User(ctx).Select("id", "name").Find(&data)
 
 	users := []map[string]interface{}{}
 
@@ -199,7 +238,13 @@ func heart(ctx *gin.Context) {
 		token, _ = ctx.Cookie(middleware.AUTHORIZATION)
 	}
 	key := constant.HEARTBEAT + strconv.Itoa(userId)
-	valueIp, b := cache.Get(key)
+	valueIp, b, err := cache.Get(key)
+	if err != nil {
+		// 缓存不可用时不影响用户使用
+		log.Ctx(ctx).Warnf("用户id: %d 获取心跳信息失败,err: %s", userId, err.Error())
+		result.Ok(nil, "").Json(ctx)
+		return
+	}
 	// ip 不一致则说明同一时间内多个用户使用一个账号
 	if b {
 		if valueIp != ip {
@@ -208,13 +253,15 @@ func heart(ctx *gin.Context) {
 			// 2.后端将 token 存入黑名单
 			// 3.对黑名单进行计数：可能用户不服
 			var blackService = services.BlacklistService{}
-			blackService.Add(userId, token)
-			blackService.AddBlackByToken(token)
+			blackService.Add(ctx, userId, token)
+			blackService.AddBlackByToken(ctx, token)
 			result.Err("你已涉嫌同一账号多人使用，请注意你的行为").Json(ctx)
 			return
 		}
 	} else {
-		cache.Set(key, ip, constant.HEARTBEAT_TTL)
+		if err = cache.Set(key, ip, constant.HEARTBEAT_TTL); err != nil {
+			log.Ctx(ctx).Warnf("用户id: %d 保存心跳信息失败,err: %s", userId, err.Error())
+		}
 	}
 	result.Ok(nil, "").Json(ctx)
 	return
@@ -0,0 +1,38 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"xhyovo.cn/community/pkg/health"
+	"xhyovo.cn/community/pkg/lifecycle"
+)
+
+// 探活、就绪检查和监控指标,不需要登录
+func InitHealthRouters(r *gin.Engine) {
+	r.GET("/healthz", healthz)
+	r.GET("/readyz", readyz)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+}
+
+// 检查 db、存储、smtp,任一失败返回 503
+func healthz(ctx *gin.Context) {
+	checks, ok := health.Check(ctx.Request.Context())
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, gin.H{"ok": ok, "checks": checks})
+}
+
+// 在 healthz 的基础上要求所有组件启动完成,停止过程中返回 503 使流量不再进入
+func readyz(ctx *gin.Context) {
+	ready := lifecycle.GetInstance().Ready()
+	checks, ok := health.Check(ctx.Request.Context())
+	status := http.StatusOK
+	if !ready || !ok {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, gin.H{"ok": ready && ok, "ready": ready, "checks": checks})
+}
@@ -19,7 +19,7 @@ func pageRate(ctx *gin.Context) {
 
 	p, limit := page.GetPage(ctx)
 	var noteService services.RateService
-	state, notes := noteService.Page(p, limit)
+	state, notes := noteService.Page(ctx, p, limit)
 
 	result.Ok(map[string]interface{}{
 		"state": state,
@@ -30,7 +30,7 @@ func pageRate(ctx *gin.Context) {
 
 func getUserCount(ctx *gin.Context) {
 	var count int64
-	model.User().Count(&count)
+	model.User(ctx).Count(&count)
 	result.Ok(count, "").Json(ctx)
 
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"xhyovo.cn/community/cmd/community/middleware"
 	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/pkg/result"
 	xt "xhyovo.cn/community/pkg/time"
@@ -30,7 +31,7 @@ func Login(c *gin.Context) {
 
 	var login model.LoginForm
 	if err := c.ShouldBindJSON(&login); err != nil {
-		result.Err(utils.GetValidateErr(login, err)).Json(c)
+		result.Error(utils.ValidateErr(login, err)).Json(c)
 		return
 	}
 	loginLog := model.LoginLogs{
@@ -41,32 +42,32 @@ func Login(c *gin.Context) {
 		CreatedAt: xt.Now(),
 	}
 	var logS services.LogServices
-	user, err := services.Login(login)
+	user, err := services.Login(c, login)
 	if err != nil {
 		loginLog.State = err.Error()
-		logS.InsertLoginLog(loginLog)
-		result.Err(err.Error()).Json(c)
+		logS.InsertLoginLog(c, loginLog)
+		result.Error(err).Json(c)
 		return
 	}
 
 	// 判断黑名单
 	var userService services.UserService
-	if userService.IsBlack(user.ID) {
-		result.Err("你已涉嫌违规社区文化，已被纳入小黑屋，如误封请联系我：xhyQAQ250").Json(c)
+	if userService.IsBlack(c, user.ID) {
+		result.Error(errs.Banned.WithMsg("你已涉嫌违规社区文化，已被纳入小黑屋，如误封请联系我：xhyQAQ250")).Json(c)
 		return
 	}
 
 	token, err := middleware.GenerateToken(user.ID, user.Name+uuid.New().String())
 	if err != nil {
 		loginLog.State = err.Error()
-		logS.InsertLoginLog(loginLog)
-		result.Err(err.Error()).Json(c)
+		logS.InsertLoginLog(c, loginLog)
+		result.Error(err).Json(c)
 		return
 	}
 
 	c.SetCookie(middleware.AUTHORIZATION, token, int(constant.Token_TTl.Seconds()), "/", c.Request.Host, false, true)
 	loginLog.State = "登录成功"
-	logS.InsertLoginLog(loginLog)
+	logS.InsertLoginLog(c, loginLog)
 	result.OkWithMsg(map[string]string{"token": token}, "登录成功").Json(c)
 }
 
@@ -84,34 +85,34 @@ func Register(c *gin.Context) {
 	var logS services.LogServices
 	if err != nil {
 		loginLog.State = err.Error()
-		logS.InsertLoginLog(loginLog)
-		result.Err(utils.GetValidateErr(form, err)).Json(c)
+		logS.InsertLoginLog(c, loginLog)
+		result.Error(utils.ValidateErr(form, err)).Json(c)
 		return
 	}
 
 	if err != nil {
-		log.Warnf("账户: %s 注册失败,获取加密密码错误,err %s", form.Account, err.Error())
-		result.Err(err.Error()).Json(c)
+		log.Ctx(c).Warnf("账户: %s 注册失败,获取加密密码错误,err %s", form.Account, err.Error())
+		result.Error(err).Json(c)
 		return
 	}
 
-	id, err := services.Register(form.Account, form.Password, form.Name, form.Code)
+	id, err := services.Register(c, form.Account, form.Password, form.Name, form.Code)
 	if err != nil {
 		loginLog.State = err.Error()
-		logS.InsertLoginLog(loginLog)
-		result.Err(err.Error()).Json(c)
+		logS.InsertLoginLog(c, loginLog)
+		result.Error(err).Json(c)
 		return
 	}
 	var d services.Draft
-	d.InitDraft(id)
+	d.InitDraft(c, id)
 
 	loginLog.State = "注册成功"
-	logS.InsertLoginLog(loginLog)
+	logS.InsertLoginLog(c, loginLog)
 	token, err := middleware.GenerateToken(id, form.Name)
 	if err != nil {
 		loginLog.State = err.Error()
-		logS.InsertLoginLog(loginLog)
-		result.Err(err.Error()).Json(c)
+		logS.InsertLoginLog(c, loginLog)
+		result.Error(err).Json(c)
 		return
 	}
 	c.SetCookie(middleware.AUTHORIZATION, token, int(constant.Token_TTl.Seconds()), "/", c.Request.Host, false, true)
@@ -12,6 +12,8 @@ import (
 // init router
 
 func InitFrontedRouter(r *gin.Engine) {
+	r.Use(middleware.RequestId, middleware.Locale, middleware.Metrics)
+	InitHealthRouters(r)
 	fileInfo, err := os.Stat("./web/assets")
 	if err == nil && fileInfo.IsDir() {
 		r.Static("/assets", "./web/assets")
@@ -26,7 +28,9 @@ func InitFrontedRouter(r *gin.Engine) {
 
 	InitLoginRegisterRouters(r)
 	InitIndexRouters(r)
+	InitSeoRouters(r)
 	frontend.InitFileRouters(r)
+	frontend.InitFeedRouters(r)
 	r.Use(middleware.Auth)
 	frontend.InitUserRouters(r)
 	frontend.InitArticleRouter(r)
@@ -39,6 +43,9 @@ func InitFrontedRouter(r *gin.Engine) {
 	frontend.InitCourseRouters(r)
 	frontend.InitNoteRouters(r)
 	frontend.InitMeetingRouters(r)
+	frontend.InitSearchRouters(r)
+	frontend.InitSeriesRouters(r)
+	frontend.InitBookmarkRouters(r)
 
 	r.Use(middleware.AdminAuth)
 	backend.InitTypeRouters(r)
@@ -56,5 +63,8 @@ func InitFrontedRouter(r *gin.Engine) {
 	backend.InitOrderRouters(r)
 	backend.InitMonitRouters(r)
 	backend.InitMeetingRouters(r)
+	backend.InitDelayJobRouters(r)
+	backend.InitRoleRouters(r)
+	backend.InitModerationRouters(r)
 
 }
@@ -0,0 +1,82 @@
+package routers
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/utils"
+	services "xhyovo.cn/community/server/service"
+)
+
+// 页面的缓存时间,正文中图片的签名地址有效期为 1 小时
+const (
+	pageMaxAge    = "600"
+	sitemapMaxAge = "3600"
+)
+
+//go:embed templates/article.html
+var articleHtml string
+
+// 模板随程序打包,不依赖 web 目录
+var articleTemplate = template.Must(template.New("article").Funcs(utils.GlobalFunc()).Parse(articleHtml))
+
+var seoService services.SeoService
+
+// InitSeoRouters 服务端渲染的文章页面、sitemap 和 robots,给搜索引擎和分享卡片使用
+func InitSeoRouters(r *gin.Engine) {
+	r.GET("/article/:id", articlePage)
+	r.GET("/sitemap.xml", sitemap)
+	r.GET("/robots.txt", robots)
+}
+
+// 文章页面,兼容 /article/1.html
+func articlePage(ctx *gin.Context) {
+	id, err := strconv.Atoi(strings.TrimSuffix(ctx.Param("id"), ".html"))
+	if err != nil {
+		ctx.String(http.StatusNotFound, i18n.T(ctx, "文章不存在"))
+		return
+	}
+	page, err := seoService.ArticlePage(ctx, id)
+	if err != nil {
+		if errors.Is(err, errs.NotFound) {
+			ctx.String(http.StatusNotFound, i18n.T(ctx, "文章不存在"))
+			return
+		}
+		log.Ctx(ctx).Warnf("查询文章页面失败,文章id: %d,err: %s", id, err.Error())
+		ctx.String(http.StatusInternalServerError, i18n.T(ctx, "服务器内部错误"))
+		return
+	}
+	var buf bytes.Buffer
+	if err = articleTemplate.Execute(&buf, page); err != nil {
+		log.Ctx(ctx).Warnf("渲染文章页面失败,文章id: %d,err: %s", id, err.Error())
+		ctx.String(http.StatusInternalServerError, i18n.T(ctx, "服务器内部错误"))
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age="+pageMaxAge)
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func sitemap(ctx *gin.Context) {
+	b, err := seoService.Sitemap(ctx)
+	if err != nil {
+		log.Ctx(ctx).Warnf("生成 sitemap 失败,err: %s", err.Error())
+		ctx.String(http.StatusInternalServerError, i18n.T(ctx, "服务器内部错误"))
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age="+sitemapMaxAge)
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", b)
+}
+
+func robots(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age="+sitemapMaxAge)
+	ctx.String(http.StatusOK, seoService.Robots())
+}
@@ -11,7 +11,7 @@ echo "Building the back end..."
     # then
     #     BUILD_PATH="./build/community.exe"
     GOOS=linux go build -ldflags "-s -w" -o ${BUILD_PATH} ./cmd/community
-    # cp -r ./cmd/community/config.yaml ./build/config.yaml
+    cp ./cmd/community/config.example.yaml ./build/config.yaml
     cp ./deployment/start.sh ./build/start.sh
     echo "build finished"
 )
@@ -1,2 +1,2 @@
 cd $(dirname "$(readlink -f "$0")")
-GIN_MODE=release ./community
\ No newline at end of file
+GIN_MODE=release ./community -config ./config.yaml
\ No newline at end of file
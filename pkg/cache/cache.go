@@ -1,30 +1,66 @@
 package cache
 
+// 缓存,单实例部署使用进程内的 memory 驱动,多实例部署使用 redis 驱动共享登录限制、黑名单等状态
+
 import (
-	"github.com/patrickmn/go-cache"
+	"errors"
 	"time"
+
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/log"
 )
 
-var c *cache.Cache
+const (
+	DriverMemory = "memory"
+	DriverRedis  = "redis"
+)
 
-func Init() {
-	c = cache.New(5*time.Minute, 10*time.Minute)
+// Cache 缓存的值统一为字符串,与 redis 保持一致
+type Cache interface {
+	// Get key 不存在或已过期时返回 false
+	Get(key string) (string, bool, error)
+	// Set 设置值,ttl 为 0 时使用默认过期时间
+	Set(key, value string, ttl time.Duration) error
+	// Incr 原子自增 1 并返回自增后的值,key 不存在时从 0 开始并设置 ttl,已存在时不修改过期时间
+	Incr(key string, ttl time.Duration) (int64, error)
+	// Delete 删除 key,不存在时不报错
+	Delete(keys ...string) error
+	// Close 释放连接
+	Close() error
 }
 
-func GetInstance() *cache.Cache {
+var c Cache
+
+func Init(cacheConfig config.CacheConfig) {
+	var err error
+	c, err = New(cacheConfig)
+	if err != nil {
+		log.Errorf("初始化缓存失败,err: %s", err.Error())
+		panic(err.Error())
+	}
+}
+
+func New(cacheConfig config.CacheConfig) (Cache, error) {
+	switch cacheConfig.Driver {
+	case "", DriverMemory:
+		return newMemory(cacheConfig), nil
+	case DriverRedis:
+		return newRedis(cacheConfig)
+	default:
+		return nil, errors.New("不支持的缓存驱动: " + cacheConfig.Driver)
+	}
+}
+
+func GetInstance() Cache {
 	return c
 }
 
+// CountLimit 在 ttl 内调用次数达到 limit 后返回 false,计数从第一次调用开始计时
 func CountLimit(key string, limit int, ttl time.Duration) bool {
-	v, e := c.Get(key)
-	if !e {
-		v = 0
-	}
-	i := v.(int)
-	i++
-	if i == limit {
-		return false
+	count, err := c.Incr(key, ttl)
+	if err != nil {
+		log.Warnf("缓存计数失败,key: %s,err: %s", key, err.Error())
+		return true
 	}
-	c.Set(key, i, ttl)
-	return true
+	return count < int64(limit)
 }
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"xhyovo.cn/community/pkg/config"
+)
+
+// 两种驱动使用同一套用例,过期通过 fastForward 模拟
+func testCache(t *testing.T, cache Cache, fastForward func(time.Duration)) {
+	if _, ok, err := cache.Get("missing"); ok || err != nil {
+		t.Fatalf("不存在的 key: %v, %v", ok, err)
+	}
+	if err := cache.Set("k", "v", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := cache.Get("k"); !ok || err != nil || v != "v" {
+		t.Fatalf("Get: %s, %v, %v", v, ok, err)
+	}
+	if err := cache.Delete("k", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := cache.Get("k"); ok {
+		t.Fatal("Delete 后 key 仍然存在")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Incr("counter", time.Minute); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _, _ := cache.Get("counter"); v != "50" {
+		t.Fatalf("并发自增结果: %s", v)
+	}
+	// 自增不会延长过期时间
+	fastForward(40 * time.Second)
+	if n, _ := cache.Incr("counter", time.Minute); n != 51 {
+		t.Fatalf("自增结果: %d", n)
+	}
+	fastForward(30 * time.Second)
+	if n, _ := cache.Incr("counter", time.Minute); n != 1 {
+		t.Fatalf("过期后重新计数: %d", n)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	cache, err := New(config.CacheConfig{Driver: DriverMemory, DefaultExpiration: time.Minute, CleanupInterval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// go-cache 使用系统时间判断过期,这里缩短 ttl 代替快进
+	testCache(t, &scaledMemory{cache.(*memoryCache)}, func(d time.Duration) {
+		time.Sleep(d / time.Second * time.Millisecond * 5)
+	})
+}
+
+// scaledMemory 将 ttl 按 1s -> 5ms 缩短
+type scaledMemory struct {
+	*memoryCache
+}
+
+func (s *scaledMemory) Set(key, value string, ttl time.Duration) error {
+	return s.memoryCache.Set(key, value, ttl/time.Second*time.Millisecond*5)
+}
+
+func (s *scaledMemory) Incr(key string, ttl time.Duration) (int64, error) {
+	return s.memoryCache.Incr(key, ttl/time.Second*time.Millisecond*5)
+}
+
+func TestRedis(t *testing.T) {
+	s := miniredis.RunT(t)
+	cache, err := New(config.CacheConfig{Driver: DriverRedis, Address: s.Addr(), Prefix: "test:", DefaultExpiration: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache(t, cache, s.FastForward)
+	if !s.Exists("test:counter") {
+		t.Fatal("key 前缀不正确")
+	}
+}
+
+func TestCountLimit(t *testing.T) {
+	Init(config.CacheConfig{Driver: DriverMemory, DefaultExpiration: time.Minute, CleanupInterval: time.Minute})
+	for i := 1; i < 5; i++ {
+		if !CountLimit("login", 5, time.Minute) {
+			t.Fatalf("第 %d 次不应该被限制", i)
+		}
+	}
+	if CountLimit("login", 5, time.Minute) {
+		t.Fatal("第 5 次应该被限制")
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"xhyovo.cn/community/pkg/config"
+)
+
+// memoryCache 进程内缓存,多实例之间不共享
+type memoryCache struct {
+	c *cache.Cache
+}
+
+func newMemory(cacheConfig config.CacheConfig) *memoryCache {
+	return &memoryCache{c: cache.New(cacheConfig.DefaultExpiration, cacheConfig.CleanupInterval)}
+}
+
+func (m *memoryCache) Get(key string) (string, bool, error) {
+	v, ok := m.c.Get(key)
+	if !ok {
+		return "", false, nil
+	}
+	switch value := v.(type) {
+	case string:
+		return value, true, nil
+	case int64:
+		// Incr 写入的计数
+		return strconv.FormatInt(value, 10), true, nil
+	default:
+		return fmt.Sprint(value), true, nil
+	}
+}
+
+func (m *memoryCache) Set(key, value string, ttl time.Duration) error {
+	m.c.Set(key, value, m.expiration(ttl))
+	return nil
+}
+
+func (m *memoryCache) Incr(key string, ttl time.Duration) (int64, error) {
+	for {
+		// Add 只在 key 不存在时成功,保证并发下只有一个调用设置过期时间
+		if err := m.c.Add(key, int64(1), m.expiration(ttl)); err == nil {
+			return 1, nil
+		}
+		v, err := m.c.IncrementInt64(key, 1)
+		if err == nil {
+			return v, nil
+		}
+		// Add 之后 key 恰好过期,重新计数;值不是数字时直接返回错误
+		if _, ok := m.c.Get(key); ok {
+			return 0, err
+		}
+	}
+}
+
+func (m *memoryCache) Delete(keys ...string) error {
+	for _, key := range keys {
+		m.c.Delete(key)
+	}
+	return nil
+}
+
+func (m *memoryCache) Close() error {
+	return nil
+}
+
+func (m *memoryCache) expiration(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return cache.DefaultExpiration
+	}
+	return ttl
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"xhyovo.cn/community/pkg/config"
+)
+
+// 单次请求 redis 的超时时间
+const redisTimeout = 3 * time.Second
+
+// key 不存在时设置过期时间,INCR 与 PEXPIRE 在脚本中执行保证原子性
+var incrScript = redis.NewScript(`
+local v = redis.call('INCR', KEYS[1])
+if v == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return v`)
+
+// redisCache 兼容 redis 协议的缓存,多实例之间共享
+type redisCache struct {
+	client            *redis.Client
+	prefix            string
+	defaultExpiration time.Duration
+}
+
+func newRedis(cacheConfig config.CacheConfig) (*redisCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cacheConfig.Address,
+		Password: cacheConfig.Password,
+		DB:       cacheConfig.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisCache{client: client, prefix: cacheConfig.Prefix, defaultExpiration: cacheConfig.DefaultExpiration}, nil
+}
+
+func (r *redisCache) Get(key string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	v, err := r.client.Get(ctx, r.prefix+key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return v, true, nil
+}
+
+func (r *redisCache) Set(key, value string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return r.client.Set(ctx, r.prefix+key, value, r.expiration(ttl)).Err()
+}
+
+func (r *redisCache) Incr(key string, ttl time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return incrScript.Run(ctx, r.client, []string{r.prefix + key}, r.expiration(ttl).Milliseconds()).Int64()
+}
+
+func (r *redisCache) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return r.client.Del(ctx, prefixed...).Err()
+}
+
+func (r *redisCache) Close() error {
+	return r.client.Close()
+}
+
+func (r *redisCache) expiration(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return r.defaultExpiration
+	}
+	return ttl
+}
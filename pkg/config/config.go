@@ -1,78 +1,352 @@
 package config
 
 // 读取配置信息并且给各个配置类赋值
+// 优先级: 环境变量(COMMUNITY_ 前缀) > 配置文件 > default 标签
 
 import (
+	"errors"
+	"fmt"
 	"os"
-	"strconv"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
 )
 
+// EnvPrefix 环境变量前缀,如 db.address 对应 COMMUNITY_DB_ADDRESS
+const EnvPrefix = "COMMUNITY"
+
 type AppConfig struct {
-	ServerBind  string      `yaml:"serverBind" default:":8080"`
-	DbConfig    DbConfig    `yaml:"db"`
-	OssConfig   OssConfig   `yaml:"oss"`
-	EmailConfig EmailConfig `yaml:"email"`
+	ServerBind       string           `yaml:"serverBind" default:":8080"`
+	ShutdownTimeout  time.Duration    `yaml:"shutdownTimeout" default:"30s"` // 停止时等待请求、后台任务完成的最长时间
+	DbConfig         DbConfig         `yaml:"db"`
+	OssConfig        OssConfig        `yaml:"oss"`
+	EmailConfig      EmailConfig      `yaml:"email"`
+	JwtConfig        JwtConfig        `yaml:"jwt"`
+	LogConfig        LogConfig        `yaml:"log"`
+	CacheConfig      CacheConfig      `yaml:"cache"`
+	SiteConfig       SiteConfig       `yaml:"site"`
+	ModerationConfig ModerationConfig `yaml:"moderation"`
+	SearchConfig     SearchConfig     `yaml:"search"`
+	HotConfig        HotConfig        `yaml:"hot"`
 }
 
 type DbConfig struct {
-	Address  string `yaml:"address"`
-	Database string `yaml:"database"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	Driver       string        `yaml:"driver" default:"mysql"` // 数据库驱动: mysql / sqlite / postgres
+	Address      string        `yaml:"address"`                // host:port
+	Database     string        `yaml:"database"`               // 数据库名,sqlite 为文件路径
+	Username     string        `yaml:"username"`
+	Password     string        `yaml:"password"`
+	SSLMode      string        `yaml:"sslMode" default:"disable"`  // postgres 的 sslmode
+	AutoMigrate  bool          `yaml:"autoMigrate"`                // 启动时自动执行未执行的迁移
+	QueryTimeout time.Duration `yaml:"queryTimeout" default:"10s"` // 单条 sql 的超时时间,0 表示不限制
+	SlowQuery    time.Duration `yaml:"slowQuery" default:"200ms"`  // 超过该耗时的 sql 以 warn 级别输出,0 表示不记录
 }
 
 type OssConfig struct {
+	Driver    string `yaml:"driver" default:"aliyun"` // 存储驱动: aliyun / local / s3
 	AccessKey string `yaml:"accessKey"`
 	SecretKey string `yaml:"secretKey"`
 	Bucket    string `yaml:"bucket"`
 	Cdn       string `yaml:"cdn"`
-	Callback  string `json:"callback"`
+	Callback  string `yaml:"callback"`
 	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`    // s3 区域
+	UseSSL    bool   `yaml:"useSSL"`    // s3 是否使用 https
+	LocalPath string `yaml:"localPath"` // local 驱动的文件存放目录
 }
 
 type EmailConfig struct {
 	Address   string `yaml:"address"`
-	PollCount int    `yaml:"pollCount"`
+	PollCount int    `yaml:"pollCount" default:"10"`
 	Username  string `yaml:"username"`
 	Password  string `yaml:"password"`
 	Host      string `yaml:"host"`
 }
 
-var instance *AppConfig
+type JwtConfig struct {
+	Secret string        `yaml:"secret"`                // 签名密钥
+	Expire time.Duration `yaml:"expire" default:"720h"` // token 有效期
+}
+
+type LogConfig struct {
+	Level      string            `yaml:"level" default:"info"`                   // 支持热更新
+	Levels     map[string]string `yaml:"levels"`                                 // 按包设置级别,如 pkg/delay: debug、gorm: warn,支持热更新
+	Format     string            `yaml:"format" default:"console"`               // 输出格式: console / json
+	Path       string            `yaml:"path" default:"./community_log/log.log"` // 为空时不写文件
+	Stdout     bool              `yaml:"stdout" default:"true"`                  // 同时输出到标准输出
+	MaxSize    int               `yaml:"maxSize" default:"100"`                  // 单个文件大小,单位 MB
+	MaxBackups int               `yaml:"maxBackups" default:"10"`                // 保留的旧文件数
+	MaxAge     int               `yaml:"maxAge" default:"30"`                    // 保留天数
+	Compress   bool              `yaml:"compress" default:"true"`
+}
+
+type CacheConfig struct {
+	Driver            string        `yaml:"driver" default:"memory"` // 缓存驱动: memory / redis,多实例部署需要使用 redis
+	DefaultExpiration time.Duration `yaml:"defaultExpiration" default:"5m"`
+	CleanupInterval   time.Duration `yaml:"cleanupInterval" default:"10m"` // memory 驱动清理过期 key 的间隔
+	Address           string        `yaml:"address"`                       // redis 地址 host:port
+	Password          string        `yaml:"password"`
+	DB                int           `yaml:"db"`
+	Prefix            string        `yaml:"prefix" default:"community:"` // redis key 前缀
+}
+
+// SiteConfig 站点信息,支持热更新
+type SiteConfig struct {
+	Name        string `yaml:"name" default:"技术鸭社区"`
+	Url         string `yaml:"url"` // 站点访问地址,如 https://example.com
+	Description string `yaml:"description"`
+}
+
+// ModerationConfig 内容审核的启发式规则,支持热更新;敏感词在管理后台维护
+type ModerationConfig struct {
+	MaxLinks       int    `yaml:"maxLinks" default:"10"`       // 链接数超过该值时命中,0 表示不检查
+	LinkAction     string `yaml:"linkAction" default:"review"` // review / reject
+	MaxRepeatChars int    `yaml:"maxRepeatChars" default:"30"` // 同一个字符连续出现超过该次数时命中,0 表示不检查
+	MaxRepeatLines int    `yaml:"maxRepeatLines" default:"10"` // 同一行出现超过该次数时命中,0 表示不检查
+	RepeatAction   string `yaml:"repeatAction" default:"review"`
+}
+
+// SearchConfig 全文搜索,索引在内存中,启动时从数据库构建,发布、修改、删除时增量更新
+type SearchConfig struct {
+	RebuildInterval time.Duration `yaml:"rebuildInterval" default:"1h"` // 定期全量重建索引的间隔,0 表示只在启动时构建
+}
+
+// HotConfig 文章浏览量和热门排行
+type HotConfig struct {
+	ViewWindow      time.Duration `yaml:"viewWindow" default:"30m"`      // 同一用户、IP 在该时间内重复浏览同一文章只计一次
+	FlushInterval   time.Duration `yaml:"flushInterval" default:"1m"`    // 浏览量在内存中累计,按该间隔写入数据库
+	RefreshInterval time.Duration `yaml:"refreshInterval" default:"10m"` // 重新计算热门排行的间隔
+	MaxAge          time.Duration `yaml:"maxAge" default:"720h"`         // 只有该时间内发布的文章参与热门排行
+}
+
+var instance atomic.Value
+
+var (
+	listenerLock sync.Mutex
+	listeners    []func(old, new *AppConfig)
+)
 
 func GetInstance() *AppConfig {
-	return instance
-}
-
-func Init() {
-	pollCount, _ := strconv.Atoi(os.Getenv("POLLCOUNT"))
-	if pollCount == 0 {
-		pollCount = 10
-	}
-	appConfig := &AppConfig{
-		ServerBind: os.Getenv("SERVER_BIND"),
-		DbConfig: DbConfig{
-			Address:  os.Getenv("DB_HOST"),
-			Database: os.Getenv("DB_DATABASE"),
-			Username: os.Getenv("DB_USER"),
-			Password: os.Getenv("DB_PASS"),
-		},
-		OssConfig: OssConfig{
-			AccessKey: os.Getenv("OSS_ACCESS_KEY"),
-			Bucket:    os.Getenv("OSS_BUCKET"),
-			SecretKey: os.Getenv("OSS_SECRET_KEY"),
-			Cdn:       os.Getenv("OSS_CDN"),
-			Callback:  os.Getenv("OSS_CALLBACK"),
-			Endpoint:  os.Getenv("OSS_ENDPOINT"),
-		},
-		EmailConfig: EmailConfig{
-			Address:   os.Getenv("ADDRESS"),
-			Username:  os.Getenv("USERNAME"),
-			Password:  os.Getenv("PASSWORD"),
-			Host:      os.Getenv("HOST"),
-			PollCount: pollCount,
-		},
-	}
-	instance = appConfig
+	c, _ := instance.Load().(*AppConfig)
+	return c
+}
+
+// OnChange 注册配置热更新回调,只有 reloadable 中的字段会变化
+func OnChange(f func(old, new *AppConfig)) {
+	listenerLock.Lock()
+	defer listenerLock.Unlock()
+	listeners = append(listeners, f)
+}
+
+// Init 加载配置,path 为空时只读取环境变量;配置文件变化时热更新可以安全修改的字段
+func Init(path string) error {
+	v, err := newViper(path)
+	if err != nil {
+		return err
+	}
+	appConfig, err := load(v)
+	if err != nil {
+		return err
+	}
+	instance.Store(appConfig)
+	if v.ConfigFileUsed() != "" {
+		v.OnConfigChange(func(fsnotify.Event) {
+			reload(v)
+		})
+		v.WatchConfig()
+	}
+	return nil
+}
+
+// Load 读取配置并校验,不会修改全局配置
+func Load(path string) (*AppConfig, error) {
+	v, err := newViper(path)
+	if err != nil {
+		return nil, err
+	}
+	return load(v)
+}
+
+func newViper(path string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	bindKeys(v, reflect.TypeOf(AppConfig{}), "")
+	if path == "" {
+		return v, nil
+	}
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+	return v, nil
+}
+
+// bindKeys 注册所有配置项,使环境变量在配置文件缺少对应字段时也能生效,同时设置 default 标签的默认值
+func bindKeys(v *viper.Viper, t reflect.Type, prefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + field.Tag.Get("yaml")
+		if field.Type.Kind() == reflect.Struct {
+			bindKeys(v, field.Type, key+".")
+			continue
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			v.SetDefault(key, def)
+		} else {
+			v.SetDefault(key, reflect.Zero(field.Type).Interface())
+		}
+	}
+}
+
+func load(v *viper.Viper) (*AppConfig, error) {
+	var appConfig AppConfig
+	err := v.Unmarshal(&appConfig, func(c *mapstructure.DecoderConfig) {
+		c.TagName = "yaml"
+	})
+	if err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	if err = appConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return &appConfig, nil
+}
+
+func reload(v *viper.Viper) {
+	newConfig, err := load(v)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "配置热更新失败,继续使用旧配置,err: %s\n", err.Error())
+		return
+	}
+	old := GetInstance()
+	merged := *old
+	reloadable(&merged, newConfig)
+	instance.Store(&merged)
+
+	listenerLock.Lock()
+	fs := append([]func(old, new *AppConfig){}, listeners...)
+	listenerLock.Unlock()
+	for _, f := range fs {
+		f(old, &merged)
+	}
+}
+
+// reloadable 可以在运行时修改的字段,其余字段需要重启生效
+func reloadable(dst, src *AppConfig) {
+	dst.LogConfig.Level = src.LogConfig.Level
+	dst.LogConfig.Levels = src.LogConfig.Levels
+	dst.SiteConfig = src.SiteConfig
+	dst.ModerationConfig = src.ModerationConfig
+}
+
+// Validate 校验配置,所有错误一并返回
+func (c *AppConfig) Validate() error {
+	var errs []string
+	required := func(value, key string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, key+" 不能为空")
+		}
+	}
+	required(c.ServerBind, "serverBind")
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, "shutdownTimeout 必须大于 0")
+	}
+	switch c.DbConfig.Driver {
+	case "mysql", "postgres":
+		required(c.DbConfig.Address, "db.address")
+		required(c.DbConfig.Database, "db.database")
+		required(c.DbConfig.Username, "db.username")
+	case "sqlite":
+		required(c.DbConfig.Database, "db.database")
+	default:
+		errs = append(errs, "db.driver 只支持 mysql / sqlite / postgres")
+	}
+
+	switch c.OssConfig.Driver {
+	case "aliyun", "s3":
+		required(c.OssConfig.Endpoint, "oss.endpoint")
+		required(c.OssConfig.Bucket, "oss.bucket")
+		required(c.OssConfig.AccessKey, "oss.accessKey")
+		required(c.OssConfig.SecretKey, "oss.secretKey")
+	case "local":
+		required(c.OssConfig.Endpoint, "oss.endpoint")
+	default:
+		errs = append(errs, "oss.driver 只支持 aliyun / local / s3")
+	}
+
+	if c.EmailConfig.PollCount <= 0 {
+		errs = append(errs, "email.pollCount 必须大于 0")
+	}
+
+	if len(c.JwtConfig.Secret) < 16 {
+		errs = append(errs, "jwt.secret 长度不能小于 16")
+	}
+	if c.JwtConfig.Expire <= 0 {
+		errs = append(errs, "jwt.expire 必须大于 0")
+	}
+
+	logLevel := func(l, key string) {
+		switch strings.ToLower(l) {
+		case "debug", "info", "warn", "error":
+		default:
+			errs = append(errs, key+" 只支持 debug / info / warn / error")
+		}
+	}
+	logLevel(c.LogConfig.Level, "log.level")
+	for pkg, l := range c.LogConfig.Levels {
+		logLevel(l, "log.levels."+pkg)
+	}
+	if c.LogConfig.Format != "console" && c.LogConfig.Format != "json" {
+		errs = append(errs, "log.format 只支持 console / json")
+	}
+	if c.LogConfig.Path == "" && !c.LogConfig.Stdout {
+		errs = append(errs, "log.path 为空时 log.stdout 必须为 true")
+	}
+	if c.LogConfig.Path != "" && (c.LogConfig.MaxSize <= 0 || c.LogConfig.MaxBackups < 0 || c.LogConfig.MaxAge < 0) {
+		errs = append(errs, "log.maxSize 必须大于 0,log.maxBackups 和 log.maxAge 不能小于 0")
+	}
+
+	if c.CacheConfig.DefaultExpiration <= 0 || c.CacheConfig.CleanupInterval <= 0 {
+		errs = append(errs, "cache.defaultExpiration 和 cache.cleanupInterval 必须大于 0")
+	}
+	switch c.CacheConfig.Driver {
+	case "memory":
+	case "redis":
+		required(c.CacheConfig.Address, "cache.address")
+	default:
+		errs = append(errs, "cache.driver 只支持 memory / redis")
+	}
 
+	required(c.SiteConfig.Name, "site.name")
+
+	m := c.ModerationConfig
+	if m.MaxLinks < 0 || m.MaxRepeatChars < 0 || m.MaxRepeatLines < 0 {
+		errs = append(errs, "moderation.maxLinks、maxRepeatChars、maxRepeatLines 不能小于 0")
+	}
+	if m.LinkAction != "review" && m.LinkAction != "reject" {
+		errs = append(errs, "moderation.linkAction 只支持 review / reject")
+	}
+	if m.RepeatAction != "review" && m.RepeatAction != "reject" {
+		errs = append(errs, "moderation.repeatAction 只支持 review / reject")
+	}
+
+	if c.SearchConfig.RebuildInterval < 0 {
+		errs = append(errs, "search.rebuildInterval 不能小于 0")
+	}
+	if c.HotConfig.ViewWindow <= 0 || c.HotConfig.FlushInterval <= 0 || c.HotConfig.RefreshInterval <= 0 || c.HotConfig.MaxAge <= 0 {
+		errs = append(errs, "hot.viewWindow、hot.flushInterval、hot.refreshInterval、hot.maxAge 必须大于 0")
+	}
+
+	if len(errs) > 0 {
+		return errors.New("配置校验失败: " + strings.Join(errs, "; "))
+	}
+	return nil
 }
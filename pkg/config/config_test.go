@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+db:
+  address: "127.0.0.1:3306"
+  database: "community"
+  username: "root"
+oss:
+  driver: "local"
+  endpoint: "http://127.0.0.1:8080"
+jwt:
+  secret: "0123456789abcdef"
+site:
+  url: "http://127.0.0.1:8080"
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("COMMUNITY_DB_PASSWORD", "env-pass")
+	t.Setenv("COMMUNITY_EMAIL_POLLCOUNT", "3")
+	t.Setenv("COMMUNITY_JWT_EXPIRE", "1h")
+
+	c, err := Load(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DbConfig.Address != "127.0.0.1:3306" || c.DbConfig.Password != "env-pass" {
+		t.Fatalf("db 配置不正确: %+v", c.DbConfig)
+	}
+	if c.EmailConfig.PollCount != 3 || c.JwtConfig.Expire != time.Hour {
+		t.Fatalf("环境变量未覆盖: %+v %+v", c.EmailConfig, c.JwtConfig)
+	}
+	if c.ServerBind != ":8080" || c.LogConfig.Level != "info" || c.CacheConfig.DefaultExpiration != 5*time.Minute {
+		t.Fatalf("默认值不正确: %+v", c)
+	}
+}
+
+func TestLoadValidate(t *testing.T) {
+	_, err := Load(writeConfig(t, strings.Replace(testConfig, `driver: "local"`, `driver: "ftp"`, 1)+"log:\n  level: verbose\n"))
+	if err == nil {
+		t.Fatal("配置错误时应当返回错误")
+	}
+	for _, key := range []string{"oss.driver", "log.level"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("错误信息缺少 %s: %s", key, err.Error())
+		}
+	}
+}
+
+func TestReloadable(t *testing.T) {
+	old := &AppConfig{ServerBind: ":8080", LogConfig: LogConfig{Level: "info", Path: "a.log"}}
+	changed := &AppConfig{ServerBind: ":9090", LogConfig: LogConfig{Level: "debug", Path: "b.log"}, SiteConfig: SiteConfig{Name: "new"}}
+	merged := *old
+	reloadable(&merged, changed)
+	if merged.ServerBind != ":8080" || merged.LogConfig.Path != "a.log" {
+		t.Fatalf("不支持热更新的字段被修改: %+v", merged)
+	}
+	if merged.LogConfig.Level != "debug" || merged.SiteConfig.Name != "new" {
+		t.Fatalf("热更新字段未生效: %+v", merged)
+	}
+}
@@ -1,6 +1,11 @@
 package constant
 
-import "xhyovo.cn/community/server/response"
+import (
+	"context"
+
+	"xhyovo.cn/community/pkg/i18n"
+	"xhyovo.cn/community/server/response"
+)
 
 const (
 	Draft int = iota + 1
@@ -10,17 +15,19 @@ const (
 	PrivateQuestion
 	QADraft
 	Top
+	Scheduled // 定时发布,到发布时间后变为发布 (QA 为待解决)
 )
 
 var name map[int]string
 
 var msg map[int]string
 
-func GetArticleName(id int) string {
-	return name[id]
+// GetArticleName 状态名称,按 ctx 中的语言翻译
+func GetArticleName(ctx context.Context, id int) string {
+	return i18n.T(ctx, name[id])
 }
-func GetArticleMsg(id int) string {
-	return msg[id]
+func GetArticleMsg(ctx context.Context, id int) string {
+	return i18n.T(ctx, msg[id])
 }
 func init() {
 	name = make(map[int]string)
@@ -30,6 +37,7 @@ func init() {
 	name[Resolved] = "已解决"
 	name[PrivateQuestion] = "私密提问"
 	name[Top] = "置顶"
+	name[Scheduled] = "定时发布"
 	msg = make(map[int]string)
 	msg[Draft] = "保存草稿"
 	msg[Published] = "发布成功"
@@ -38,12 +46,13 @@ func init() {
 	msg[PrivateQuestion] = "发布为私密提问"
 	msg[QADraft] = "保存草稿"
 	msg[Top] = "置顶"
+	msg[Scheduled] = "已设置定时发布"
 }
 
-func ListState() []response.ArticleState {
+func ListState(ctx context.Context) []response.ArticleState {
 	var states = make([]response.ArticleState, 0, len(name))
 	for k, v := range name {
-		states = append(states, response.ArticleState{Id: k, Name: v})
+		states = append(states, response.ArticleState{Id: k, Name: i18n.T(ctx, v)})
 
 	}
 	return states
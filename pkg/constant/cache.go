@@ -7,6 +7,7 @@ const (
 	BLACK_LIST       = "black_list:"
 	BLACK_LIST_COUNT = "black_list:count:"
 	HEARTBEAT        = "heartbeat:"
+	ARTICLE_VIEW     = "article:view:"
 )
 
 const (
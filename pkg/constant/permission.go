@@ -0,0 +1,101 @@
+package constant
+
+// 后台权限,角色通过 role_permissions 关联权限,后台接口按权限校验
+// 新增权限时在 Permissions 中登记,管理后台据此展示可分配的权限
+
+const (
+	PermAll = "*" // 所有权限,仅内置的 admin 角色使用
+
+	PermDashboardView = "dashboard.view"
+
+	PermArticleView   = "article.view" // 后台文章列表,前台查看他人的草稿和私密提问
+	PermArticleDelete = "article.delete"
+	PermArticleState  = "article.state"  // 修改文章状态、置顶
+	PermArticleExport = "article.export" // 导出任意用户的文章
+
+	PermCommentView   = "comment.view"
+	PermCommentDelete = "comment.delete"
+
+	PermTypeManage    = "type.manage"
+	PermCourseManage  = "course.manage"
+	PermUserTagManage = "user_tag.manage"
+	PermMessageManage = "message.manage" // 消息模板
+
+	PermUserView   = "user.view"
+	PermUserEdit   = "user.edit" // 修改用户信息、重置密码
+	PermUserDelete = "user.delete"
+	PermUserBan    = "user.ban"
+
+	PermMemberManage = "member.manage"
+	PermCodeManage   = "code.manage" // 邀请码
+	PermOrderView    = "order.view"
+
+	PermMeetingApprove = "meeting.approve" // 审核、PASS
+	PermMeetingManage  = "meeting.manage"  // 会议记录、删除、通知参会人
+
+	PermFileView  = "file.view"
+	PermLogView   = "log.view"
+	PermLogLevel  = "log.level"
+	PermMonitView = "monit.view"
+	PermDelayJob  = "delay.manage"
+
+	PermRoleManage = "role.manage"
+
+	PermModerationManage = "moderation.manage" // 敏感词、审核用户内容
+)
+
+// 内置角色
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleEditor    = "editor"
+)
+
+type Permission struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Permissions 所有可分配的权限
+var Permissions = []Permission{
+	{PermDashboardView, "查看仪表盘"},
+	{PermArticleView, "查看文章"},
+	{PermArticleDelete, "删除文章"},
+	{PermArticleState, "修改文章状态"},
+	{PermArticleExport, "导出文章"},
+	{PermCommentView, "查看评论"},
+	{PermCommentDelete, "删除评论"},
+	{PermTypeManage, "管理分类"},
+	{PermCourseManage, "管理课程"},
+	{PermUserTagManage, "管理用户标签"},
+	{PermMessageManage, "管理消息模板"},
+	{PermUserView, "查看用户"},
+	{PermUserEdit, "修改用户"},
+	{PermUserDelete, "删除用户"},
+	{PermUserBan, "封禁用户"},
+	{PermMemberManage, "管理会员等级"},
+	{PermCodeManage, "管理邀请码"},
+	{PermOrderView, "查看订单"},
+	{PermMeetingApprove, "审核会议"},
+	{PermMeetingManage, "管理会议"},
+	{PermFileView, "查看文件"},
+	{PermLogView, "查看日志"},
+	{PermLogLevel, "修改日志级别"},
+	{PermMonitView, "查看监控"},
+	{PermDelayJob, "管理延迟任务"},
+	{PermRoleManage, "管理角色"},
+	{PermModerationManage, "管理内容审核"},
+}
+
+// ValidPermission 是否为登记过的权限
+func ValidPermission(code string) bool {
+	if code == PermAll {
+		return true
+	}
+	for _, p := range Permissions {
+		if p.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -1,54 +1,385 @@
 package delay
 
+// 持久化的延迟队列,任务保存在 delay_jobs 表中,重启后继续执行
+// 执行语义为至少一次: 任务执行前加租约,进程在执行中退出时租约过期后会被再次执行,处理函数需要保证幂等
+// 同一个 key 只保留一个任务,重复 Schedule 会覆盖之前的执行时间和参数
+
 import (
-	"github.com/RussellLuo/timingwheel"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"xhyovo.cn/community/pkg/log"
+)
+
+const (
+	// 轮询到期任务的间隔
+	pollInterval = time.Second
+	// 单次轮询取出的任务数
+	batchSize = 100
+	// 任务执行的租约,超过该时间未完成视为执行失败,会被再次执行
+	lease = 5 * time.Minute
+	// 默认最大执行次数,超过后任务标记为 dead 不再执行
+	defaultMaxAttempts = 5
+	// 重试退避: 10s、20s、40s ... 最长 1h
+	backoffBase = 10 * time.Second
+	backoffMax  = time.Hour
 )
 
-var taskMap = make(map[int]*task)
+// 执行记录的状态
+const (
+	StateSuccess = "success" // 执行成功
+	StateRetry   = "retry"   // 执行失败,等待重试
+	StateDead    = "dead"    // 超过最大执行次数,不再执行
+)
+
+var ErrUnknownType = errors.New("未注册的任务类型")
+
+// DelayJobs 待执行的任务
+type DelayJobs struct {
+	ID          int        `gorm:"primarykey" json:"id"`
+	JobKey      string     `gorm:"size:191;uniqueIndex" json:"jobKey"`
+	JobType     string     `gorm:"size:64" json:"jobType"`
+	Payload     string     `json:"payload"`
+	RunAt       time.Time  `gorm:"index" json:"runAt"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	LastError   string     `json:"lastError"`
+	LockToken   string     `gorm:"size:64" json:"-"`
+	LockedUntil *time.Time `json:"lockedUntil"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// DelayJobHistories 任务的每一次执行记录
+type DelayJobHistories struct {
+	ID         int       `gorm:"primarykey" json:"id"`
+	JobKey     string    `gorm:"size:191;index" json:"jobKey"`
+	JobType    string    `gorm:"size:64" json:"jobType"`
+	Payload    string    `json:"payload"`
+	Attempt    int       `json:"attempt"`
+	State      string    `gorm:"size:16" json:"state"`
+	Error      string    `json:"error"`
+	RunAt      time.Time `json:"runAt"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+type handlerFunc func(ctx context.Context, payload []byte) error
+
+var (
+	handlerLock sync.RWMutex
+	handlers    = make(map[string]handlerFunc)
+)
 
-type task struct {
-	id    int // 任务标识
-	timer *timingwheel.Timer
+// Register 注册任务类型的处理函数,payload 为 Schedule 时传入的值,需要在 Init 之前注册
+func Register[T any](jobType string, handler func(ctx context.Context, payload T) error) {
+	handlerLock.Lock()
+	defer handlerLock.Unlock()
+	handlers[jobType] = func(ctx context.Context, raw []byte) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("解析任务参数失败: %w", err)
+		}
+		return handler(ctx, payload)
+	}
 }
 
-func newTask(id int, timer *timingwheel.Timer) *task {
-	return &task{id: id, timer: timer}
+func getHandler(jobType string) (handlerFunc, bool) {
+	handlerLock.RLock()
+	defer handlerLock.RUnlock()
+	h, ok := handlers[jobType]
+	return h, ok
 }
 
 var delay *DelayQueue
 
 type DelayQueue struct {
-	tw *timingwheel.TimingWheel
+	db     *gorm.DB
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
 }
 
-func init() {
-
-	tw1 := timingwheel.NewTimingWheel(time.Second, 60)
-	tw1.Start()
-	delay = &DelayQueue{tw: tw1}
+// Init 创建并启动全局延迟队列
+func Init(db *gorm.DB) {
+	delay = New(db)
+	delay.Start()
 }
 
 func GetInstant() *DelayQueue {
 	return delay
 }
 
-func (delay *DelayQueue) Add(id int, expireTIme time.Time, taskFunction func()) {
-	// 过期时间 - 当前时间
-	delay.tw.AfterFunc(expireTIme.Sub(time.Now()), taskFunction)
+func New(db *gorm.DB) *DelayQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DelayQueue{db: db, ctx: ctx, cancel: cancel, wake: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+// Start 启动轮询
+func (d *DelayQueue) Start() {
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			d.poll()
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-ticker.C:
+			case <-d.wake:
+			}
+		}
+	}()
+}
+
+// Stop 停止轮询,等待正在执行的任务结束;ctx 过期时不再等待,未完成的任务在租约过期后会被再次执行
+func (d *DelayQueue) Stop(ctx context.Context) error {
+	d.cancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Schedule 在 runAt 执行 jobType 类型的任务,key 相同的任务会被覆盖
+func (d *DelayQueue) Schedule(key, jobType string, runAt time.Time, payload any) error {
+	if _, ok := getHandler(jobType); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	job := DelayJobs{
+		JobKey:      key,
+		JobType:     jobType,
+		Payload:     string(raw),
+		RunAt:       runAt,
+		MaxAttempts: defaultMaxAttempts,
+	}
+	// 覆盖时清空租约,正在执行的旧任务结束后不会影响新任务
+	err = d.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "job_key"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"job_type":     job.JobType,
+			"payload":      job.Payload,
+			"run_at":       job.RunAt,
+			"attempts":     0,
+			"max_attempts": job.MaxAttempts,
+			"last_error":   "",
+			"lock_token":   "",
+			"locked_until": nil,
+			"updated_at":   time.Now(),
+		}),
+	}).Create(&job).Error
+	if err != nil {
+		return err
+	}
+	if !runAt.After(time.Now()) {
+		d.notify()
+	}
+	return nil
+}
+
+// Reschedule 修改任务的执行时间,参数不变;任务不存在或已执行完成时返回 false
+func (d *DelayQueue) Reschedule(key string, runAt time.Time) (bool, error) {
+	tx := d.db.Model(&DelayJobs{}).Where("job_key = ?", key).Updates(map[string]any{
+		"run_at":       runAt,
+		"attempts":     0,
+		"last_error":   "",
+		"lock_token":   "",
+		"locked_until": nil,
+		"updated_at":   time.Now(),
+	})
+	if tx.Error != nil || tx.RowsAffected == 0 {
+		return false, tx.Error
+	}
+	if !runAt.After(time.Now()) {
+		d.notify()
+	}
+	return true, nil
+}
+
+// Cancel 取消任务,任务不存在时不报错
+func (d *DelayQueue) Cancel(key string) error {
+	return d.db.Where("job_key = ?", key).Delete(&DelayJobs{}).Error
+}
+
+// Exists 任务是否还未执行完成
+func (d *DelayQueue) Exists(key string) (bool, error) {
+	var count int64
+	err := d.db.Model(&DelayJobs{}).Where("job_key = ?", key).Count(&count).Error
+	return count > 0, err
+}
+
+// Payload 读取还未执行完成的任务参数到 v,任务不存在时返回 false
+func (d *DelayQueue) Payload(key string, v any) (bool, error) {
+	var job DelayJobs
+	if err := d.db.Select("payload").Where("job_key = ?", key).Limit(1).Find(&job).Error; err != nil {
+		return false, err
+	}
+	if job.Payload == "" {
+		return false, nil
+	}
+	return true, json.Unmarshal([]byte(job.Payload), v)
+}
+
+// Depth 待执行的任务数
+func (d *DelayQueue) Depth() (int64, error) {
+	var count int64
+	err := d.db.Model(&DelayJobs{}).Count(&count).Error
+	return count, err
+}
+
+// Page 待执行的任务
+func (d *DelayQueue) Page(page, limit int) ([]DelayJobs, int64, error) {
+	var jobs []DelayJobs
+	var count int64
+	tx := d.db.Model(&DelayJobs{})
+	if err := tx.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	err := tx.Order("run_at").Offset((page - 1) * limit).Limit(limit).Find(&jobs).Error
+	return jobs, count, err
 }
 
-func (delay *DelayQueue) Remove(id int) {
-	removeTask(id)
+// PageHistory 执行记录,key 为空时查询全部
+func (d *DelayQueue) PageHistory(key string, page, limit int) ([]DelayJobHistories, int64, error) {
+	var histories []DelayJobHistories
+	var count int64
+	tx := d.db.Model(&DelayJobHistories{})
+	if key != "" {
+		tx = tx.Where("job_key = ?", key)
+	}
+	if err := tx.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	err := tx.Order("id desc").Offset((page - 1) * limit).Limit(limit).Find(&histories).Error
+	return histories, count, err
 }
 
-func addTask(task *task) {
-	taskMap[task.id] = task
+func (d *DelayQueue) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// poll 按执行时间顺序执行所有到期且未被占用的任务
+func (d *DelayQueue) poll() {
+	for d.ctx.Err() == nil {
+		now := time.Now()
+		var jobs []DelayJobs
+		err := d.db.Where("run_at <= ?", now).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Order("run_at").Order("id").
+			Limit(batchSize).
+			Find(&jobs).Error
+		if err != nil {
+			log.Warnf("查询延迟任务失败,err: %s", err.Error())
+			return
+		}
+		for _, job := range jobs {
+			if d.ctx.Err() != nil {
+				return
+			}
+			d.run(job)
+		}
+		if len(jobs) < batchSize {
+			return
+		}
+	}
+}
+
+func (d *DelayQueue) run(job DelayJobs) {
+	// 加租约,多个实例同时轮询时只有一个能执行成功
+	now := time.Now()
+	lockedUntil := now.Add(lease)
+	token := uuid.NewString()
+	tx := d.db.Model(&DelayJobs{}).
+		Where("id = ? AND lock_token = ?", job.ID, job.LockToken).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Updates(map[string]any{"lock_token": token, "locked_until": lockedUntil, "attempts": gorm.Expr("attempts + 1")})
+	if tx.Error != nil {
+		log.Warnf("延迟任务加锁失败,key: %s,err: %s", job.JobKey, tx.Error.Error())
+		return
+	}
+	if tx.RowsAffected == 0 {
+		return
+	}
+	job.Attempts++
+
+	history := DelayJobHistories{
+		JobKey:    job.JobKey,
+		JobType:   job.JobType,
+		Payload:   job.Payload,
+		Attempt:   job.Attempts,
+		RunAt:     job.RunAt,
+		StartedAt: now,
+	}
+	err := d.execute(job, lockedUntil)
+	history.FinishedAt = time.Now()
+
+	owned := d.db.Model(&DelayJobs{}).Where("id = ? AND lock_token = ?", job.ID, token)
+	if err == nil {
+		history.State = StateSuccess
+		owned.Delete(&DelayJobs{})
+		log.Infof("延迟任务执行成功,key: %s", job.JobKey)
+	} else if job.Attempts >= job.MaxAttempts {
+		history.State = StateDead
+		history.Error = err.Error()
+		owned.Delete(&DelayJobs{})
+		log.Errorf("延迟任务执行失败且不再重试,key: %s,err: %s", job.JobKey, err.Error())
+	} else {
+		history.State = StateRetry
+		history.Error = err.Error()
+		owned.Updates(map[string]any{
+			"run_at":       time.Now().Add(Backoff(job.Attempts)),
+			"last_error":   err.Error(),
+			"lock_token":   "",
+			"locked_until": nil,
+		})
+		log.Warnf("延迟任务执行失败,等待重试,key: %s,attempt: %d,err: %s", job.JobKey, job.Attempts, err.Error())
+	}
+	if err = d.db.Create(&history).Error; err != nil {
+		log.Warnf("保存延迟任务执行记录失败,key: %s,err: %s", job.JobKey, err.Error())
+	}
+}
+
+func (d *DelayQueue) execute(job DelayJobs, deadline time.Time) (err error) {
+	h, ok := getHandler(job.JobType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, job.JobType)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("任务 panic: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	return h(ctx, []byte(job.Payload))
 }
 
-func removeTask(id int) {
-	task, b := taskMap[id]
-	if b {
-		task.timer.Stop()
+// Backoff 第 attempt 次失败后等待的时间
+func Backoff(attempt int) time.Duration {
+	wait := backoffBase
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if wait >= backoffMax {
+			return backoffMax
+		}
 	}
+	return wait
 }
@@ -0,0 +1,158 @@
+package delay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/migrate"
+	"xhyovo.cn/community/pkg/mysql"
+)
+
+type testPayload struct {
+	Name string `json:"name"`
+}
+
+func newTestQueue(t *testing.T) *DelayQueue {
+	db, err := mysql.Open(config.DbConfig{Driver: mysql.DriverSQLite, Database: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrate.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	return New(db)
+}
+
+func TestScheduleAndRun(t *testing.T) {
+	d := newTestQueue(t)
+	var got []string
+	Register("test.ok", func(ctx context.Context, p testPayload) error {
+		got = append(got, p.Name)
+		return nil
+	})
+
+	if err := d.Schedule("a", "test.ok", time.Now().Add(-time.Second), testPayload{Name: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	// 相同 key 覆盖之前的任务
+	if err := d.Schedule("a", "test.ok", time.Now().Add(-time.Second), testPayload{Name: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Schedule("future", "test.ok", time.Now().Add(time.Hour), testPayload{Name: "future"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Schedule("b", "test.unknown", time.Now(), nil); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("未注册的任务类型: %v", err)
+	}
+
+	var payload testPayload
+	if ok, err := d.Payload("future", &payload); err != nil || !ok || payload.Name != "future" {
+		t.Fatalf("任务参数: %v, %+v, %v", ok, payload, err)
+	}
+
+	d.poll()
+	if len(got) != 1 || got[0] != "second" {
+		t.Fatalf("执行结果: %v", got)
+	}
+	if exist, _ := d.Exists("a"); exist {
+		t.Fatal("执行成功的任务应该被删除")
+	}
+	if ok, _ := d.Payload("a", &payload); ok {
+		t.Fatal("执行成功的任务没有参数")
+	}
+	histories, count, err := d.PageHistory("a", 1, 10)
+	if err != nil || count != 1 || histories[0].State != StateSuccess {
+		t.Fatalf("执行记录: %+v, %d, %v", histories, count, err)
+	}
+
+	// 修改执行时间后保留参数
+	if ok, err := d.Reschedule("future", time.Now().Add(-time.Second)); !ok || err != nil {
+		t.Fatalf("修改执行时间失败: %v", err)
+	}
+	d.poll()
+	if len(got) != 2 || got[1] != "future" {
+		t.Fatalf("执行结果: %v", got)
+	}
+	if ok, _ := d.Reschedule("future", time.Now()); ok {
+		t.Fatal("已执行的任务不能修改执行时间")
+	}
+
+	if err = d.Schedule("future", "test.ok", time.Now().Add(time.Hour), testPayload{Name: "future"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Cancel("future"); err != nil {
+		t.Fatal(err)
+	}
+	if exist, _ := d.Exists("future"); exist {
+		t.Fatal("任务取消失败")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	d := newTestQueue(t)
+	Register("test.fail", func(ctx context.Context, p testPayload) error {
+		panic("boom")
+	})
+	if err := d.Schedule("c", "test.fail", time.Now(), testPayload{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= defaultMaxAttempts; attempt++ {
+		d.poll()
+		var job DelayJobs
+		err := d.db.Where("job_key = ?", "c").First(&job).Error
+		if attempt == defaultMaxAttempts {
+			if err == nil {
+				t.Fatal("超过最大执行次数后任务应该被删除")
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Attempts != attempt || !job.RunAt.After(time.Now()) || job.LastError == "" {
+			t.Fatalf("第 %d 次失败后: %+v", attempt, job)
+		}
+		// 跳过退避时间
+		d.db.Model(&job).Update("run_at", time.Now().Add(-time.Second))
+	}
+
+	histories, count, _ := d.PageHistory("c", 1, 10)
+	if count != defaultMaxAttempts || histories[0].State != StateDead || histories[1].State != StateRetry {
+		t.Fatalf("执行记录: %+v", histories)
+	}
+}
+
+func TestExpiredLease(t *testing.T) {
+	d := newTestQueue(t)
+	runs := 0
+	Register("test.lease", func(ctx context.Context, p testPayload) error {
+		runs++
+		return nil
+	})
+	if err := d.Schedule("d", "test.lease", time.Now(), testPayload{}); err != nil {
+		t.Fatal(err)
+	}
+	// 模拟其他实例正在执行
+	d.db.Model(&DelayJobs{}).Where("job_key = ?", "d").
+		Updates(map[string]any{"lock_token": "other", "locked_until": time.Now().Add(time.Minute)})
+	d.poll()
+	if runs != 0 {
+		t.Fatal("租约未过期的任务不应该执行")
+	}
+	// 模拟执行中进程退出,租约过期后再次执行
+	d.db.Model(&DelayJobs{}).Where("job_key = ?", "d").Update("locked_until", time.Now().Add(-time.Second))
+	d.poll()
+	if runs != 1 {
+		t.Fatalf("租约过期的任务执行次数: %d", runs)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if Backoff(1) != backoffBase || Backoff(3) != 4*backoffBase || Backoff(100) != backoffMax {
+		t.Fatal("退避时间不正确")
+	}
+}
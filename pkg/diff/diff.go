@@ -0,0 +1,219 @@
+// Package diff 按行比较文本,输出 unified diff
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// 编辑次数超过该值时不再计算最短编辑序列,剩余部分按整体替换输出,避免超大文本占用过多内存
+const maxEdits = 2000
+
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines 比较 a、b 两组行,返回把 a 变为 b 的最短编辑序列 (Myers 算法)
+func Lines(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	lines := make([]Line, 0, len(a)+len(b))
+	for _, s := range a[:prefix] {
+		lines = append(lines, Line{Equal, s})
+	}
+	lines = append(lines, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, s := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Equal, s})
+	}
+	return lines
+}
+
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset)
+			}
+		}
+	}
+	lines := make([]Line, 0, n+m)
+	for _, s := range a {
+		lines = append(lines, Line{Delete, s})
+	}
+	for _, s := range b {
+		lines = append(lines, Line{Insert, s})
+	}
+	return lines
+}
+
+func backtrack(trace [][]int, a, b []string, offset int) []Line {
+	x, y := len(a), len(b)
+	var reversed []Line
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Line{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, Line{Insert, b[y-1]})
+			} else {
+				reversed = append(reversed, Line{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	lines := make([]Line, len(reversed))
+	for i := range reversed {
+		lines[i] = reversed[len(reversed)-1-i]
+	}
+	return lines
+}
+
+// Stat 新增、删除的行数
+func Stat(lines []Line) (added, deleted int) {
+	for _, l := range lines {
+		switch l.Op {
+		case Insert:
+			added++
+		case Delete:
+			deleted++
+		}
+	}
+	return
+}
+
+// Split 按行切分文本,忽略末尾的换行
+func Split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
+
+// Unified 输出 a 到 b 的 unified diff,每处修改前后保留 context 行,没有差异时返回空字符串
+func Unified(fromName, toName, a, b string, context int) string {
+	lines := Lines(Split(a), Split(b))
+	// 每一行之前 a、b 各有多少行
+	aPos := make([]int, len(lines)+1)
+	bPos := make([]int, len(lines)+1)
+	for i, l := range lines {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if l.Op != Insert {
+			aPos[i+1]++
+		}
+		if l.Op != Delete {
+			bPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(lines); {
+		for i < len(lines) && lines[i].Op == Equal {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// 两处修改之间的相同行不超过 2*context 时合并为一个 hunk
+		end := i
+		for {
+			for end < len(lines) && lines[end].Op != Equal {
+				end++
+			}
+			next := end
+			for next < len(lines) && lines[next].Op == Equal {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				if end+context < next {
+					next = end + context
+				}
+				end = next
+				break
+			}
+			end = next
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aPos[start], aPos[end]), hunkRange(bPos[start], bPos[end]))
+		for _, l := range lines[start:end] {
+			switch l.Op {
+			case Equal:
+				sb.WriteByte(' ')
+			case Insert:
+				sb.WriteByte('+')
+			case Delete:
+				sb.WriteByte('-')
+			}
+			sb.WriteString(l.Text)
+			sb.WriteByte('\n')
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func hunkRange(from, to int) string {
+	count := to - from
+	if count == 0 {
+		return fmt.Sprintf("%d,0", from)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", from+1)
+	}
+	return fmt.Sprintf("%d,%d", from+1, count)
+}
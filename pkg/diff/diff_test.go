@@ -0,0 +1,51 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\nj\nk\n"
+	want := "--- v1\n+++ v2\n" +
+		"@@ -1,10 +1,10 @@\n a\n-b\n+B\n c\n d\n e\n f\n g\n h\n-i\n j\n+k\n"
+	if got := Unified("v1", "v2", a, b, 3); got != want {
+		t.Fatalf("got:\n%s", got)
+	}
+	// context 为 1 时拆分为两个 hunk
+	want = "--- v1\n+++ v2\n" +
+		"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n" +
+		"@@ -8,3 +8,3 @@\n h\n-i\n j\n+k\n"
+	if got := Unified("v1", "v2", a, b, 1); got != want {
+		t.Fatalf("got:\n%s", got)
+	}
+	if got := Unified("v1", "v2", "x\ny", "x\ny\n", 3); got != "" {
+		t.Fatalf("没有差异: %q", got)
+	}
+	want = "--- v1\n+++ v2\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Unified("v1", "v2", "", "x\ny", 3); got != want {
+		t.Fatalf("got:\n%s", got)
+	}
+}
+
+func TestLines(t *testing.T) {
+	a := Split("a\nb\nc")
+	b := Split("x\na\nc")
+	lines := Lines(a, b)
+	added, deleted := Stat(lines)
+	if len(lines) != 4 || added != 1 || deleted != 1 {
+		t.Fatalf("%+v", lines)
+	}
+
+	// 超过 maxEdits 时整体替换
+	var sa, sb []string
+	for i := 0; i < maxEdits; i++ {
+		sa = append(sa, "a"+strings.Repeat("x", i%7))
+		sb = append(sb, "b"+strings.Repeat("x", i%5))
+	}
+	added, deleted = Stat(Lines(sa, sb))
+	if added != maxEdits || deleted != maxEdits {
+		t.Fatalf("added: %d, deleted: %d", added, deleted)
+	}
+}
@@ -1,29 +1,34 @@
 package email
 
 import (
+	"context"
 	"github.com/jordan-wright/email"
+	"net"
 	"net/smtp"
 	"time"
 	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/metrics"
 )
 
 var emailPoll *email.Pool
 
 var from string
 
-func Init(address, username, password, host string, pollCount int) {
+var address string
+
+func Init(addr, username, password, host string, pollCount int) {
 	p, err := email.NewPool(
-		address,
+		addr,
 		pollCount,
 		smtp.PlainAuth("", username, password, host),
 	)
 	if err != nil {
 		log.Errorf("初始化 email 失败,err: %s", err.Error())
 		panic(err.Error())
-		return
 	}
 	emailPoll = p
 	from = username
+	address = addr
 }
 
 func Send(to []string, content, subject string) {
@@ -36,8 +41,37 @@ func Send(to []string, content, subject string) {
 	e.Subject = subject
 	e.Text = []byte(content)
 	err := emailPoll.Send(e, 10*time.Second)
+	metrics.EmailSent(err)
 	if err != nil {
 		log.Warnf("发送邮箱失败,接收人: %v,err: %s", to, err.Error())
 		return
 	}
 }
+
+// Close 关闭连接池,等待正在发送的邮件完成,ctx 过期时不再等待
+func Close(ctx context.Context) error {
+	if emailPoll == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		emailPoll.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ping 检查 smtp 服务是否可以连接
+func Ping(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
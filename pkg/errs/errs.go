@@ -0,0 +1,95 @@
+package errs
+
+// 业务错误码,service 返回这里定义的错误,pkg/result 根据错误码和 http 状态码渲染响应
+// 错误码为 http 状态码 * 100 + 序号,一经发布不再修改,客户端按错误码而不是错误信息判断错误类型
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+var (
+	UnderReview     = New(20200, http.StatusAccepted, "内容已提交审核,审核通过后发布")
+	BadRequest      = New(40000, http.StatusBadRequest, "请求参数错误")
+	Validation      = New(40001, http.StatusBadRequest, "参数校验失败")
+	Unauthorized    = New(40100, http.StatusUnauthorized, "未登录")
+	TokenInvalid    = New(40101, http.StatusUnauthorized, "token 无效或已过期,请重新登录")
+	LoginFailed     = New(40102, http.StatusUnauthorized, "账号或密码错误")
+	Forbidden       = New(40300, http.StatusForbidden, "无权限")
+	Banned          = New(40301, http.StatusForbidden, "你已涉嫌违规社区文化,已被纳入小黑屋")
+	NotFound        = New(40400, http.StatusNotFound, "资源不存在")
+	Conflict        = New(40900, http.StatusConflict, "资源已存在")
+	StateConflict   = New(40901, http.StatusConflict, "当前状态不允许该操作")
+	InUse           = New(40902, http.StatusConflict, "资源被引用,不可删除")
+	ContentRejected = New(42200, http.StatusUnprocessableEntity, "内容包含违规信息,请修改后重试")
+	TooManyRequests = New(42900, http.StatusTooManyRequests, "操作次数过多,请稍后重试")
+	Internal        = New(50000, http.StatusInternalServerError, "服务器内部错误")
+	Unavailable     = New(50300, http.StatusServiceUnavailable, "服务暂不可用,请稍后重试")
+)
+
+// FieldError 单个字段的校验错误,field 为 json 字段名
+type FieldError struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+type Error struct {
+	Code    int
+	Status  int
+	Msg     string
+	Details []FieldError
+	cause   error
+}
+
+func New(code, status int, msg string) *Error {
+	return &Error{Code: code, Status: status, Msg: msg}
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 错误码相同即认为是同一种错误,errors.Is(err, errs.NotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMsg 返回相同错误码、不同错误信息的错误,不修改 e
+func (e *Error) WithMsg(msg string) *Error {
+	c := *e
+	c.Msg = msg
+	return &c
+}
+
+// Wrap 记录原始错误,响应中只返回 e 的错误信息,原始错误用于日志
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.cause = err
+	return &c
+}
+
+// WithDetails 附加字段级的校验错误
+func (e *Error) WithDetails(details []FieldError) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// From 取出 err 中的业务错误,gorm.ErrRecordNotFound 视为 NotFound,其他错误返回 false
+func From(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound.Wrap(err), true
+	}
+	return nil, false
+}
@@ -0,0 +1,302 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// ContentTypes 各格式的响应类型
+var ContentTypes = map[string]string{
+	FormatRSS:  "application/rss+xml; charset=utf-8",
+	FormatAtom: "application/atom+xml; charset=utf-8",
+	FormatJSON: "application/feed+json; charset=utf-8",
+}
+
+// Feed 与输出格式无关的订阅源
+type Feed struct {
+	Title       string
+	Link        string // 站点页面地址
+	FeedLink    string // 订阅源自身的地址
+	Description string
+	Updated     time.Time
+	Items       []Item
+}
+
+type Item struct {
+	ID         string
+	Title      string
+	Link       string
+	Summary    string // 纯文本摘要
+	Image      string // 封面地址
+	Author     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// Render 按格式输出,格式不支持时返回 false
+func (f *Feed) Render(format string) ([]byte, bool, error) {
+	var (
+		b   []byte
+		err error
+	)
+	switch format {
+	case FormatRSS:
+		b, err = f.RSS()
+	case FormatAtom:
+		b, err = f.Atom()
+	case FormatJSON:
+		b, err = f.JSON()
+	default:
+		return nil, false, nil
+	}
+	return b, true, err
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Self          atomLink  `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description"`
+	Author      string        `xml:"dc:creator,omitempty"`
+	Categories  []string      `xml:"category"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// RSS 2.0,封面作为 enclosure,同时写入 description
+func (f *Feed) RSS() ([]byte, error) {
+	doc := rss{Channel: rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Self:          atomLink{Href: f.FeedLink, Rel: "self", Type: "application/rss+xml"},
+		Description:   f.Description,
+		LastBuildDate: f.Updated.Format(time.RFC1123Z),
+	}}
+	for _, item := range f.Items {
+		r := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.Link},
+			Description: item.htmlSummary(),
+			Author:      item.Author,
+			Categories:  item.Categories,
+			PubDate:     item.Published.Format(time.RFC1123Z),
+		}
+		if item.Image != "" {
+			r.Enclosure = &rssEnclosure{URL: item.Image, Type: imageType(item.Image)}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, r)
+	}
+	return marshalXML(doc, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">`)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Sub     string      `xml:"subtitle,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    atomText       `xml:"summary"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Atom 封面作为 enclosure 链接
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		Title:   f.Title,
+		ID:      f.FeedLink,
+		Links:   []atomLink{{Href: f.Link}, {Href: f.FeedLink, Rel: "self", Type: "application/atom+xml"}},
+		Updated: f.Updated.Format(time.RFC3339),
+		Sub:     f.Description,
+	}
+	for _, item := range f.Items {
+		e := atomEntry{
+			Title:     item.Title,
+			ID:        item.Link,
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate"}},
+			Published: item.Published.Format(time.RFC3339),
+			Updated:   item.Updated.Format(time.RFC3339),
+			Summary:   atomText{Type: "html", Value: item.htmlSummary()},
+		}
+		if item.Image != "" {
+			e.Links = append(e.Links, atomLink{Href: item.Image, Rel: "enclosure", Type: imageType(item.Image)})
+		}
+		if item.Author != "" {
+			e.Author = &atomAuthor{Name: item.Author}
+		}
+		for _, c := range item.Categories {
+			e.Categories = append(e.Categories, atomCategory{Term: c})
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+	return marshalXML(doc, "")
+}
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url"`
+	FeedURL     string     `json:"feed_url"`
+	Description string     `json:"description,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	ContentText   string       `json:"content_text"`
+	Summary       string       `json:"summary,omitempty"`
+	Image         string       `json:"image,omitempty"`
+	DatePublished string       `json:"date_published"`
+	DateModified  string       `json:"date_modified"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+// JSON Feed 1.1
+func (f *Feed) JSON() ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedLink,
+		Description: f.Description,
+		Items:       make([]jsonItem, 0, len(f.Items)),
+	}
+	for _, item := range f.Items {
+		j := jsonItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentText:   item.Summary,
+			Summary:       item.Summary,
+			Image:         item.Image,
+			DatePublished: item.Published.Format(time.RFC3339),
+			DateModified:  item.Updated.Format(time.RFC3339),
+			Tags:          item.Categories,
+		}
+		if item.Author != "" {
+			j.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, j)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(doc)
+	return buf.Bytes(), err
+}
+
+// htmlSummary 摘要转义后作为 html,有封面时放在摘要前
+func (i Item) htmlSummary() string {
+	var b strings.Builder
+	if i.Image != "" {
+		b.WriteString(`<p><img src="` + html.EscapeString(i.Image) + `" alt="` + html.EscapeString(i.Title) + `"></p>`)
+	}
+	if i.Summary != "" {
+		b.WriteString("<p>" + html.EscapeString(i.Summary) + "</p>")
+	}
+	return b.String()
+}
+
+// imageType 按扩展名推断图片类型,地址带参数时也尝试参数中的文件名,推断不出时为 image/*
+func imageType(link string) string {
+	candidates := []string{link}
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		candidates = append(candidates, link[:i])
+	}
+	for _, c := range candidates {
+		if t := mime.TypeByExtension(strings.ToLower(path.Ext(c))); strings.HasPrefix(t, "image/") {
+			return t
+		}
+	}
+	return "image/*"
+}
+
+// marshalXML root 不为空时替换根元素的开始标签,用于声明版本和额外的命名空间
+func marshalXML(v any, root string) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if root != "" {
+		if i := strings.Index(string(b), ">"); i >= 0 {
+			b = append([]byte(root), b[i+1:]...)
+		}
+	}
+	return append([]byte(xml.Header), b...), nil
+}
@@ -0,0 +1,62 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	f := &Feed{Title: "社区", Link: "https://a.com", FeedLink: "https://a.com/community/feed/rss", Updated: now, Items: []Item{{
+		ID: "1", Title: "a < b", Link: "https://a.com/article/1", Summary: "摘要 & 更多", Image: "https://a.com/community/feed/cover/1",
+		Author: "xhy", Categories: []string{"Go", "并发"}, Published: now, Updated: now,
+	}}}
+
+	b, ok, err := f.Render(FormatRSS)
+	if err != nil || !ok {
+		t.Fatal(err)
+	}
+	var r struct {
+		Version string `xml:"version,attr"`
+		Items   []struct {
+			Title       string   `xml:"title"`
+			Description string   `xml:"description"`
+			Categories  []string `xml:"category"`
+			PubDate     string   `xml:"pubDate"`
+		} `xml:"channel>item"`
+	}
+	if err = xml.Unmarshal(b, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != "2.0" || len(r.Items) != 1 || r.Items[0].Title != "a < b" || len(r.Items[0].Categories) != 2 || r.Items[0].PubDate != "Tue, 02 Jan 2024 03:04:05 +0000" {
+		t.Fatalf("rss: %s", b)
+	}
+	if !strings.Contains(r.Items[0].Description, "<p>摘要 &amp; 更多</p>") {
+		t.Fatalf("摘要应转义为 html: %s", r.Items[0].Description)
+	}
+
+	b, _, _ = f.Render(FormatAtom)
+	var a struct {
+		XMLName xml.Name
+		Entries []struct {
+			ID      string `xml:"id"`
+			Updated string `xml:"updated"`
+		} `xml:"entry"`
+	}
+	if err = xml.Unmarshal(b, &a); err != nil || a.XMLName.Space != "http://www.w3.org/2005/Atom" || a.Entries[0].ID != "https://a.com/article/1" || a.Entries[0].Updated != "2024-01-02T03:04:05Z" {
+		t.Fatalf("atom: %s, %v", b, err)
+	}
+
+	b, _, _ = f.Render(FormatJSON)
+	var j map[string]any
+	if err = json.Unmarshal(b, &j); err != nil || j["version"] != "https://jsonfeed.org/version/1.1" || len(j["items"].([]any)) != 1 {
+		t.Fatalf("json: %s, %v", b, err)
+	}
+
+	if _, ok, _ = f.Render("xml"); ok {
+		t.Fatal("不支持的格式")
+	}
+}
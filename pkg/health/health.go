@@ -0,0 +1,84 @@
+package health
+
+// 健康检查,各组件启动后注册检查函数,/healthz、/readyz 并发执行所有检查
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 单个检查的超时时间
+const checkTimeout = 3 * time.Second
+
+type Result struct {
+	Status string `json:"status"` // ok / fail
+	Error  string `json:"error,omitempty"`
+	Took   string `json:"took"`
+}
+
+var (
+	lock   sync.RWMutex
+	checks = make(map[string]func(ctx context.Context) error)
+)
+
+// Register 注册检查,name 相同时覆盖
+func Register(name string, check func(ctx context.Context) error) {
+	lock.Lock()
+	defer lock.Unlock()
+	checks[name] = check
+}
+
+// Check 执行所有检查,全部成功时 ok 为 true
+func Check(ctx context.Context) (map[string]Result, bool) {
+	lock.RLock()
+	current := make(map[string]func(ctx context.Context) error, len(checks))
+	for name, check := range checks {
+		current[name] = check
+	}
+	lock.RUnlock()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]Result, len(current))
+		ok      = true
+	)
+	for name, check := range current {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+			start := time.Now()
+			err := run(ctx, check)
+			r := Result{Status: "ok", Took: time.Since(start).String()}
+			if err != nil {
+				r.Status = "fail"
+				r.Error = err.Error()
+			}
+			mu.Lock()
+			results[name] = r
+			if err != nil {
+				ok = false
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	return results, ok
+}
+
+// 检查函数不一定响应 ctx,超时后直接返回
+func run(ctx context.Context, check func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
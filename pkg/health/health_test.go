@@ -0,0 +1,29 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	checks = make(map[string]func(ctx context.Context) error)
+	Register("ok", func(ctx context.Context) error { return nil })
+	results, ok := Check(context.Background())
+	if !ok || results["ok"].Status != "ok" {
+		t.Fatalf("检查结果: %+v", results)
+	}
+
+	Register("fail", func(ctx context.Context) error { return errors.New("连接失败") })
+	Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	results, ok = Check(ctx)
+	if ok || results["fail"].Error != "连接失败" || results["slow"].Status != "fail" || results["ok"].Status != "ok" {
+		t.Fatalf("检查结果: %+v", results)
+	}
+}
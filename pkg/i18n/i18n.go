@@ -0,0 +1,152 @@
+package i18n
+
+// 多语言,源语言为中文: 代码中直接写中文文本,文本本身作为翻译的 key,其他语言的翻译放在 locales/<locale>.json
+// 没有翻译的文本原样输出,所以新增文本时不需要同时修改翻译文件
+// 语言优先级: 请求参数 lang > 用户设置 > cookie locale > Accept-Language > 默认中文
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	Default = "zh-CN"
+	English = "en"
+)
+
+//go:embed locales/*.json
+var files embed.FS
+
+// locale -> 中文原文 -> 译文
+var catalogues = map[string]map[string]string{}
+
+func init() {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err.Error())
+	}
+	for _, entry := range entries {
+		raw, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err.Error())
+		}
+		messages := map[string]string{}
+		if err = json.Unmarshal(raw, &messages); err != nil {
+			panic(fmt.Sprintf("解析翻译文件 %s 失败: %s", entry.Name(), err.Error()))
+		}
+		catalogues[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+}
+
+// Supported 支持的语言
+func Supported() []string {
+	locales := []string{Default}
+	for locale := range catalogues {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// Normalize 将 en-US、zh、zh_TW 等转换为支持的语言,不支持时返回空字符串
+func Normalize(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(locale, "_", "-")))
+	if locale == "" {
+		return ""
+	}
+	lang := strings.SplitN(locale, "-", 2)[0]
+	if lang == "zh" {
+		return Default
+	}
+	for supported := range catalogues {
+		if strings.ToLower(supported) == locale || strings.SplitN(strings.ToLower(supported), "-", 2)[0] == lang {
+			return supported
+		}
+	}
+	return ""
+}
+
+// Negotiate 按 Accept-Language 的权重选择支持的语言,都不支持时返回默认语言
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if locale := Normalize(fields[0]); locale != "" && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
+
+type localeKey struct{}
+
+// NewContext 将语言放入 ctx
+func NewContext(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext 获取 ctx 中的语言,不存在时返回默认语言
+func FromContext(ctx context.Context) string {
+	if ctx != nil {
+		if locale, ok := ctx.Value(localeKey{}).(string); ok && locale != "" {
+			return locale
+		}
+	}
+	return Default
+}
+
+// T 按 ctx 中的语言翻译,args 不为空时 key 作为格式化模板
+func T(ctx context.Context, key string, args ...interface{}) string {
+	return Tr(FromContext(ctx), key, args...)
+}
+
+// Tr 按指定语言翻译,用于给其他用户发送通知等与当前请求语言无关的场景
+func Tr(locale, key string, args ...interface{}) string {
+	if translated, ok := catalogues[locale][key]; ok {
+		key = translated
+	}
+	if len(args) == 0 {
+		return key
+	}
+	return fmt.Sprintf(key, args...)
+}
+
+// Message 确定接收人的语言后再翻译的文本
+type Message struct {
+	key  string
+	args []interface{}
+	raw  bool
+}
+
+// M 需要翻译的文本
+func M(key string, args ...interface{}) Message {
+	return Message{key: key, args: args}
+}
+
+// Raw 不需要翻译的文本,如管理员填写的通知内容
+func Raw(text string) Message {
+	return Message{key: text, raw: true}
+}
+
+// In 翻译为指定语言
+func (m Message) In(locale string) string {
+	if m.raw {
+		return m.key
+	}
+	return Tr(locale, m.key, m.args...)
+}
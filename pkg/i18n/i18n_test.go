@@ -0,0 +1,57 @@
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                             Default,
+		"en-US,en;q=0.9":               English,
+		"zh-CN,zh;q=0.9,en;q=0.8":      Default,
+		"fr-FR, en-GB;q=0.7, zh;q=0.5": English,
+		"de;q=0.9, zh-TW;q=0.8":        Default,
+		"ja":                           Default,
+		"en;q=0.2, zh-Hans-CN;q=0.9":   Default,
+	}
+	for header, want := range cases {
+		if got := Negotiate(header); got != want {
+			t.Errorf("%q: %s, want %s", header, got, want)
+		}
+	}
+	if Normalize("en_US") != English || Normalize("xx") != "" {
+		t.Fatal("Normalize")
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	ctx := NewContext(context.Background(), English)
+	if got := T(ctx, "文章不存在"); got != "Article not found" {
+		t.Fatalf("翻译: %s", got)
+	}
+	if got := T(ctx, "没有翻译的文本"); got != "没有翻译的文本" {
+		t.Fatalf("没有翻译时原样输出: %s", got)
+	}
+	if got := T(context.Background(), "文章不支持该状态: %s", "草稿"); got != "文章不支持该状态: 草稿" {
+		t.Fatalf("默认语言: %s", got)
+	}
+	if got := M("已 ban 掉用户：%s", "a@b.c").In(English); got != "Banned user: a@b.c" {
+		t.Fatalf("Message: %s", got)
+	}
+	if got := Raw("100% 原样").In(English); got != "100% 原样" {
+		t.Fatalf("Raw: %s", got)
+	}
+}
+
+// 译文的格式化参数个数需要与原文一致
+func TestCatalogueVerbs(t *testing.T) {
+	for locale, messages := range catalogues {
+		for key, translated := range messages {
+			if strings.Count(key, "%") != strings.Count(translated, "%") || strings.Count(key, "${") != strings.Count(translated, "${") {
+				t.Errorf("%s: %q -> %q 参数不一致", locale, key, translated)
+			}
+		}
+	}
+}
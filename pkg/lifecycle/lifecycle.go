@@ -0,0 +1,163 @@
+package lifecycle
+
+// 组件生命周期管理: 按注册顺序启动,按相反顺序停止
+// 后台任务通过 Go 启动,停止时在超时时间内等待执行完成
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"xhyovo.cn/community/pkg/log"
+)
+
+// Component Start、Stop 都可以为空
+type Component struct {
+	Name  string
+	Start func() error
+	Stop  func(ctx context.Context) error
+}
+
+type Manager struct {
+	lock       sync.Mutex
+	components []Component
+	started    int
+	ready      int32
+}
+
+var instance = New()
+
+func GetInstance() *Manager {
+	return instance
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+// Add 注册组件,需要在 Start 之前调用
+func (m *Manager) Add(c Component) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.components = append(m.components, c)
+}
+
+// Ready 所有组件启动完成且未开始停止
+func (m *Manager) Ready() bool {
+	return atomic.LoadInt32(&m.ready) == 1
+}
+
+// Start 按顺序启动组件,某个组件启动失败时停止已经启动的组件
+func (m *Manager) Start(timeout time.Duration) error {
+	m.lock.Lock()
+	components := m.components
+	m.lock.Unlock()
+	for _, c := range components {
+		if err := start(c); err != nil {
+			log.Errorf("组件 %s 启动失败,err: %s", c.Name, err.Error())
+			m.Stop(timeout)
+			return fmt.Errorf("组件 %s 启动失败: %w", c.Name, err)
+		}
+		log.Infof("组件 %s 已启动", c.Name)
+		m.lock.Lock()
+		m.started++
+		m.lock.Unlock()
+	}
+	atomic.StoreInt32(&m.ready, 1)
+	return nil
+}
+
+// Stop 按相反顺序停止已启动的组件,所有组件共用 timeout,超时后剩余组件的 ctx 直接过期
+func (m *Manager) Stop(timeout time.Duration) error {
+	atomic.StoreInt32(&m.ready, 0)
+	m.lock.Lock()
+	components := m.components[:m.started]
+	m.started = 0
+	m.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var errs []string
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		if c.Stop == nil {
+			continue
+		}
+		if err := c.Stop(ctx); err != nil {
+			log.Warnf("组件 %s 停止失败,err: %s", c.Name, err.Error())
+			errs = append(errs, c.Name+": "+err.Error())
+			continue
+		}
+		log.Infof("组件 %s 已停止", c.Name)
+	}
+	if len(errs) > 0 {
+		return errors.New("组件停止失败: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Run 启动所有组件,收到 SIGINT / SIGTERM 后停止
+func (m *Manager) Run(timeout time.Duration) error {
+	if err := m.Start(timeout); err != nil {
+		return err
+	}
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	signal.Stop(quit)
+	log.Infof("收到信号 %s,开始停止", sig.String())
+	return m.Stop(timeout)
+}
+
+// Init 函数初始化失败时会 panic,这里转换为 error 以便停止已启动的组件
+func start(c Component) (err error) {
+	if c.Start == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return c.Start()
+}
+
+var tasks sync.WaitGroup
+
+// Go 启动后台任务,停止时会等待后台任务执行完成,代替直接使用 go
+// 后台任务在请求结束后仍会执行,传给 f 的 ctx 只保留请求 id,不会随请求取消
+func Go(ctx context.Context, f func(ctx context.Context)) {
+	ctx = log.NewContext(context.Background(), log.RequestId(ctx))
+	tasks.Add(1)
+	go func() {
+		defer tasks.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Ctx(ctx).Errorf("后台任务 panic: %v", r)
+			}
+		}()
+		f(ctx)
+	}()
+}
+
+// Wait 等待所有后台任务执行完成,ctx 过期时返回
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待后台任务超时: %w", ctx.Err())
+	}
+}
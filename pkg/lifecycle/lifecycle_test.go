@@ -0,0 +1,71 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStartStopOrder(t *testing.T) {
+	var events []string
+	m := New()
+	for _, name := range []string{"db", "cache", "http"} {
+		name := name
+		m.Add(Component{
+			Name:  name,
+			Start: func() error { events = append(events, "start "+name); return nil },
+			Stop:  func(ctx context.Context) error { events = append(events, "stop "+name); return nil },
+		})
+	}
+	if err := m.Start(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Ready() {
+		t.Fatal("启动后应该 ready")
+	}
+	if err := m.Stop(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if m.Ready() {
+		t.Fatal("停止后不应该 ready")
+	}
+	want := []string{"start db", "start cache", "start http", "stop http", "stop cache", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("启动停止顺序: %v", events)
+	}
+}
+
+func TestStartFailure(t *testing.T) {
+	var stopped []string
+	m := New()
+	m.Add(Component{Name: "db", Stop: func(ctx context.Context) error { stopped = append(stopped, "db"); return nil }})
+	m.Add(Component{Name: "cache", Start: func() error { panic("连接失败") }, Stop: func(ctx context.Context) error {
+		stopped = append(stopped, "cache")
+		return nil
+	}})
+	m.Add(Component{Name: "http", Start: func() error { return errors.New("不应该启动") }})
+	if err := m.Start(time.Second); err == nil {
+		t.Fatal("启动失败应该返回错误")
+	}
+	if m.Ready() || !reflect.DeepEqual(stopped, []string{"db"}) {
+		t.Fatalf("只停止已启动的组件: %v", stopped)
+	}
+}
+
+func TestWait(t *testing.T) {
+	release := make(chan struct{})
+	Go(context.Background(), func(ctx context.Context) { <-release })
+	Go(context.Background(), func(ctx context.Context) { panic("后台任务 panic 不影响等待") })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Wait(ctx); err == nil {
+		t.Fatal("后台任务未完成时应该超时")
+	}
+	close(release)
+	if err := Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,32 @@
+package log
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type requestIdKey struct{}
+
+// NewContext 将请求 id 放入 ctx,之后通过 Ctx(ctx) 打印的日志都会带上请求 id
+func NewContext(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestId 获取 ctx 中的请求 id,不存在时返回空字符串
+func RequestId(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// Ctx 返回带请求 id 的 logger,ctx 中没有请求 id 时与包级函数一致
+func Ctx(ctx context.Context) *zap.SugaredLogger {
+	id := RequestId(ctx)
+	if id == "" {
+		return base
+	}
+	return base.With("requestId", id)
+}
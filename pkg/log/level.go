@@ -0,0 +1,170 @@
+package log
+
+// 日志级别,支持全局级别和按包(或 logger 名称)单独设置,均可在运行时修改
+// 包名为模块内的路径,如 pkg/delay、server/service,按路径前缀匹配,server 会同时作用于 server/service 和 server/dao
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const modulePrefix = "xhyovo.cn/community/"
+
+var (
+	level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	// map[string]zapcore.Level,整体替换,读时不加锁
+	packageLevels atomic.Value
+	levelLock     sync.Mutex
+)
+
+func init() {
+	packageLevels.Store(map[string]zapcore.Level{})
+}
+
+// SetLevel 修改全局日志级别,如 debug / info / warn / error
+func SetLevel(l string) error {
+	lvl, err := parseLevel(l)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(lvl)
+	return nil
+}
+
+// GetLevel 全局日志级别
+func GetLevel() string {
+	return level.Level().String()
+}
+
+// SetPackageLevel 修改单个包的日志级别,l 为空时删除该包的设置,使用全局级别
+func SetPackageLevel(pkg, l string) error {
+	pkg = strings.Trim(pkg, "/")
+	levelLock.Lock()
+	defer levelLock.Unlock()
+	current := packageLevels.Load().(map[string]zapcore.Level)
+	next := make(map[string]zapcore.Level, len(current)+1)
+	for k, v := range current {
+		next[k] = v
+	}
+	if l == "" {
+		delete(next, pkg)
+	} else {
+		lvl, err := parseLevel(l)
+		if err != nil {
+			return err
+		}
+		next[pkg] = lvl
+	}
+	packageLevels.Store(next)
+	return nil
+}
+
+// SetPackageLevels 替换所有包的日志级别,用于配置加载和热更新
+func SetPackageLevels(levels map[string]string) error {
+	next := make(map[string]zapcore.Level, len(levels))
+	for pkg, l := range levels {
+		lvl, err := parseLevel(l)
+		if err != nil {
+			return err
+		}
+		next[strings.Trim(pkg, "/")] = lvl
+	}
+	levelLock.Lock()
+	defer levelLock.Unlock()
+	packageLevels.Store(next)
+	return nil
+}
+
+// GetPackageLevels 当前按包设置的日志级别
+func GetPackageLevels() map[string]string {
+	current := packageLevels.Load().(map[string]zapcore.Level)
+	levels := make(map[string]string, len(current))
+	for pkg, lvl := range current {
+		levels[pkg] = lvl.String()
+	}
+	return levels
+}
+
+// levelOf 先按 logger 名称匹配,再按调用方所在的包匹配,都没有时使用全局级别
+func levelOf(name, pkg string) zapcore.Level {
+	current := packageLevels.Load().(map[string]zapcore.Level)
+	if len(current) > 0 {
+		if lvl, ok := matchLevel(current, name); ok {
+			return lvl
+		}
+		if lvl, ok := matchLevel(current, pkg); ok {
+			return lvl
+		}
+	}
+	return level.Level()
+}
+
+// matchLevel 最长前缀匹配
+func matchLevel(levels map[string]zapcore.Level, path string) (zapcore.Level, bool) {
+	for path != "" {
+		if lvl, ok := levels[path]; ok {
+			return lvl, true
+		}
+		i := strings.LastIndexAny(path, "/.")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return 0, false
+}
+
+// minLevel 所有设置中最低的级别,低于该级别的日志直接丢弃
+func minLevel() zapcore.Level {
+	lvl := level.Level()
+	for _, l := range packageLevels.Load().(map[string]zapcore.Level) {
+		if l < lvl {
+			lvl = l
+		}
+	}
+	return lvl
+}
+
+// callerPackage 从调用方函数名中取出模块内的包路径,如 xhyovo.cn/community/pkg/delay.(*DelayQueue).run -> pkg/delay
+func callerPackage(function string) string {
+	if !strings.HasPrefix(function, modulePrefix) {
+		return ""
+	}
+	function = function[len(modulePrefix):]
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+// levelCore 按包过滤日志,调用位置在 Check 之后才会填充,所以在 Write 时过滤
+type levelCore struct {
+	zapcore.Core
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= minLevel()
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields)}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *levelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level < levelOf(ent.LoggerName, callerPackage(ent.Caller.Function)) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
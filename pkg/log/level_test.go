@@ -0,0 +1,53 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCallerPackage(t *testing.T) {
+	cases := map[string]string{
+		"xhyovo.cn/community/pkg/delay.(*DelayQueue).run": "pkg/delay",
+		"xhyovo.cn/community/server/service.init.func1":   "server/service",
+		"xhyovo.cn/community/cmd/community.main":          "cmd/community",
+		"gorm.io/gorm.(*DB).Find":                         "",
+	}
+	for function, want := range cases {
+		if got := callerPackage(function); got != want {
+			t.Errorf("%s: %s, want %s", function, got, want)
+		}
+	}
+}
+
+func TestPackageLevels(t *testing.T) {
+	defer SetLevel("info")
+	defer SetPackageLevels(nil)
+	observed, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(&levelCore{Core: observed}, zap.AddCaller()).Sugar()
+
+	if err := SetLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPackageLevels(map[string]string{"pkg": "debug", "gorm": "error"}); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("pkg/log 属于 pkg,输出")
+	logger.Named("gorm").Warn("gorm 只输出 error")
+	logger.Named("gorm").Error("输出")
+	if err := SetPackageLevel("pkg", ""); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("删除后使用全局级别,不输出")
+	if logs.Len() != 2 {
+		t.Fatalf("输出的日志: %v", logs.AllUntimed())
+	}
+	if !Enabled("gorm", zapcore.ErrorLevel) || Enabled("gorm", zapcore.WarnLevel) {
+		t.Fatal("Enabled 应该使用 logger 名称的级别")
+	}
+	if err := SetPackageLevel("pkg", "verbose"); err == nil {
+		t.Fatal("不支持的级别应该报错")
+	}
+}
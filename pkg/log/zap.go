@@ -1,20 +1,32 @@
 package log
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/natefinch/lumberjack"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"os"
+	"xhyovo.cn/community/pkg/config"
 )
 
-var log *zap.SugaredLogger
+// 未 Init 时(如单元测试)不输出日志
+var (
+	base = zap.NewNop().Sugar()
+	// 包级函数多一层调用,需要跳过一层才能打印出正确的调用位置
+	log = base
+)
 
 /*
-setJSONEncoder 设置logger编码
+newEncoder 设置logger编码,format 为 json 或 console
 */
-func setJSONEncoder() zapcore.Encoder {
+func newEncoder(format string) zapcore.Encoder {
 	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder   //转换编码的时间戳
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder //转换编码的时间戳
+	if format == "json" {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
 	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder //编码级别调整为大写的级别输出
 	return zapcore.NewConsoleEncoder(encoderConfig)
 }
@@ -22,45 +34,69 @@ func setJSONEncoder() zapcore.Encoder {
 /*
 setLoggerWrite 设置logger写入文件
 */
-func setLoggerWrite() zapcore.WriteSyncer {
+func setLoggerWrite(logConfig config.LogConfig) zapcore.WriteSyncer {
 	l := &lumberjack.Logger{
-		Filename:   "./community_log/log.log", //Filename 是要写入日志的文件。
-		MaxSize:    1,                         //MaxSize 是日志文件在轮换之前的最大大小（以兆字节为单位）。它默认为 100 兆字节
-		MaxBackups: 1,                         //MaxBackups 是要保留的最大旧日志文件数。默认是保留所有旧的日志文件（尽管 MaxAge 可能仍会导致它们被删除。）
-		MaxAge:     30,                        //MaxAge 是根据文件名中编码的时间戳保留旧日志文件的最大天数。
-		Compress:   true,                      //压缩
-		LocalTime:  true,                      //LocalTime 确定用于格式化备份文件中的时间戳的时间是否是计算机的本地时间。默认是使用 UTC 时间。
+		Filename:   logConfig.Path,       //Filename 是要写入日志的文件。
+		MaxSize:    logConfig.MaxSize,    //MaxSize 是日志文件在轮换之前的最大大小（以兆字节为单位）。它默认为 100 兆字节
+		MaxBackups: logConfig.MaxBackups, //MaxBackups 是要保留的最大旧日志文件数。默认是保留所有旧的日志文件（尽管 MaxAge 可能仍会导致它们被删除。）
+		MaxAge:     logConfig.MaxAge,     //MaxAge 是根据文件名中编码的时间戳保留旧日志文件的最大天数。
+		Compress:   logConfig.Compress,   //压缩
+		LocalTime:  true,                 //LocalTime 确定用于格式化备份文件中的时间戳的时间是否是计算机的本地时间。默认是使用 UTC 时间。
 	}
 	return zapcore.AddSync(l)
 }
 
-func Init() {
-	core := zapcore.NewCore(setJSONEncoder(), zapcore.NewMultiWriteSyncer(setLoggerWrite(), os.Stdout), zap.InfoLevel)
-	log = zap.New(core, zap.AddCaller()).Sugar()
+func Init(logConfig config.LogConfig) {
+	if err := SetLevel(logConfig.Level); err != nil {
+		panic(err.Error())
+	}
+	if err := SetPackageLevels(logConfig.Levels); err != nil {
+		panic(err.Error())
+	}
+	var writers []zapcore.WriteSyncer
+	if logConfig.Path != "" {
+		writers = append(writers, setLoggerWrite(logConfig))
+	}
+	if logConfig.Stdout {
+		writers = append(writers, zapcore.AddSync(os.Stdout))
+	}
+	core := &levelCore{Core: zapcore.NewCore(newEncoder(logConfig.Format), zapcore.NewMultiWriteSyncer(writers...), zapcore.DebugLevel)}
+	base = zap.New(core, zap.AddCaller()).Sugar()
+	log = base.WithOptions(zap.AddCallerSkip(1))
+}
+
+// Named 返回指定名称的 logger,可以通过 log.levels 单独设置级别,如 gorm
+func Named(name string) *zap.SugaredLogger {
+	return base.Named(name)
+}
+
+// Enabled 名称为 name 的 logger 是否会输出 lvl 级别的日志,用于跳过开销较大的日志拼接
+func Enabled(name string, lvl zapcore.Level) bool {
+	return lvl >= levelOf(name, "")
 }
 
 func Info(args ...interface{}) {
-	log.Info(args)
+	log.Info(args...)
 }
 func Infof(template string, args ...interface{}) {
 	log.Infof(template, args...)
 }
 func Logln(lvl zapcore.Level, args ...interface{}) {
-	log.Logln(lvl, args)
+	log.Logln(lvl, args...)
 }
 func Warn(args ...interface{}) {
-	log.Warn(args)
+	log.Warn(args...)
 }
 func Warnf(template string, args ...interface{}) {
 	log.Warnf(template, args...)
 }
 
 func Warnln(args ...interface{}) {
-	log.Warnln(args)
+	log.Warnln(args...)
 }
 
 func Error(args ...interface{}) {
-	log.Error(args)
+	log.Error(args...)
 }
 
 func Errorf(template string, args ...interface{}) {
@@ -68,5 +104,13 @@ func Errorf(template string, args ...interface{}) {
 }
 
 func Errorln(args ...interface{}) {
-	log.Errorln(args)
+	log.Errorln(args...)
+}
+
+func parseLevel(l string) (zapcore.Level, error) {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToLower(l))); err != nil {
+		return lvl, fmt.Errorf("不支持的日志级别: %s", l)
+	}
+	return lvl, nil
 }
@@ -0,0 +1,64 @@
+package metrics
+
+// prometheus 指标,通过 /metrics 暴露
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "community"
+
+var (
+	// HttpDuration 按路由统计请求耗时和状态码,route 为 gin 注册的路由,未匹配的请求为 unmatched
+	HttpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	emailSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "email_sent_total",
+		Help:      "邮件发送次数",
+	}, []string{"result"})
+
+	notifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notification_recipients_total",
+		Help:      "订阅通知的接收人数",
+	}, []string{"event"})
+)
+
+// EmailSent 记录一次邮件发送结果
+func EmailSent(err error) {
+	if err != nil {
+		emailSent.WithLabelValues("failure").Inc()
+		return
+	}
+	emailSent.WithLabelValues("success").Inc()
+}
+
+// Notified 记录一次通知扇出的接收人数
+func Notified(eventId, recipients int) {
+	notifications.WithLabelValues(strconv.Itoa(eventId)).Add(float64(recipients))
+}
+
+// RegisterDB 注册连接池指标
+func RegisterDB(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// RegisterGauge 注册采集时计算的指标,如延迟队列长度
+func RegisterGauge(name, help string, f func() float64) error {
+	return prometheus.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, f))
+}
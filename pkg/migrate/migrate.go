@@ -0,0 +1,233 @@
+package migrate
+
+// 版本化的数据库迁移,sql 文件随二进制一起打包
+// 每种数据库一个目录: sql/mysql、sql/sqlite、sql/postgres,三个目录的版本需要保持一致
+// 文件命名: <版本号>_<名称>.up.sql / <版本号>_<名称>.down.sql,版本号递增
+// 每条语句以行尾的 ; 结束,-- 开头的行为注释
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/log"
+)
+
+//go:embed sql
+var files embed.FS
+
+// SchemaMigrations 已执行的迁移记录
+type SchemaMigrations struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Load 读取指定数据库内置的所有迁移,按版本号升序
+func Load(dialect string) ([]Migration, error) {
+	return load(files, path.Join("sql", dialect))
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("迁移文件 %s 命名不正确", name)
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		idx := strings.Index(base, "_")
+		if idx <= 0 {
+			return nil, fmt.Errorf("迁移文件 %s 缺少版本号", name)
+		}
+		version, err := strconv.Atoi(base[:idx])
+		if err != nil {
+			return nil, fmt.Errorf("迁移文件 %s 版本号不正确", name)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: base[idx+1:]}
+			byVersion[version] = m
+		} else if m.Name != base[idx+1:] {
+			return nil, fmt.Errorf("迁移版本 %d 存在多个名称: %s, %s", version, m.Name, base[idx+1:])
+		}
+		if direction == "up" {
+			m.Up = splitStatements(string(content))
+		} else {
+			m.Down = splitStatements(string(content))
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil || m.Down == nil {
+			return nil, fmt.Errorf("迁移版本 %d 缺少 up 或 down 文件", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// splitStatements 按行尾的 ; 拆分语句,去掉注释行
+func splitStatements(content string) []string {
+	statements := []string{}
+	var sb strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(sb.String()))
+			sb.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(sb.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+func applied(db *gorm.DB) (map[int]SchemaMigrations, error) {
+	if err := db.AutoMigrate(&SchemaMigrations{}); err != nil {
+		return nil, err
+	}
+	var records []SchemaMigrations
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	m := make(map[int]SchemaMigrations, len(records))
+	for _, r := range records {
+		m[r.Version] = r
+	}
+	return m, nil
+}
+
+// Up 执行所有未执行的迁移,返回执行的数量
+func Up(db *gorm.DB) (int, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return 0, err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, m := range migrations {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		if err = exec(db, m.Up); err != nil {
+			return count, fmt.Errorf("执行迁移 %d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		record := SchemaMigrations{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+		if err = db.Create(&record).Error; err != nil {
+			return count, err
+		}
+		log.Infof("已执行迁移 %d_%s", m.Version, m.Name)
+		count++
+	}
+	return count, nil
+}
+
+// Down 回滚最近执行的 steps 个迁移,返回回滚的数量
+func Down(db *gorm.DB, steps int) (int, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return 0, err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+		m := migrations[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+		if err = exec(db, m.Down); err != nil {
+			return count, fmt.Errorf("回滚迁移 %d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		if err = db.Delete(&SchemaMigrations{}, m.Version).Error; err != nil {
+			return count, err
+		}
+		log.Infof("已回滚迁移 %d_%s", m.Version, m.Name)
+		count++
+	}
+	return count, nil
+}
+
+// GetStatus 所有迁移的执行情况
+func GetStatus(db *gorm.DB) ([]Status, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if r, ok := done[m.Version]; ok {
+			s.Applied = true
+			appliedAt := r.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// mysql 的 DDL 会隐式提交,为了各数据库行为一致这里不使用事务,失败后需要根据报错手动处理
+func exec(db *gorm.DB, statements []string) error {
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
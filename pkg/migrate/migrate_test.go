@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/mysql"
+)
+
+var dialects = []string{mysql.DriverMySQL, mysql.DriverSQLite, mysql.DriverPostgres}
+
+func TestLoad(t *testing.T) {
+	var versions []string
+	for _, dialect := range dialects {
+		migrations, err := Load(dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var vs []string
+		for i, m := range migrations {
+			if i > 0 && migrations[i-1].Version >= m.Version {
+				t.Fatalf("%s 迁移版本未递增: %d", dialect, m.Version)
+			}
+			if len(m.Up) == 0 || len(m.Down) == 0 {
+				t.Fatalf("%s 迁移 %d_%s 没有语句", dialect, m.Version, m.Name)
+			}
+			vs = append(vs, strconv.Itoa(m.Version)+"_"+m.Name)
+		}
+		// 各数据库的迁移版本必须一致
+		if versions != nil && !reflect.DeepEqual(versions, vs) {
+			t.Fatalf("%s 的迁移与 %s 不一致: %v, %v", dialect, dialects[0], vs, versions)
+		}
+		versions = vs
+	}
+}
+
+func TestUpDownSQLite(t *testing.T) {
+	db, err := mysql.Open(config.DbConfig{Driver: mysql.DriverSQLite, Database: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, _ := Load(mysql.DriverSQLite)
+	count, err := Up(db)
+	if err != nil || count != len(migrations) {
+		t.Fatalf("执行迁移失败: %d, %v", count, err)
+	}
+	if count, err = Up(db); err != nil || count != 0 {
+		t.Fatalf("重复执行迁移: %d, %v", count, err)
+	}
+	status, err := GetStatus(db)
+	if err != nil || len(status) != len(migrations) || !status[len(status)-1].Applied {
+		t.Fatalf("迁移状态不正确: %+v, %v", status, err)
+	}
+	if count, err = Down(db, len(migrations)); err != nil || count != len(migrations) {
+		t.Fatalf("回滚迁移失败: %d, %v", count, err)
+	}
+	if db.Migrator().HasTable("articles") {
+		t.Fatal("回滚后表仍然存在")
+	}
+}
+
+func TestLoadMissingDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_init.up.sql": {Data: []byte("CREATE TABLE a (id int);")},
+	}
+	if _, err := load(fsys, "sql"); err == nil {
+		t.Fatal("缺少 down 文件时应当返回错误")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	content := `-- 注释
+CREATE TABLE a (
+    id int
+);
+
+DROP TABLE b;
+INSERT INTO c VALUES ('x')`
+	want := []string{"CREATE TABLE a (\n    id int\n);", "DROP TABLE b;", "INSERT INTO c VALUES ('x')"}
+	if got := splitStatements(content); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,355 @@
+-- 初始表结构,覆盖 server/model 下的所有模型
+-- 使用 IF NOT EXISTS,已经按 docs/sql 手动建表的库可以直接执行
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id`          int(11)      NOT NULL AUTO_INCREMENT,
+    `name`        varchar(50)  DEFAULT NULL,
+    `account`     varchar(100) DEFAULT NULL,
+    `password`    varchar(255) DEFAULT NULL,
+    `invite_code` varchar(20)  DEFAULT NULL COMMENT '注册时使用的邀请码',
+    `desc`        longtext,
+    `avatar`      varchar(255) DEFAULT NULL,
+    `state`       int(11)      NOT NULL DEFAULT '0',
+    `subscribe`   tinyint(1)   NOT NULL DEFAULT '0' COMMENT '1: 未订阅站内消息 2:订阅站内消息',
+    `created_at`  datetime     DEFAULT NULL,
+    `updated_at`  datetime     DEFAULT NULL,
+    `deleted_at`  datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_users_account` (`account`),
+    KEY `idx_users_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户';
+
+CREATE TABLE IF NOT EXISTS `user_tags` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `name`       varchar(50)  DEFAULT NULL,
+    `color`      varchar(50)  DEFAULT NULL,
+    `created_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户标签';
+
+CREATE TABLE IF NOT EXISTS `user_tag_relations` (
+    `id`          int(11) NOT NULL AUTO_INCREMENT,
+    `user_id`     int(11) NOT NULL,
+    `user_tag_id` int(11) NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_user_tag_relations_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `member_infos` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `name`       varchar(255) DEFAULT NULL,
+    `desc`       varchar(255) DEFAULT NULL,
+    `money`      int(11)      NOT NULL DEFAULT '0',
+    `created_at` datetime     DEFAULT NULL,
+    `updated_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员等级';
+
+CREATE TABLE IF NOT EXISTS `invite_codes` (
+    `id`               int(11)     NOT NULL AUTO_INCREMENT,
+    `member_id`        int(11)     NOT NULL,
+    `code`             varchar(20) NOT NULL,
+    `state`            tinyint(1)  NOT NULL DEFAULT '0' COMMENT '0 未使用 1 已使用',
+    `acquisition_type` int(11)     NOT NULL DEFAULT '0' COMMENT '1 购买 2 赠予',
+    `creator`          int(11)     NOT NULL DEFAULT '0',
+    `created_at`       datetime    DEFAULT NULL,
+    `updated_at`       datetime    DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_invite_codes_code` (`code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='邀请码';
+
+CREATE TABLE IF NOT EXISTS `orders` (
+    `id`               int(11)     NOT NULL AUTO_INCREMENT,
+    `invite_code`      varchar(20) DEFAULT NULL,
+    `price`            int(11)     NOT NULL DEFAULT '0',
+    `purchaser`        int(11)     NOT NULL DEFAULT '0' COMMENT '购买者',
+    `creator`          int(11)     NOT NULL DEFAULT '0' COMMENT '订单创建者',
+    `acquisition_type` int(11)     NOT NULL DEFAULT '0' COMMENT '1 赠予 2 购买',
+    `created_at`       datetime    DEFAULT NULL,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='订单';
+
+CREATE TABLE IF NOT EXISTS `types` (
+    `id`            int(11)      NOT NULL AUTO_INCREMENT,
+    `parent_id`     int(11)      DEFAULT NULL,
+    `title`         varchar(255) DEFAULT NULL,
+    `desc`          varchar(255) DEFAULT NULL,
+    `state`         tinyint(1)   DEFAULT NULL,
+    `sort`          int(11)      DEFAULT NULL,
+    `article_state` varchar(255) DEFAULT NULL COMMENT '分类下文章的状态',
+    `flag_name`     varchar(10)  NOT NULL COMMENT '唯一标识名',
+    `created_at`    datetime     DEFAULT NULL,
+    `updated_at`    datetime     DEFAULT NULL,
+    `deleted_at`    datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `types_pk_flag_name` (`flag_name`),
+    KEY `idx_types_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='分类';
+
+CREATE TABLE IF NOT EXISTS `articles` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `title`      varchar(255) DEFAULT NULL,
+    `content`    longtext,
+    `user_id`    int(11)      NOT NULL,
+    `state`      int(11)      NOT NULL DEFAULT '0',
+    `like`       int(11)      NOT NULL DEFAULT '0',
+    `type`       int(11)      DEFAULT NULL,
+    `top_number` int(11)      NOT NULL DEFAULT '0',
+    `cover`      varchar(255) DEFAULT NULL,
+    `abstract`   varchar(500) DEFAULT NULL,
+    `created_at` datetime     DEFAULT NULL,
+    `updated_at` datetime     DEFAULT NULL,
+    `deleted_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_articles_user_id` (`user_id`),
+    KEY `idx_articles_type` (`type`),
+    KEY `idx_articles_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章';
+
+CREATE TABLE IF NOT EXISTS `article_likes` (
+    `id`         int(11) NOT NULL AUTO_INCREMENT,
+    `article_id` int(11) NOT NULL,
+    `user_id`    int(11) NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_article_likes_article_id_user_id` (`article_id`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章点赞';
+
+CREATE TABLE IF NOT EXISTS `article_relations` (
+    `id`         int(11)  NOT NULL AUTO_INCREMENT,
+    `parent_id`  int(11)  NOT NULL DEFAULT '0',
+    `root_id`    int(11)  NOT NULL DEFAULT '0',
+    `article_id` int(11)  NOT NULL,
+    `created_at` datetime DEFAULT NULL,
+    `updated_at` datetime DEFAULT NULL,
+    `deleted_at` datetime DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_article_relations_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `article_tags` (
+    `id`          int(11)      NOT NULL AUTO_INCREMENT,
+    `tag_name`    varchar(20)  DEFAULT NULL,
+    `description` varchar(255) DEFAULT NULL,
+    `user_id`     int(11)      NOT NULL COMMENT '操作人',
+    `created_at`  datetime     DEFAULT NULL,
+    `updated_at`  datetime     DEFAULT NULL,
+    `deleted_at`  datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `article_tags_tag_name_uindex` (`tag_name`),
+    KEY `idx_article_tags_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章标签';
+
+CREATE TABLE IF NOT EXISTS `article_tag_relations` (
+    `article_id` int(11) NOT NULL,
+    `tag_id`     int(11) NOT NULL,
+    `user_id`    int(11) NOT NULL DEFAULT '0',
+    KEY `idx_article_tag_relations_article_id` (`article_id`),
+    KEY `idx_article_tag_relations_tag_id` (`tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章与标签关联';
+
+CREATE TABLE IF NOT EXISTS `article_tag_user_relations` (
+    `user_id` int(11) NOT NULL COMMENT '用户',
+    `tag_id`  int(11) NOT NULL COMMENT '标签',
+    UNIQUE KEY `article_tag_user_relations_user_id_tag_id_uindex` (`user_id`, `tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `comments` (
+    `id`               int(11)  NOT NULL AUTO_INCREMENT,
+    `parent_id`        int(11)  NOT NULL DEFAULT '0',
+    `root_id`          int(11)  NOT NULL DEFAULT '0',
+    `content`          longtext NOT NULL,
+    `from_user_id`     int(11)  NOT NULL,
+    `to_user_id`       int(11)  NOT NULL DEFAULT '0',
+    `business_id`      int(11)  NOT NULL,
+    `business_user_id` int(11)  NOT NULL DEFAULT '0',
+    `tenant_id`        int(11)  NOT NULL DEFAULT '0',
+    `created_at`       datetime DEFAULT NULL,
+    `updated_at`       datetime DEFAULT NULL,
+    `deleted_at`       datetime DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_comments_business_id` (`business_id`),
+    KEY `idx_comments_root_id` (`root_id`),
+    KEY `idx_comments_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='评论';
+
+CREATE TABLE IF NOT EXISTS `qa_adoptions` (
+    `id`         int(11)  NOT NULL AUTO_INCREMENT,
+    `article_id` int(11)  NOT NULL,
+    `comment_id` int(11)  NOT NULL,
+    `created_at` datetime DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_qa_adoptions_article_id` (`article_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='问答采纳';
+
+CREATE TABLE IF NOT EXISTS `drafts` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `content`    longtext,
+    `type`       int(11)      NOT NULL DEFAULT '0',
+    `label_ids`  varchar(255) DEFAULT NULL,
+    `user_id`    int(11)      NOT NULL,
+    `article_id` int(11)      NOT NULL DEFAULT '0',
+    `state`      int(11)      NOT NULL DEFAULT '0' COMMENT '1:存在临时文本 2:不存在',
+    `created_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_drafts_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='草稿';
+
+CREATE TABLE IF NOT EXISTS `files` (
+    `id`          int(11)      NOT NULL AUTO_INCREMENT,
+    `file_key`    varchar(255) NOT NULL,
+    `size`        bigint(20)   DEFAULT NULL,
+    `format`      varchar(255) DEFAULT NULL,
+    `user_id`     int(11)      DEFAULT NULL,
+    `business_id` int(11)      DEFAULT NULL,
+    `tenant_id`   int(11)      DEFAULT NULL,
+    `created_at`  datetime     DEFAULT NULL,
+    `updated_at`  datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_files_file_key` (`file_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文件';
+
+CREATE TABLE IF NOT EXISTS `courses` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `title`      varchar(255) DEFAULT NULL,
+    `desc`       longtext,
+    `technology` varchar(255) DEFAULT NULL,
+    `url`        varchar(255) DEFAULT NULL,
+    `user_id`    int(11)      NOT NULL DEFAULT '0',
+    `money`      int(11)      NOT NULL DEFAULT '0',
+    `cover`      varchar(255) DEFAULT NULL,
+    `score`      int(11)      NOT NULL DEFAULT '0',
+    `state`      int(11)      NOT NULL DEFAULT '0',
+    `created_at` datetime     DEFAULT NULL,
+    `updated_at` datetime     DEFAULT NULL,
+    `deleted_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_courses_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='课程';
+
+CREATE TABLE IF NOT EXISTS `courses_sections` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `title`      varchar(255) DEFAULT NULL,
+    `content`    longtext,
+    `user_id`    int(11)      NOT NULL DEFAULT '0',
+    `sort`       int(11)      NOT NULL DEFAULT '0',
+    `course_id`  int(11)      NOT NULL,
+    `created_at` datetime     DEFAULT NULL,
+    `deleted_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_courses_sections_course_id` (`course_id`),
+    KEY `idx_courses_sections_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='课程章节';
+
+CREATE TABLE IF NOT EXISTS `meetings` (
+    `id`                 int(11)      NOT NULL AUTO_INCREMENT,
+    `initiator_id`       int(11)      NOT NULL COMMENT '申请人',
+    `title`              varchar(255) NOT NULL,
+    `description`        longtext     NOT NULL,
+    `record`             longtext     NOT NULL,
+    `initiator_time`     datetime     NOT NULL COMMENT '申请时间',
+    `meeting_start_time` datetime     NOT NULL COMMENT '会议开始时间',
+    `meeting_end_time`   datetime     NOT NULL COMMENT '会议结束时间',
+    `signup_end_time`    datetime     NOT NULL COMMENT '报名截止时间',
+    `state`              varchar(50)  NOT NULL,
+    `state_message`      varchar(255) NOT NULL,
+    `meeting_link`       varchar(255) NOT NULL,
+    `created_at`         datetime     DEFAULT NULL,
+    `updated_at`         datetime     NOT NULL,
+    `deleted_at`         datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_meetings_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会议';
+
+CREATE TABLE IF NOT EXISTS `meeting_join_users` (
+    `id`         int(11)  NOT NULL AUTO_INCREMENT,
+    `meeting_id` int(11)  NOT NULL,
+    `user_id`    int(11)  NOT NULL,
+    `created_at` datetime DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_meeting_join_users_meeting_id` (`meeting_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会议参与人';
+
+CREATE TABLE IF NOT EXISTS `message_templates` (
+    `id`         int(11)  NOT NULL AUTO_INCREMENT,
+    `content`    longtext NOT NULL,
+    `event_id`   int(11)  DEFAULT NULL,
+    `created_at` datetime DEFAULT NULL,
+    `updated_at` datetime DEFAULT NULL,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息模板';
+
+CREATE TABLE IF NOT EXISTS `message_states` (
+    `id`         int(11)    NOT NULL AUTO_INCREMENT,
+    `content`    longtext   NOT NULL,
+    `from`       int(11)    NOT NULL,
+    `to`         int(11)    NOT NULL,
+    `state`      tinyint(1) NOT NULL DEFAULT '1' COMMENT '未读:1 已读:0',
+    `type`       tinyint(1) NOT NULL DEFAULT '1' COMMENT '通知消息:1 @:2',
+    `article_id` int(11)    NOT NULL DEFAULT '0',
+    `event_id`   int(11)    NOT NULL DEFAULT '0',
+    `created_at` datetime   DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_message_states_to` (`to`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='站内消息';
+
+CREATE TABLE IF NOT EXISTS `message_logs` (
+    `id`         int(11)  NOT NULL AUTO_INCREMENT,
+    `content`    longtext NOT NULL,
+    `from`       int(11)  NOT NULL,
+    `to`         int(11)  NOT NULL,
+    `type`       int(11)  NOT NULL,
+    `article_id` int(11)  NOT NULL DEFAULT '0',
+    `event_id`   int(11)  NOT NULL DEFAULT '0',
+    `created_at` datetime DEFAULT NULL,
+    `deleted_at` datetime DEFAULT NULL,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息日志';
+
+CREATE TABLE IF NOT EXISTS `subscriptions` (
+    `id`            int(11)     NOT NULL AUTO_INCREMENT,
+    `subscriber_id` int(11)     NOT NULL COMMENT '订阅人',
+    `send_id`       int(11)     NOT NULL DEFAULT '0' COMMENT '发送人',
+    `event_id`      int(11)     NOT NULL,
+    `business_id`   int(11)     NOT NULL,
+    `index_key`     varchar(64) DEFAULT NULL,
+    `created_at`    datetime    DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_subscriptions_index_key` (`index_key`),
+    KEY `idx_subscriptions_event_id_business_id` (`event_id`, `business_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='订阅';
+
+CREATE TABLE IF NOT EXISTS `rates` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `user_id`    int(11)      NOT NULL,
+    `content`    longtext,
+    `avatar`     varchar(255) DEFAULT NULL,
+    `created_at` datetime     DEFAULT NULL,
+    `updated_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='评价';
+
+CREATE TABLE IF NOT EXISTS `oper_logs` (
+    `id`             int(11)      NOT NULL AUTO_INCREMENT,
+    `request_method` varchar(10)  DEFAULT NULL,
+    `request_info`   varchar(500) DEFAULT NULL,
+    `request_body`   longtext,
+    `response_data`  longtext,
+    `user_id`        int(11)      DEFAULT NULL,
+    `ip`             varchar(64)  DEFAULT NULL,
+    `user_agent`     varchar(500) DEFAULT NULL,
+    `platform`       varchar(100) DEFAULT NULL,
+    `exec_at`        varchar(50)  DEFAULT NULL,
+    `created_at`     datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='操作日志';
+
+CREATE TABLE IF NOT EXISTS `login_logs` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `account`    varchar(100) DEFAULT NULL,
+    `state`      varchar(50)  DEFAULT NULL,
+    `browser`    varchar(255) DEFAULT NULL,
+    `equipment`  varchar(255) DEFAULT NULL,
+    `ip`         varchar(64)  DEFAULT NULL,
+    `created_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='登录日志';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `delay_job_histories`;
+DROP TABLE IF EXISTS `delay_jobs`;
@@ -0,0 +1,34 @@
+-- 持久化延迟队列,见 pkg/delay
+
+CREATE TABLE IF NOT EXISTS `delay_jobs` (
+    `id`           int(11)      NOT NULL AUTO_INCREMENT,
+    `job_key`      varchar(191) NOT NULL,
+    `job_type`     varchar(64)  NOT NULL,
+    `payload`      text,
+    `run_at`       datetime     NOT NULL,
+    `attempts`     int(11)      NOT NULL DEFAULT 0,
+    `max_attempts` int(11)      NOT NULL DEFAULT 0,
+    `last_error`   text,
+    `lock_token`   varchar(64)  NOT NULL DEFAULT '',
+    `locked_until` datetime     DEFAULT NULL,
+    `created_at`   datetime     DEFAULT NULL,
+    `updated_at`   datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_delay_jobs_job_key` (`job_key`),
+    KEY `idx_delay_jobs_run_at` (`run_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='延迟任务';
+
+CREATE TABLE IF NOT EXISTS `delay_job_histories` (
+    `id`          int(11)      NOT NULL AUTO_INCREMENT,
+    `job_key`     varchar(191) NOT NULL,
+    `job_type`    varchar(64)  NOT NULL,
+    `payload`     text,
+    `attempt`     int(11)      NOT NULL DEFAULT 0,
+    `state`       varchar(16)  NOT NULL,
+    `error`       text,
+    `run_at`      datetime     DEFAULT NULL,
+    `started_at`  datetime     DEFAULT NULL,
+    `finished_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_delay_job_histories_job_key` (`job_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='延迟任务执行记录';
@@ -0,0 +1,2 @@
+DROP INDEX `idx_oper_logs_request_id` ON `oper_logs`;
+ALTER TABLE `oper_logs` DROP COLUMN `request_id`;
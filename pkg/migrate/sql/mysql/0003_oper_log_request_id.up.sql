@@ -0,0 +1,4 @@
+-- 操作日志记录请求 id,用于关联同一请求的日志
+
+ALTER TABLE `oper_logs` ADD COLUMN `request_id` varchar(64) DEFAULT NULL;
+CREATE INDEX `idx_oper_logs_request_id` ON `oper_logs` (`request_id`);
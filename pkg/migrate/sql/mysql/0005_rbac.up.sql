@@ -0,0 +1,64 @@
+-- 基于角色的权限控制,替代通过会员等级名称 admin 判断管理员
+
+CREATE TABLE IF NOT EXISTS `roles` (
+    `id`          int(11)      NOT NULL AUTO_INCREMENT,
+    `name`        varchar(64)  NOT NULL,
+    `description` varchar(255) NOT NULL DEFAULT '',
+    `built_in`    tinyint(1)   NOT NULL DEFAULT 0,
+    `created_at`  datetime     DEFAULT NULL,
+    `updated_at`  datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_roles_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='角色';
+
+CREATE TABLE IF NOT EXISTS `role_permissions` (
+    `id`         int(11)     NOT NULL AUTO_INCREMENT,
+    `role_id`    int(11)     NOT NULL,
+    `permission` varchar(64) NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_role_permissions_role_permission` (`role_id`, `permission`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='角色拥有的权限';
+
+CREATE TABLE IF NOT EXISTS `user_roles` (
+    `id`         int(11)  NOT NULL AUTO_INCREMENT,
+    `user_id`    int(11)  NOT NULL,
+    `role_id`    int(11)  NOT NULL,
+    `created_at` datetime DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_user_roles_user_role` (`user_id`, `role_id`),
+    KEY `idx_user_roles_role_id` (`role_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户的角色';
+
+-- 内置角色,admin 拥有所有权限,moderator 负责内容审核,editor 负责内容运营
+
+INSERT INTO `roles` (`name`, `description`, `built_in`, `created_at`, `updated_at`) VALUES ('admin', '管理员', 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+INSERT INTO `roles` (`name`, `description`, `built_in`, `created_at`, `updated_at`) VALUES ('moderator', '版主', 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+INSERT INTO `roles` (`name`, `description`, `built_in`, `created_at`, `updated_at`) VALUES ('editor', '编辑', 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, '*' FROM `roles` WHERE `name` = 'admin';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'article.view' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'article.delete' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'article.state' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'comment.view' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'comment.delete' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'user.view' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'user.ban' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'meeting.approve' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'meeting.manage' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'log.view' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'dashboard.view' FROM `roles` WHERE `name` = 'moderator';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'article.view' FROM `roles` WHERE `name` = 'editor';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'article.state' FROM `roles` WHERE `name` = 'editor';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'type.manage' FROM `roles` WHERE `name` = 'editor';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'course.manage' FROM `roles` WHERE `name` = 'editor';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'user_tag.manage' FROM `roles` WHERE `name` = 'editor';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'message.manage' FROM `roles` WHERE `name` = 'editor';
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'dashboard.view' FROM `roles` WHERE `name` = 'editor';
+
+-- 之前通过会员等级 admin 判断管理员,迁移为 admin 角色
+INSERT INTO `user_roles` (`user_id`, `role_id`, `created_at`)
+SELECT u.`id`, r.`id`, CURRENT_TIMESTAMP FROM `users` u
+    JOIN `invite_codes` inv ON u.`invite_code` = inv.`code`
+    JOIN `member_infos` m ON m.`id` = inv.`member_id`
+    JOIN `roles` r ON r.`name` = 'admin'
+WHERE m.`name` = 'admin' AND u.`deleted_at` IS NULL;
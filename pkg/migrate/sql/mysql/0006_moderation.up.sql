@@ -0,0 +1,31 @@
+-- 内容审核: 敏感词词库和命中记录
+
+CREATE TABLE IF NOT EXISTS `sensitive_words` (
+    `id`         int(11)     NOT NULL AUTO_INCREMENT,
+    `word`       varchar(64) NOT NULL,
+    `action`     varchar(16) NOT NULL,
+    `created_at` datetime    DEFAULT NULL,
+    `updated_at` datetime    DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_sensitive_words_word` (`word`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='敏感词';
+
+CREATE TABLE IF NOT EXISTS `moderation_records` (
+    `id`          int(11)     NOT NULL AUTO_INCREMENT,
+    `scene`       varchar(32) NOT NULL,
+    `business_id` int(11)     NOT NULL DEFAULT 0,
+    `user_id`     int(11)     NOT NULL DEFAULT 0,
+    `action`      varchar(16) NOT NULL,
+    `hits`        text,
+    `content`     mediumtext,
+    `payload`     mediumtext,
+    `state`       varchar(16) NOT NULL DEFAULT '',
+    `reviewer`    int(11)     NOT NULL DEFAULT 0,
+    `reviewed_at` datetime    DEFAULT NULL,
+    `created_at`  datetime    DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_moderation_records_state` (`state`),
+    KEY `idx_moderation_records_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='内容审核命中记录';
+
+INSERT INTO `role_permissions` (`role_id`, `permission`) SELECT `id`, 'moderation.manage' FROM `roles` WHERE `name` = 'moderator';
@@ -0,0 +1,23 @@
+-- 文章历史版本,每次保存生成一个不可修改的版本
+
+CREATE TABLE IF NOT EXISTS `article_revisions` (
+    `id`         int(11)      NOT NULL AUTO_INCREMENT,
+    `article_id` int(11)      NOT NULL,
+    `version`    int(11)      NOT NULL,
+    `user_id`    int(11)      NOT NULL DEFAULT 0,
+    `title`      varchar(255) NOT NULL DEFAULT '',
+    `abstract`   text,
+    `content`    longtext,
+    `type`       int(11)      NOT NULL DEFAULT 0,
+    `state`      int(11)      NOT NULL DEFAULT 0,
+    `summary`    varchar(255) NOT NULL DEFAULT '',
+    `added`      int(11)      NOT NULL DEFAULT 0,
+    `deleted`    int(11)      NOT NULL DEFAULT 0,
+    `created_at` datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_article_revisions_version` (`article_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章历史版本';
+
+-- 已有文章的当前内容作为第一个版本,包括已删除的文章
+INSERT INTO `article_revisions` (`article_id`, `version`, `user_id`, `title`, `abstract`, `content`, `type`, `state`, `summary`, `created_at`)
+SELECT `id`, 1, `user_id`, COALESCE(`title`, ''), `abstract`, `content`, COALESCE(`type`, 0), `state`, '初始版本', `updated_at` FROM `articles`;
@@ -0,0 +1,4 @@
+DROP INDEX `idx_article_relations_article_id` ON `article_relations`;
+DROP INDEX `idx_article_relations_root_id` ON `article_relations`;
+ALTER TABLE `article_relations` DROP COLUMN `sort`;
+DROP TABLE IF EXISTS `article_series`;
@@ -0,0 +1,19 @@
+-- 文章系列,系列中的文章保存在 article_relations 中: root_id 为系列 id,sort 为顺序
+
+CREATE TABLE IF NOT EXISTS `article_series` (
+    `id`          int(11)      NOT NULL AUTO_INCREMENT,
+    `title`       varchar(100) NOT NULL,
+    `description` varchar(500) NOT NULL DEFAULT '',
+    `cover`       varchar(255) NOT NULL DEFAULT '',
+    `user_id`     int(11)      NOT NULL,
+    `created_at`  datetime     DEFAULT NULL,
+    `updated_at`  datetime     DEFAULT NULL,
+    `deleted_at`  datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_article_series_user_id` (`user_id`),
+    KEY `idx_article_series_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章系列';
+
+ALTER TABLE `article_relations` ADD COLUMN `sort` int(11) NOT NULL DEFAULT 0;
+CREATE INDEX `idx_article_relations_root_id` ON `article_relations` (`root_id`, `sort`);
+CREATE INDEX `idx_article_relations_article_id` ON `article_relations` (`article_id`);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `article_views`;
+ALTER TABLE `articles` DROP COLUMN `views`;
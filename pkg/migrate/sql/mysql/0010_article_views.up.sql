@@ -0,0 +1,13 @@
+-- 文章浏览量,article_views 按天记录,用于每日、每周热门
+
+ALTER TABLE `articles` ADD COLUMN `views` int(11) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS `article_views` (
+    `id`         int(11) NOT NULL AUTO_INCREMENT,
+    `article_id` int(11) NOT NULL,
+    `day`        int(11) NOT NULL COMMENT '日期,如 20240101',
+    `views`      int(11) NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_article_views_article_id_day` (`article_id`, `day`),
+    KEY `idx_article_views_day` (`day`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文章每日浏览量';
@@ -0,0 +1,27 @@
+-- 收藏夹和收藏,folder_id 为 0 表示默认收藏夹
+
+CREATE TABLE IF NOT EXISTS `bookmark_folders` (
+    `id`          int(11)      NOT NULL AUTO_INCREMENT,
+    `user_id`     int(11)      NOT NULL,
+    `name`        varchar(50)  NOT NULL,
+    `description` varchar(255) NOT NULL DEFAULT '',
+    `public`      tinyint(1)   NOT NULL DEFAULT 0 COMMENT '0 私密 1 公开',
+    `created_at`  datetime     DEFAULT NULL,
+    `updated_at`  datetime     DEFAULT NULL,
+    `deleted_at`  datetime     DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_bookmark_folders_user_id` (`user_id`),
+    KEY `idx_bookmark_folders_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='收藏夹';
+
+CREATE TABLE IF NOT EXISTS `bookmarks` (
+    `id`            int(11)    NOT NULL AUTO_INCREMENT,
+    `user_id`       int(11)    NOT NULL,
+    `folder_id`     int(11)    NOT NULL DEFAULT 0,
+    `business_type` tinyint(1) NOT NULL COMMENT '1 文章、问答 2 课程章节',
+    `business_id`   int(11)    NOT NULL,
+    `created_at`    datetime   DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_bookmarks_user_folder_business` (`user_id`, `folder_id`, `business_type`, `business_id`),
+    KEY `idx_bookmarks_business` (`business_type`, `business_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='收藏';
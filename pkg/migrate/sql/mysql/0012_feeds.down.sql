@@ -0,0 +1,3 @@
+DROP INDEX `users_feed_token_uindex` ON `users`;
+ALTER TABLE `users` DROP COLUMN `feed_token`;
+ALTER TABLE `types` DROP COLUMN `public`;
@@ -0,0 +1,13 @@
+-- 评论表态: 点赞和固定的表情,comments.likes 为点赞数,用于按点赞排序
+
+ALTER TABLE `comments` ADD COLUMN `likes` int(11) NOT NULL DEFAULT 0 COMMENT '点赞数';
+
+CREATE TABLE IF NOT EXISTS `comment_reactions` (
+    `id`         int(11)     NOT NULL AUTO_INCREMENT,
+    `comment_id` int(11)     NOT NULL,
+    `user_id`    int(11)     NOT NULL,
+    `reaction`   varchar(16) NOT NULL COMMENT 'like heart laugh hooray confused eyes rocket',
+    `created_at` datetime    DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_comment_reactions_comment_user_reaction` (`comment_id`, `user_id`, `reaction`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='评论表态';
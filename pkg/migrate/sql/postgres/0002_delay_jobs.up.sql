@@ -0,0 +1,32 @@
+-- 持久化延迟队列,见 pkg/delay
+
+CREATE TABLE IF NOT EXISTS "delay_jobs" (
+    "id"           serial PRIMARY KEY,
+    "job_key"      varchar(191) NOT NULL,
+    "job_type"     varchar(64)  NOT NULL,
+    "payload"      text,
+    "run_at"       timestamp     NOT NULL,
+    "attempts"     integer      NOT NULL DEFAULT 0,
+    "max_attempts" integer      NOT NULL DEFAULT 0,
+    "last_error"   text,
+    "lock_token"   varchar(64)  NOT NULL DEFAULT '',
+    "locked_until" timestamp,
+    "created_at"   timestamp,
+    "updated_at"   timestamp
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_delay_jobs_job_key" ON "delay_jobs" ("job_key");
+CREATE INDEX IF NOT EXISTS "idx_delay_jobs_run_at" ON "delay_jobs" ("run_at");
+
+CREATE TABLE IF NOT EXISTS "delay_job_histories" (
+    "id"          serial PRIMARY KEY,
+    "job_key"     varchar(191) NOT NULL,
+    "job_type"    varchar(64)  NOT NULL,
+    "payload"     text,
+    "attempt"     integer      NOT NULL DEFAULT 0,
+    "state"       varchar(16)  NOT NULL,
+    "error"       text,
+    "run_at"      timestamp,
+    "started_at"  timestamp,
+    "finished_at" timestamp
+);
+CREATE INDEX IF NOT EXISTS "idx_delay_job_histories_job_key" ON "delay_job_histories" ("job_key");
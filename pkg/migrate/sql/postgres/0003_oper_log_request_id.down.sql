@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_oper_logs_request_id";
+ALTER TABLE "oper_logs" DROP COLUMN "request_id";
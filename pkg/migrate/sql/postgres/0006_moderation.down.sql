@@ -0,0 +1,3 @@
+DELETE FROM "role_permissions" WHERE "permission" = 'moderation.manage';
+DROP TABLE IF EXISTS "moderation_records";
+DROP TABLE IF EXISTS "sensitive_words";
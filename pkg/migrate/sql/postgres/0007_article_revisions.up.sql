@@ -0,0 +1,22 @@
+-- 文章历史版本,每次保存生成一个不可修改的版本
+
+CREATE TABLE IF NOT EXISTS "article_revisions" (
+    "id"         serial PRIMARY KEY,
+    "article_id" integer      NOT NULL,
+    "version"    integer      NOT NULL,
+    "user_id"    integer      NOT NULL DEFAULT 0,
+    "title"      varchar(255) NOT NULL DEFAULT '',
+    "abstract"   text,
+    "content"    text,
+    "type"       integer      NOT NULL DEFAULT 0,
+    "state"      integer      NOT NULL DEFAULT 0,
+    "summary"    varchar(255) NOT NULL DEFAULT '',
+    "added"      integer      NOT NULL DEFAULT 0,
+    "deleted"    integer      NOT NULL DEFAULT 0,
+    "created_at" timestamp
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_article_revisions_version" ON "article_revisions" ("article_id", "version");
+
+-- 已有文章的当前内容作为第一个版本,包括已删除的文章
+INSERT INTO "article_revisions" ("article_id", "version", "user_id", "title", "abstract", "content", "type", "state", "summary", "created_at")
+SELECT "id", 1, "user_id", COALESCE("title", ''), "abstract", "content", COALESCE("type", 0), "state", '初始版本', "updated_at" FROM "articles";
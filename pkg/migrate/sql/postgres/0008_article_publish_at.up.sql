@@ -0,0 +1,3 @@
+-- 定时发布的文章的发布时间
+
+ALTER TABLE "articles" ADD COLUMN "publish_at" timestamp;
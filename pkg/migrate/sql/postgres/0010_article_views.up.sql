@@ -0,0 +1,12 @@
+-- 文章浏览量,article_views 按天记录,用于每日、每周热门
+
+ALTER TABLE "articles" ADD COLUMN "views" integer NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "article_views" (
+    "id"         serial PRIMARY KEY,
+    "article_id" integer NOT NULL,
+    "day"        integer NOT NULL,
+    "views"      integer NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_article_views_article_id_day" ON "article_views" ("article_id", "day");
+CREATE INDEX IF NOT EXISTS "idx_article_views_day" ON "article_views" ("day");
@@ -0,0 +1,25 @@
+-- 收藏夹和收藏,folder_id 为 0 表示默认收藏夹
+
+CREATE TABLE IF NOT EXISTS "bookmark_folders" (
+    "id"          serial PRIMARY KEY,
+    "user_id"     integer      NOT NULL,
+    "name"        varchar(50)  NOT NULL,
+    "description" varchar(255) NOT NULL DEFAULT '',
+    "public"      boolean      NOT NULL DEFAULT false,
+    "created_at"  timestamp,
+    "updated_at"  timestamp,
+    "deleted_at"  timestamp
+);
+CREATE INDEX IF NOT EXISTS "idx_bookmark_folders_user_id" ON "bookmark_folders" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_bookmark_folders_deleted_at" ON "bookmark_folders" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "bookmarks" (
+    "id"            serial PRIMARY KEY,
+    "user_id"       integer NOT NULL,
+    "folder_id"     integer NOT NULL DEFAULT 0,
+    "business_type" integer NOT NULL,
+    "business_id"   integer NOT NULL,
+    "created_at"    timestamp
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_bookmarks_user_folder_business" ON "bookmarks" ("user_id", "folder_id", "business_type", "business_id");
+CREATE INDEX IF NOT EXISTS "idx_bookmarks_business" ON "bookmarks" ("business_type", "business_id");
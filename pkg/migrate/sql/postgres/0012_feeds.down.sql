@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "users_feed_token_uindex";
+ALTER TABLE "users" DROP COLUMN "feed_token";
+ALTER TABLE "types" DROP COLUMN "public";
@@ -0,0 +1,12 @@
+-- 评论表态: 点赞和固定的表情,comments.likes 为点赞数,用于按点赞排序
+
+ALTER TABLE "comments" ADD COLUMN "likes" integer NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "comment_reactions" (
+    "id"         serial PRIMARY KEY,
+    "comment_id" integer     NOT NULL,
+    "user_id"    integer     NOT NULL,
+    "reaction"   varchar(16) NOT NULL,
+    "created_at" timestamp
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_comment_reactions_comment_user_reaction" ON "comment_reactions" ("comment_id", "user_id", "reaction");
@@ -0,0 +1,28 @@
+DROP TABLE IF EXISTS "login_logs";
+DROP TABLE IF EXISTS "oper_logs";
+DROP TABLE IF EXISTS "rates";
+DROP TABLE IF EXISTS "subscriptions";
+DROP TABLE IF EXISTS "message_logs";
+DROP TABLE IF EXISTS "message_states";
+DROP TABLE IF EXISTS "message_templates";
+DROP TABLE IF EXISTS "meeting_join_users";
+DROP TABLE IF EXISTS "meetings";
+DROP TABLE IF EXISTS "courses_sections";
+DROP TABLE IF EXISTS "courses";
+DROP TABLE IF EXISTS "files";
+DROP TABLE IF EXISTS "drafts";
+DROP TABLE IF EXISTS "qa_adoptions";
+DROP TABLE IF EXISTS "comments";
+DROP TABLE IF EXISTS "article_tag_user_relations";
+DROP TABLE IF EXISTS "article_tag_relations";
+DROP TABLE IF EXISTS "article_tags";
+DROP TABLE IF EXISTS "article_relations";
+DROP TABLE IF EXISTS "article_likes";
+DROP TABLE IF EXISTS "articles";
+DROP TABLE IF EXISTS "types";
+DROP TABLE IF EXISTS "orders";
+DROP TABLE IF EXISTS "invite_codes";
+DROP TABLE IF EXISTS "member_infos";
+DROP TABLE IF EXISTS "user_tag_relations";
+DROP TABLE IF EXISTS "user_tags";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,328 @@
+-- 初始表结构,覆盖 server/model 下的所有模型
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "name"        varchar(50),
+    "account"     varchar(100),
+    "password"    varchar(255),
+    "invite_code" varchar(20),
+    "desc"        text,
+    "avatar"      varchar(255),
+    "state"       integer  NOT NULL DEFAULT 0,
+    "subscribe"   integer  NOT NULL DEFAULT 0,
+    "created_at"  datetime,
+    "updated_at"  datetime,
+    "deleted_at"  datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_users_account" ON "users" ("account");
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "user_tags" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "name"       varchar(50),
+    "color"      varchar(50),
+    "created_at" datetime
+);
+
+CREATE TABLE IF NOT EXISTS "user_tag_relations" (
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "user_id"     integer NOT NULL,
+    "user_tag_id" integer NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_user_tag_relations_user_id" ON "user_tag_relations" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "member_infos" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "name"       varchar(255),
+    "desc"       varchar(255),
+    "money"      integer NOT NULL DEFAULT 0,
+    "created_at" datetime,
+    "updated_at" datetime
+);
+
+CREATE TABLE IF NOT EXISTS "invite_codes" (
+    "id"               integer PRIMARY KEY AUTOINCREMENT,
+    "member_id"        integer     NOT NULL,
+    "code"             varchar(20) NOT NULL,
+    "state"            numeric     NOT NULL DEFAULT false,
+    "acquisition_type" integer     NOT NULL DEFAULT 0,
+    "creator"          integer     NOT NULL DEFAULT 0,
+    "created_at"       datetime,
+    "updated_at"       datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_invite_codes_code" ON "invite_codes" ("code");
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id"               integer PRIMARY KEY AUTOINCREMENT,
+    "invite_code"      varchar(20),
+    "price"            integer NOT NULL DEFAULT 0,
+    "purchaser"        integer NOT NULL DEFAULT 0,
+    "creator"          integer NOT NULL DEFAULT 0,
+    "acquisition_type" integer NOT NULL DEFAULT 0,
+    "created_at"       datetime
+);
+
+CREATE TABLE IF NOT EXISTS "types" (
+    "id"            integer PRIMARY KEY AUTOINCREMENT,
+    "parent_id"     integer,
+    "title"         varchar(255),
+    "desc"          varchar(255),
+    "state"         numeric,
+    "sort"          integer,
+    "article_state" varchar(255),
+    "flag_name"     varchar(10) NOT NULL,
+    "created_at"    datetime,
+    "updated_at"    datetime,
+    "deleted_at"    datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "types_pk_flag_name" ON "types" ("flag_name");
+CREATE INDEX IF NOT EXISTS "idx_types_deleted_at" ON "types" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "articles" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "title"      varchar(255),
+    "content"    text,
+    "user_id"    integer NOT NULL,
+    "state"      integer NOT NULL DEFAULT 0,
+    "like"       integer NOT NULL DEFAULT 0,
+    "type"       integer,
+    "top_number" integer NOT NULL DEFAULT 0,
+    "cover"      varchar(255),
+    "abstract"   varchar(500),
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_articles_user_id" ON "articles" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_articles_type" ON "articles" ("type");
+CREATE INDEX IF NOT EXISTS "idx_articles_deleted_at" ON "articles" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "article_likes" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "article_id" integer NOT NULL,
+    "user_id"    integer NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_article_likes_article_id_user_id" ON "article_likes" ("article_id", "user_id");
+
+CREATE TABLE IF NOT EXISTS "article_relations" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "parent_id"  integer NOT NULL DEFAULT 0,
+    "root_id"    integer NOT NULL DEFAULT 0,
+    "article_id" integer NOT NULL,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_article_relations_deleted_at" ON "article_relations" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "article_tags" (
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "tag_name"    varchar(20),
+    "description" varchar(255),
+    "user_id"     integer NOT NULL,
+    "created_at"  datetime,
+    "updated_at"  datetime,
+    "deleted_at"  datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "article_tags_tag_name_uindex" ON "article_tags" ("tag_name");
+CREATE INDEX IF NOT EXISTS "idx_article_tags_deleted_at" ON "article_tags" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "article_tag_relations" (
+    "article_id" integer NOT NULL,
+    "tag_id"     integer NOT NULL,
+    "user_id"    integer NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS "idx_article_tag_relations_article_id" ON "article_tag_relations" ("article_id");
+CREATE INDEX IF NOT EXISTS "idx_article_tag_relations_tag_id" ON "article_tag_relations" ("tag_id");
+
+CREATE TABLE IF NOT EXISTS "article_tag_user_relations" (
+    "user_id" integer NOT NULL,
+    "tag_id"  integer NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "article_tag_user_relations_user_id_tag_id_uindex" ON "article_tag_user_relations" ("user_id", "tag_id");
+
+CREATE TABLE IF NOT EXISTS "comments" (
+    "id"               integer PRIMARY KEY AUTOINCREMENT,
+    "parent_id"        integer NOT NULL DEFAULT 0,
+    "root_id"          integer NOT NULL DEFAULT 0,
+    "content"          text    NOT NULL,
+    "from_user_id"     integer NOT NULL,
+    "to_user_id"       integer NOT NULL DEFAULT 0,
+    "business_id"      integer NOT NULL,
+    "business_user_id" integer NOT NULL DEFAULT 0,
+    "tenant_id"        integer NOT NULL DEFAULT 0,
+    "created_at"       datetime,
+    "updated_at"       datetime,
+    "deleted_at"       datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_business_id" ON "comments" ("business_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_root_id" ON "comments" ("root_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "qa_adoptions" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "article_id" integer NOT NULL,
+    "comment_id" integer NOT NULL,
+    "created_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_qa_adoptions_article_id" ON "qa_adoptions" ("article_id");
+
+CREATE TABLE IF NOT EXISTS "drafts" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "content"    text,
+    "type"       integer NOT NULL DEFAULT 0,
+    "label_ids"  varchar(255),
+    "user_id"    integer NOT NULL,
+    "article_id" integer NOT NULL DEFAULT 0,
+    "state"      integer NOT NULL DEFAULT 0,
+    "created_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_drafts_user_id" ON "drafts" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "files" (
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "file_key"    varchar(255) NOT NULL,
+    "size"        bigint,
+    "format"      varchar(255),
+    "user_id"     integer,
+    "business_id" integer,
+    "tenant_id"   integer,
+    "created_at"  datetime,
+    "updated_at"  datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_files_file_key" ON "files" ("file_key");
+
+CREATE TABLE IF NOT EXISTS "courses" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "title"      varchar(255),
+    "desc"       text,
+    "technology" varchar(255),
+    "url"        varchar(255),
+    "user_id"    integer NOT NULL DEFAULT 0,
+    "money"      integer NOT NULL DEFAULT 0,
+    "cover"      varchar(255),
+    "score"      integer NOT NULL DEFAULT 0,
+    "state"      integer NOT NULL DEFAULT 0,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_courses_deleted_at" ON "courses" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "courses_sections" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "title"      varchar(255),
+    "content"    text,
+    "user_id"    integer NOT NULL DEFAULT 0,
+    "sort"       integer NOT NULL DEFAULT 0,
+    "course_id"  integer NOT NULL,
+    "created_at" datetime,
+    "deleted_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_courses_sections_course_id" ON "courses_sections" ("course_id");
+CREATE INDEX IF NOT EXISTS "idx_courses_sections_deleted_at" ON "courses_sections" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "meetings" (
+    "id"                 integer PRIMARY KEY AUTOINCREMENT,
+    "initiator_id"       integer      NOT NULL,
+    "title"              varchar(255) NOT NULL,
+    "description"        text         NOT NULL,
+    "record"             text         NOT NULL,
+    "initiator_time"     datetime     NOT NULL,
+    "meeting_start_time" datetime     NOT NULL,
+    "meeting_end_time"   datetime     NOT NULL,
+    "signup_end_time"    datetime     NOT NULL,
+    "state"              varchar(50)  NOT NULL,
+    "state_message"      varchar(255) NOT NULL,
+    "meeting_link"       varchar(255) NOT NULL,
+    "created_at"         datetime,
+    "updated_at"         datetime     NOT NULL,
+    "deleted_at"         datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_meetings_deleted_at" ON "meetings" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "meeting_join_users" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "meeting_id" integer NOT NULL,
+    "user_id"    integer NOT NULL,
+    "created_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_meeting_join_users_meeting_id" ON "meeting_join_users" ("meeting_id");
+
+CREATE TABLE IF NOT EXISTS "message_templates" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "content"    text NOT NULL,
+    "event_id"   integer,
+    "created_at" datetime,
+    "updated_at" datetime
+);
+
+CREATE TABLE IF NOT EXISTS "message_states" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "content"    text    NOT NULL,
+    "from"       integer NOT NULL,
+    "to"         integer NOT NULL,
+    "state"      integer NOT NULL DEFAULT 1,
+    "type"       integer NOT NULL DEFAULT 1,
+    "article_id" integer NOT NULL DEFAULT 0,
+    "event_id"   integer NOT NULL DEFAULT 0,
+    "created_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_message_states_to" ON "message_states" ("to");
+
+CREATE TABLE IF NOT EXISTS "message_logs" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "content"    text    NOT NULL,
+    "from"       integer NOT NULL,
+    "to"         integer NOT NULL,
+    "type"       integer NOT NULL,
+    "article_id" integer NOT NULL DEFAULT 0,
+    "event_id"   integer NOT NULL DEFAULT 0,
+    "created_at" datetime,
+    "deleted_at" datetime
+);
+
+CREATE TABLE IF NOT EXISTS "subscriptions" (
+    "id"            integer PRIMARY KEY AUTOINCREMENT,
+    "subscriber_id" integer NOT NULL,
+    "send_id"       integer NOT NULL DEFAULT 0,
+    "event_id"      integer NOT NULL,
+    "business_id"   integer NOT NULL,
+    "index_key"     varchar(64),
+    "created_at"    datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_subscriptions_index_key" ON "subscriptions" ("index_key");
+CREATE INDEX IF NOT EXISTS "idx_subscriptions_event_id_business_id" ON "subscriptions" ("event_id", "business_id");
+
+CREATE TABLE IF NOT EXISTS "rates" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "user_id"    integer NOT NULL,
+    "content"    text,
+    "avatar"     varchar(255),
+    "created_at" datetime,
+    "updated_at" datetime
+);
+
+CREATE TABLE IF NOT EXISTS "oper_logs" (
+    "id"             integer PRIMARY KEY AUTOINCREMENT,
+    "request_method" varchar(10),
+    "request_info"   varchar(500),
+    "request_body"   text,
+    "response_data"  text,
+    "user_id"        integer,
+    "ip"             varchar(64),
+    "user_agent"     varchar(500),
+    "platform"       varchar(100),
+    "exec_at"        varchar(50),
+    "created_at"     datetime
+);
+
+CREATE TABLE IF NOT EXISTS "login_logs" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "account"    varchar(100),
+    "state"      varchar(50),
+    "browser"    varchar(255),
+    "equipment"  varchar(255),
+    "ip"         varchar(64),
+    "created_at" datetime
+);
@@ -0,0 +1,3 @@
+-- 用户的语言设置,为空时按请求头协商
+
+ALTER TABLE "users" ADD COLUMN "locale" varchar(16) NOT NULL DEFAULT '';
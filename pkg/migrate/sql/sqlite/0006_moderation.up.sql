@@ -0,0 +1,29 @@
+-- 内容审核: 敏感词词库和命中记录
+
+CREATE TABLE IF NOT EXISTS "sensitive_words" (
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "word"       varchar(64) NOT NULL,
+    "action"     varchar(16) NOT NULL,
+    "created_at" datetime,
+    "updated_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_sensitive_words_word" ON "sensitive_words" ("word");
+
+CREATE TABLE IF NOT EXISTS "moderation_records" (
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "scene"       varchar(32) NOT NULL,
+    "business_id" integer     NOT NULL DEFAULT 0,
+    "user_id"     integer     NOT NULL DEFAULT 0,
+    "action"      varchar(16) NOT NULL,
+    "hits"        text,
+    "content"     text,
+    "payload"     text,
+    "state"       varchar(16) NOT NULL DEFAULT '',
+    "reviewer"    integer     NOT NULL DEFAULT 0,
+    "reviewed_at" datetime,
+    "created_at"  datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_moderation_records_state" ON "moderation_records" ("state");
+CREATE INDEX IF NOT EXISTS "idx_moderation_records_user_id" ON "moderation_records" ("user_id");
+
+INSERT INTO "role_permissions" ("role_id", "permission") SELECT "id", 'moderation.manage' FROM "roles" WHERE "name" = 'moderator';
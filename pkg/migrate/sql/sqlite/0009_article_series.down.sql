@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_article_relations_article_id";
+DROP INDEX IF EXISTS "idx_article_relations_root_id";
+ALTER TABLE "article_relations" DROP COLUMN "sort";
+DROP TABLE IF EXISTS "article_series";
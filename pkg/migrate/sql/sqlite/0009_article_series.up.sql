@@ -0,0 +1,18 @@
+-- 文章系列,系列中的文章保存在 article_relations 中: root_id 为系列 id,sort 为顺序
+
+CREATE TABLE IF NOT EXISTS "article_series" (
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "title"       varchar(100) NOT NULL,
+    "description" varchar(500) NOT NULL DEFAULT '',
+    "cover"       varchar(255) NOT NULL DEFAULT '',
+    "user_id"     integer      NOT NULL,
+    "created_at"  datetime,
+    "updated_at"  datetime,
+    "deleted_at"  datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_article_series_user_id" ON "article_series" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_article_series_deleted_at" ON "article_series" ("deleted_at");
+
+ALTER TABLE "article_relations" ADD COLUMN "sort" integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_article_relations_root_id" ON "article_relations" ("root_id", "sort");
+CREATE INDEX IF NOT EXISTS "idx_article_relations_article_id" ON "article_relations" ("article_id");
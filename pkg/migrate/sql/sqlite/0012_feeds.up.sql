@@ -0,0 +1,5 @@
+-- 订阅源: 公开分类未登录也能订阅,会员分类需要用户的订阅 token
+
+ALTER TABLE "types" ADD COLUMN "public" integer NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN "feed_token" varchar(64) DEFAULT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "users_feed_token_uindex" ON "users" ("feed_token");
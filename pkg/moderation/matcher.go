@@ -0,0 +1,113 @@
+package moderation
+
+// Matcher 基于 Aho-Corasick 自动机的多模式匹配,一次扫描找出文本中所有敏感词
+// 匹配时忽略大小写以及空白、标点、符号,"敏 感-词" 与 "敏感词" 视为相同
+
+import "unicode"
+
+type node struct {
+	next     map[rune]int
+	fail     int
+	patterns []int // 以该节点结尾的模式,包括通过 fail 指针继承的
+}
+
+type Matcher struct {
+	nodes    []node
+	patterns []string
+	lengths  []int // 模式归一化后的长度
+}
+
+// Match 一次命中,Start、End 为原文中的 rune 下标,左闭右开
+type Match struct {
+	Pattern int
+	Start   int
+	End     int
+}
+
+// NewMatcher 构建自动机,归一化后为空的模式会被忽略
+func NewMatcher(patterns []string) *Matcher {
+	m := &Matcher{nodes: []node{{next: map[rune]int{}}}, patterns: patterns, lengths: make([]int, len(patterns))}
+	for i, p := range patterns {
+		cur := 0
+		for _, r := range p {
+			if skip(r) {
+				continue
+			}
+			m.lengths[i]++
+			r = unicode.ToLower(r)
+			nxt, ok := m.nodes[cur].next[r]
+			if !ok {
+				nxt = len(m.nodes)
+				m.nodes = append(m.nodes, node{next: map[rune]int{}})
+				m.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		if cur != 0 {
+			m.nodes[cur].patterns = append(m.nodes[cur].patterns, i)
+		}
+	}
+	// 按层构建 fail 指针
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			f := m.nodes[cur].fail
+			for f != 0 {
+				if _, ok := m.nodes[f].next[r]; ok {
+					break
+				}
+				f = m.nodes[f].fail
+			}
+			if nxt, ok := m.nodes[f].next[r]; ok && nxt != child {
+				m.nodes[child].fail = nxt
+			}
+			m.nodes[child].patterns = append(m.nodes[child].patterns, m.nodes[m.nodes[child].fail].patterns...)
+			queue = append(queue, child)
+		}
+	}
+	return m
+}
+
+// Find 返回所有命中,同一位置可能命中多个模式
+func (m *Matcher) Find(text string) []Match {
+	if m == nil || len(m.patterns) == 0 {
+		return nil
+	}
+	runes := []rune(text)
+	// 归一化后的字符在原文中的位置,用于还原命中的范围
+	pos := make([]int, 0, len(runes))
+	var matches []Match
+	cur := 0
+	for i, r := range runes {
+		if skip(r) {
+			continue
+		}
+		pos = append(pos, i)
+		r = unicode.ToLower(r)
+		for cur != 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		cur = m.nodes[cur].next[r] // 根节点没有该字符时为 0
+		for _, p := range m.nodes[cur].patterns {
+			matches = append(matches, Match{Pattern: p, Start: pos[len(pos)-m.lengths[p]], End: i + 1})
+		}
+	}
+	return matches
+}
+
+// Pattern 第 i 个模式的原文
+func (m *Matcher) Pattern(i int) string {
+	return m.patterns[i]
+}
+
+func skip(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
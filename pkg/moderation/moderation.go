@@ -0,0 +1,197 @@
+package moderation
+
+// 用户内容审核: 敏感词词库 + 链接数、重复内容的启发式规则
+// 每条规则命中后执行对应的动作,多条规则命中时取最严格的动作: reject > review > mask
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 命中后的动作
+const (
+	ActionMask   = "mask"   // 将敏感词替换为 *,内容正常保存
+	ActionReview = "review" // 内容暂不保存,管理员审核通过后再保存
+	ActionReject = "reject" // 拒绝保存
+)
+
+// 规则
+const (
+	RuleWord   = "word"
+	RuleLinks  = "links"
+	RuleRepeat = "repeat"
+)
+
+// ValidAction 是否为支持的动作,启发式规则不支持 mask,见 config 校验
+func ValidAction(action string) bool {
+	return action == ActionMask || action == ActionReview || action == ActionReject
+}
+
+func severity(action string) int {
+	switch action {
+	case ActionMask:
+		return 1
+	case ActionReview:
+		return 2
+	case ActionReject:
+		return 3
+	}
+	return 0
+}
+
+// Word 词库中的敏感词
+type Word struct {
+	Word   string
+	Action string
+}
+
+// Dictionary 构建好的词库,构建后只读,可以并发使用
+type Dictionary struct {
+	matcher *Matcher
+	actions []string
+}
+
+func NewDictionary(words []Word) *Dictionary {
+	patterns := make([]string, len(words))
+	actions := make([]string, len(words))
+	for i, w := range words {
+		patterns[i] = w.Word
+		actions[i] = w.Action
+	}
+	return &Dictionary{matcher: NewMatcher(patterns), actions: actions}
+}
+
+// Options 启发式规则,Max 为 0 时不检查
+type Options struct {
+	MaxLinks       int    // 链接数超过该值时命中
+	LinkAction     string // links 规则的动作
+	MaxRepeatChars int    // 同一个字符连续出现超过该次数时命中
+	MaxRepeatLines int    // 同一行出现超过该次数时命中,忽略代码块和过短的行
+	RepeatAction   string // repeat 规则的动作
+}
+
+// Hit 一次命中
+type Hit struct {
+	Rule   string `json:"rule"`
+	Detail string `json:"detail"` // 命中的敏感词或统计结果
+	Action string `json:"action"`
+}
+
+// Result 审核结果,Action 为空表示没有命中
+type Result struct {
+	Action string
+	Hits   []Hit
+	Text   string // 执行 mask 后的文本
+}
+
+// Merge 合并多段文本的结果,Text 不合并
+func (r *Result) Merge(o Result) {
+	r.Hits = append(r.Hits, o.Hits...)
+	if severity(o.Action) > severity(r.Action) {
+		r.Action = o.Action
+	}
+}
+
+// Check 审核一段文本
+func Check(text string, dict *Dictionary, opts Options) Result {
+	res := Result{Text: text}
+	if dict != nil {
+		matches := dict.matcher.Find(text)
+		var masks []Match
+		seen := make(map[int]bool)
+		for _, m := range matches {
+			action := dict.actions[m.Pattern]
+			if action == ActionMask {
+				masks = append(masks, m)
+			}
+			if seen[m.Pattern] {
+				continue
+			}
+			seen[m.Pattern] = true
+			res.Merge(Result{Action: action, Hits: []Hit{{Rule: RuleWord, Detail: dict.matcher.Pattern(m.Pattern), Action: action}}})
+		}
+		res.Text = mask(text, masks)
+	}
+	if opts.MaxLinks > 0 {
+		if n := len(linkPattern.FindAllStringIndex(text, -1)); n > opts.MaxLinks {
+			res.Merge(Result{Action: opts.LinkAction, Hits: []Hit{{Rule: RuleLinks, Detail: fmt.Sprintf("%d", n), Action: opts.LinkAction}}})
+		}
+	}
+	if opts.MaxRepeatChars > 0 {
+		if r, n := longestRun(text); n > opts.MaxRepeatChars {
+			res.Merge(Result{Action: opts.RepeatAction, Hits: []Hit{{Rule: RuleRepeat, Detail: fmt.Sprintf("%q x %d", r, n), Action: opts.RepeatAction}}})
+		}
+	}
+	if opts.MaxRepeatLines > 0 {
+		if line, n := mostRepeatedLine(text); n > opts.MaxRepeatLines {
+			res.Merge(Result{Action: opts.RepeatAction, Hits: []Hit{{Rule: RuleRepeat, Detail: fmt.Sprintf("%q x %d", line, n), Action: opts.RepeatAction}}})
+		}
+	}
+	return res
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s)\]>"']+|\bwww\.[^\s)\]>"']+`)
+
+func mask(text string, matches []Match) string {
+	if len(matches) == 0 {
+		return text
+	}
+	runes := []rune(text)
+	for _, m := range matches {
+		for i := m.Start; i < m.End; i++ {
+			if !skip(runes[i]) {
+				runes[i] = '*'
+			}
+		}
+	}
+	return string(runes)
+}
+
+// longestRun 连续出现次数最多的字母、数字或汉字,分隔线等符号不计算
+func longestRun(text string) (rune, int) {
+	var best, last rune
+	bestN, n := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			last, n = 0, 0
+			continue
+		}
+		if r == last {
+			n++
+		} else {
+			last, n = r, 1
+		}
+		if n > bestN {
+			best, bestN = r, n
+		}
+	}
+	return best, bestN
+}
+
+// 少于该长度的行不参与重复行统计,如代码中的 }、end
+const minRepeatLine = 5
+
+// mostRepeatedLine 出现次数最多的行,忽略 ``` 代码块
+func mostRepeatedLine(text string) (string, int) {
+	counts := make(map[string]int)
+	var best string
+	bestN := 0
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || len([]rune(line)) < minRepeatLine {
+			continue
+		}
+		counts[line]++
+		if counts[line] > bestN {
+			best, bestN = line, counts[line]
+		}
+	}
+	return best, bestN
+}
@@ -0,0 +1,56 @@
+package moderation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	m := NewMatcher([]string{"he", "she", "his", "hers", "敏感词"})
+	var got []string
+	for _, match := range m.Find("ushers 敏 感-词") {
+		got = append(got, m.Pattern(match.Pattern))
+	}
+	want := []string{"she", "he", "hers", "敏感词"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("命中: %v", got)
+	}
+	matches := m.Find("SHE")
+	if len(matches) != 2 || matches[0].Start != 0 || matches[0].End != 3 || matches[1].Start != 1 {
+		t.Fatalf("忽略大小写: %+v", matches)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dict := NewDictionary([]Word{{"傻瓜", ActionMask}, {"加微信", ActionReview}, {"赌博", ActionReject}})
+	opts := Options{MaxLinks: 2, LinkAction: ActionReview, MaxRepeatChars: 5, MaxRepeatLines: 2, RepeatAction: ActionReject}
+
+	res := Check("你是傻 瓜", dict, opts)
+	if res.Action != ActionMask || res.Text != "你是* *" {
+		t.Fatalf("mask: %+v", res)
+	}
+	if res = Check("傻瓜,加微信", dict, opts); res.Action != ActionReview || len(res.Hits) != 2 {
+		t.Fatalf("取最严格的动作: %+v", res)
+	}
+	if res = Check("赌博", dict, opts); res.Action != ActionReject {
+		t.Fatalf("reject: %+v", res)
+	}
+	if res = Check("http://a.com https://b.com www.c.com", dict, opts); res.Action != ActionReview || res.Hits[0].Rule != RuleLinks {
+		t.Fatalf("links: %+v", res)
+	}
+	if res = Check("哈哈哈哈哈哈", dict, opts); res.Action != ActionReject {
+		t.Fatalf("重复字符: %+v", res)
+	}
+	if res = Check("------------", dict, opts); res.Action != "" {
+		t.Fatalf("分隔线不算重复: %+v", res)
+	}
+	spam := strings.Repeat("快来买课程\n", 3)
+	if res = Check(spam, dict, opts); res.Action != ActionReject {
+		t.Fatalf("重复行: %+v", res)
+	}
+	code := "```\n" + strings.Repeat("return nil\n", 5) + "```"
+	if res = Check(code, dict, opts); res.Action != "" {
+		t.Fatalf("忽略代码块: %+v", res)
+	}
+}
@@ -0,0 +1,129 @@
+package mysql
+
+// 不同数据库之间有差异的 sql 片段,dao 中拼接 sql 时统一使用这里的方法
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	sqlite "github.com/glebarez/go-sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	// sqlite 没有内置 regexp 函数,`x REGEXP y` 会调用 regexp(y, x)
+	sqlite.MustRegisterDeterministicScalarFunction("regexp", 2, sqliteRegexp)
+}
+
+// Dialect 当前数据库类型: mysql / sqlite / postgres
+func Dialect() string {
+	return dialectOf(instance)
+}
+
+func dialectOf(db *gorm.DB) string {
+	return db.Dialector.Name()
+}
+
+// Quote 给关键字列名加引号,如 like、desc、to
+func Quote(name string) string {
+	var sb strings.Builder
+	instance.Dialector.QuoteTo(&sb, name)
+	return sb.String()
+}
+
+// GroupConcat 把分组内的值用 separator 拼接,没有值时返回空字符串
+func GroupConcat(expr, separator string, distinct bool) string {
+	return groupConcat(Dialect(), expr, separator, distinct)
+}
+
+func groupConcat(dialect, expr, separator string, distinct bool) string {
+	d := ""
+	if distinct {
+		d = "DISTINCT "
+	}
+	var s string
+	switch dialect {
+	case DriverPostgres:
+		s = fmt.Sprintf("STRING_AGG(%s%s, '%s')", d, expr, separator)
+	case DriverSQLite:
+		if distinct {
+			// sqlite 的 DISTINCT 聚合只支持一个参数,默认分隔符为 ,
+			s = fmt.Sprintf("GROUP_CONCAT(DISTINCT %s)", expr)
+			if separator != "," {
+				s = fmt.Sprintf("REPLACE(%s, ',', '%s')", s, separator)
+			}
+		} else {
+			s = fmt.Sprintf("GROUP_CONCAT(%s%s, '%s')", d, expr, separator)
+		}
+	default:
+		s = fmt.Sprintf("GROUP_CONCAT(%s%s SEPARATOR '%s')", d, expr, separator)
+	}
+	return "COALESCE(" + s + ", '')"
+}
+
+// Regexp 正则匹配条件,参数为正则表达式
+func Regexp(column string) string {
+	return regexpCondition(Dialect(), column)
+}
+
+func regexpCondition(dialect, column string) string {
+	if dialect == DriverPostgres {
+		return column + " ~ ?"
+	}
+	return column + " REGEXP ?"
+}
+
+// Like 不区分大小写的模糊匹配条件,与 mysql 默认排序规则的行为一致,参数使用 Contains 转义
+func Like(column string) string {
+	return likeCondition(Dialect(), column)
+}
+
+// 转义符不使用反斜杠,mysql 字符串中的反斜杠本身需要转义,各数据库写法不同
+func likeCondition(dialect, column string) string {
+	if dialect == DriverPostgres {
+		return column + " ILIKE ? ESCAPE '!'"
+	}
+	return column + " LIKE ? ESCAPE '!'"
+}
+
+var likeReplacer = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// Contains 包含 s 的模糊匹配参数,s 中的 % 和 _ 按原样匹配
+func Contains(s string) string {
+	return "%" + likeReplacer.Replace(s) + "%"
+}
+
+var regexpCache sync.Map
+
+func sqliteRegexp(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	pattern, value := toString(args[0]), toString(args[1])
+	re, ok := regexpCache.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		re, _ = regexpCache.LoadOrStore(pattern, compiled)
+	}
+	if re.(*regexp.Regexp).MatchString(value) {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func toString(v driver.Value) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	default:
+		return fmt.Sprint(s)
+	}
+}
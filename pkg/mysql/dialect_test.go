@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"testing"
+
+	"xhyovo.cn/community/pkg/config"
+)
+
+func TestGroupConcat(t *testing.T) {
+	cases := []struct {
+		dialect, separator string
+		distinct           bool
+		want               string
+	}{
+		{DriverMySQL, ", ", false, "COALESCE(GROUP_CONCAT(t.name SEPARATOR ', '), '')"},
+		{DriverMySQL, ",", true, "COALESCE(GROUP_CONCAT(DISTINCT t.name SEPARATOR ','), '')"},
+		{DriverPostgres, ", ", true, "COALESCE(STRING_AGG(DISTINCT t.name, ', '), '')"},
+		{DriverSQLite, ", ", false, "COALESCE(GROUP_CONCAT(t.name, ', '), '')"},
+		{DriverSQLite, ", ", true, "COALESCE(REPLACE(GROUP_CONCAT(DISTINCT t.name), ',', ', '), '')"},
+		{DriverSQLite, ",", true, "COALESCE(GROUP_CONCAT(DISTINCT t.name), '')"},
+	}
+	for _, c := range cases {
+		if got := groupConcat(c.dialect, "t.name", c.separator, c.distinct); got != c.want {
+			t.Errorf("%s: got %s, want %s", c.dialect, got, c.want)
+		}
+	}
+}
+
+func TestSQLiteDialect(t *testing.T) {
+	Init(config.DbConfig{Driver: DriverSQLite, Database: ":memory:"})
+	db := GetInstance()
+	if Dialect() != DriverSQLite {
+		t.Fatalf("dialect: %s", Dialect())
+	}
+	err := db.Exec("CREATE TABLE t (id integer, " + Quote("desc") + " varchar(20))").Error
+	if err == nil {
+		err = db.Exec("INSERT INTO t VALUES (1, 'Go'), (1, 'Go'), (1, 'Java'), (2, '/community/courses/section/12')").Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tags string
+	db.Raw("SELECT " + GroupConcat(Quote("desc"), ", ", true) + " FROM t WHERE id = 1").Scan(&tags)
+	if tags != "Go, Java" && tags != "Java, Go" {
+		t.Errorf("group concat: %q", tags)
+	}
+	db.Raw("SELECT " + GroupConcat(Quote("desc"), ", ", false) + " FROM t WHERE id = 3").Scan(&tags)
+	if tags != "" {
+		t.Errorf("没有值时应当返回空字符串: %q", tags)
+	}
+
+	var count int64
+	db.Table("t").Where(Regexp(Quote("desc")), `^/community/courses/section/[0-9]+$`).Count(&count)
+	if count != 1 {
+		t.Errorf("regexp count: %d", count)
+	}
+	db.Table("t").Where(Like(Quote("desc")), Contains("go")).Count(&count)
+	if count != 2 {
+		t.Errorf("like count: %d", count)
+	}
+	db.Exec("INSERT INTO t VALUES (3, '50%'), (3, '500'), (3, 'a_b!')")
+	db.Table("t").Where(Like(Quote("desc")), Contains("0%")).Count(&count)
+	if count != 1 {
+		t.Errorf("%% 应按原样匹配: %d", count)
+	}
+	db.Table("t").Where(Like(Quote("desc")), Contains("_b!")).Count(&count)
+	if count != 1 {
+		t.Errorf("_ 和 ! 应按原样匹配: %d", count)
+	}
+}
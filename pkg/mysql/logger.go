@@ -0,0 +1,94 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"xhyovo.cn/community/pkg/log"
+)
+
+// loggerName gorm 日志的 logger 名称,可以通过 log.levels.gorm 单独设置级别
+const loggerName = "gorm"
+
+// gormLogger 将 gorm 的日志输出到 zap: 出错的 sql 为 error,慢查询为 warn,其余 sql 为 debug
+// 级别统一由 log 配置控制,忽略 gorm 的 LogMode
+type gormLogger struct {
+	slow time.Duration
+}
+
+func newGormLogger(slow time.Duration) logger.Interface {
+	return gormLogger{slow: slow}
+}
+
+// sugar zap 记录的调用位置是 gorm 内部,改为记录业务代码的位置
+func (l gormLogger) sugar(ctx context.Context) *zap.SugaredLogger {
+	return log.Ctx(ctx).Named(loggerName).WithOptions(zap.WithCaller(false)).With("source", source())
+}
+
+func (l gormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.sugar(ctx).Infof(msg, args...)
+}
+
+func (l gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.sugar(ctx).Warnf(msg, args...)
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.sugar(ctx).Errorf(msg, args...)
+}
+
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	slow := l.slow > 0 && elapsed > l.slow
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	var lvl zapcore.Level
+	switch {
+	case failed:
+		lvl = zapcore.ErrorLevel
+	case slow:
+		lvl = zapcore.WarnLevel
+	default:
+		lvl = zapcore.DebugLevel
+	}
+	// 拼接 sql 有一定开销,不输出时跳过
+	if !log.Enabled(loggerName, lvl) {
+		return
+	}
+	sql, rows := fc()
+	fields := []interface{}{"elapsed", elapsed.String(), "rows", rows, "sql", sql}
+	switch {
+	case failed:
+		l.sugar(ctx).Errorw("sql 执行失败", append(fields, "err", err.Error())...)
+	case slow:
+		l.sugar(ctx).Warnw("慢查询", append(fields, "threshold", l.slow.String())...)
+	default:
+		l.sugar(ctx).Debugw("sql", fields...)
+	}
+}
+
+// source 调用 gorm 的业务代码位置,跳过 gorm 和本文件
+func source() string {
+	for i := 2; i < 20; i++ {
+		_, file, line, ok := runtime.Caller(i)
+		if !ok {
+			break
+		}
+		if strings.Contains(file, "gorm.io/") || strings.HasSuffix(file, "/pkg/mysql/logger.go") {
+			continue
+		}
+		return file + ":" + strconv.Itoa(line)
+	}
+	return ""
+}
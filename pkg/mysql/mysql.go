@@ -1,38 +1,110 @@
 package mysql
 
+// 包名沿用 mysql,实际支持 mysql / sqlite / postgres 三种数据库
+
 import (
+	"context"
 	"fmt"
+	"net"
+	"time"
+
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
-	"time"
+	"xhyovo.cn/community/pkg/config"
 	"xhyovo.cn/community/pkg/log"
 )
 
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
 var instance *gorm.DB
 
 func GetInstance() *gorm.DB {
 	return instance
 }
 
-func Init(username, password, address, database string) {
-	d := "%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=true&loc=Local&timeout=10s"
+func Init(dbConfig config.DbConfig) {
 	var err error
-	dsn := fmt.Sprintf(d, username, password, address, database)
-	instance, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+	instance, err = Open(dbConfig)
+	if err != nil {
+		log.Errorf("初始化 db 失败,err: %s", err.Error())
+		panic(err.Error())
+	}
+}
+
+// Ping 检查全局实例的连接
+func Ping(ctx context.Context) error {
+	sqlDB, err := instance.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close 关闭全局实例的连接
+func Close() error {
+	if instance == nil {
+		return nil
+	}
+	sqlDB, err := instance.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Open 根据配置打开数据库连接,不修改全局实例
+func Open(dbConfig config.DbConfig) (*gorm.DB, error) {
+	dialector, err := newDialector(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{SingularTable: true},
-		Logger:         logger.Default.LogMode(logger.Info),
+		Logger:         newGormLogger(dbConfig.SlowQuery),
 	})
-	db, err := instance.DB()
-	db.SetMaxIdleConns(10)  //空闲连接数
-	db.SetMaxOpenConns(100) //最大连接数
-	db.SetConnMaxLifetime(time.Minute)
 	if err != nil {
-		panic("连接数据库失败！")
+		return nil, err
 	}
+	if err = registerTimeout(db, dbConfig.QueryTimeout); err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Errorf("初始化 db 失败,err: %s", err.Error())
-		panic(err.Error())
+		return nil, err
+	}
+	if dbConfig.Driver == DriverSQLite {
+		// sqlite 同一时间只允许一个写连接,避免 database is locked
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxIdleConns(10)  //空闲连接数
+		sqlDB.SetMaxOpenConns(100) //最大连接数
+		sqlDB.SetConnMaxLifetime(time.Minute)
+	}
+	return db, nil
+}
+
+func newDialector(dbConfig config.DbConfig) (gorm.Dialector, error) {
+	switch dbConfig.Driver {
+	case "", DriverMySQL:
+		d := "%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=true&loc=Local&timeout=10s"
+		return mysql.Open(fmt.Sprintf(d, dbConfig.Username, dbConfig.Password, dbConfig.Address, dbConfig.Database)), nil
+	case DriverSQLite:
+		return sqlite.Open(dbConfig.Database + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), nil
+	case DriverPostgres:
+		host, port, err := net.SplitHostPort(dbConfig.Address)
+		if err != nil {
+			return nil, fmt.Errorf("db.address 格式应为 host:port,err: %w", err)
+		}
+		d := "host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=Asia/Shanghai connect_timeout=10"
+		return postgres.Open(fmt.Sprintf(d, host, port, dbConfig.Username, dbConfig.Password, dbConfig.Database, dbConfig.SSLMode)), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", dbConfig.Driver)
 	}
 }
@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	timeoutCancelKey = "community:timeout_cancel"
+	timeoutCtxKey    = "community:timeout_ctx"
+)
+
+// registerTimeout 给每条 sql 加上超时时间,ctx 已有更早的截止时间(如请求被取消)时以 ctx 为准
+// Row / Rows 的结果在回调之外读取,不能在回调结束时取消,只使用调用方的 ctx
+func registerTimeout(db *gorm.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+	before := func(db *gorm.DB) {
+		ctx, cancel := context.WithTimeout(db.Statement.Context, timeout)
+		db.InstanceSet(timeoutCtxKey, db.Statement.Context)
+		db.InstanceSet(timeoutCancelKey, cancel)
+		db.Statement.Context = ctx
+	}
+	// 同一个 *gorm.DB 可能执行多次(如先 Count 再 Find),结束后需要恢复原来的 ctx
+	after := func(db *gorm.DB) {
+		if cancel, ok := db.InstanceGet(timeoutCancelKey); ok {
+			cancel.(context.CancelFunc)()
+		}
+		if ctx, ok := db.InstanceGet(timeoutCtxKey); ok {
+			db.Statement.Context = ctx.(context.Context)
+		}
+	}
+
+	// * 表示注册在所有回调之前 / 之后,事务也在超时时间内
+	callback := db.Callback()
+	if err := callback.Query().Before("*").Register("community:timeout_before", before); err != nil {
+		return err
+	}
+	if err := callback.Query().After("*").Register("community:timeout_after", after); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("*").Register("community:timeout_before", before); err != nil {
+		return err
+	}
+	if err := callback.Raw().After("*").Register("community:timeout_after", after); err != nil {
+		return err
+	}
+	if err := callback.Create().Before("*").Register("community:timeout_before", before); err != nil {
+		return err
+	}
+	if err := callback.Create().After("*").Register("community:timeout_after", after); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("*").Register("community:timeout_before", before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("*").Register("community:timeout_after", after); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("*").Register("community:timeout_before", before); err != nil {
+		return err
+	}
+	return callback.Delete().After("*").Register("community:timeout_after", after)
+}
@@ -0,0 +1,47 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"xhyovo.cn/community/pkg/config"
+)
+
+type timeoutItems struct {
+	ID   int
+	Name string
+}
+
+func TestQueryTimeout(t *testing.T) {
+	db, err := Open(config.DbConfig{Driver: DriverSQLite, Database: ":memory:", QueryTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&timeoutItems{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Create(&timeoutItems{Name: "go"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一个 *gorm.DB 先 Count 再 Find,第一次执行后超时 ctx 已经取消,第二次不能受影响
+	var count int64
+	var items []timeoutItems
+	tx := db.WithContext(context.Background()).Model(&timeoutItems{}).Where("name = ?", "go")
+	if err = tx.Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Find(&items).Error; err != nil || count != 1 || len(items) != 1 {
+		t.Fatalf("count: %d, items: %v, err: %v", count, items, err)
+	}
+
+	// 请求取消后 sql 不再执行
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = db.WithContext(ctx).Find(&items).Error
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("请求取消后: %v", err)
+	}
+}
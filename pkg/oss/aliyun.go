@@ -0,0 +1,132 @@
+package oss
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"xhyovo.cn/community/pkg/config"
+)
+
+type policyConfig struct {
+	Expiration string     `json:"expiration"`
+	Conditions [][]string `json:"conditions"`
+}
+
+type callbackParam struct {
+	CallbackUrl      string `json:"callbackUrl"`
+	CallbackBody     string `json:"callbackBody"`
+	CallbackBodyType string `json:"callbackBodyType"`
+}
+
+type aliyunStorage struct {
+	bucket *oss.Bucket
+	conf   config.OssConfig
+}
+
+func newAliyun(conf config.OssConfig) (*aliyunStorage, error) {
+	client, err := oss.New(conf.Endpoint, conf.AccessKey, conf.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := client.Bucket(conf.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &aliyunStorage{bucket: bucket, conf: conf}, nil
+}
+
+func (a *aliyunStorage) Put(key string, reader io.Reader, size int64, contentType string) error {
+	return a.bucket.PutObject(key, reader, oss.ContentType(contentType), oss.ContentLength(size))
+}
+
+func (a *aliyunStorage) Stat(key string) (*ObjectInfo, error) {
+	exist, err := a.bucket.IsObjectExist(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, ErrObjectNotExist
+	}
+	meta, err := a.bucket.GetObjectDetailedMeta(key)
+	if err != nil {
+		return nil, err
+	}
+	size, _ := strconv.ParseInt(meta.Get("Content-Length"), 10, 64)
+	return &ObjectInfo{Key: key, Size: size, ContentType: meta.Get("Content-Type")}, nil
+}
+
+func (a *aliyunStorage) Get(key string) (io.ReadCloser, error) {
+	body, err := a.bucket.GetObject(key)
+	if e, ok := err.(oss.ServiceError); ok && e.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotExist
+	}
+	return body, err
+}
+
+func (a *aliyunStorage) SignURL(key string, expire time.Duration) (string, error) {
+	singUrl, err := a.bucket.SignURL(key, oss.HTTPGet, int64(expire.Seconds()))
+	if err != nil {
+		return "", err
+	}
+	return replaceHost(singUrl, a.conf.Cdn), nil
+}
+
+func (a *aliyunStorage) Delete(key string) error {
+	return a.bucket.DeleteObject(key)
+}
+
+func (a *aliyunStorage) PostPolicy(dir string, callbackBody string, expire time.Duration) (*PolicyToken, error) {
+	token, err := buildOssPolicy(a.conf.AccessKey, a.conf.SecretKey, dir, a.conf.Callback, callbackBody, expire)
+	if err != nil {
+		return nil, err
+	}
+	token.Driver = DriverAliyun
+	token.Host = a.conf.Endpoint
+	return token, nil
+}
+
+// 生成 oss 风格的 post policy,local 驱动复用同样的格式
+func buildOssPolicy(accessKey, secretKey, dir, callbackUrl, callbackBody string, expire time.Duration) (*PolicyToken, error) {
+	expireEnd := time.Now().Add(expire).Unix()
+
+	var cf policyConfig
+	cf.Expiration = time.Unix(expireEnd, 0).UTC().Format("2006-01-02T15:04:05Z")
+	cf.Conditions = append(cf.Conditions, []string{"starts-with", "$key", dir})
+	r, err := json.Marshal(cf)
+	if err != nil {
+		return nil, err
+	}
+	policy := base64.StdEncoding.EncodeToString(r)
+
+	var callbackStr []byte
+	callbackStr, err = json.Marshal(callbackParam{
+		CallbackUrl:      callbackUrl,
+		CallbackBody:     callbackBody,
+		CallbackBodyType: "application/json",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PolicyToken{
+		AccessKeyId: accessKey,
+		Expire:      expireEnd,
+		Signature:   signPolicy(secretKey, policy),
+		Policy:      policy,
+		Directory:   dir,
+		Callback:    base64.StdEncoding.EncodeToString(callbackStr),
+	}, nil
+}
+
+func signPolicy(secretKey, policy string) string {
+	h := hmac.New(sha1.New, []byte(secretKey))
+	io.WriteString(h, policy)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,302 @@
+package oss
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/log"
+)
+
+// 本地文件访问、上传的路由前缀
+const localRoutePrefix = "/community/file/local"
+
+// localStorage 本地磁盘存储,用于开发和 CI 环境,签名地址由 gin 路由提供访问
+type localStorage struct {
+	root   string
+	conf   config.OssConfig
+	secret []byte
+}
+
+func newLocal(conf config.OssConfig) (*localStorage, error) {
+	root := conf.LocalPath
+	if root == "" {
+		root = "./community_files"
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	secret := []byte(conf.SecretKey)
+	if len(secret) == 0 {
+		// 未配置密钥时随机生成,重启后之前签发的地址失效
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		log.Warnf("local 存储未配置 secretKey,已随机生成")
+	}
+	return &localStorage{root: root, conf: conf, secret: secret}, nil
+}
+
+func (l *localStorage) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" {
+		return "", errors.New("fileKey 不合法")
+	}
+	return filepath.Join(l.root, filepath.FromSlash(cleaned)), nil
+}
+
+func (l *localStorage) Put(key string, reader io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, reader); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (l *localStorage) Stat(key string) (*ObjectInfo, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil, ErrObjectNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := detectContentType(p)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{Key: key, Size: info.Size(), ContentType: contentType}, nil
+}
+
+func (l *localStorage) Get(key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotExist
+	}
+	return f, err
+}
+
+func (l *localStorage) SignURL(key string, expire time.Duration) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(expire).Unix(), 10)
+	u := strings.TrimRight(l.conf.Endpoint, "/") + localRoutePrefix + "/" + strings.TrimLeft(key, "/") +
+		"?expires=" + expires + "&signature=" + l.sign(key, expires)
+	return replaceHost(u, l.conf.Cdn), nil
+}
+
+func (l *localStorage) Delete(key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *localStorage) PostPolicy(dir string, callbackBody string, expire time.Duration) (*PolicyToken, error) {
+	token, err := buildOssPolicy(l.conf.AccessKey, string(l.secret), dir, l.conf.Callback, callbackBody, expire)
+	if err != nil {
+		return nil, err
+	}
+	token.Driver = DriverLocal
+	token.Host = strings.TrimRight(l.conf.Endpoint, "/") + localRoutePrefix
+	return token, nil
+}
+
+func (l *localStorage) sign(key, expires string) string {
+	h := hmac.New(sha256.New, l.secret)
+	io.WriteString(h, strings.TrimLeft(key, "/")+"\n"+expires)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func detectContentType(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, err := f.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// InitLocalRouters 注册 local 驱动的访问、上传路由,其他驱动不注册
+func InitLocalRouters(r *gin.Engine) {
+	l, ok := storage.(*localStorage)
+	if !ok {
+		return
+	}
+	group := r.Group(localRoutePrefix)
+	group.GET("/*key", l.serve)
+	group.POST("", l.upload)
+}
+
+// 访问签名地址
+func (l *localStorage) serve(ctx *gin.Context) {
+	key := strings.TrimLeft(ctx.Param("key"), "/")
+	expires := ctx.Query("expires")
+	expireAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expireAt {
+		ctx.String(http.StatusForbidden, "签名已过期")
+		return
+	}
+	if !hmac.Equal([]byte(l.sign(key, expires)), []byte(ctx.Query("signature"))) {
+		ctx.String(http.StatusForbidden, "签名不正确")
+		return
+	}
+	p, err := l.path(key)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if info, err := os.Stat(p); err != nil || info.IsDir() {
+		ctx.String(http.StatusNotFound, ErrObjectNotExist.Error())
+		return
+	}
+	if contentType, err := detectContentType(p); err == nil {
+		ctx.Header("Content-Type", contentType)
+	}
+	ctx.File(p)
+}
+
+// 模拟 oss 表单直传:校验 policy,保存文件后回调 callback
+func (l *localStorage) upload(ctx *gin.Context) {
+	policy := ctx.PostForm("policy")
+	if !hmac.Equal([]byte(signPolicy(string(l.secret), policy)), []byte(ctx.PostForm("Signature"))) {
+		ctx.String(http.StatusForbidden, "policy 签名不正确")
+		return
+	}
+	// 写入的路径以规范化后的 key 为准,目录校验也要用规范化后的 key,避免 5/../6/ 写到别人的目录
+	key, err := CleanKey(ctx.PostForm("key"))
+	if err != nil {
+		ctx.String(http.StatusForbidden, err.Error())
+		return
+	}
+	if err := checkPolicy(policy, key); err != nil {
+		ctx.String(http.StatusForbidden, err.Error())
+		return
+	}
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+	if err = l.Put(key, file, fileHeader.Size, fileHeader.Header.Get("Content-Type")); err != nil {
+		log.Ctx(ctx).Warnf("local 存储保存文件失败,fileKey: %s,err: %s", key, err.Error())
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	callback := ctx.PostForm("callback")
+	if callback == "" {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+	info, err := l.Stat(key)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	status, body, err := doCallback(callback, info)
+	if err != nil {
+		log.Ctx(ctx).Warnf("local 存储回调失败,fileKey: %s,err: %s", key, err.Error())
+		ctx.String(http.StatusBadGateway, err.Error())
+		return
+	}
+	ctx.Data(status, "application/json", body)
+}
+
+func checkPolicy(policy, key string) error {
+	raw, err := base64.StdEncoding.DecodeString(policy)
+	if err != nil {
+		return err
+	}
+	var cf policyConfig
+	if err = json.Unmarshal(raw, &cf); err != nil {
+		return err
+	}
+	expiration, err := time.Parse("2006-01-02T15:04:05Z", cf.Expiration)
+	if err != nil || time.Now().After(expiration) {
+		return errors.New("policy 已过期")
+	}
+	for _, condition := range cf.Conditions {
+		if len(condition) == 3 && condition[0] == "starts-with" && condition[1] == "$key" && !strings.HasPrefix(key, condition[2]) {
+			return errors.New("fileKey 不在允许的目录下")
+		}
+	}
+	return nil
+}
+
+// 与 oss 一致,替换 callbackBody 中的系统变量后请求 callbackUrl
+func doCallback(callback string, info *ObjectInfo) (int, []byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(callback)
+	if err != nil {
+		return 0, nil, err
+	}
+	var param callbackParam
+	if err = json.Unmarshal(raw, &param); err != nil {
+		return 0, nil, err
+	}
+	object, _ := json.Marshal(info.Key)
+	mimeType, _ := json.Marshal(info.ContentType)
+	body := strings.NewReplacer(
+		"${object}", string(object),
+		"${size}", strconv.FormatInt(info.Size, 10),
+		"${mimeType}", string(mimeType),
+	).Replace(param.CallbackBody)
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(param.CallbackUrl, param.CallbackBodyType, bytes.NewBufferString(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, err
+}
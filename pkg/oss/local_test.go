@@ -0,0 +1,71 @@
+package oss
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/config"
+)
+
+func TestLocalUploadKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	Init(config.OssConfig{Driver: DriverLocal, LocalPath: root, SecretKey: "secret"})
+	r := gin.New()
+	InitLocalRouters(r)
+	token, err := GetInstance().PostPolicy("5/", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload := func(key string) int {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		w.WriteField("key", key)
+		w.WriteField("policy", token.Policy)
+		w.WriteField("Signature", token.Signature)
+		f, _ := w.CreateFormFile("file", "avatar.png")
+		f.Write([]byte("png"))
+		w.Close()
+		req := httptest.NewRequest(http.MethodPost, localRoutePrefix, &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	// 前缀校验在规范化后的 key 上进行,不能写到其他用户的目录
+	for _, key := range []string{"5/../6/avatar.png", "/5/avatar.png", "5/..", "6/avatar.png"} {
+		if code := upload(key); code != http.StatusForbidden {
+			t.Fatalf("key %q: %d", key, code)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(root, "6", "avatar.png")); !os.IsNotExist(err) {
+		t.Fatalf("不能写入其他用户的目录: %v", err)
+	}
+	if code := upload("5/./avatar.png"); code != http.StatusNoContent {
+		t.Fatalf("上传: %d", code)
+	}
+	if _, err = os.Stat(filepath.Join(root, "5", "avatar.png")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCleanKey(t *testing.T) {
+	for key, want := range map[string]string{"5/a.png": "5/a.png", "5//b/./a.png": "5/b/a.png", "5/a..png": "5/a..png"} {
+		if got, err := CleanKey(key); err != nil || got != want {
+			t.Fatalf("%q: %q, %v", key, got, err)
+		}
+	}
+	for _, key := range []string{"", ".", "/5/a.png", "5/../6/a.png", "..", `5\..\6\a.png`} {
+		if _, err := CleanKey(key); err != ErrInvalidKey {
+			t.Fatalf("%q: %v", key, err)
+		}
+	}
+}
@@ -1,11 +1,67 @@
 package oss
 
 import (
-	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"xhyovo.cn/community/pkg/config"
 	"xhyovo.cn/community/pkg/log"
 )
 
-var bucket *oss.Bucket
+const (
+	DriverAliyun = "aliyun"
+	DriverLocal  = "local"
+	DriverS3     = "s3"
+)
+
+// 签名 url 默认有效期
+const signUrlExpire = time.Hour
+
+var ErrObjectNotExist = errors.New("对象不存在")
+
+var ErrInvalidKey = errors.New("fileKey 不合法")
+
+// Storage 对象存储,屏蔽不同厂商的差异
+type Storage interface {
+	// Put 上传对象
+	Put(key string, reader io.Reader, size int64, contentType string) error
+	// Stat 获取对象元信息,对象不存在时返回 ErrObjectNotExist
+	Stat(key string) (*ObjectInfo, error)
+	// Get 读取对象内容,对象不存在时返回 ErrObjectNotExist,读取完成后需要 Close
+	Get(key string) (io.ReadCloser, error)
+	// SignURL 生成带签名的 GET 访问地址
+	SignURL(key string, expire time.Duration) (string, error)
+	// Delete 删除对象
+	Delete(key string) error
+	// PostPolicy 生成前端直传所需的 post policy,dir 为允许上传的 key 前缀
+	PostPolicy(dir string, callbackBody string, expire time.Duration) (*PolicyToken, error)
+}
+
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+}
+
+// PolicyToken 前端直传凭证
+// aliyun / local 由存储端回调 callback,s3 不支持回调,需要前端上传成功后自行将 callback 中的 callbackBody 提交到 callbackUrl
+type PolicyToken struct {
+	Driver      string            `json:"driver"`
+	AccessKeyId string            `json:"accessid"`
+	Host        string            `json:"host"`
+	Expire      int64             `json:"expire"`
+	Signature   string            `json:"signature"`
+	Policy      string            `json:"policy"`
+	Directory   string            `json:"dir"`
+	Callback    string            `json:"callback"`
+	Fields      map[string]string `json:"fields,omitempty"` // 表单中需要额外携带的字段
+}
+
+var storage Storage
 
 var en string
 
@@ -13,32 +69,83 @@ func GetEndpoint() string {
 	return en
 }
 
-func GetInstance() *oss.Bucket {
-	return bucket
+func GetInstance() Storage {
+	return storage
 }
 
-func Init(endpoint, accessKey, accessSec, bucketN string) {
-	client, err := oss.New(endpoint, accessKey, accessSec)
+func Init(ossConfig config.OssConfig) {
+	var err error
+	switch ossConfig.Driver {
+	case "", DriverAliyun:
+		storage, err = newAliyun(ossConfig)
+	case DriverLocal:
+		storage, err = newLocal(ossConfig)
+	case DriverS3:
+		storage, err = newS3(ossConfig)
+	default:
+		err = errors.New("不支持的存储驱动: " + ossConfig.Driver)
+	}
 	if err != nil {
 		log.Errorf("初始化 oss 失败,err: %s", err.Error())
 		panic(err.Error())
-		return
 	}
-	bucket, err = client.Bucket(bucketN)
+	en = ossConfig.Endpoint
+}
 
-	if err != nil {
-		log.Errorf("获取 oss bucket 失败,err: %s", err.Error())
-		panic(err.Error())
-		return
+// 健康检查使用的 key,不存在即说明存储可以访问
+const pingKey = ".healthz"
+
+// Ping 检查存储是否可以访问
+func Ping() error {
+	if _, err := storage.Stat(pingKey); err != nil && err != ErrObjectNotExist {
+		return err
 	}
-	en = endpoint
+	return nil
 }
 
 func SingUrl(fileKey string) string {
-	singUrl, err := bucket.SignURL(fileKey, oss.HTTPGet, 3600)
+	singUrl, err := storage.SignURL(fileKey, signUrlExpire)
 	if err != nil {
-		log.Errorf("获取 oss bucket 失败,err: %s", err.Error())
+		log.Errorf("获取签名 url 失败,fileKey: %s,err: %s", fileKey, err.Error())
 		return ""
 	}
 	return singUrl
 }
+
+// 签名地址的 host 替换为 cdn
+func replaceHost(rawUrl, cdn string) string {
+	if cdn == "" {
+		return rawUrl
+	}
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	c, err := url.Parse(cdn)
+	if err != nil || c.Host == "" {
+		// cdn 只配置了域名
+		u.Host = cdn
+		return u.String()
+	}
+	u.Scheme = c.Scheme
+	u.Host = c.Host
+	return u.String()
+}
+
+// CleanKey 规范化对象 key,包含 .. 、反斜杠或以 / 开头时返回 ErrInvalidKey,
+// 前缀校验需要在规范化之后的 key 上进行
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,139 @@
+package oss
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"xhyovo.cn/community/pkg/config"
+)
+
+// 单次请求 s3 的超时时间
+const s3Timeout = 30 * time.Second
+
+// s3Storage 兼容 S3 协议的存储,本地可以使用 MinIO 代替
+type s3Storage struct {
+	client *minio.Client
+	conf   config.OssConfig
+}
+
+func newS3(conf config.OssConfig) (*s3Storage, error) {
+	client, err := minio.New(conf.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(conf.AccessKey, conf.SecretKey, ""),
+		Secure: conf.UseSSL,
+		Region: conf.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3Storage{client: client, conf: conf}, nil
+}
+
+func (s *s3Storage) Put(key string, reader io.Reader, size int64, contentType string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	_, err := s.client.PutObject(ctx, s.conf.Bucket, key, reader, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *s3Storage) Stat(key string) (*ObjectInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	info, err := s.client.StatObject(ctx, s.conf.Bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil, ErrObjectNotExist
+		}
+		return nil, err
+	}
+	return &ObjectInfo{Key: key, Size: info.Size, ContentType: info.ContentType}, nil
+}
+
+// Get 对象内容按需读取,超时时间从开始读取算起,Close 时释放
+func (s *s3Storage) Get(key string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	object, err := s.client.GetObject(ctx, s.conf.Bucket, key, minio.GetObjectOptions{})
+	if err == nil {
+		// GetObject 不会请求存储,Stat 确认对象存在
+		_, err = object.Stat()
+	}
+	if err != nil {
+		cancel()
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil, ErrObjectNotExist
+		}
+		return nil, err
+	}
+	return &cancelReadCloser{ReadCloser: object, cancel: cancel}, nil
+}
+
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+func (s *s3Storage) SignURL(key string, expire time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	u, err := s.client.PresignedGetObject(ctx, s.conf.Bucket, key, expire, nil)
+	if err != nil {
+		return "", err
+	}
+	return replaceHost(u.String(), s.conf.Cdn), nil
+}
+
+func (s *s3Storage) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	return s.client.RemoveObject(ctx, s.conf.Bucket, key, minio.RemoveObjectOptions{})
+}
+
+// PostPolicy s3 没有上传回调,callback 原样返回给前端,由前端上传成功后提交
+func (s *s3Storage) PostPolicy(dir string, callbackBody string, expire time.Duration) (*PolicyToken, error) {
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(s.conf.Bucket); err != nil {
+		return nil, err
+	}
+	if err := policy.SetKeyStartsWith(dir); err != nil {
+		return nil, err
+	}
+	expireAt := time.Now().Add(expire)
+	if err := policy.SetExpires(expireAt); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	u, formData, err := s.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	callbackStr, err := json.Marshal(callbackParam{
+		CallbackUrl:      s.conf.Callback,
+		CallbackBody:     callbackBody,
+		CallbackBodyType: "application/json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyToken{
+		Driver:      DriverS3,
+		AccessKeyId: s.conf.AccessKey,
+		Host:        u.String(),
+		Expire:      expireAt.Unix(),
+		Signature:   formData["x-amz-signature"],
+		Policy:      formData["policy"],
+		Directory:   dir,
+		Callback:    base64.StdEncoding.EncodeToString(callbackStr),
+		Fields:      formData,
+	}, nil
+}
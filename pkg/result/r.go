@@ -1,12 +1,20 @@
 package result
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
+)
 
 type R struct {
-	Code int         `json:"code"`
-	Data interface{} `json:"data"`
-	Msg  string      `json:"msg"`
-	Ok   bool        `json:"ok"`
+	Code    int               `json:"code"`
+	Data    interface{}       `json:"data"`
+	Msg     string            `json:"msg"`
+	Ok      bool              `json:"ok"`
+	Details []errs.FieldError `json:"details,omitempty"` // 参数校验失败时每个字段的错误
+	status  int               // http 状态码,默认 200
 }
 
 func OkWithMsg(data any, msg string) *R {
@@ -16,20 +24,31 @@ func OkWithMsg(data any, msg string) *R {
 func Ok(data any, msg string) *R {
 	return &R{Code: 200, Data: data, Msg: msg, Ok: true}
 }
+
+// Err 未分类的错误,为兼容旧客户端 http 状态码仍为 200,新代码优先使用 Error 返回 errs 中定义的错误
 func Err(msg string) *R {
 	return &R{Code: 500, Data: nil, Msg: msg}
 }
 
+// Error errs 中定义的错误使用对应的错误码和 http 状态码,其他错误与 Err 一致
+func Error(err error) *R {
+	e, ok := errs.From(err)
+	if !ok {
+		return Err(err.Error())
+	}
+	return &R{Code: e.Code, Msg: e.Msg, Details: e.Details, status: e.Status}
+}
+
 func Auto(data any, err error) *R {
 	if err == nil {
 		return Ok(data, "成功")
 	}
-	return Err(err.Error())
+	return Error(err)
 }
 
 func Page(data any, total int64, err error) *R {
 	if err != nil {
-		return Err(err.Error())
+		return Error(err)
 	}
 	return Ok(map[string]any{
 		"list":  data,
@@ -53,10 +72,27 @@ func (t *R) ErrMsg(msg string) *R {
 }
 
 func (r *R) Json(c *gin.Context) {
-
-	c.JSON(200, r)
+	r.translate(c)
+	c.JSON(r.Status(), r)
 }
 
 func (r *R) Xml(c *gin.Context) {
-	c.XML(r.Code, r)
+	r.translate(c)
+	c.XML(r.Status(), r)
+}
+
+// translate 按请求的语言翻译提示信息
+func (r *R) translate(c *gin.Context) {
+	r.Msg = i18n.T(c, r.Msg)
+	for i := range r.Details {
+		r.Details[i].Msg = i18n.T(c, r.Details[i].Msg)
+	}
+}
+
+// Status 响应的 http 状态码
+func (r *R) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
 }
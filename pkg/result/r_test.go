@@ -0,0 +1,68 @@
+package result
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/utils"
+)
+
+func render(r *R) (int, R) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	r.Json(c)
+	var body R
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body
+}
+
+func TestError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   int
+		msg    string
+	}{
+		{errs.NotFound.WithMsg("文章不存在"), http.StatusNotFound, 40400, "文章不存在"},
+		{fmt.Errorf("查询文章: %w", errs.Forbidden), http.StatusForbidden, 40300, "无权限"},
+		{gorm.ErrRecordNotFound, http.StatusNotFound, 40400, "资源不存在"},
+		{errors.New("未分类的错误"), http.StatusOK, 500, "未分类的错误"},
+	}
+	for _, c := range cases {
+		status, body := render(Error(c.err))
+		if status != c.status || body.Code != c.code || body.Msg != c.msg || body.Ok {
+			t.Errorf("%v: status %d, body %+v", c.err, status, body)
+		}
+	}
+	if status, body := render(Page([]int{1}, 1, nil)); status != http.StatusOK || !body.Ok {
+		t.Errorf("成功: status %d, body %+v", status, body)
+	}
+}
+
+func TestValidateErr(t *testing.T) {
+	type form struct {
+		Title string `json:"title" binding:"required" msg:"标题不能为空"`
+		Email string `json:"email" binding:"required,email"`
+	}
+	v := validator.New()
+	v.SetTagName("binding")
+	var f form
+	status, body := render(Error(utils.ValidateErr(&f, v.Struct(f))))
+	if status != http.StatusBadRequest || body.Code != 40001 || len(body.Details) != 2 {
+		t.Fatalf("status %d, body %+v", status, body)
+	}
+	if body.Details[0] != (errs.FieldError{Field: "title", Msg: "标题不能为空"}) || body.Details[1].Field != "email" {
+		t.Fatalf("details: %+v", body.Details)
+	}
+	if !errors.Is(utils.ValidateErr(f, errors.New("EOF")), errs.BadRequest) {
+		t.Fatal("非校验错误应该是 BadRequest")
+	}
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// 摘要长度,单位为字符
+	snippetLen = 120
+	// 摘要从第一个命中位置之前多少个字符开始
+	snippetLead = 20
+
+	highlightStart = "<em>"
+	highlightEnd   = "</em>"
+)
+
+// Highlight 将 text 中命中 terms 的部分用 <em> 标记,其余部分做 html 转义
+// width > 0 时截取第一个命中附近 width 个字符作为摘要,没有命中时取开头
+func Highlight(text string, terms []string, width int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		t := []rune(term)
+		if len(t) == 0 {
+			continue
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if !equalRunes(lower[i:i+len(t)], t) {
+				continue
+			}
+			for j := i; j < i+len(t); j++ {
+				marked[j] = true
+			}
+			if first == -1 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if width > 0 && len(runes) > width {
+		if first > snippetLead {
+			start = first - snippetLead
+		}
+		end = start + width
+		if end > len(runes) {
+			end = len(runes)
+			start = end - width
+		}
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	in := false
+	for i := start; i < end; i++ {
+		if marked[i] != in {
+			in = marked[i]
+			if in {
+				b.WriteString(highlightStart)
+			} else {
+				b.WriteString(highlightEnd)
+			}
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+	}
+	if in {
+		b.WriteString(highlightEnd)
+	}
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
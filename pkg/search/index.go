@@ -0,0 +1,358 @@
+package search
+
+// 内存中的倒排索引,启动时从数据库全量构建,发布、修改、删除时增量更新
+// 相关度使用 BM25,标题中的命中权重更高
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 文档类型
+const (
+	KindArticle = "article"
+	KindComment = "comment"
+	KindSection = "section"
+)
+
+const (
+	k1         = 1.2
+	b          = 0.75
+	titleBoost = 3.0
+)
+
+type Key struct {
+	Kind string
+	ID   int
+}
+
+// Doc 被索引的文档,Body 为纯文本
+type Doc struct {
+	Kind       string
+	ID         int
+	Title      string
+	Body       string
+	Types      []int // 文章的分类及其父分类
+	Tags       []int
+	AuthorId   int
+	State      int
+	CreatedAt  time.Time
+	BusinessId int // 评论所属的文章、章节等,章节所属的课程
+	TenantId   int // 评论的业务类型
+	Parent     Key // 父文档不在索引中时不返回该文档,如已删除文章下的评论
+}
+
+func (d *Doc) Key() Key {
+	return Key{Kind: d.Kind, ID: d.ID}
+}
+
+type posting struct {
+	title int32
+	body  int32
+}
+
+type entry struct {
+	doc      Doc
+	titleLen int
+	bodyLen  int
+	terms    []string
+}
+
+// Query 查询条件,切片为空、数值为 0、时间为零值时不过滤
+type Query struct {
+	Text     string
+	Kinds    []string
+	Types    []int
+	Tags     []int // 命中任意一个标签即可
+	AuthorId int
+	States   []int
+	From     time.Time
+	To       time.Time
+	SortTime bool // 按时间倒序,默认按相关度
+	Offset   int
+	Limit    int
+}
+
+// Hit 查询结果,Doc 不包含 Body
+type Hit struct {
+	Doc     Doc
+	Score   float64
+	Title   string // 高亮后的标题
+	Snippet string // 高亮后的摘要
+}
+
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[Key]*entry
+	postings map[string]map[Key]posting
+	titleSum int
+	bodySum  int
+	// 全量构建期间的增量修改,构建完成后重放,nil 表示删除
+	pending map[Key]*Doc
+	ready   bool
+}
+
+func New() *Index {
+	return &Index{docs: make(map[Key]*entry), postings: make(map[string]map[Key]posting)}
+}
+
+var index = New()
+
+func GetInstance() *Index {
+	return index
+}
+
+// Ready 是否完成过一次全量构建
+func (idx *Index) Ready() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.ready
+}
+
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Put 新增或替换文档
+func (idx *Index) Put(doc Doc) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.pending != nil {
+		d := doc
+		idx.pending[doc.Key()] = &d
+	}
+	idx.put(doc)
+}
+
+// Delete 删除文档,不存在时忽略
+func (idx *Index) Delete(key Key) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.pending != nil {
+		idx.pending[key] = nil
+	}
+	idx.remove(key)
+}
+
+// BeginRebuild 开始全量构建,之后的 Put、Delete 会在 Rebuild 时重放,避免被构建时读取的旧数据覆盖
+func (idx *Index) BeginRebuild() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.pending = make(map[Key]*Doc)
+}
+
+// Rebuild 用 docs 替换整个索引,需要先调用 BeginRebuild
+func (idx *Index) Rebuild(docs []Doc) {
+	fresh := New()
+	for _, doc := range docs {
+		fresh.put(doc)
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for key, doc := range idx.pending {
+		if doc == nil {
+			fresh.remove(key)
+		} else {
+			fresh.put(*doc)
+		}
+	}
+	idx.docs, idx.postings = fresh.docs, fresh.postings
+	idx.titleSum, idx.bodySum = fresh.titleSum, fresh.bodySum
+	idx.pending = nil
+	idx.ready = true
+}
+
+// AbortRebuild 全量构建失败时停止记录增量修改
+func (idx *Index) AbortRebuild() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.pending = nil
+}
+
+func (idx *Index) put(doc Doc) {
+	key := doc.Key()
+	idx.remove(key)
+	titleTokens := Tokenize(doc.Title)
+	bodyTokens := Tokenize(doc.Body)
+	counts := make(map[string]posting)
+	for _, t := range titleTokens {
+		p := counts[t]
+		p.title++
+		counts[t] = p
+	}
+	for _, t := range bodyTokens {
+		p := counts[t]
+		p.body++
+		counts[t] = p
+	}
+	e := &entry{doc: doc, titleLen: len(titleTokens), bodyLen: len(bodyTokens), terms: make([]string, 0, len(counts))}
+	for t, p := range counts {
+		m, ok := idx.postings[t]
+		if !ok {
+			m = make(map[Key]posting)
+			idx.postings[t] = m
+		}
+		m[key] = p
+		e.terms = append(e.terms, t)
+	}
+	idx.docs[key] = e
+	idx.titleSum += e.titleLen
+	idx.bodySum += e.bodyLen
+}
+
+func (idx *Index) remove(key Key) {
+	e, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+	for _, t := range e.terms {
+		m := idx.postings[t]
+		delete(m, key)
+		if len(m) == 0 {
+			delete(idx.postings, t)
+		}
+	}
+	idx.titleSum -= e.titleLen
+	idx.bodySum -= e.bodyLen
+	delete(idx.docs, key)
+}
+
+// Search 所有查询词都命中的文档,返回当前页和总数
+func (idx *Index) Search(q Query) ([]Hit, int) {
+	terms := QueryTerms(q.Text)
+	if len(terms) == 0 {
+		return []Hit{}, 0
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	lists := make([]map[Key]posting, 0, len(terms))
+	for _, t := range terms {
+		m, ok := idx.postings[t]
+		if !ok {
+			return []Hit{}, 0
+		}
+		lists = append(lists, m)
+	}
+	// 从最短的倒排表开始求交集
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+
+	n := float64(len(idx.docs))
+	avgTitle := math.Max(float64(idx.titleSum)/n, 1)
+	avgBody := math.Max(float64(idx.bodySum)/n, 1)
+	type scored struct {
+		e     *entry
+		score float64
+	}
+	var matched []scored
+	for key := range lists[0] {
+		e := idx.docs[key]
+		if !idx.match(e, q) {
+			continue
+		}
+		score := 0.0
+		ok := true
+		for _, m := range lists {
+			p, found := m[key]
+			if !found {
+				ok = false
+				break
+			}
+			idf := math.Log(1 + (n-float64(len(m))+0.5)/(float64(len(m))+0.5))
+			tf := titleBoost*float64(p.title)/(1-b+b*float64(e.titleLen)/avgTitle) +
+				float64(p.body)/(1-b+b*float64(e.bodyLen)/avgBody)
+			score += idf * tf * (k1 + 1) / (tf + k1)
+		}
+		if ok {
+			matched = append(matched, scored{e, score})
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		a, c := matched[i], matched[j]
+		if !q.SortTime && a.score != c.score {
+			return a.score > c.score
+		}
+		if !a.e.doc.CreatedAt.Equal(c.e.doc.CreatedAt) {
+			return a.e.doc.CreatedAt.After(c.e.doc.CreatedAt)
+		}
+		return a.e.doc.ID > c.e.doc.ID
+	})
+
+	total := len(matched)
+	start := q.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+	}
+	hits := make([]Hit, 0, end-start)
+	for _, s := range matched[start:end] {
+		doc := s.e.doc
+		h := Hit{
+			Score:   s.score,
+			Title:   Highlight(doc.Title, terms, 0),
+			Snippet: Highlight(doc.Body, terms, snippetLen),
+		}
+		doc.Body = ""
+		h.Doc = doc
+		hits = append(hits, h)
+	}
+	return hits, total
+}
+
+func (idx *Index) match(e *entry, q Query) bool {
+	d := &e.doc
+	if d.Parent.Kind != "" {
+		if _, ok := idx.docs[d.Parent]; !ok {
+			return false
+		}
+	}
+	if len(q.Kinds) > 0 && !containsString(q.Kinds, d.Kind) {
+		return false
+	}
+	if len(q.Types) > 0 && !intersects(q.Types, d.Types) {
+		return false
+	}
+	if len(q.Tags) > 0 && !intersects(q.Tags, d.Tags) {
+		return false
+	}
+	if q.AuthorId != 0 && d.AuthorId != q.AuthorId {
+		return false
+	}
+	if len(q.States) > 0 && (d.Kind != KindArticle || !intersects(q.States, []int{d.State})) {
+		return false
+	}
+	if !q.From.IsZero() && d.CreatedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !d.CreatedAt.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(a, c []int) bool {
+	for _, x := range a {
+		for _, y := range c {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Go语言 v1.18")
+	want := []string{"go", "语", "语言", "言", "v1", "18"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("分词: %v", got)
+	}
+	if got = QueryTerms("全文搜索 搜索 树"); !reflect.DeepEqual(got, []string{"全文", "文搜", "搜索", "树"}) {
+		t.Fatalf("查询分词: %v", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	now := time.Now()
+	idx := New()
+	idx.Put(Doc{Kind: KindArticle, ID: 1, Title: "Go 并发编程", Body: "介绍 goroutine 和 channel", Types: []int{2, 1}, Tags: []int{7}, AuthorId: 1, CreatedAt: now})
+	idx.Put(Doc{Kind: KindArticle, ID: 2, Title: "Java 入门", Body: "也会提到 Go 的并发编程模型", Types: []int{3, 1}, AuthorId: 2, CreatedAt: now.Add(time.Hour)})
+	idx.Put(Doc{Kind: KindComment, ID: 1, Body: "并发编程很难", Parent: Key{KindArticle, 2}, CreatedAt: now})
+
+	hits, total := idx.Search(Query{Text: "并发编程"})
+	if total != 3 || hits[0].Doc.ID != 1 || hits[0].Doc.Kind != KindArticle {
+		t.Fatalf("标题命中排在前面: %+v", hits)
+	}
+	if hits[0].Title != "Go <em>并发编程</em>" || hits[0].Doc.Body != "" {
+		t.Fatalf("高亮: %+v", hits[0])
+	}
+	if _, total = idx.Search(Query{Text: "并发 java"}); total != 1 {
+		t.Fatalf("所有词都需要命中: %d", total)
+	}
+	if hits, _ = idx.Search(Query{Text: "并发", Types: []int{1}, SortTime: true}); len(hits) != 2 || hits[0].Doc.ID != 2 {
+		t.Fatalf("分类过滤、按时间排序: %+v", hits)
+	}
+	if _, total = idx.Search(Query{Text: "并发", Tags: []int{7}, AuthorId: 1}); total != 1 {
+		t.Fatalf("标签、作者过滤: %d", total)
+	}
+	if _, total = idx.Search(Query{Text: "并发", From: now.Add(time.Minute)}); total != 1 {
+		t.Fatalf("时间过滤: %d", total)
+	}
+
+	// 父文档删除后评论不再返回
+	idx.Delete(Key{KindArticle, 2})
+	if _, total = idx.Search(Query{Text: "并发"}); total != 1 {
+		t.Fatalf("删除后: %d", total)
+	}
+
+	// 全量构建期间的修改不会被覆盖
+	idx.BeginRebuild()
+	idx.Put(Doc{Kind: KindArticle, ID: 3, Title: "并发新文章"})
+	idx.Rebuild([]Doc{{Kind: KindArticle, ID: 1, Title: "旧标题"}})
+	if _, total = idx.Search(Query{Text: "并发"}); total != 1 || idx.Len() != 2 {
+		t.Fatalf("重建: %d %d", total, idx.Len())
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	if got := Highlight("<b>Go</b> 很好用,go!", []string{"go"}, 0); got != "&lt;b&gt;<em>Go</em>&lt;/b&gt; 很好用,<em>go</em>!" {
+		t.Fatalf("高亮: %s", got)
+	}
+	long := strings.Repeat("一", 50) + "关键词" + strings.Repeat("二", 50)
+	want := "..." + strings.Repeat("一", 20) + "<em>关键</em>词" + strings.Repeat("二", 7) + "..."
+	if got := Highlight(long, []string{"关键"}, 30); got != want {
+		t.Fatalf("摘要: %s", got)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	md := "# 标题\n\n![图](a.png) 见 [文档](http://x.com) 和 **加粗**\n```go\nfmt.Println()\n```"
+	if got := PlainText(md); got != "标题 见 文档 和 加粗 fmt.Println()" {
+		t.Fatalf("纯文本: %q", got)
+	}
+}
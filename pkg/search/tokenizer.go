@@ -0,0 +1,102 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 单个英文、数字词的最大长度,超过时截断,避免 base64 等长串占用索引
+const maxWordLen = 64
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// Tokenize 索引时的分词: 中日韩文字同时输出单字和相邻两字,英文、数字按词输出并转为小写
+// 按两字切分不需要词典,"全文搜索" 可以被 "文搜"、"搜索" 命中,代价是索引较大
+func Tokenize(text string) []string {
+	return tokenize(text, false)
+}
+
+// QueryTerms 查询时的分词: 连续的中日韩文字只输出相邻两字,单个字时输出单字,结果去重
+func QueryTerms(text string) []string {
+	terms := tokenize(text, true)
+	seen := make(map[string]bool, len(terms))
+	res := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func tokenize(text string, query bool) []string {
+	var tokens []string
+	var run []rune
+	cjk := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if !cjk {
+			if len(run) > maxWordLen {
+				run = run[:maxWordLen]
+			}
+			tokens = append(tokens, string(run))
+		} else {
+			for i := range run {
+				if !query || len(run) == 1 {
+					tokens = append(tokens, string(run[i]))
+				}
+				if i+1 < len(run) {
+					tokens = append(tokens, string(run[i:i+2]))
+				}
+			}
+		}
+		run = run[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			if !cjk {
+				flush()
+				cjk = true
+			}
+			run = append(run, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if cjk {
+				flush()
+				cjk = false
+			}
+			run = append(run, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+var (
+	mdCodeFence = regexp.MustCompile("(?m)^\\s*```.*$")
+	mdImage     = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	mdLink      = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdHtml      = regexp.MustCompile(`<[^>]+>`)
+	mdPrefix    = regexp.MustCompile(`(?m)^\s*(#{1,6}|>|[-*+]|\d+\.)\s+`)
+	mdEmphasis  = regexp.MustCompile("(\\*\\*|__|~~|`)")
+	spaces      = regexp.MustCompile(`\s+`)
+)
+
+// PlainText 去掉 markdown 标记,用于建立索引和生成摘要
+func PlainText(markdown string) string {
+	s := mdCodeFence.ReplaceAllString(markdown, "")
+	s = mdImage.ReplaceAllString(s, "")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdHtml.ReplaceAllString(s, "")
+	s = mdPrefix.ReplaceAllString(s, "")
+	s = mdEmphasis.ReplaceAllString(s, "")
+	return strings.TrimSpace(spaces.ReplaceAllString(s, " "))
+}
@@ -1,10 +1,33 @@
 package utils
 
 import (
+	"bytes"
+	"net/url"
 	"regexp"
 	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
 )
 
+// markdown 链接、图片和 html 标签中带 fileKey 参数的地址,如 ![](/community/file/singUrl?fileKey=1/xxx)
+var fileKeyLinkRe = regexp.MustCompile(`(\]\(|src=["']|href=["'])([^)"'\s]*[?&]fileKey=([^)&"'\s#]+)[^)"'\s]*)`)
+
+// markdown 图片和 html img 标签的地址,如 ![](images/a.png "title")、<img src="a.png">
+var imageLinkRe = regexp.MustCompile(`(!\[[^\]]*\]\(\s*<?|<img\s[^>]*?src=["'])([^)>"'\s]+)`)
+
+// 默认不输出原始 html,并过滤 javascript: 等危险链接,用户内容可以直接渲染
+var markdownRenderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// MarkdownToHtml 把 markdown 渲染为 html,支持表格、删除线、任务列表等 GFM 语法
+func MarkdownToHtml(markdown string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(markdown), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 func GetFirstImage(markdown string) string {
 	// 使用正则表达式匹配 Markdown 中的图片链接
 	re := regexp.MustCompile(`\[.*?]\((.*?)\)`)
@@ -23,3 +46,29 @@ func GetFirstImage(markdown string) string {
 	// 否则返回 null
 	return ""
 }
+
+// ReplaceFileKeys 把带 fileKey 参数的地址替换为 replace 的返回值,返回空字符串时保留原地址
+func ReplaceFileKeys(markdown string, replace func(fileKey string) string) string {
+	return fileKeyLinkRe.ReplaceAllStringFunc(markdown, func(m string) string {
+		sub := fileKeyLinkRe.FindStringSubmatch(m)
+		key, err := url.QueryUnescape(sub[3])
+		if err != nil {
+			return m
+		}
+		if target := replace(key); target != "" {
+			return sub[1] + target
+		}
+		return m
+	})
+}
+
+// ReplaceImageLinks 把图片地址替换为 replace 的返回值,返回空字符串时保留原地址
+func ReplaceImageLinks(markdown string, replace func(link string) string) string {
+	return imageLinkRe.ReplaceAllStringFunc(markdown, func(m string) string {
+		sub := imageLinkRe.FindStringSubmatch(m)
+		if target := replace(sub[2]); target != "" {
+			return sub[1] + target
+		}
+		return m
+	})
+}
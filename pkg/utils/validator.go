@@ -5,23 +5,43 @@ import (
 	"github.com/go-playground/validator/v10"
 	"reflect"
 	"strings"
+	"xhyovo.cn/community/pkg/errs"
 )
 
 func GetValidateErr(obj any, rawErr error) string {
-	validationErrs, ok := rawErr.(validator.ValidationErrors)
-	if !ok {
-		return rawErr.Error()
+	return ValidateErr(obj, rawErr).Error()
+}
+
+// ValidateErr 将参数绑定的错误转换为 errs.Validation,每个字段的错误信息优先使用 msg 标签
+// 不是校验错误时(如 json 格式错误)返回 errs.BadRequest
+func ValidateErr(obj any, rawErr error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(rawErr, &validationErrs) {
+		if _, ok := errs.From(rawErr); ok {
+			return rawErr
+		}
+		return errs.BadRequest.WithMsg(rawErr.Error()).Wrap(rawErr)
+	}
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
 	var errString []string
+	var details []errs.FieldError
 	for _, validationErr := range validationErrs {
-		field, ok := reflect.TypeOf(obj).FieldByName(validationErr.Field())
-		if ok {
-			if e := field.Tag.Get("msg"); e != "" {
-				errString = append(errString, e)
-				continue
+		detail := errs.FieldError{Field: validationErr.Field(), Msg: validationErr.Error()}
+		if t != nil && t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(validationErr.StructField()); ok {
+				if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+					detail.Field = name
+				}
+				if e := field.Tag.Get("msg"); e != "" {
+					detail.Msg = e
+				}
 			}
 		}
-		errString = append(errString, validationErr.Error())
+		errString = append(errString, detail.Msg)
+		details = append(details, detail)
 	}
-	return errors.New(strings.Join(errString, "\n")).Error()
+	return errs.Validation.WithMsg(strings.Join(errString, "\n")).WithDetails(details).Wrap(rawErr)
 }
@@ -1,6 +1,8 @@
 package dao
 
 import (
+	"context"
+	"fmt"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 
@@ -12,118 +14,128 @@ import (
 type Article struct {
 }
 
-func (a *Article) QuerySingle(article model.Articles) (*model.Articles, error) {
+func (a *Article) QuerySingle(ctx context.Context, article model.Articles) (*model.Articles, error) {
 	result := &model.Articles{}
-	err := model.Article().Model(article).Where(article).First(result).Error
+	err := model.Article(ctx).Model(article).Where(article).First(result).Error
 	return result, err
 }
 
-func (a *Article) QueryList(article *model.Articles, page, limit int) ([]*model.Articles, error) {
+func (a *Article) QueryList(ctx context.Context, article *model.Articles, page, limit int) ([]*model.Articles, error) {
 	if limit < 1 {
 		limit = 10
 	}
 	if page < 1 {
 		page = 1
 	}
-	userDb := model.Article().Model(article).Where(article)
+	userDb := model.Article(ctx).Model(article).Where(article)
 
 	articleList := []*model.Articles{}
 	userDb.Offset((page - 1) * limit).Limit(limit).Find(&articleList)
 	return articleList, userDb.Error
 }
 
-func (a *Article) Count() int64 {
+func (a *Article) Count(ctx context.Context) int64 {
 	var count int64
-	model.Type().Count(&count)
+	model.Type(ctx).Count(&count)
 	return count
 }
 
-func (a *Article) Delete(articleId, userId int) error {
-	return model.Article().Model(&model.Articles{}).Delete(&model.Articles{
+func (a *Article) Delete(ctx context.Context, articleId, userId int) error {
+	return model.Article(ctx).Model(&model.Articles{}).Delete(&model.Articles{
 		ID:     articleId,
 		UserId: userId,
 	}).Error
 }
 
-func (a *Article) Create(article *model.Articles) error {
+func (a *Article) Create(ctx context.Context, article *model.Articles) error {
 	article.CreatedAt = time.Now()
 	article.UpdatedAt = time.Now()
-	return model.Article().Model(article).Create(article).Error
+	return model.Article(ctx).Model(article).Create(article).Error
 }
 
-func (a *Article) Update(article *model.Articles) error {
+func (a *Article) Update(ctx context.Context, article *model.Articles) error {
 	article.UpdatedAt = time.Now()
-	return model.Article().Model(article).Where(&model.Articles{
+	return model.Article(ctx).Model(article).Where(&model.Articles{
 		ID:     article.ID,
 		UserId: article.UserId,
 	}).Save(article).Error
 }
 
-func (a *Article) CountByTypeId(id int) int64 {
+func (a *Article) CountByTypeId(ctx context.Context, id int) int64 {
 	var count int64
-	model.Article().Where("type = ?", id).Count(&count)
+	model.Article(ctx).Where("type = ?", id).Count(&count)
 	return count
 }
 
-func (a *Article) ExistById(id int) bool {
+func (a *Article) ExistById(ctx context.Context, id int) bool {
 	var count int64
-	model.Article().Where("id = ?", id).Count(&count)
+	model.Article(ctx).Where("id = ?", id).Count(&count)
 	return count == 1
 }
 
-func (a *Article) ListByIdsSelectIdTitle(ids []int) []model.Articles {
+func (a *Article) ListByIdsSelectIdTitle(ctx context.Context, ids []int) []model.Articles {
 	var articles []model.Articles
-	model.Article().Where("id in ?", ids).Select("id,title").Find(&articles)
+	model.Article(ctx).Where("id in ?", ids).Select("id,title").Find(&articles)
 
 	return articles
 }
 
-func (a *Article) GetById(id int) model.Articles {
+func (a *Article) GetById(ctx context.Context, id int) model.Articles {
 	var article model.Articles
-	model.Article().Where("id = ?", id).First(&article)
+	model.Article(ctx).Where("id = ?", id).First(&article)
 	return article
 }
 
-func (a *Article) CreateLike(articleId, userId int) bool {
+func (a *Article) CreateLike(ctx context.Context, articleId, userId int) bool {
 
-	tx := model.ArticleLike().Create(&model.Article_Likes{ArticleId: articleId, UserId: userId})
+	tx := model.ArticleLike(ctx).Create(&model.Article_Likes{ArticleId: articleId, UserId: userId})
 	affected := tx.RowsAffected
 	return affected == 1
 }
 
-func (a *Article) DeleteLike(articleId, userId int) {
-	model.ArticleLike().Delete("article_id = ? and user_id = ?", articleId, userId)
+func (a *Article) DeleteLike(ctx context.Context, articleId, userId int) {
+	model.ArticleLike(ctx).Delete("article_id = ? and user_id = ?", articleId, userId)
 }
 
-func (a *Article) UpdateCount(articleId, number int) {
-	model.Article().Where("id = ?", articleId).Update("like", gorm.Expr("'like' + ?", number))
+func (a *Article) UpdateCount(ctx context.Context, articleId, number int) {
+	model.Article(ctx).Where("id = ?", articleId).Update("like", gorm.Expr(mysql.Quote("like")+" + ?", number))
 }
 
-func (a *Article) GetArticleSql() *gorm.DB {
-	query := mysql.GetInstance().Table("articles").
-		Select("articles.id, articles.title, LEFT(articles.content, 100) as `desc`, articles.cover,articles.state, articles.`like`, articles.created_at,articles.updated_at," +
+// 收藏文章的用户数
+var bookmarkCountSql = fmt.Sprintf("( SELECT COUNT(DISTINCT user_id) FROM bookmarks WHERE bookmarks.business_type = %d and bookmarks.business_id = articles.id) AS bookmarks, ", model.BookmarkArticle)
+
+// 列的顺序与 services.buildResultArticles 的 Scan 保持一致,列表的摘要为正文的前 100 个字
+// group by 需要带上 join 表的列,postgres 不允许查询未分组的列
+func (a *Article) GetArticleSql(ctx context.Context) *gorm.DB {
+	query := mysql.GetInstance().WithContext(ctx).Table("articles").
+		Select("articles.id, articles.title, SUBSTR(articles.content, 1, 100) as " + mysql.Quote("desc") + ", articles.cover,articles.state, articles." + mysql.Quote("like") + ", articles.views, articles.created_at,articles.updated_at," +
 			"tp.id as type_id, tp.title as type_title, tp.flag_name as type_flag, " +
 			"u.name as u_name, u.id as u_id, u.avatar as u_avatar, " +
-			"  GROUP_CONCAT(DISTINCT atg.tag_name) as tags").
+			"( SELECT COUNT(*) FROM comments WHERE comments.business_id = articles.id and tenant_id = 0) AS comments, " +
+			bookmarkCountSql +
+			mysql.GroupConcat("atg.tag_name", ",", true) + " as tags").
 		Joins("LEFT JOIN article_tag_relations as atr on atr.article_id = articles.id").
 		Joins("LEFT JOIN article_tags as atg on atg.id = atr.tag_id").
 		Joins("LEFT JOIN types as tp on tp.id = articles.type").
 		Joins("LEFT JOIN users as u on u.id = articles.user_id").
+		Group("articles.id, tp.id, tp.title, tp.flag_name, u.name, u.id, u.avatar").
 		Where("articles.deleted_at is null")
 	return query
 }
 
 // 用这个
-func (a *Article) GetQueryArticleSql() *gorm.DB {
-	query := mysql.GetInstance().Table("articles").
+func (a *Article) GetQueryArticleSql(ctx context.Context) *gorm.DB {
+	query := mysql.GetInstance().WithContext(ctx).Table("articles").
 		Select("articles.id, articles.title, articles.abstract,articles.cover," +
-			"articles.state,articles.`like`,articles.created_at,articles.updated_at,types.id as type_id,types.title as type_title," +
-			"types.flag_name as type_flag,users.`name` as u_name,users.id as u_id,users.avatar as u_avatar, ( SELECT COUNT(*) FROM comments WHERE comments.business_id = articles.id and tenant_id = 0) AS comments, GROUP_CONCAT(at.tag_name SEPARATOR ', ') AS tags").
+			"articles.state,articles." + mysql.Quote("like") + ",articles.views,articles.created_at,articles.updated_at,types.id as type_id,types.title as type_title," +
+			"types.flag_name as type_flag,users.name as u_name,users.id as u_id,users.avatar as u_avatar, ( SELECT COUNT(*) FROM comments WHERE comments.business_id = articles.id and tenant_id = 0) AS comments, " +
+			bookmarkCountSql +
+			mysql.GroupConcat("at.tag_name", ", ", false) + " AS tags").
 		Joins("LEFT JOIN article_tag_relations atr ON articles.id = atr.article_id").
 		Joins("LEFT JOIN article_tags at ON atr.tag_id = at.id").
 		Joins("join users on users.id = articles.user_id").
 		Joins("JOIN types on types.id = articles.type").
-		Group("articles.id, articles.title").
+		Group("articles.id, articles.title, types.id, types.title, types.flag_name, users.name, users.id, users.avatar").
 		Where("articles.deleted_at is null")
 	return query
 }
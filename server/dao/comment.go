@@ -1,6 +1,9 @@
 package dao
 
 import (
+	"context"
+	"sort"
+
 	"xhyovo.cn/community/server/model"
 )
 
@@ -8,8 +11,8 @@ type CommentDao struct {
 }
 
 // 发布评论
-func (a *CommentDao) AddComment(comment *model.Comments) {
-	db := model.Comment()
+func (a *CommentDao) AddComment(ctx context.Context, comment *model.Comments) {
+	db := model.Comment(ctx)
 	db.Create(&comment)
 	// 如果为根评论,则需要获取id设置rootId
 	if comment.ParentId == 0 {
@@ -19,32 +22,32 @@ func (a *CommentDao) AddComment(comment *model.Comments) {
 }
 
 // 删除评论
-func (a *CommentDao) Delete(id, userId int) int {
+func (a *CommentDao) Delete(ctx context.Context, id, userId int) int {
 
-	tx := model.Comment().Delete(&model.Comments{ID: id, FromUserId: userId})
+	tx := model.Comment(ctx).Delete(&model.Comments{ID: id, FromUserId: userId})
 	affected := tx.RowsAffected
 	return int(affected)
 }
 
-func (a *CommentDao) Create(comment *model.Comments) error {
-	return model.Comment().Model(&model.Comments{}).Create(comment).Error
+func (a *CommentDao) Create(ctx context.Context, comment *model.Comments) error {
+	return model.Comment(ctx).Model(&model.Comments{}).Create(comment).Error
 }
 
 // 查询用户所管理的评论
-func (a *CommentDao) GetAllCommentsByArticleID(page, limit, fromUserId, businessId, tenantId int) ([]*model.Comments, int64) {
+func (a *CommentDao) GetAllCommentsByArticleID(ctx context.Context, page, limit, fromUserId, businessId, tenantId int) ([]*model.Comments, int64) {
 	var comments []*model.Comments
 	var count int64
-	db := model.Comment().Where("from_user_id = ? or to_user_id = ? or business_user_id = ? and tenant_id = ?", fromUserId, fromUserId, fromUserId, tenantId)
+	db := model.Comment(ctx).Where("from_user_id = ? or to_user_id = ? or business_user_id = ? and tenant_id = ?", fromUserId, fromUserId, fromUserId, tenantId)
 	db.Count(&count)
 	db.Order("created_at desc").Limit(limit).Offset((page - 1) * limit).Find(&comments)
 	return comments, count
 }
 
 // 查询根评论下的子评论总数
-func (a *CommentDao) GetCommentsCountByRootId(rootIds []int) map[int]int {
+func (a *CommentDao) GetCommentsCountByRootId(ctx context.Context, rootIds []int) map[int]int {
 	sql := "SELECT root_id, COUNT(*) AS number FROM comments WHERE root_id IN (?)GROUP BY root_id;"
 	var ChildCommentNumber []*model.ChildCommentNumber
-	model.Comment().Raw(sql, rootIds).Scan(&ChildCommentNumber)
+	model.Comment(ctx).Raw(sql, rootIds).Scan(&ChildCommentNumber)
 	m := make(map[int]int)
 	for i := range ChildCommentNumber {
 		commentNumber := ChildCommentNumber[i]
@@ -56,11 +59,15 @@ func (a *CommentDao) GetCommentsCountByRootId(rootIds []int) map[int]int {
 }
 
 // 查询文章下的评论带分页并且只显示跟评论的前n条
-func (a *CommentDao) GetCommentsByArticleID(page, limit, businessId int) ([]*model.Comments, int64) {
+func (a *CommentDao) GetCommentsByArticleID(ctx context.Context, page, limit, businessId int, order string) ([]*model.Comments, int64) {
 	// 查询所有根评论,只想要根评论
 	var parentIds []int
 	var comments []*model.Comments
-	model.Comment().Where("business_id", businessId).Order("created_at desc").Select("id").Group("root_id").Limit(limit).Offset((page - 1) * limit).Find(&parentIds)
+	if order == model.CommentSortLikes {
+		model.Comment(ctx).Where("business_id = ? and parent_id = 0", businessId).Order("likes desc, id desc").Limit(limit).Offset((page-1)*limit).Pluck("id", &parentIds)
+	} else {
+		model.Comment(ctx).Where("business_id", businessId).Order("MAX(created_at) desc").Select("root_id").Group("root_id").Limit(limit).Offset((page - 1) * limit).Find(&parentIds)
+	}
 
 	if len(parentIds) == 0 {
 		return comments, 0
@@ -68,50 +75,63 @@ func (a *CommentDao) GetCommentsByArticleID(page, limit, businessId int) ([]*mod
 
 	// 根据根评论查
 	sql := "select c.* from comments c where (select count(id) from comments where root_Id = c.root_id and id<=c.id ) <= 5 and  c.root_id in  ? order by root_id desc"
-	model.Comment().Raw(sql, parentIds).Scan(&comments)
+	model.Comment(ctx).Raw(sql, parentIds).Scan(&comments)
+	if order == model.CommentSortLikes {
+		// 按根评论的点赞数重新排序
+		index := make(map[int]int, len(parentIds))
+		for i, id := range parentIds {
+			index[id] = i
+		}
+		sort.SliceStable(comments, func(i, j int) bool {
+			return index[comments[i].RootId] < index[comments[j].RootId]
+		})
+	}
 
-	count := a.GetCommentsCountByArticleID(businessId)
+	count := a.GetCommentsCountByArticleID(ctx, businessId)
 	return comments, count
 }
 
 // 根据根评论查询下的子评论
-func (a *CommentDao) GetCommentsByCommentID(page, limit, rootId int) []*model.Comments {
+func (a *CommentDao) GetCommentsByCommentID(ctx context.Context, page, limit, rootId int, order string) []*model.Comments {
 	var comments []*model.Comments
 
-	db := model.Comment()
+	db := model.Comment(ctx)
+	if order == model.CommentSortLikes {
+		db = db.Order("likes desc")
+	}
 	db.Limit(limit).Offset((page-1)*limit).Where("root_id = ? and id <> root_id", rootId).Order("created_at desc").Find(&comments)
 	return comments
 }
 
 // 查询跟评论下的评论总数
-func (a *CommentDao) GetRootCommentsCountByArticleID(rootId int) int64 {
+func (a *CommentDao) GetRootCommentsCountByArticleID(ctx context.Context, rootId int) int64 {
 	sql := "select count(id) from comments where root_id =?"
 	var count int64
-	db := model.Comment()
+	db := model.Comment(ctx)
 	db.Raw(sql, rootId).Scan(&count)
 	return count
 }
 
 // 获取文章评论总数
-func (a *CommentDao) GetCommentsCountByArticleID(businessId int) int64 {
+func (a *CommentDao) GetCommentsCountByArticleID(ctx context.Context, businessId int) int64 {
 	var count int64
-	model.Comment().Where("parent_id = 0 and business_id = ?", businessId).Count(&count)
+	model.Comment(ctx).Where("parent_id = 0 and business_id = ?", businessId).Count(&count)
 	return count
 }
 
-func (a *CommentDao) ExistById(id int, userId int, businessId int, rootId int) bool {
+func (a *CommentDao) ExistById(ctx context.Context, id int, userId int, businessId int, rootId int) bool {
 	var count int64
-	model.Comment().Where("id = ? and from_user_id = ? and business_id = ? and root_id = ?", id, userId, businessId, rootId).Count(&count)
+	model.Comment(ctx).Where("id = ? and from_user_id = ? and business_id = ? and root_id = ?", id, userId, businessId, rootId).Count(&count)
 	return count == 1
 }
 
-func (a *CommentDao) GetByParentId(parentId int) (comment model.Comments) {
-	model.Comment().Where("id = ?", parentId).First(&comment)
+func (a *CommentDao) GetByParentId(ctx context.Context, parentId int) (comment model.Comments) {
+	model.Comment(ctx).Where("id = ?", parentId).First(&comment)
 	return
 }
 
-func (a *CommentDao) GetByRootId(rootId int) (comment model.Comments) {
-	model.Comment().Where("root_id = ?", rootId).First(&comment)
+func (a *CommentDao) GetByRootId(ctx context.Context, rootId int) (comment model.Comments) {
+	model.Comment(ctx).Where("root_id = ?", rootId).First(&comment)
 	return
 
 }
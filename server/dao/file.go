@@ -1,35 +1,36 @@
 package dao
 
 import (
+	"context"
 	"xhyovo.cn/community/server/model"
 )
 
 type File struct {
 }
 
-func (*File) Save(file *model.Files) {
-	model.File().Create(&file)
+func (*File) Save(ctx context.Context, file *model.Files) {
+	model.File(ctx).Create(&file)
 }
 
-func (*File) GetFileInfo(fileId, tenantId int) *model.Files {
+func (*File) GetFileInfo(ctx context.Context, fileId, tenantId int) *model.Files {
 	fileInfo := &model.Files{}
-	model.File().Where(&model.Files{ID: fileId, TenantId: tenantId}).Find(fileInfo)
+	model.File(ctx).Where(&model.Files{ID: fileId, TenantId: tenantId}).Find(fileInfo)
 
 	return fileInfo
 }
 
-func (*File) Delete(userId, fileId, tenantId int) {
-	model.File().Where("id = ? and user_id = ? and tenant_id = ?", fileId, userId, tenantId).Delete(&model.Files{})
+func (*File) Delete(ctx context.Context, userId, fileId, tenantId int) {
+	model.File(ctx).Where("id = ? and user_id = ? and tenant_id = ?", fileId, userId, tenantId).Delete(&model.Files{})
 }
 
-func (*File) Deletes(userId, businessId, tenantId int) {
-	model.File().Where("business_id = ? and user_id = ? and tenant_id = ?", businessId, userId, tenantId).Delete(&model.Files{})
+func (*File) Deletes(ctx context.Context, userId, businessId, tenantId int) {
+	model.File(ctx).Where("business_id = ? and user_id = ? and tenant_id = ?", businessId, userId, tenantId).Delete(&model.Files{})
 
 }
 
-func (*File) GetFileKeys(businessId int) []string {
+func (*File) GetFileKeys(ctx context.Context, businessId int) []string {
 	var files []model.Files
-	model.File().Where("business_id = ?", businessId).Find(files)
+	model.File(ctx).Where("business_id = ?", businessId).Find(files)
 	var fileKeys []string
 	for _, file := range files {
 		fileKeys = append(fileKeys, file.FileKey)
@@ -37,10 +38,10 @@ func (*File) GetFileKeys(businessId int) []string {
 	return fileKeys
 }
 
-func (f *File) PageFiles(p, limit, userId int) []model.Files {
+func (f *File) PageFiles(ctx context.Context, p, limit, userId int) []model.Files {
 
 	var files []model.Files
-	tx := model.File().Offset((p - 1) * limit).Limit(limit).Order("created_at desc")
+	tx := model.File(ctx).Offset((p - 1) * limit).Limit(limit).Order("created_at desc")
 	if userId != 0 {
 		tx.Where("user_id = ?", userId)
 	}
@@ -49,10 +50,10 @@ func (f *File) PageFiles(p, limit, userId int) []model.Files {
 	return files
 }
 
-func (f *File) Count() int64 {
+func (f *File) Count(ctx context.Context) int64 {
 
 	var count int64
-	model.File().Count(&count)
+	model.File(ctx).Count(&count)
 
 	return count
 }
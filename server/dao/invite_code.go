@@ -1,40 +1,43 @@
 package dao
 
-import "xhyovo.cn/community/server/model"
+import (
+	"context"
+	"xhyovo.cn/community/server/model"
+)
 
 type InviteCode struct {
 }
 
 // 是否存在code
-func (*InviteCode) Exist(code string) bool {
+func (*InviteCode) Exist(ctx context.Context, code string) bool {
 
 	var count int64
 	object := &model.InviteCodes{}
-	model.InviteCode().Where("code = ?", code).Find(object).Count(&count)
+	model.InviteCode(ctx).Where("code = ?", code).Find(object).Count(&count)
 
 	return count == 1
 }
 
-func (*InviteCode) Del(code int) int64 {
+func (*InviteCode) Del(ctx context.Context, code int) int64 {
 
-	tx := model.InviteCode().Where("code = ? and state = 0", code).Delete(&model.InviteCodes{})
+	tx := model.InviteCode(ctx).Where("code = ? and state = ?", code, false).Delete(&model.InviteCodes{})
 	return tx.RowsAffected
 }
 
-func (*InviteCode) SetState(code string) {
+func (*InviteCode) SetState(ctx context.Context, code string) {
 
-	model.InviteCode().Where("code = ?", code).Update("state", 1)
+	model.InviteCode(ctx).Where("code = ?", code).Update("state", true)
 }
 
-func (c *InviteCode) GetCount() int64 {
+func (c *InviteCode) GetCount(ctx context.Context) int64 {
 	var count int64
-	model.InviteCode().Count(&count)
+	model.InviteCode(ctx).Count(&count)
 	return count
 }
 
-func (c *InviteCode) PageCodes(page int, limit int, code string) []*model.InviteCodes {
+func (c *InviteCode) PageCodes(ctx context.Context, page int, limit int, code string) []*model.InviteCodes {
 	var codes []*model.InviteCodes
-	tx := model.InviteCode()
+	tx := model.InviteCode(ctx)
 	if code != "" {
 		tx.Where("code like ?", "%"+code+"%")
 	}
@@ -42,6 +45,6 @@ func (c *InviteCode) PageCodes(page int, limit int, code string) []*model.Invite
 	return codes
 }
 
-func (c *InviteCode) SaveCodes(codeList []*model.InviteCodes) {
-	model.InviteCode().Create(&codeList)
+func (c *InviteCode) SaveCodes(ctx context.Context, codeList []*model.InviteCodes) {
+	model.InviteCode(ctx).Create(&codeList)
 }
@@ -1,39 +1,42 @@
 package dao
 
-import "xhyovo.cn/community/server/model"
+import (
+	"context"
+	"xhyovo.cn/community/server/model"
+)
 
 type MemberDao struct {
 }
 
-func (*MemberDao) ListMemberInfo() []*model.MemberInfos {
+func (*MemberDao) ListMemberInfo(ctx context.Context) []*model.MemberInfos {
 	var members []*model.MemberInfos
-	model.MemberInfo().Find(&members)
+	model.MemberInfo(ctx).Find(&members)
 	return members
 }
 
 // save or updated
-func (*MemberDao) SaveMemberInfo(memberInfo *model.MemberInfos) {
+func (*MemberDao) SaveMemberInfo(ctx context.Context, memberInfo *model.MemberInfos) {
 	if memberInfo.ID == 0 {
-		model.MemberInfo().Save(&memberInfo)
+		model.MemberInfo(ctx).Save(&memberInfo)
 	} else {
-		model.MemberInfo().Where("id = ?", memberInfo.ID).Updates(&memberInfo)
+		model.MemberInfo(ctx).Where("id = ?", memberInfo.ID).Updates(&memberInfo)
 	}
 
 }
 
-func (*MemberDao) DeleteMemberInfo(id int) {
-	model.MemberInfo().Where("id = ?", id).Delete(&model.MemberInfos{})
+func (*MemberDao) DeleteMemberInfo(ctx context.Context, id int) {
+	model.MemberInfo(ctx).Where("id = ?", id).Delete(&model.MemberInfos{})
 }
 
-func (*MemberDao) Count(id int) int64 {
+func (*MemberDao) Count(ctx context.Context, id int) int64 {
 	var count int64
-	model.MemberInfo().Where("id = ?", id).Count(&count)
+	model.MemberInfo(ctx).Where("id = ?", id).Count(&count)
 	return count
 }
 
-func (d *MemberDao) ListByIdsSelectIdAndName(ids []int) []*model.MemberInfos {
+func (d *MemberDao) ListByIdsSelectIdAndName(ctx context.Context, ids []int) []*model.MemberInfos {
 
 	var m []*model.MemberInfos
-	model.MemberInfo().Where("id in ?", ids).Find(&m)
+	model.MemberInfo(ctx).Where("id in ?", ids).Find(&m)
 	return m
 }
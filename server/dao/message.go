@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/server/model"
 )
@@ -9,49 +10,49 @@ type MessageDao struct {
 }
 
 // 消息模板crud
-func (*MessageDao) ListMessageTemplate(page, limit int) []*model.MessageTemplates {
+func (*MessageDao) ListMessageTemplate(ctx context.Context, page, limit int) []*model.MessageTemplates {
 	var templates []*model.MessageTemplates
-	model.MessageTemplate().Limit(limit).Offset((page - 1) * limit).Order("created_at desc").Find(&templates)
+	model.MessageTemplate(ctx).Limit(limit).Offset((page - 1) * limit).Order("created_at desc").Find(&templates)
 	return templates
 }
 
-func (*MessageDao) GetMessageTemplate(id int) string {
+func (*MessageDao) GetMessageTemplate(ctx context.Context, id int) string {
 	var messageTemplate string
-	model.MessageTemplate().Where("event_id = ?", id).Select("content").Find(&messageTemplate)
+	model.MessageTemplate(ctx).Where("event_id = ?", id).Select("content").Find(&messageTemplate)
 	return messageTemplate
 }
 
-func (*MessageDao) SaveMessageTemplate(template model.MessageTemplates) error {
+func (*MessageDao) SaveMessageTemplate(ctx context.Context, template model.MessageTemplates) error {
 
 	if template.ID == 0 {
-		return mysql.GetInstance().Save(&template).Error
+		return mysql.GetInstance().WithContext(ctx).Save(&template).Error
 	}
 
-	return model.MessageTemplate().Where("id = ?", template.ID).Updates(&model.MessageTemplates{Content: template.Content, EventId: template.EventId}).Error
+	return model.MessageTemplate(ctx).Where("id = ?", template.ID).Updates(&model.MessageTemplates{Content: template.Content, EventId: template.EventId}).Error
 }
 
-func (*MessageDao) DeleteMessageTemplate(id int) {
-	model.MessageTemplate().Where("id = ?", id).Delete(model.MessageTemplates{})
+func (*MessageDao) DeleteMessageTemplate(ctx context.Context, id int) {
+	model.MessageTemplate(ctx).Where("id = ?", id).Delete(model.MessageTemplates{})
 }
 
 // 消息日志crud
-func (*MessageDao) ListMessageLogs(page, limit int) []*model.MessageLogs {
+func (*MessageDao) ListMessageLogs(ctx context.Context, page, limit int) []*model.MessageLogs {
 	var messageLogs []*model.MessageLogs
-	model.MessageLog().Limit(limit).Offset((page - 1) * limit).Order("created_at desc").Find(&messageLogs)
+	model.MessageLog(ctx).Limit(limit).Offset((page - 1) * limit).Order("created_at desc").Find(&messageLogs)
 	return messageLogs
 }
 
 // 添加记录
-func (*MessageDao) SaveMessageLogs(messageLog []*model.MessageLogs) {
-	model.MessageLog().Create(&messageLog)
+func (*MessageDao) SaveMessageLogs(ctx context.Context, messageLog []*model.MessageLogs) {
+	model.MessageLog(ctx).Create(&messageLog)
 }
 
-func (*MessageDao) DeleteMessageLogs(id []int) {
-	model.MessageLog().Delete(&id)
+func (*MessageDao) DeleteMessageLogs(ctx context.Context, id []int) {
+	model.MessageLog(ctx).Delete(&id)
 }
 
 // 保存消息
-func (*MessageDao) SaveMessage(from, types, eventId, businessId int, to []int, content string) {
+func (*MessageDao) SaveMessage(ctx context.Context, from, types, eventId, businessId int, to []int, content string) {
 	var msgs []*model.MessageStates
 	for i := range to {
 		state := &model.MessageStates{
@@ -66,38 +67,38 @@ func (*MessageDao) SaveMessage(from, types, eventId, businessId int, to []int, c
 		msgs = append(msgs, state)
 	}
 
-	model.MessageState().Create(&msgs)
+	model.MessageState(ctx).Create(&msgs)
 }
 
-func (*MessageDao) ReadMessage2(typee, eventId, businessId, userId int) int64 {
-	tx := model.MessageState().Where("type = ? and event_id = ? and article_id = ? and `to` = ?", typee, eventId, businessId, userId).Updates(map[string]interface{}{
+func (*MessageDao) ReadMessage2(ctx context.Context, typee, eventId, businessId, userId int) int64 {
+	tx := model.MessageState(ctx).Where("type = ? and event_id = ? and article_id = ? and "+mysql.Quote("to")+" = ?", typee, eventId, businessId, userId).Updates(map[string]interface{}{
 		"state": 0,
 	})
 	return tx.RowsAffected
 }
 
 // 删除用户收到的消息(确认消息),
-func (*MessageDao) ReadMessage(id []int, userId int) int64 {
-	tx := model.MessageState().Where("id in ? and `to` = ?", id, userId).Updates(map[string]interface{}{
+func (*MessageDao) ReadMessage(ctx context.Context, id []int, userId int) int64 {
+	tx := model.MessageState(ctx).Where("id in ? and "+mysql.Quote("to")+" = ?", id, userId).Updates(map[string]interface{}{
 		"state": 0,
 	})
 	return tx.RowsAffected
 }
 
-func (d *MessageDao) ListMessage(page, limit, userId, types, state int) []*model.MessageStates {
+func (d *MessageDao) ListMessage(ctx context.Context, page, limit, userId, types, state int) []*model.MessageStates {
 	m := model.MessageStates{
 		To:    userId,
 		Type:  types,
 		State: state,
 	}
 	var message []*model.MessageStates
-	model.MessageState().Where(&m).Limit(limit).Offset((page - 1) * limit).Order("created_at desc").Find(&message)
+	model.MessageState(ctx).Where(&m).Limit(limit).Offset((page - 1) * limit).Order("created_at desc").Find(&message)
 	return message
 }
 
-func (d *MessageDao) CountMessage(userId, types, state int) int64 {
+func (d *MessageDao) CountMessage(ctx context.Context, userId, types, state int) int64 {
 	var count int64
 
-	model.MessageState().Where(model.MessageStates{To: userId, Type: types, State: state}).Count(&count)
+	model.MessageState(ctx).Where(model.MessageStates{To: userId, Type: types, State: state}).Count(&count)
 	return count
 }
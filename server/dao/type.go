@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/server/model"
 )
@@ -8,34 +9,34 @@ import (
 type Type struct {
 }
 
-func (t *Type) Save(types *model.Types) (int, error) {
-	d := mysql.GetInstance().Save(&types)
+func (t *Type) Save(ctx context.Context, types *model.Types) (int, error) {
+	d := mysql.GetInstance().WithContext(ctx).Save(&types)
 	return types.ID, d.Error
 }
 
-func (t *Type) Update(types *model.Types) error {
-	return model.Type().Model(types).Save(types).Error
+func (t *Type) Update(ctx context.Context, types *model.Types) error {
+	return model.Type(ctx).Model(types).Save(types).Error
 }
 
-func (t *Type) Delete(id int) error {
-	d := model.Type().Where("id = ?", id).Delete(&model.Types{})
+func (t *Type) Delete(ctx context.Context, id int) error {
+	d := model.Type(ctx).Where("id = ?", id).Delete(&model.Types{})
 	if d.Error != nil {
 		return d.Error
 	}
-	d = model.Type().Where("parent_id = ?", id).Delete(&model.Types{})
+	d = model.Type(ctx).Where("parent_id = ?", id).Delete(&model.Types{})
 	return d.Error
 }
 
-func (t *Type) List(parentId int) []model.Types {
+func (t *Type) List(ctx context.Context, parentId int) []model.Types {
 	var types []model.Types
-	model.Type().Where("parent_id = ?", parentId).Find(&types)
+	model.Type(ctx).Where("parent_id = ?", parentId).Find(&types)
 
 	return types
 }
 
-func (t *Type) GetById(typeId int) model.Types {
+func (t *Type) GetById(ctx context.Context, typeId int) model.Types {
 	var typeObject model.Types
-	model.Type().Where("id = ?", typeId).Find(&typeObject)
+	model.Type(ctx).Where("id = ?", typeId).Find(&typeObject)
 	return typeObject
 
 }
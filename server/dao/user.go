@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"xhyovo.cn/community/server/model"
 )
 
@@ -8,14 +9,14 @@ type UserDao struct {
 }
 
 // set user info by article user id
-func (*UserDao) SetUserInfo(articles []*model.Articles) {
+func (*UserDao) SetUserInfo(ctx context.Context, articles []*model.Articles) {
 	var userIds []int
 
 	for _, article := range articles {
 		userIds = append(userIds, article.UserId)
 	}
 	users := []model.Users{}
-	model.User().Find(&users, userIds)
+	model.User(ctx).Find(&users, userIds)
 	var userMap = make(map[int]model.Users)
 	for _, user := range users {
 		userMap[user.ID] = user
@@ -27,59 +28,59 @@ func (*UserDao) SetUserInfo(articles []*model.Articles) {
 
 }
 
-func (*UserDao) QueryUsersByUserIds(ids []int) []model.Users {
+func (*UserDao) QueryUsersByUserIds(ctx context.Context, ids []int) []model.Users {
 	users := []model.Users{}
-	model.User().Find(&users, ids)
+	model.User(ctx).Find(&users, ids)
 	return users
 }
 
-func (*UserDao) QueryUser(user *model.Users) *model.Users {
+func (*UserDao) QueryUser(ctx context.Context, user *model.Users) *model.Users {
 
-	model.User().Where(&user).Find(&user)
+	model.User(ctx).Where(&user).Find(&user)
 	return user
 }
 
-func (*UserDao) QueryUserSimple(user *model.Users) (result model.UserSimple, err error) {
-	err = model.User().
+func (*UserDao) QueryUserSimple(ctx context.Context, user *model.Users) (result model.UserSimple, err error) {
+	err = model.User(ctx).
 		Joins("JOIN invite_codes ON invite_codes.code = users.invite_code").
 		Joins("JOIN member_infos ON member_infos.id = invite_codes.member_id").
 		Select("users.*, member_infos.name as u_role").Where(&user).Find(&result).Error
 	return
 }
 
-func (*UserDao) CreateUser(account, name, pswd, ininviteCode string) int {
+func (*UserDao) CreateUser(ctx context.Context, account, name, pswd, ininviteCode string) int {
 
 	user := model.Users{Account: account, Name: name, Password: pswd, InviteCode: ininviteCode, Subscribe: 1}
-	model.User().Create(&user)
+	model.User(ctx).Create(&user)
 	return user.ID
 }
 
-func (d *UserDao) UpdateUser(user *model.Users) {
-	model.User().Where("id = ?", user.ID).Updates(&user)
+func (d *UserDao) UpdateUser(ctx context.Context, user *model.Users) {
+	model.User(ctx).Where("id = ?", user.ID).Updates(&user)
 }
 
-func (d *UserDao) ListByIds(id ...int) []string {
+func (d *UserDao) ListByIds(ctx context.Context, id ...int) []string {
 	var email []string
-	model.User().Where("id in ?", id).Select("account").Find(&email)
+	model.User(ctx).Where("id in ?", id).Select("account").Find(&email)
 	return email
 
 }
 
-func (d *UserDao) ListByIdsSelectIdName(ids []int) []model.Users {
+func (d *UserDao) ListByIdsSelectIdName(ctx context.Context, ids []int) []model.Users {
 	var users []model.Users
-	model.User().Where("id in ?", ids).Select("id,name,account,`desc`,avatar").Find(&users)
+	model.User(ctx).Where("id in ?", ids).Select("id", "name", "account", "desc", "avatar", "locale").Find(&users)
 	return users
 }
 
-func (d *UserDao) ExistById(id int) bool {
+func (d *UserDao) ExistById(ctx context.Context, id int) bool {
 	var count int64
-	model.User().Where("id = ?", id).Count(&count)
+	model.User(ctx).Where("id = ?", id).Count(&count)
 	return count == 1
 }
 
-func (d *UserDao) GetById(id int) model.Users {
+func (d *UserDao) GetById(ctx context.Context, id int) model.Users {
 	var user model.Users
-	model.User().Where("id = ?", id).First(&user)
+	model.User(ctx).Where("id = ?", id).First(&user)
 	user.Password = ""
 	return user
 }
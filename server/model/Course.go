@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -24,6 +25,6 @@ type Courses struct {
 	Sections    []CoursesSections `json:"sections" gorm:"-;"`
 }
 
-func Course() *gorm.DB {
-	return mysql.GetInstance().Model(&Courses{})
+func Course(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Courses{})
 }
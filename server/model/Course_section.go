@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -20,6 +21,6 @@ type CoursesSections struct {
 	NextId      int            `json:"nextId" gorm:"-"`
 }
 
-func CoursesSection() *gorm.DB {
-	return mysql.GetInstance().Model(&CoursesSections{})
+func CoursesSection(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&CoursesSections{})
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"xhyovo.cn/community/pkg/time"
 
 	"gorm.io/gorm"
@@ -8,20 +9,22 @@ import (
 )
 
 type Articles struct {
-	ID        int            `gorm:"primarykey" json:"id"`
-	CreatedAt time.LocalTime `json:"createdAt"`
-	UpdatedAt time.LocalTime `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index,omitempty"`
-	Title     string         `json:"title" binding:"required" msg:"标题不能未空"`
-	Content   string         `json:"content,omitempty" binding:"required" msg:"描述不能未空"`
-	UserId    int            `json:"userId,omitempty"`
-	State     int            `json:"state"` // 状态:草稿/发布/待解决/已解决/私密提问
-	Like      int            `json:"like"`
-	Type      int            `json:"type"`
-	TopNumber int            `json:"topNumber"`
-	Cover     string         `json:"cover"`
-	Abstract  string         `json:"abstract"`
-	Tags      []int          `json:"tags" gorm:"-"`
+	ID        int             `gorm:"primarykey" json:"id"`
+	CreatedAt time.LocalTime  `json:"createdAt"`
+	UpdatedAt time.LocalTime  `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt  `json:"deletedAt,omitempty" gorm:"index,omitempty"`
+	Title     string          `json:"title" binding:"required" msg:"标题不能未空"`
+	Content   string          `json:"content,omitempty" binding:"required" msg:"描述不能未空"`
+	UserId    int             `json:"userId,omitempty"`
+	State     int             `json:"state"` // 状态:草稿/发布/待解决/已解决/私密提问
+	Like      int             `json:"like"`
+	Views     int             `json:"views"`
+	Type      int             `json:"type"`
+	TopNumber int             `json:"topNumber"`
+	Cover     string          `json:"cover"`
+	Abstract  string          `json:"abstract"`
+	PublishAt *time.LocalTime `json:"publishAt,omitempty"` // 定时发布的时间
+	Tags      []int           `json:"tags" gorm:"-"`
 	Users     `gorm:"-" json:"user"`
 }
 
@@ -30,6 +33,7 @@ type ArticleData struct {
 	Title      string `json:"title"`
 	State      int    `json:"state"` // 状态:草稿/发布/待解决/已解决/已关闭
 	Like       int    `json:"like"`
+	Views      int    `json:"views"`
 	Comments   int    `json:"comments"`
 	Cover      string `json:"cover"`
 	Abstract   string `json:"abstract"`
@@ -41,8 +45,11 @@ type ArticleData struct {
 	UpdatedAt  time.LocalTime `json:"updatedAt"`
 	StateName  string         `json:"stateName"`
 	TopNumber  int            `json:"topNumber"`
+	Series     *SeriesNav     `json:"series,omitempty" gorm:"-"` // 文章所在的系列,上一篇、下一篇
+	Bookmarks  int            `json:"bookmarks"`                 // 收藏的用户数
+	Bookmarked bool           `json:"bookmarked" gorm:"-"`       // 当前用户是否已收藏,只在文章详情中返回
 }
 
-func Article() *gorm.DB {
-	return mysql.GetInstance().Model(&Articles{})
+func Article(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Articles{})
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 )
@@ -11,6 +12,6 @@ type Article_Likes struct {
 	UserId    int `json:"userId"`
 }
 
-func ArticleLike() *gorm.DB {
-	return mysql.GetInstance().Model(&Article_Likes{})
+func ArticleLike(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Article_Likes{})
 }
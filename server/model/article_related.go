@@ -1,11 +1,13 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
 )
 
+// ArticleRelations 文章系列中的文章,RootId 为系列 id,Sort 为在系列中的顺序
 type ArticleRelations struct {
 	ID        int            `gorm:"primarykey"`
 	CreatedAt time.LocalTime `json:"createdAt"`
@@ -14,8 +16,9 @@ type ArticleRelations struct {
 	ParentId  int            `json:"parentId"`
 	RootId    int            `json:"rootId"`
 	ArticleId int            `json:"articleId"`
+	Sort      int            `json:"sort"`
 }
 
-func ArticleRelation() *gorm.DB {
-	return mysql.GetInstance().Model(&ArticleRelations{})
+func ArticleRelation(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&ArticleRelations{})
 }
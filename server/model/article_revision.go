@@ -0,0 +1,41 @@
+package model
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/time"
+)
+
+// ArticleRevisions 文章历史版本,只新增不修改,文章删除后保留
+type ArticleRevisions struct {
+	ID        int             `gorm:"primaryKey" json:"id"`
+	ArticleId int             `json:"articleId"`
+	Version   int             `json:"version"`
+	UserId    int             `json:"userId"` // 保存该版本的用户
+	Title     string          `json:"title"`
+	Abstract  string          `json:"abstract,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	Type      int             `json:"type"`
+	State     int             `json:"state"`
+	Summary   string          `json:"summary"`
+	Added     int             `json:"added"`   // 相比上一个版本新增的行数
+	Deleted   int             `json:"deleted"` // 相比上一个版本删除的行数
+	CreatedAt *time.LocalTime `json:"createdAt"`
+	UserName  string          `json:"userName" gorm:"-"`
+}
+
+// RevisionDiff 两个版本之间的差异,各字段为 unified diff,没有变化时为空
+type RevisionDiff struct {
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+	Title    string `json:"title"`
+	Abstract string `json:"abstract"`
+	Content  string `json:"content"`
+	Added    int    `json:"added"`
+	Deleted  int    `json:"deleted"`
+}
+
+func ArticleRevision(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&ArticleRevisions{})
+}
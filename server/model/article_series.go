@@ -0,0 +1,53 @@
+package model
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/time"
+)
+
+// ArticleSeries 文章系列,如多篇组成的教程
+type ArticleSeries struct {
+	ID          int             `gorm:"primarykey" json:"id"`
+	Title       string          `json:"title" binding:"required,max=100" msg:"系列标题不能为空"`
+	Description string          `json:"description" binding:"max=500" msg:"系列简介不能超过 500 字"`
+	Cover       string          `json:"cover"`
+	UserId      int             `json:"userId"`
+	CreatedAt   *time.LocalTime `json:"createdAt"`
+	UpdatedAt   *time.LocalTime `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"`
+	Articles    int             `json:"articles" gorm:"-"` // 公开的文章数
+	User        UserSimple      `json:"user" gorm:"-"`
+}
+
+// SeriesArticle 系列中的文章
+type SeriesArticle struct {
+	ID        int             `json:"id"`
+	Title     string          `json:"title"`
+	Abstract  string          `json:"abstract"`
+	State     int             `json:"state"`
+	StateName string          `json:"stateName"`
+	Sort      int             `json:"sort"`
+	CreatedAt *time.LocalTime `json:"createdAt"`
+}
+
+// SeriesDetail 系列详情,文章按顺序排列
+type SeriesDetail struct {
+	ArticleSeries
+	List []SeriesArticle `json:"list"`
+}
+
+// SeriesNav 文章在系列中的位置
+type SeriesNav struct {
+	ID    int            `json:"id"`
+	Title string         `json:"title"`
+	Index int            `json:"index"` // 从 1 开始
+	Total int            `json:"total"`
+	Prev  *SeriesArticle `json:"prev"`
+	Next  *SeriesArticle `json:"next"`
+}
+
+func Series(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&ArticleSeries{})
+}
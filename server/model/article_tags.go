@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -39,14 +40,14 @@ type TagArticleCount struct {
 	TagName      string
 }
 
-func ArticleTag() *gorm.DB {
-	return mysql.GetInstance().Model(&ArticleTags{})
+func ArticleTag(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&ArticleTags{})
 }
 
-func ArticleTagRelation() *gorm.DB {
-	return mysql.GetInstance().Model(&ArticleTagRelations{})
+func ArticleTagRelation(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&ArticleTagRelations{})
 }
 
-func ArticleTagUserRelation() *gorm.DB {
-	return mysql.GetInstance().Model(&ArticleTagUserRelations{})
+func ArticleTagUserRelation(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&ArticleTagUserRelations{})
 }
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/mysql"
+)
+
+// ArticleViews 文章每天的浏览量
+type ArticleViews struct {
+	ID        int `gorm:"primarykey" json:"id"`
+	ArticleId int `json:"articleId"`
+	Day       int `json:"day"` // 日期,如 20240101
+	Views     int `json:"views"`
+}
+
+// HotArticle 热门文章
+type HotArticle struct {
+	*ArticleData
+	Score float64 `json:"score"`
+}
+
+func ArticleView(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&ArticleViews{})
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/time"
+)
+
+// 收藏的内容类型
+const (
+	BookmarkArticle = 1 // 文章、问答
+	BookmarkSection = 2 // 课程章节
+)
+
+// BookmarkFolders 收藏夹,公开的收藏夹其他用户可以查看
+type BookmarkFolders struct {
+	ID          int             `gorm:"primarykey" json:"id"`
+	UserId      int             `json:"userId"`
+	Name        string          `json:"name" binding:"required,max=50" msg:"收藏夹名称不能为空且不能超过 50 字"`
+	Description string          `json:"description" binding:"max=255" msg:"收藏夹简介不能超过 255 字"`
+	Public      bool            `json:"public"`
+	CreatedAt   *time.LocalTime `json:"createdAt"`
+	UpdatedAt   *time.LocalTime `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"`
+	Count       int             `json:"count" gorm:"-"` // 收藏数
+}
+
+// Bookmarks 收藏,同一内容可以收藏到多个收藏夹,FolderId 为 0 表示默认收藏夹
+type Bookmarks struct {
+	ID           int            `gorm:"primarykey" json:"id"`
+	UserId       int            `json:"userId"`
+	FolderId     int            `json:"folderId"`
+	BusinessType int            `json:"businessType"`
+	BusinessId   int            `json:"businessId"`
+	CreatedAt    time.LocalTime `json:"createdAt"`
+}
+
+// BookmarkItem 收藏列表中的一项
+type BookmarkItem struct {
+	ID           int            `json:"id"`
+	FolderId     int            `json:"folderId"`
+	FolderName   string         `json:"folderName" gorm:"-"`
+	BusinessType int            `json:"businessType"`
+	BusinessId   int            `json:"businessId"`
+	Title        string         `json:"title"`
+	Abstract     string         `json:"abstract"`
+	CourseId     int            `json:"courseId,omitempty"` // 章节所在的课程
+	CreatedAt    time.LocalTime `json:"createdAt"`
+}
+
+func BookmarkFolder(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&BookmarkFolders{})
+}
+
+func Bookmark(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Bookmarks{})
+}
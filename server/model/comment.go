@@ -1,12 +1,16 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/time"
 
 	"xhyovo.cn/community/pkg/mysql"
 )
 
+// 评论列表的排序,默认按最新回复
+const CommentSortLikes = "likes" // 按点赞数
+
 type Comments struct {
 	ID                 int            `gorm:"primarykey" json:"id"`
 	CreatedAt          time.LocalTime `json:"createdAt"`
@@ -20,6 +24,7 @@ type Comments struct {
 	BusinessId         int            `json:"businessId" binding:"required" msg:"评论对象不可为空"`
 	BusinessUserId     int            `json:"businessUserId"`
 	TenantId           int            `json:"tenantId"`
+	Likes              int            `json:"likes"` // 点赞数
 	ChildComments      []*Comments    `gorm:"-" json:"childComments"`
 	ChildCommentNumber int            `gorm:"-" json:"childCommentNumber"`
 	FromUserName       string         `json:"fromUserName" gorm:"-"`
@@ -28,6 +33,8 @@ type Comments struct {
 	FromUserAvatar     string         `json:"fromUserAvatar" gorm:"-"`
 	ToUserAvatar       string         `json:"toUserAvatar" gorm:"-"`
 	AdoptionState      bool           `json:"adoptionState" gorm:"-"`
+	Reactions          map[string]int `json:"reactions" gorm:"-"`   // 各表态的数量
+	MyReactions        []string       `json:"myReactions" gorm:"-"` // 当前用户的表态
 }
 
 type ChildCommentNumber struct {
@@ -35,6 +42,6 @@ type ChildCommentNumber struct {
 	Number int `json:"number"`
 }
 
-func Comment() *gorm.DB {
-	return mysql.GetInstance().Model(&Comments{})
+func Comment(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Comments{})
 }
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/time"
+)
+
+// 评论的表态,点赞之外只支持固定的表情
+const (
+	ReactionLike     = "like"
+	ReactionHeart    = "heart"
+	ReactionLaugh    = "laugh"
+	ReactionHooray   = "hooray"
+	ReactionConfused = "confused"
+	ReactionEyes     = "eyes"
+	ReactionRocket   = "rocket"
+)
+
+// Reactions 支持的表态,按展示顺序
+var Reactions = []string{ReactionLike, ReactionHeart, ReactionLaugh, ReactionHooray, ReactionConfused, ReactionEyes, ReactionRocket}
+
+// CommentReactions 用户对评论的表态,同一用户可以对一条评论做多种表态
+type CommentReactions struct {
+	ID        int            `gorm:"primarykey" json:"id"`
+	CommentId int            `json:"commentId"`
+	UserId    int            `json:"userId"`
+	Reaction  string         `json:"reaction"`
+	CreatedAt time.LocalTime `json:"createdAt"`
+}
+
+// CommentReactionUser 表态的用户
+type CommentReactionUser struct {
+	UserId     int            `json:"userId"`
+	UserName   string         `json:"userName" gorm:"-"`
+	UserAvatar string         `json:"userAvatar" gorm:"-"`
+	Reaction   string         `json:"reaction"`
+	CreatedAt  time.LocalTime `json:"createdAt"`
+}
+
+func CommentReaction(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&CommentReactions{})
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -18,6 +19,6 @@ type Drafts struct {
 	CreatedAt time.LocalTime `json:"createdAt"`
 }
 
-func Draft() *gorm.DB {
-	return mysql.GetInstance().Model(&Drafts{})
+func Draft(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Drafts{})
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -20,6 +21,6 @@ type Files struct {
 	SizeName   string         `json:"sizeName" gorm:"-"`
 }
 
-func File() *gorm.DB {
-	return mysql.GetInstance().Model(&Files{})
+func File(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Files{})
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/time"
 
@@ -26,6 +27,6 @@ type GenerateCode struct {
 	AcquisitionType int `json:"acquisitionType"` // 获取类型:1 购买，2赠予
 }
 
-func InviteCode() *gorm.DB {
-	return mysql.GetInstance().Model(&InviteCodes{})
+func InviteCode(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&InviteCodes{})
 }
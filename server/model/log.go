@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -17,6 +18,7 @@ type OperLogs struct {
 	UserAgent     string         `json:"userAgent"`
 	Platform      string         `json:"platform"`
 	ExecAt        string         `json:"execAt"`
+	RequestId     string         `json:"requestId"`
 	CreatedAt     time.LocalTime `json:"createdAt"`
 	UserName      string         `gorm:"-" json:"userName"`
 }
@@ -29,6 +31,7 @@ type LogSearch struct {
 	StartTime     string `form:"startTime"`
 	EndTime       string `form:"endTime"`
 	Account       string `form:"account"`
+	RequestId     string `form:"requestId"`
 }
 
 type LoginLogs struct {
@@ -41,10 +44,10 @@ type LoginLogs struct {
 	CreatedAt time.LocalTime `json:"createdAt"`
 }
 
-func OperLog() *gorm.DB {
-	return mysql.GetInstance().Model(&OperLogs{})
+func OperLog(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&OperLogs{})
 }
 
-func LoginLog() *gorm.DB {
-	return mysql.GetInstance().Model(&LoginLogs{})
+func LoginLog(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&LoginLogs{})
 }
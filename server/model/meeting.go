@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"gorm.io/gorm"
 	sysTime "time"
@@ -19,6 +20,7 @@ type Meetings struct {
 	MeetingEndTime   *time.LocalTime    `gorm:"not null" json:"meetingEndTime"`                                  // 会议结束时间
 	SignupEndTime    *time.LocalTime    `gorm:"not null" json:"signupEndTime"`                                   // 报名截止时间
 	State            string             `gorm:"not null" json:"state"`                                           // 状态
+	StateName        string             `gorm:"-" json:"stateName"`                                              // 状态名称,按请求语言翻译
 	StateMessage     string             `gorm:"not null" json:"stateMessage"`                                    // 状态消息
 	MeetingLink      string             `gorm:"not null" json:"meetingLink"`
 	UpdatedAt        time.LocalTime     `gorm:"not null" json:"updatedAt"`
@@ -30,8 +32,8 @@ type Meetings struct {
 	InitiatorAvatar  string             `json:"initiatorAvatar" gorm:"-"`
 }
 
-func Meeting() *gorm.DB {
-	return mysql.GetInstance().Model(&Meetings{})
+func Meeting(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Meetings{})
 }
 
 func (m *Meetings) PrintLog() string {
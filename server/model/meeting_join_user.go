@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"time"
 	"xhyovo.cn/community/pkg/mysql"
@@ -14,6 +15,6 @@ type MeetingJoinUsers struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-func MeetingJoinUser() *gorm.DB {
-	return mysql.GetInstance().Model(&MeetingJoinUsers{})
+func MeetingJoinUser(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&MeetingJoinUsers{})
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/time"
 
@@ -16,6 +17,6 @@ type MemberInfos struct {
 	UpdatedAt time.LocalTime `json:"updatedAt"`
 }
 
-func MemberInfo() *gorm.DB {
-	return mysql.GetInstance().Model(&MemberInfos{})
+func MemberInfo(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&MemberInfos{})
 }
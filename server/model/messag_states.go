@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/time"
 
@@ -19,6 +20,6 @@ type MessageStates struct {
 	CreatedAt time.LocalTime `json:"createdAt"` // 发送时间
 }
 
-func MessageState() *gorm.DB {
-	return mysql.GetInstance().Model(&MessageStates{})
+func MessageState(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&MessageStates{})
 }
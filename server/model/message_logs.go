@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/time"
 
@@ -19,6 +20,6 @@ type MessageLogs struct {
 	DeletedAt gorm.DeletedAt `json:"deletedAt"`
 }
 
-func MessageLog() *gorm.DB {
-	return mysql.GetInstance().Model(&MessageLogs{})
+func MessageLog(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&MessageLogs{})
 }
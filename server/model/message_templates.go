@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/time"
 
@@ -16,6 +17,6 @@ type MessageTemplates struct {
 	EventName string         `json:"eventName" gorm:"-"`
 }
 
-func MessageTemplate() *gorm.DB {
-	return mysql.GetInstance().Model(&MessageTemplates{})
+func MessageTemplate(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&MessageTemplates{})
 }
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/time"
+)
+
+type SensitiveWords struct {
+	ID        int             `gorm:"primaryKey" json:"id"`
+	Word      string          `json:"word" binding:"required,max=64" msg:"敏感词不能为空"`
+	Action    string          `json:"action" binding:"required,oneof=mask review reject" msg:"动作只支持 mask / review / reject"`
+	CreatedAt *time.LocalTime `json:"createdAt"`
+	UpdatedAt *time.LocalTime `json:"updatedAt"`
+}
+
+// ModerationRecords 内容审核命中记录,action 为 review 时 payload 保存待审核的内容
+type ModerationRecords struct {
+	ID         int             `gorm:"primaryKey" json:"id"`
+	Scene      string          `json:"scene"`
+	BusinessId int             `json:"businessId"`
+	UserId     int             `json:"userId"`
+	Action     string          `json:"action"`
+	Hits       string          `json:"hits"` // 命中的规则,json 数组
+	Content    string          `json:"content"`
+	Payload    string          `json:"-"`
+	State      string          `json:"state"` // review 的审核状态: pending / approved / rejected
+	Reviewer   int             `json:"reviewer"`
+	ReviewedAt *time.LocalTime `json:"reviewedAt"`
+	CreatedAt  *time.LocalTime `json:"createdAt"`
+	UserName   string          `json:"userName" gorm:"-"`
+}
+
+func SensitiveWord(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&SensitiveWords{})
+}
+
+func ModerationRecord(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&ModerationRecords{})
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -18,6 +19,6 @@ type Orders struct {
 	CreatorName     string         `json:"creatorName" gorm:"-"`
 }
 
-func Order() *gorm.DB {
-	return mysql.GetInstance().Model(&Orders{})
+func Order(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Orders{})
 }
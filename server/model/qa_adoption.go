@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -13,7 +14,7 @@ type QaAdoptions struct {
 	CreatedAt time.LocalTime `json:"createdAt"`
 }
 
-func QaAdoption() *gorm.DB {
+func QaAdoption(ctx context.Context) *gorm.DB {
 
-	return mysql.GetInstance().Model(&QaAdoptions{})
+	return mysql.GetInstance().WithContext(ctx).Model(&QaAdoptions{})
 }
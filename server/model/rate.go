@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -16,6 +17,6 @@ type Rates struct {
 	Nickname  string         `json:"nickName" gorm:"-"`
 }
 
-func Rate() *gorm.DB {
-	return mysql.GetInstance().Model(&Rates{})
+func Rate(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Rates{})
 }
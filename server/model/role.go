@@ -0,0 +1,43 @@
+package model
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/time"
+)
+
+type Roles struct {
+	ID          int             `gorm:"primaryKey" json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	BuiltIn     bool            `json:"builtIn"` // 内置角色不能删除和修改权限
+	CreatedAt   *time.LocalTime `json:"createdAt"`
+	UpdatedAt   *time.LocalTime `json:"updatedAt"`
+	Permissions []string        `json:"permissions" gorm:"-"`
+}
+
+type RolePermissions struct {
+	ID         int    `json:"id"`
+	RoleId     int    `json:"roleId"`
+	Permission string `json:"permission"`
+}
+
+type UserRoles struct {
+	ID        int             `json:"id"`
+	UserId    int             `json:"userId"`
+	RoleId    int             `json:"roleId"`
+	CreatedAt *time.LocalTime `json:"createdAt"`
+}
+
+func Role(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Roles{})
+}
+
+func RolePermission(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&RolePermissions{})
+}
+
+func UserRole(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&UserRoles{})
+}
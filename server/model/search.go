@@ -0,0 +1,18 @@
+package model
+
+import "xhyovo.cn/community/pkg/time"
+
+// SearchResult 全文搜索结果,title、snippet 中命中的部分用 <em> 标记,其余部分已转义
+type SearchResult struct {
+	Kind       string         `json:"kind"` // article / comment / section
+	ID         int            `json:"id"`
+	Title      string         `json:"title"` // 评论为所属文章、章节的标题,未高亮
+	Snippet    string         `json:"snippet"`
+	Score      float64        `json:"score"`
+	State      int            `json:"state,omitempty"`
+	StateName  string         `json:"stateName,omitempty"`
+	BusinessId int            `json:"businessId"` // 评论所属的文章、章节,章节所属的课程
+	TenantId   int            `json:"tenantId"`   // 评论的业务类型
+	CreatedAt  time.LocalTime `json:"createdAt"`
+	User       UserSimple     `json:"user"`
+}
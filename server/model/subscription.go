@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/time"
 
@@ -25,6 +26,6 @@ type SubscriptionState struct {
 	BusinessId   int `json:"businessId"`
 }
 
-func Subscription() *gorm.DB {
-	return mysql.GetInstance().Model(&Subscriptions{})
+func Subscription(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Subscriptions{})
 }
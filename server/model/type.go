@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -19,6 +20,7 @@ type Types struct {
 	ArticleState  string         `json:"articleState"` // 分类下文章的状态
 	ArticleStates []string       `gorm:"-" json:"articleStates"`
 	FlagName      string         `json:"flagName"`
+	Public        bool           `json:"public"` // 公开分类未登录也能访问,一级分类公开时二级分类都公开
 	Children      []Types        `gorm:"-" json:"children"`
 }
 
@@ -28,6 +30,6 @@ type TypeSimple struct {
 	TypeTitle string `json:"title" gorm:"column:title"`    // title
 }
 
-func Type() *gorm.DB {
-	return mysql.GetInstance().Model(&Types{})
+func Type(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Types{})
 }
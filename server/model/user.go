@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -19,6 +20,7 @@ type Users struct {
 	Avatar     string `json:"avatar"`
 	State      int    `json:"state"`
 	Subscribe  int    `json:"subscribe"` // 1: 未订阅站内消息 2:订阅站内消息 (发送邮箱)
+	Locale     string `json:"locale"`    // 语言设置,为空时按请求头协商
 }
 
 type UserSimple struct {
@@ -31,6 +33,7 @@ type UserSimple struct {
 	State     int            `json:"state" gorm:"column:state"`
 	CreatedAt time.LocalTime `json:"createdAt"`
 	Subscribe int            `json:"subscribe"` // 1: 未订阅站内消息 2:订阅站内消息 (发送邮箱)
+	Locale    string         `json:"locale"`
 }
 
 type LoginForm struct {
@@ -38,6 +41,6 @@ type LoginForm struct {
 	Password string `binding:"required" json:"password" msg:"密码不能为空"`
 }
 
-func User() *gorm.DB {
-	return mysql.GetInstance().Model(&Users{})
+func User(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&Users{})
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/pkg/time"
@@ -19,10 +20,10 @@ type UserTagRelations struct {
 	UserTagId int `json:"UserTagId"`
 }
 
-func UserTag() *gorm.DB {
-	return mysql.GetInstance().Model(&UserTags{})
+func UserTag(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&UserTags{})
 }
 
-func UserTagRelation() *gorm.DB {
-	return mysql.GetInstance().Model(&UserTagRelations{})
+func UserTagRelation(ctx context.Context) *gorm.DB {
+	return mysql.GetInstance().WithContext(ctx).Model(&UserTagRelations{})
 }
@@ -1,19 +1,51 @@
 package request
 
+import "xhyovo.cn/community/pkg/time"
+
 type ReqArticle struct {
-	ID         int    `gorm:"primarykey" json:"id"`
-	Title      string `json:"title" binding:"required" msg:"标题不能未空"`
-	Content    string `json:"content,omitempty" binding:"required" msg:"描述不能未空"`
-	UserId     int    `json:"userId,omitempty"`
-	Abstract   string `json:"abstract"`
-	State      int    `json:"state"` // 状态:草稿/发布/待解决/已解决/私密提问
-	Type       int    `json:"type"`
-	Tags       []int  `json:"tags" gorm:"-"`
-	NoticeUser []int  `json:"noticeUser"`
-	Cover      string `json:"cover"`
+	ID         int             `gorm:"primarykey" json:"id"`
+	Title      string          `json:"title" binding:"required" msg:"标题不能未空"`
+	Content    string          `json:"content,omitempty" binding:"required" msg:"描述不能未空"`
+	UserId     int             `json:"userId,omitempty"`
+	Abstract   string          `json:"abstract"`
+	State      int             `json:"state"` // 状态:草稿/发布/待解决/已解决/私密提问
+	Type       int             `json:"type"`
+	Tags       []int           `json:"tags" gorm:"-"`
+	NoticeUser []int           `json:"noticeUser"`
+	Cover      string          `json:"cover"`
+	Summary    string          `json:"summary"`   // 修改说明,为空时自动生成
+	PublishAt  *time.LocalTime `json:"publishAt"` // 状态为定时发布时必填,格式 2006-01-02 15:04:05
+	SeriesId   int             `json:"seriesId"`  // 加入系列,一篇文章只能属于一个系列
 }
 
 type TopArticle struct {
 	Id        int `json:"id"`
 	TopNumber int `json:"topNumber"`
 }
+
+// 比较文章的两个版本
+type ReqRevisionDiff struct {
+	From int `form:"from" binding:"required,min=1" msg:"from 版本不能为空"`
+	To   int `form:"to" binding:"required,min=1" msg:"to 版本不能为空"`
+}
+
+// 修改定时发布时间
+type ReqSchedule struct {
+	PublishAt *time.LocalTime `json:"publishAt" binding:"required" msg:"发布时间不能为空"`
+}
+
+// 把文章加入系列
+type ReqSeriesArticle struct {
+	ArticleId int `json:"articleId" binding:"required" msg:"文章id不能为空"`
+}
+
+// 调整系列中文章的顺序
+type ReqSeriesSort struct {
+	ArticleIds []int `json:"articleIds" binding:"required" msg:"文章id不能为空"`
+}
+
+// 导入文章,dryRun 时只返回报告
+type ReqArticleImport struct {
+	DryRun bool `form:"dryRun"`
+	TypeId int  `form:"typeId"` // 没有匹配到分类的文章使用的分类
+}
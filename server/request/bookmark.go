@@ -0,0 +1,20 @@
+package request
+
+// 收藏文章、问答或课程章节
+type ReqBookmark struct {
+	FolderId     int `json:"folderId"` // 0 为默认收藏夹
+	BusinessType int `json:"businessType" binding:"required,oneof=1 2" msg:"收藏类型只能是 1 文章 或 2 课程章节"`
+	BusinessId   int `json:"businessId" binding:"required" msg:"收藏的内容不能为空"`
+}
+
+// 移动收藏到其他收藏夹
+type ReqBookmarkMove struct {
+	FolderId int `json:"folderId"`
+}
+
+// 搜索收藏,FolderId 为空时查询所有收藏夹
+type ReqBookmarkSearch struct {
+	FolderId     *int   `form:"folderId"`
+	BusinessType int    `form:"type"`
+	Keyword      string `form:"q"`
+}
@@ -0,0 +1,6 @@
+package request
+
+// 对评论表态,已经表态过时取消
+type ReqCommentReaction struct {
+	Reaction string `json:"reaction" binding:"required,oneof=like heart laugh hooray confused eyes rocket" msg:"表态只能是 like、heart、laugh、hooray、confused、eyes、rocket"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// 修改日志级别 req,package 为空时修改全局级别,level 为空时删除该包的单独设置
+type ReqLogLevel struct {
+	Package string `json:"package"`
+	Level   string `json:"level"`
+}
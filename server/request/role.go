@@ -0,0 +1,15 @@
+package request
+
+// 保存角色 req,id 为 0 时新增
+type ReqRole struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name" binding:"required,max=64" msg:"角色名称不能为空"`
+	Description string   `json:"description" binding:"max=255" msg:"描述过长"`
+	Permissions []string `json:"permissions"`
+}
+
+// 设置用户角色 req,覆盖用户原有的角色
+type ReqUserRoles struct {
+	UserId  int   `json:"userId" binding:"required" msg:"用户不能为空"`
+	RoleIds []int `json:"roleIds"`
+}
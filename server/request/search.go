@@ -0,0 +1,14 @@
+package request
+
+// 全文搜索 req,from、to 格式为 2006-01-02,to 包含当天
+type ReqSearch struct {
+	Keyword string `form:"q" binding:"required" msg:"搜索内容不能为空"`
+	Kind    string `form:"kind"` // article / comment / section,为空时搜索全部
+	Type    int    `form:"type"` // 文章分类,一级分类包含其子分类
+	Tags    []int  `form:"tags"`
+	Author  int    `form:"author"`
+	State   int    `form:"state"`
+	From    string `form:"from"`
+	To      string `form:"to"`
+	Sort    string `form:"sort"` // time 按时间倒序,默认按相关度
+}
@@ -1,9 +1,11 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"sort"
 	"strings"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/search"
 	"xhyovo.cn/community/server/model"
 	"xhyovo.cn/community/server/service/event"
 )
@@ -12,10 +14,10 @@ type CourseService struct {
 }
 
 // 发布课程
-func (*CourseService) Publish(course model.Courses) {
+func (*CourseService) Publish(ctx context.Context, course model.Courses) {
 	course.Technology = strings.Join(course.TechnologyS, ",")
 	if course.ID == 0 {
-		model.Course().Save(&course)
+		model.Course(ctx).Save(&course)
 		var subscriptionService SubscriptionService
 		var b SubscribeData
 		b.UserId = course.UserId
@@ -23,41 +25,46 @@ func (*CourseService) Publish(course model.Courses) {
 		b.CourseId = course.ID
 		b.SubscribeId = course.UserId
 		var messageTemp = "你关注的用户 ${user.name} 发布了最新课程: ${course.title}"
-		subscriptionService.DoWithMessageTempl(event.UserFollowingEvent, b, messageTemp)
+		subscriptionService.DoWithMessageTempl(ctx, event.UserFollowingEvent, b, messageTemp)
 	} else {
-		model.Course().Where("id = ?", course.ID).Updates(&course)
+		model.Course(ctx).Where("id = ?", course.ID).Updates(&course)
 	}
 }
 
 // 获取课程详细信息
-func (*CourseService) GetCourseDetail(id int) *model.Courses {
+func (*CourseService) GetCourseDetail(ctx context.Context, id int) *model.Courses {
 	var course *model.Courses
-	model.Course().Where("id = ?", id).Find(&course)
+	model.Course(ctx).Where("id = ?", id).Find(&course)
 	course.TechnologyS = strings.Split(course.Technology, ",")
 	return course
 }
 
 // 获取课程列表
-func (*CourseService) PageCourse(page, limit int) (courses []model.Courses, count int64) {
-	model.Course().Offset((page - 1) * limit).Limit(limit).Order("created_at desc").Find(&courses)
-	model.Course().Count(&count)
+func (*CourseService) PageCourse(ctx context.Context, page, limit int) (courses []model.Courses, count int64) {
+	model.Course(ctx).Offset((page - 1) * limit).Limit(limit).Order("created_at desc").Find(&courses)
+	model.Course(ctx).Count(&count)
 	return
 }
 
 // 删除课程
-func (*CourseService) DeleteCourse(id int) {
-	model.Course().Delete("id = ?", id)
-	model.CoursesSection().Where("course_id = ?", id).Delete(&model.CoursesSections{})
+func (*CourseService) DeleteCourse(ctx context.Context, id int) {
+	var sectionIds []int
+	model.CoursesSection(ctx).Where("course_id = ?", id).Pluck("id", &sectionIds)
+	model.Course(ctx).Delete("id = ?", id)
+	model.CoursesSection(ctx).Where("course_id = ?", id).Delete(&model.CoursesSections{})
+	for _, sectionId := range sectionIds {
+		search.GetInstance().Delete(search.Key{Kind: search.KindSection, ID: sectionId})
+	}
 }
 
 // 发布章节
-func (c *CourseService) PublishSection(section model.CoursesSections) error {
-	if c.GetCourseDetail(section.CourseId).ID == 0 {
-		return errors.New("对应课程不存在")
+func (c *CourseService) PublishSection(ctx context.Context, section model.CoursesSections) error {
+	if c.GetCourseDetail(ctx, section.CourseId).ID == 0 {
+		return errs.NotFound.WithMsg("对应课程不存在")
 	}
 	if section.ID == 0 {
 
-		model.CoursesSection().Save(&section)
+		model.CoursesSection(ctx).Save(&section)
 		var b SubscribeData
 		var subscriptionService SubscriptionService
 		b.UserId = section.UserId
@@ -65,7 +72,7 @@ func (c *CourseService) PublishSection(section model.CoursesSections) error {
 		b.SubscribeId = section.CourseId
 		b.CourseId = section.CourseId
 		b.SectionId = section.ID
-		subscriptionService.Do(event.CourseUpdate, b)
+		subscriptionService.Do(ctx, event.CourseUpdate, b)
 
 		var b2 SubscribeData
 		b2.UserId = section.UserId
@@ -74,20 +81,22 @@ func (c *CourseService) PublishSection(section model.CoursesSections) error {
 		b2.SubscribeId = section.UserId
 		b2.SectionId = section.ID
 		var messageTemp = "你关注的用户 ${user.name} 在课程: ${course.title} 发布了最新章节: ${courses_section.title}"
-		subscriptionService.DoWithMessageTempl(event.UserFollowingEvent, b2, messageTemp)
+		subscriptionService.DoWithMessageTempl(ctx, event.UserFollowingEvent, b2, messageTemp)
 	} else {
-		model.CoursesSection().Where("id = ?", section.ID).Updates(&section)
+		model.CoursesSection(ctx).Where("id = ?", section.ID).Updates(&section)
 	}
+	var searchS SearchService
+	searchS.IndexSection(ctx, section.ID)
 	return nil
 }
 
 // 获取章节详细信息
-func (*CourseService) GetCourseSectionDetail(id int) *model.CoursesSections {
+func (*CourseService) GetCourseSectionDetail(ctx context.Context, id int) *model.CoursesSections {
 	var sections *model.CoursesSections
 	var courseId int
 	var courses []model.CoursesSections
-	model.CoursesSection().Where("id = ?", id).Select("course_id").Find(&courseId)
-	model.CoursesSection().Where("course_id = ? ", courseId).Order("sort").Find(&courses)
+	model.CoursesSection(ctx).Where("id = ?", id).Select("course_id").Find(&courseId)
+	model.CoursesSection(ctx).Where("course_id = ? ", courseId).Order("sort").Find(&courses)
 	// 遍历course 找到id 和 id相同的
 	for i := range courses {
 		if courses[i].ID == id {
@@ -104,25 +113,26 @@ func (*CourseService) GetCourseSectionDetail(id int) *model.CoursesSections {
 		}
 	}
 	var userS UserService
-	sections.UserSimple = userS.GetUserSimpleById(sections.UserId)
+	sections.UserSimple = userS.GetUserSimpleById(ctx, sections.UserId)
 	return sections
 }
 
 // 获取课程列表
-func (*CourseService) PageCourseSection(page, limit, courseId int) (courses []model.CoursesSections, count int64) {
-	model.CoursesSection().Where("course_id = ? ", courseId).Order("sort").Select("id", "title").Find(&courses)
+func (*CourseService) PageCourseSection(ctx context.Context, page, limit, courseId int) (courses []model.CoursesSections, count int64) {
+	model.CoursesSection(ctx).Where("course_id = ? ", courseId).Order("sort").Select("id", "title").Find(&courses)
 	count = int64(len(courses))
 	return
 }
 
 // 删除课程
-func (*CourseService) DeleteCourseSection(id int) {
-	model.CoursesSection().Delete("id = ?", id)
+func (*CourseService) DeleteCourseSection(ctx context.Context, id int) {
+	model.CoursesSection(ctx).Delete("id = ?", id)
+	search.GetInstance().Delete(search.Key{Kind: search.KindSection, ID: id})
 	// 对应评论一并删除 todo
 }
 
-func (c *CourseService) ListByIdsSelectIdTitleMap(ids []int) (m map[int]string) {
-	rows, err := model.Course().Where("id in ?", ids).Select("id", "title").Rows()
+func (c *CourseService) ListByIdsSelectIdTitleMap(ctx context.Context, ids []int) (m map[int]string) {
+	rows, err := model.Course(ctx).Where("id in ?", ids).Select("id", "title").Rows()
 	defer rows.Close()
 	if err != nil {
 		// 处理错误
@@ -140,12 +150,12 @@ func (c *CourseService) ListByIdsSelectIdTitleMap(ids []int) (m map[int]string)
 	return
 }
 
-func (c *CourseService) ListCourseTree() (courses []model.Courses) {
+func (c *CourseService) ListCourseTree(ctx context.Context) (courses []model.Courses) {
 	// 获取所有章节
-	model.Course().Find(&courses)
+	model.Course(ctx).Find(&courses)
 	// 获取所有课程
 	var sections []model.CoursesSections
-	model.CoursesSection().Find(&sections)
+	model.CoursesSection(ctx).Find(&sections)
 	// 构建课程树
 	c.buildCourseTree(courses, sections)
 	return courses
@@ -170,14 +180,14 @@ func (c *CourseService) buildCourseTree(courses []model.Courses, sections []mode
 	}
 }
 
-func (c *CourseService) ListCourseTitle() []model.Courses {
+func (c *CourseService) ListCourseTitle(ctx context.Context) []model.Courses {
 	var courses []model.Courses
-	model.Course().Select("id", "title").Find(&courses)
+	model.Course(ctx).Select("id", "title").Find(&courses)
 	return courses
 }
 
-func (c *CourseService) ListSectionByIds(ids []int) (m map[int]string) {
-	rows, err := model.CoursesSection().Where("id in ?", ids).Select("id", "title").Rows()
+func (c *CourseService) ListSectionByIds(ctx context.Context, ids []int) (m map[int]string) {
+	rows, err := model.CoursesSection(ctx).Where("id in ?", ids).Select("id", "title").Rows()
 	defer rows.Close()
 	if err != nil {
 		// 处理错误
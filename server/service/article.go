@@ -1,17 +1,24 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
-	"errors"
+	"time"
+
 	mapset "github.com/deckarep/golang-set/v2"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
 	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/search"
+	ltime "xhyovo.cn/community/pkg/time"
 	"xhyovo.cn/community/server/request"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/data"
+	"xhyovo.cn/community/pkg/delay"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/server/model"
 	"xhyovo.cn/community/server/service/event"
@@ -20,24 +27,24 @@ import (
 type ArticleService struct {
 }
 
-func (*ArticleService) GetArticleData(id, userId int) (data *model.ArticleData, err error) {
+func (*ArticleService) GetArticleData(ctx context.Context, id, userId int) (data *model.ArticleData, err error) {
 	var a model.Articles
-	model.Article().Where("id = ?", id).First(&a)
-	var u UserService
-	flag, err := u.IsAdmin(userId)
+	model.Article(ctx).Where("id = ?", id).First(&a)
+	var roleS RoleService
+	flag, err := roleS.HasPermission(ctx, userId, constant.PermArticleView)
 	if err != nil {
 		return &model.ArticleData{}, err
 	}
 	if !flag {
-		if (a.ID == 0 && a.UserId != userId && a.State == constant.Draft) || (userId != a.UserId && a.State == constant.PrivateQuestion) {
-			return &model.ArticleData{}, errors.New("文章不存在")
+		if (a.ID == 0 && a.UserId != userId && a.State == constant.Draft) || (userId != a.UserId && (a.State == constant.PrivateQuestion || a.State == constant.Scheduled)) {
+			return &model.ArticleData{}, errs.NotFound.WithMsg("文章不存在")
 		}
 	}
 
 	var tags []*model.ArticleTagSimple
-	model.ArticleTag().Joins("LEFT JOIN article_tag_relations as atr ON atr.tag_id = article_tags.id").
+	model.ArticleTag(ctx).Joins("LEFT JOIN article_tag_relations as atr ON atr.tag_id = article_tags.id").
 		Where("atr.article_id = ?", a.ID).Find(&tags)
-	us, err := userDao.QueryUserSimple(&model.Users{ID: a.UserId})
+	us, err := userDao.QueryUserSimple(ctx, &model.Users{ID: a.UserId})
 	if err != nil {
 		us = model.UserSimple{
 			UId:     0,
@@ -47,37 +54,43 @@ func (*ArticleService) GetArticleData(id, userId int) (data *model.ArticleData,
 		}
 	}
 	var typeData model.TypeSimple
-	model.Type().Where("id = ?", a.Type).First(&typeData)
+	model.Type(ctx).Where("id = ?", a.Type).First(&typeData)
 
+	var seriesS SeriesService
+	var bookmarkS BookmarkService
 	return &model.ArticleData{
 		ID:         a.ID,
 		Title:      a.Title,
 		State:      a.State,
 		Like:       a.Like,
+		Views:      a.Views,
 		Tags:       tags,
 		Desc:       a.Content,
 		UserSimple: us,
 		TypeSimple: typeData,
 		CreatedAt:  a.CreatedAt,
 		UpdatedAt:  a.UpdatedAt,
-		StateName:  constant.GetArticleName(a.State),
+		StateName:  constant.GetArticleName(ctx, a.State),
 		Abstract:   a.Abstract,
 		Cover:      a.Cover,
+		Series:     seriesS.Nav(ctx, a.ID),
+		Bookmarks:  bookmarkS.Count(ctx, model.BookmarkArticle, a.ID),
+		Bookmarked: len(bookmarkS.FolderIds(ctx, userId, model.BookmarkArticle, a.ID)) > 0,
 	}, err
 }
 
-func (a *ArticleService) PageByClassfily(typeFlag string, tagId []string, article *model.Articles, page data.QueryPage, sort data.ListSortStrategy, currentUserId int) (result []*model.ArticleData, total int64, err error) {
-	query := articleDao.GetArticleSql()
+func (a *ArticleService) PageByClassfily(ctx context.Context, typeFlag string, tagId []string, article *model.Articles, page data.QueryPage, sort data.ListSortStrategy, currentUserId int) (result []*model.ArticleData, total int64, err error) {
+	query := articleDao.GetArticleSql(ctx)
 	if len(typeFlag) > 0 {
 		query.Where("tp.flag_name = ?", typeFlag)
 	}
 	if article != nil {
 		query.Where("articles.state = ?", article.State)
 		if len(article.Title) > 0 {
-			query.Where("articles.title like ?", "%"+article.Title+"%")
+			query.Where(mysql.Like("articles.title"), mysql.Contains(article.Title))
 		}
 		if len(article.Content) > 0 {
-			query.Where("articles.content like ?", "%"+article.Content+"%")
+			query.Where(mysql.Like("articles.content"), mysql.Contains(article.Content))
 		}
 		if article.UserId > 0 {
 			query.Where("articles.user_id = ?", article.UserId)
@@ -86,7 +99,7 @@ func (a *ArticleService) PageByClassfily(typeFlag string, tagId []string, articl
 	if len(tagId) > 0 {
 		query.Where("atg.tag_name in ?", tagId)
 	}
-	query.Group("articles.id").Count(&total)
+	query.Count(&total)
 	if len(sort.OrderBy) > 0 {
 		query.Order(clause.OrderByColumn{
 			Column: clause.Column{Name: "articles." + sort.OrderBy},
@@ -101,11 +114,11 @@ func (a *ArticleService) PageByClassfily(typeFlag string, tagId []string, articl
 	}
 	defer rows.Close()
 
-	result = buildResultArticles(rows)
+	result = buildResultArticles(ctx, rows)
 	return
 }
 
-func buildResultArticles(rows *sql.Rows) []*model.ArticleData {
+func buildResultArticles(ctx context.Context, rows *sql.Rows) []*model.ArticleData {
 	var result []*model.ArticleData
 	for rows.Next() {
 		item := model.ArticleData{}
@@ -113,33 +126,33 @@ func buildResultArticles(rows *sql.Rows) []*model.ArticleData {
 		itemUser := model.UserSimple{}
 		tags := ""
 		rows.Scan(
-			&item.ID, &item.Title, &item.Abstract, &item.Cover, &item.State, &item.Like, &item.CreatedAt, &item.UpdatedAt,
+			&item.ID, &item.Title, &item.Abstract, &item.Cover, &item.State, &item.Like, &item.Views, &item.CreatedAt, &item.UpdatedAt,
 			&itemType.TypeId, &itemType.TypeTitle, &itemType.TypeFlag,
 			&itemUser.UName, &itemUser.UId, &itemUser.UAvatar,
-			&item.Comments, &tags,
+			&item.Comments, &item.Bookmarks, &tags,
 		)
 		item.UserSimple = itemUser
 		item.TypeSimple = itemType
 		item.Tags = tags
-		item.StateName = constant.GetArticleName(item.State)
+		item.StateName = constant.GetArticleName(ctx, item.State)
 
 		result = append(result, &item)
 	}
 	return result
 }
 
-func (a *ArticleService) Count() int64 {
-	return articleDao.Count()
+func (a *ArticleService) Count(ctx context.Context) int64 {
+	return articleDao.Count(ctx)
 }
 
-func (a *ArticleService) CountByTypeId(typeId int) int64 {
-	return articleDao.CountByTypeId(typeId)
+func (a *ArticleService) CountByTypeId(ctx context.Context, typeId int) int64 {
+	return articleDao.CountByTypeId(ctx, typeId)
 }
 
-func (a *ArticleService) ListByIdsSelectIdTitleMap(id []int) map[int]string {
+func (a *ArticleService) ListByIdsSelectIdTitleMap(ctx context.Context, id []int) map[int]string {
 
 	m := make(map[int]string)
-	articles := articleDao.ListByIdsSelectIdTitle(id)
+	articles := articleDao.ListByIdsSelectIdTitle(ctx, id)
 	for i := range articles {
 		v := articles[i]
 		m[v.ID] = v.Title
@@ -147,98 +160,111 @@ func (a *ArticleService) ListByIdsSelectIdTitleMap(id []int) map[int]string {
 	return m
 }
 
-func (a *ArticleService) GetById(id int) model.Articles {
-	article := articleDao.GetById(id)
-	user := userDao.GetById(article.UserId)
+func (a *ArticleService) GetById(ctx context.Context, id int) model.Articles {
+	article := articleDao.GetById(ctx, id)
+	user := userDao.GetById(ctx, article.UserId)
 	article.Users = user
 	return article
 }
 
 // 点赞/取消点赞文章
-func (a *ArticleService) Like(articleId, userId int) bool {
+func (a *ArticleService) Like(ctx context.Context, articleId, userId int) bool {
 
 	// 点赞
-	err := mysql.GetInstance().Transaction(func(tx *gorm.DB) error {
+	err := mysql.GetInstance().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&model.Article_Likes{ArticleId: articleId, UserId: userId}).Error; err != nil {
 			return err
 		}
 
-		return tx.Model(&model.Articles{}).Where("id = ?", articleId).Update("like", gorm.Expr("`like` + ?", 1)).Error
+		return tx.Model(&model.Articles{}).Where("id = ?", articleId).Update("like", gorm.Expr(mysql.Quote("like")+" + ?", 1)).Error
 	})
 
 	if err != nil {
-		mysql.GetInstance().Transaction(func(tx *gorm.DB) error {
+		mysql.GetInstance().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 			if err := tx.Where("article_id = ? and user_id = ?", articleId, userId).Delete(&model.Article_Likes{}).Error; err != nil {
 				return err
 			}
-			return tx.Model(&model.Articles{}).Where("id = ?", articleId).Update("like", gorm.Expr("`like` + ?", -1)).Error
+			return tx.Model(&model.Articles{}).Where("id = ?", articleId).Update("like", gorm.Expr(mysql.Quote("like")+" + ?", -1)).Error
 		})
 	}
 	return err == nil
 }
 
-func (a *ArticleService) PublishArticleCount(userId int) (count int64) {
+func (a *ArticleService) PublishArticleCount(ctx context.Context, userId int) (count int64) {
 	var c1 int64
 	var c2 int64
-	model.Article().Where("user_id = ? and state = ?", userId, constant.Published).Count(&c1)
-	model.Article().Where("user_id = ? and state = ?", userId, constant.Draft).Count(&c2)
+	model.Article(ctx).Where("user_id = ? and state = ?", userId, constant.Published).Count(&c1)
+	model.Article(ctx).Where("user_id = ? and state = ?", userId, constant.Draft).Count(&c2)
 
 	return c1 + c2
 }
 
-func (a *ArticleService) PublishArticlesSelectId(userId int) (id []int) {
-	model.Article().Where("user_id = ?", userId).Select("id").Find(&id)
+func (a *ArticleService) PublishArticlesSelectId(ctx context.Context, userId int) (id []int) {
+	model.Article(ctx).Where("user_id = ?", userId).Select("id").Find(&id)
 	return
 }
 
 // 获取文章的点赞次数
-func (a *ArticleService) ArticlesLikeCount(ids []int) (count int64) {
-	model.ArticleLike().Where("article_id  in ? ", ids).Count(&count)
+func (a *ArticleService) ArticlesLikeCount(ctx context.Context, ids []int) (count int64) {
+	model.ArticleLike(ctx).Where("article_id  in ? ", ids).Count(&count)
 	return
 }
 
-func (a *ArticleService) SaveArticle(article request.ReqArticle) (*model.Articles, error) {
+func (a *ArticleService) SaveArticle(ctx context.Context, article request.ReqArticle) (*model.Articles, error) {
 
 	id := article.ID
 	typeO := article.Type
 	flag := true
 	var typeS TypeService
-	types := typeS.GetById(typeO)
+	types := typeS.GetById(ctx, typeO)
 	if types.ID == 0 {
-		return nil, errors.New("分类不存在")
+		return nil, errs.NotFound.WithMsg("分类不存在")
 	}
 	if types.ParentId == 0 {
-		return nil, errors.New("不能选择一级分类")
+		return nil, errs.BadRequest.WithMsg("不能选择一级分类")
 	}
 
-	types = typeS.GetById(types.ParentId)
+	types = typeS.GetById(ctx, types.ParentId)
 	// 状态是否存在
 	state := article.State
+	if state == constant.Scheduled {
+		return nil, errs.BadRequest.WithMsg("定时发布请使用发布接口")
+	}
 
 	// QA 状态校验
 	if (state == constant.Resolved || state == constant.Pending || state == constant.QADraft) && state == constant.Published {
-		return nil, errors.New("QA不能选择已发布")
+		return nil, errs.BadRequest.WithMsg("QA不能选择已发布")
 	} else if state == constant.Published || state == constant.Draft {
 		// 文章 状态校验
 		if state == constant.Pending || state == constant.Resolved || state == constant.PrivateQuestion {
-			msg := constant.GetArticleName(state)
-			return nil, errors.New("文章不支持该状态:" + msg)
+			msg := constant.GetArticleName(ctx, state)
+			return nil, errs.BadRequest.WithMsg(i18n.T(ctx, "文章不支持该状态: %s", msg))
 		}
 	}
+	oldState := 0
 	// 修改
 	if id != 0 {
 		flag = false
 		// 获取老文章
-		oldArticle := a.GetById(id)
-		oldTypeParentId := typeS.GetById(oldArticle.Type).ParentId
+		oldArticle := a.GetById(ctx, id)
+		oldState = oldArticle.State
+		oldTypeParentId := typeS.GetById(ctx, oldArticle.Type).ParentId
 		// 修改 一级分类不能修改,如果parent不同则修改了一级分类
 		newTypeParentId := types.ID
 		if oldTypeParentId != newTypeParentId {
-			return nil, errors.New("修改的分类只能属于同一级分类下")
+			return nil, errs.BadRequest.WithMsg("修改的分类只能属于同一级分类下")
 		}
 		// 老文章状态如果为非草稿状态，则新文章不可修改为草稿状态
 		if (oldArticle.State != constant.Draft && state == constant.Draft) || (oldArticle.State != constant.QADraft && state == constant.QADraft) {
-			return nil, errors.New("旧文章状态不可从非草稿转为草稿")
+			return nil, errs.StateConflict.WithMsg("旧文章状态不可从非草稿转为草稿")
+		}
+	}
+
+	// 草稿只有作者可见,发布时再审核
+	if state != constant.Draft && state != constant.QADraft {
+		var moderationS ModerationService
+		if err := moderationS.Moderate(ctx, SceneArticleSave, article.UserId, article.ID, &article, &article.Title, &article.Content); err != nil {
+			return nil, err
 		}
 	}
 
@@ -252,21 +278,30 @@ func (a *ArticleService) SaveArticle(article request.ReqArticle) (*model.Article
 	}
 	// 分开写，避免更新 0 值
 	if article.ID == 0 {
-		mysql.GetInstance().Save(&articleObject)
+		mysql.GetInstance().WithContext(ctx).Save(&articleObject)
 	} else {
-		model.Article().Where("user_id = ? and id = ?", articleObject.UserId, articleObject.ID).Updates(&articleObject)
+		model.Article(ctx).Where("user_id = ? and id = ?", articleObject.UserId, articleObject.ID).Updates(&articleObject)
 	}
 	jsonBody, _ := json.Marshal(articleObject)
-	log.Infof("用户id: %d,保存文章: %s", articleObject.UserId, jsonBody)
+	log.Ctx(ctx).Infof("用户id: %d,保存文章: %s", articleObject.UserId, jsonBody)
 	id = articleObject.ID
 	// 关联关系
 	db := model.ArticleTagRelation
-	db().Where("article_id = ?", id).Delete(nil)
+	db(ctx).Where("article_id = ?", id).Delete(nil)
 	var tags []model.ArticleTagRelations
 	for i := range article.Tags {
 		tags = append(tags, model.ArticleTagRelations{ArticleId: id, TagId: article.Tags[i], UserId: article.UserId})
 	}
-	db().Create(&tags)
+	db(ctx).Create(&tags)
+	var searchS SearchService
+	searchS.IndexArticle(ctx, id)
+	var revisionS ArticleRevisionService
+	revisionS.Record(ctx, id, article.UserId, article.Summary)
+	if err := syncSchedule(ctx, id, state, time.Time{}, nil); err != nil {
+		log.Ctx(ctx).Warnf("取消定时发布任务失败,文章id: %d,err: %s", id, err.Error())
+	}
+	var seriesS SeriesService
+	seriesS.ArticlePublished(ctx, id, oldState, state)
 	var subscriptionService SubscriptionService
 	var d Draft
 	if flag {
@@ -275,50 +310,62 @@ func (a *ArticleService) SaveArticle(article request.ReqArticle) (*model.Article
 		b.ArticleId = articleObject.ID
 		b.CurrentBusinessId = articleObject.ID
 		b.SubscribeId = articleObject.UserId
-		subscriptionService.Do(event.UserFollowingEvent, b)
-		subscriptionService.ConstantAtSend(event.ArticleAt, id, articleObject.Content, b)
+		subscriptionService.Do(ctx, event.UserFollowingEvent, b)
+		subscriptionService.ConstantAtSend(ctx, event.ArticleAt, id, articleObject.Content, b)
 	}
-	go d.DelDraft(article.UserId)
+	go d.DelDraft(ctx, article.UserId)
 	return articleObject, nil
 }
 
-func (a *ArticleService) DeleteByUserId(articleId, userId int) (err error) {
+func (a *ArticleService) DeleteByUserId(ctx context.Context, articleId, userId int) (err error) {
 
 	// 删除文章
-	db := mysql.GetInstance()
+	db := mysql.GetInstance().WithContext(ctx)
 	tx := db.Where("id = ? and user_id = ?", articleId, userId).Delete(&model.Articles{})
 	if tx.RowsAffected == 0 {
-		return errors.New("删除文章不存在")
+		return errs.NotFound.WithMsg("删除文章不存在")
 	}
 	// 删除文章标签表
 	err = db.Where("article_id = ?", articleId).Delete(&model.ArticleTagRelations{}).Error
-	log.Infof("用户id: %d,删除文章: %d", userId, articleId)
+	search.GetInstance().Delete(search.Key{Kind: search.KindArticle, ID: articleId})
+	if err := delay.GetInstant().Cancel(articlePublishJobKey(articleId)); err != nil {
+		log.Ctx(ctx).Warnf("取消定时发布任务失败,文章id: %d,err: %s", articleId, err.Error())
+	}
+	var seriesS SeriesService
+	seriesS.RemoveArticleRelations(ctx, articleId)
+	log.Ctx(ctx).Infof("用户id: %d,删除文章: %d", userId, articleId)
 	return
 }
 
-func (a *ArticleService) Delete(articleId int) (err error) {
+func (a *ArticleService) Delete(ctx context.Context, articleId int) (err error) {
 
 	// 删除文章
-	db := mysql.GetInstance()
+	db := mysql.GetInstance().WithContext(ctx)
 	err = db.Where("id = ?", articleId).Delete(&model.Articles{}).Error
 	if err != nil {
 		return err
 	}
+	search.GetInstance().Delete(search.Key{Kind: search.KindArticle, ID: articleId})
+	if err := delay.GetInstant().Cancel(articlePublishJobKey(articleId)); err != nil {
+		log.Ctx(ctx).Warnf("取消定时发布任务失败,文章id: %d,err: %s", articleId, err.Error())
+	}
+	var seriesS SeriesService
+	seriesS.RemoveArticleRelations(ctx, articleId)
 	// 删除文章标签表
 	err = db.Where("article_id = ?", articleId).Delete(&model.ArticleTagRelations{}).Error
 	return
 }
 
-func (a *ArticleService) GetLikeState(articleId, userId int) bool {
+func (a *ArticleService) GetLikeState(ctx context.Context, articleId, userId int) bool {
 	var count int64
-	model.ArticleLike().Where("article_id  = ? and user_id = ?", articleId, userId).Count(&count)
+	model.ArticleLike(ctx).Where("article_id  = ? and user_id = ?", articleId, userId).Count(&count)
 	return count == 1
 }
 
-func (a *ArticleService) PageArticles(p, limit int) (articleList []model.ArticleData, count int64) {
+func (a *ArticleService) PageArticles(ctx context.Context, p, limit int) (articleList []model.ArticleData, count int64) {
 	var articles []model.Articles
-	model.Article().Limit(limit).Offset((p-1)*limit).Select("id", "created_at", "title", "user_id", "state", "type", "top_number").Order("created_at desc").Find(&articles)
-	model.Article().Count(&count)
+	model.Article(ctx).Limit(limit).Offset((p-1)*limit).Select("id", "created_at", "title", "user_id", "state", "type", "top_number").Order("created_at desc").Find(&articles)
+	model.Article(ctx).Count(&count)
 	if count == 0 {
 		return make([]model.ArticleData, 0), 0
 	}
@@ -346,61 +393,68 @@ func (a *ArticleService) PageArticles(p, limit int) (articleList []model.Article
 
 	var u UserService
 	var t TypeService
-	userMap := u.ListByIdsToMap(userIds.ToSlice())
-	typeMap := t.ListByIdToMap(typeIds.ToSlice())
+	userMap := u.ListByIdsToMap(ctx, userIds.ToSlice())
+	typeMap := t.ListByIdToMap(ctx, typeIds.ToSlice())
 	for i := range articles {
-		articleList[i].StateName = constant.GetArticleName(articleList[i].State)
+		articleList[i].StateName = constant.GetArticleName(ctx, articleList[i].State)
 		articleList[i].TypeSimple.TypeTitle = typeMap[articleList[i].TypeSimple.TypeId]
 		articleList[i].UserSimple.UName = userMap[articleList[i].UserSimple.UId].Name
 	}
 	return
 }
 
-func (a *ArticleService) Auth(userId, articleId int) bool {
+func (a *ArticleService) Auth(ctx context.Context, userId, articleId int) bool {
 	var count int64
-	model.Article().Where("user_id = ? and id = ?", userId, articleId).Count(&count)
+	model.Article(ctx).Where("user_id = ? and id = ?", userId, articleId).Count(&count)
 	return count == 1
 }
 
-func (a *ArticleService) UpdateState(articleId, state int) {
-	model.Article().Where("id = ?", articleId).Select("state").Updates(model.Articles{State: state})
+func (a *ArticleService) UpdateState(ctx context.Context, articleId, state int) {
+	model.Article(ctx).Where("id = ?", articleId).Select("state").Updates(model.Articles{State: state})
+	var searchS SearchService
+	searchS.IndexArticle(ctx, articleId)
 }
 
-func (a *ArticleService) QAArticleCount(userId int) (count int64) {
+func (a *ArticleService) QAArticleCount(ctx context.Context, userId int) (count int64) {
 	var c1 int64
 	var c2 int64
 	var c3 int64
 	var c4 int64
-	model.Article().Where("user_id = ? and state = ?", userId, constant.Pending).Count(&c1)
-	model.Article().Where("user_id = ? and state = ?", userId, constant.Resolved).Count(&c2)
-	model.Article().Where("user_id = ? and state = ?", userId, constant.PrivateQuestion).Count(&c3)
-	model.Article().Where("user_id = ? and state = ?", userId, constant.QADraft).Count(&c4)
+	model.Article(ctx).Where("user_id = ? and state = ?", userId, constant.Pending).Count(&c1)
+	model.Article(ctx).Where("user_id = ? and state = ?", userId, constant.Resolved).Count(&c2)
+	model.Article(ctx).Where("user_id = ? and state = ?", userId, constant.PrivateQuestion).Count(&c3)
+	model.Article(ctx).Where("user_id = ? and state = ?", userId, constant.QADraft).Count(&c4)
 	return c1 + c2 + c3 + c4
 }
 
-func (a *ArticleService) PageTopArticle(types string, page, limit int) (result []*model.ArticleData, count int64) {
-	query := articleDao.GetQueryArticleSql()
+func (a *ArticleService) PageTopArticle(ctx context.Context, types string, page, limit int) (result []*model.ArticleData, count int64) {
+	query := articleDao.GetQueryArticleSql(ctx)
 	rows, err := query.Where("articles.top_number > 0").Order("articles.top_number desc").Rows()
 	if err != nil {
 		return
 	}
 	defer rows.Close()
 
-	result = buildResultArticles(rows)
+	result = buildResultArticles(ctx, rows)
 	return
 }
-func (a *ArticleService) UpdateArticleState(article request.TopArticle) error {
+func (a *ArticleService) UpdateArticleState(ctx context.Context, article request.TopArticle) error {
 
-	return model.Article().Where("id = ?", article.Id).Updates(&article).Error
+	if err := model.Article(ctx).Where("id = ?", article.Id).Updates(&article).Error; err != nil {
+		return err
+	}
+	var searchS SearchService
+	searchS.IndexArticle(ctx, article.Id)
+	return nil
 }
 
 // 根据分类查询文章
-func (a *ArticleService) ListByTypeId(typeId, searchUserId, currentUserId, page, limit int, title string) ([]*model.ArticleData, int64) {
-	query := articleDao.GetQueryArticleSql()
+func (a *ArticleService) ListByTypeId(ctx context.Context, typeId, searchUserId, currentUserId, page, limit int, title string) ([]*model.ArticleData, int64) {
+	query := articleDao.GetQueryArticleSql(ctx)
 	if title != "" {
-		query.Where("articles.title like ?", "%"+title+"%")
+		query.Where(mysql.Like("articles.title"), mysql.Contains(title))
 	}
-	typeObject := typeDao.GetById(typeId)
+	typeObject := typeDao.GetById(ctx, typeId)
 	if typeObject.ID == 0 {
 		return []*model.ArticleData{}, 0
 	}
@@ -411,7 +465,7 @@ func (a *ArticleService) ListByTypeId(typeId, searchUserId, currentUserId, page,
 			query.Where("articles.state = ?", constant.Published)
 		} else {
 			// 当前分类的父分类
-			parentType := typeDao.GetById(typeObject.ParentId)
+			parentType := typeDao.GetById(ctx, typeObject.ParentId)
 			if parentType.Title == "QA" {
 				query.Where("articles.state = ? or articles.state = ?", constant.Pending, constant.Resolved)
 			} else if parentType.Title == "文章" {
@@ -437,50 +491,79 @@ func (a *ArticleService) ListByTypeId(typeId, searchUserId, currentUserId, page,
 	}
 	defer rows.Close()
 
-	return buildResultArticles(rows), count
+	return buildResultArticles(ctx, rows), count
 }
 
-func (a *ArticleService) PublishArticle(reqArticle request.ReqArticle) (*model.Articles, error) {
+func (a *ArticleService) PublishArticle(ctx context.Context, reqArticle request.ReqArticle) (*model.Articles, error) {
 
 	id := reqArticle.ID
 	typeO := reqArticle.Type
 	flag := true
 	var typeS TypeService
-	types := typeS.GetById(typeO)
+	types := typeS.GetById(ctx, typeO)
 	if types.ID == 0 {
-		return nil, errors.New("分类不存在")
+		return nil, errs.NotFound.WithMsg("分类不存在")
 	}
 	if types.ParentId == 0 {
-		return nil, errors.New("不能选择一级分类")
+		return nil, errs.BadRequest.WithMsg("不能选择一级分类")
 	}
 
-	types = typeS.GetById(types.ParentId)
+	types = typeS.GetById(ctx, types.ParentId)
 	// 状态是否存在
 	state := reqArticle.State
 
-	if (types.Title == "文章") && state != constant.Published && state != constant.Draft {
-		return nil, errors.New("发布普通文章状态只能选择 草稿 / 发布")
-	} else if (types.Title == "QA") && state != constant.Draft && state != constant.Resolved && state != constant.Pending && state != constant.Published {
-		return nil, errors.New("发布QA文章状态只能选择 草稿 / 待解决 / 已解决")
+	if (types.Title == "文章") && state != constant.Published && state != constant.Draft && state != constant.Scheduled {
+		return nil, errs.BadRequest.WithMsg("发布普通文章状态只能选择 草稿 / 发布 / 定时发布")
+	} else if (types.Title == "QA") && state != constant.Draft && state != constant.Resolved && state != constant.Pending && state != constant.Published && state != constant.Scheduled {
+		return nil, errs.BadRequest.WithMsg("发布QA文章状态只能选择 草稿 / 待解决 / 已解决 / 定时发布")
 	} else if (types.Title == "QA") && state == constant.Published {
 		state = constant.Pending
 	}
 
+	// 定时发布的时间需晚于当前时间
+	var publishAt time.Time
+	if state == constant.Scheduled {
+		var err error
+		if publishAt, err = schedulePublishAt(reqArticle.PublishAt); err != nil {
+			return nil, err
+		}
+	}
+
 	// 只需要处理修改情况
+	oldState := 0
 	if id != 0 {
 		// QA 无法从已解决变更为待解决
 		flag = false
 		// 获取老文章
-		oldArticle := a.GetById(id)
-		oldTypeParentId := typeS.GetById(oldArticle.Type).ParentId
+		oldArticle := a.GetById(ctx, id)
+		oldState = oldArticle.State
+		oldTypeParentId := typeS.GetById(ctx, oldArticle.Type).ParentId
 		// 修改 一级分类不能修改,如果parent不同则修改了一级分类
 		newTypeParentId := types.ID
 		if oldTypeParentId != newTypeParentId {
-			return nil, errors.New("修改的分类只能属于同一级分类下")
+			return nil, errs.BadRequest.WithMsg("修改的分类只能属于同一级分类下")
 		}
 
 		if (types.Title == "QA") && (state == constant.Draft || state == constant.Pending) && oldArticle.State == constant.Resolved {
-			return nil, errors.New("不允许从已解决变更为草稿或者待解决")
+			return nil, errs.StateConflict.WithMsg("不允许从已解决变更为草稿或者待解决")
+		}
+		if state == constant.Scheduled && oldArticle.State != constant.Draft && oldArticle.State != constant.QADraft && oldArticle.State != constant.Scheduled {
+			return nil, errs.StateConflict.WithMsg("已发布的文章不能定时发布")
+		}
+	}
+
+	var seriesS SeriesService
+	if reqArticle.SeriesId != 0 {
+		if err := seriesS.CheckAdd(ctx, reqArticle.SeriesId, id, reqArticle.UserId); err != nil {
+			return nil, err
+		}
+	}
+
+	// 草稿只有作者可见,发布时再审核
+	if state != constant.Draft && state != constant.QADraft {
+		var moderationS ModerationService
+		if err := moderationS.Moderate(ctx, SceneArticle, reqArticle.UserId, reqArticle.ID, &reqArticle, &reqArticle.Title, &reqArticle.Abstract, &reqArticle.Content); err != nil {
+			return nil, err
 		}
 	}
 
@@ -494,40 +577,61 @@ func (a *ArticleService) PublishArticle(reqArticle request.ReqArticle) (*model.A
 		Abstract: reqArticle.Abstract,
 		Cover:    reqArticle.Cover,
 	}
+	if state == constant.Scheduled {
+		local := ltime.LocalTime(publishAt)
+		articleObject.PublishAt = &local
+	}
 	// 分开写，避免更新 0 值
 	if reqArticle.ID == 0 {
-		mysql.GetInstance().Save(&articleObject)
+		mysql.GetInstance().WithContext(ctx).Save(&articleObject)
 	} else {
-		model.Article().Where("user_id = ? and id = ?", articleObject.UserId, articleObject.ID).Updates(&articleObject)
+		model.Article(ctx).Where("user_id = ? and id = ?", articleObject.UserId, articleObject.ID).Updates(&articleObject)
 	}
 	jsonBody, _ := json.Marshal(articleObject)
-	log.Infof("用户id: %d,保存文章: %s", articleObject.UserId, jsonBody)
+	log.Ctx(ctx).Infof("用户id: %d,保存文章: %s", articleObject.UserId, jsonBody)
 	id = articleObject.ID
 	// 关联关系
 	db := model.ArticleTagRelation
-	db().Where("article_id = ?", id).Delete(nil)
+	db(ctx).Where("article_id = ?", id).Delete(nil)
 	var tags []model.ArticleTagRelations
 	for i := range reqArticle.Tags {
 		tags = append(tags, model.ArticleTagRelations{ArticleId: id, TagId: reqArticle.Tags[i], UserId: reqArticle.UserId})
 	}
-	db().Create(&tags)
-	var subscriptionService SubscriptionService
+	db(ctx).Create(&tags)
+	var searchS SearchService
+	searchS.IndexArticle(ctx, id)
+	var revisionS ArticleRevisionService
+	revisionS.Record(ctx, id, reqArticle.UserId, reqArticle.Summary)
+	// 定时发布的文章直接改为公开时,取消任务前取出任务中保存的 @ 用户
+	noticeUsers := reqArticle.NoticeUser
+	scheduledToPublic := oldState == constant.Scheduled && isPublicState(state)
+	if scheduledToPublic {
+		noticeUsers = uniqueInts(append(scheduledNoticeUsers(ctx, id), noticeUsers...))
+	}
+	if err := syncSchedule(ctx, id, state, publishAt, reqArticle.NoticeUser); err != nil {
+		log.Ctx(ctx).Warnf("同步定时发布任务失败,文章id: %d,err: %s", id, err.Error())
+		if state == constant.Scheduled {
+			return nil, err
+		}
+	}
+	// 系列中的文章公开时通知订阅了系列的用户
+	if reqArticle.SeriesId != 0 {
+		if _, _, err := seriesS.add(ctx, reqArticle.SeriesId, id, reqArticle.UserId); err != nil {
+			log.Ctx(ctx).Warnf("文章加入系列失败,文章id: %d,系列id: %d,err: %s", id, reqArticle.SeriesId, err.Error())
+		}
+	}
+	seriesS.ArticlePublished(ctx, id, oldState, state)
 	var d Draft
-	if flag {
-		var b SubscribeData
-		b.UserId = articleObject.UserId
-		b.ArticleId = articleObject.ID
-		b.CurrentBusinessId = articleObject.ID
-		b.SubscribeId = articleObject.UserId
-		subscriptionService.Do(event.UserFollowingEvent, b)
-		subscriptionService.NoticeUsers(event.ArticleAt, id, reqArticle.NoticeUser, b)
+	// 定时发布的文章在公开时通知
+	if (flag && state != constant.Scheduled) || scheduledToPublic {
+		noticeArticlePublished(ctx, id, articleObject.UserId, noticeUsers)
 	}
-	go d.DelDraft(reqArticle.UserId)
+	go d.DelDraft(ctx, reqArticle.UserId)
 	return articleObject, nil
 }
 
-func (a *ArticleService) LatestArticle() (result []*model.ArticleData) {
-	query := articleDao.GetQueryArticleSql()
+func (a *ArticleService) LatestArticle(ctx context.Context) (result []*model.ArticleData) {
+	query := articleDao.GetQueryArticleSql(ctx)
 	query.Where("articles.state in (?)", []int{constant.Published, constant.Resolved, constant.Pending})
 	rows, err := query.Order("articles.created_at desc").Limit(10).Rows()
 	if err != nil {
@@ -535,10 +639,10 @@ func (a *ArticleService) LatestArticle() (result []*model.ArticleData) {
 	}
 	defer rows.Close()
 
-	result = buildResultArticles(rows)
+	result = buildResultArticles(ctx, rows)
 	return result
 }
 
-func (a *ArticleService) UpdateTopNumber(article request.TopArticle) {
-	model.Article().Where("id = ?", article.Id).Updates(&article)
+func (a *ArticleService) UpdateTopNumber(ctx context.Context, article request.TopArticle) {
+	model.Article(ctx).Where("id = ?", article.Id).Updates(&article)
 }
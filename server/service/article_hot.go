@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"xhyovo.cn/community/pkg/cache"
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/server/model"
+)
+
+// 热门分数中各项的权重
+const (
+	hotViewWeight     = 1
+	hotLikeWeight     = 5
+	hotCommentWeight  = 10
+	hotAdoptionWeight = 20
+	// 时间衰减的指数,越大旧文章下降得越快
+	hotGravity = 1.5
+	// 每日、每周热门保留的文章数
+	hotPeriodLimit = 100
+)
+
+// 热门榜单的周期
+const (
+	HotDay  = "day"
+	HotWeek = "week"
+)
+
+var hotPeriods = map[string]time.Duration{
+	HotDay:  24 * time.Hour,
+	HotWeek: 7 * 24 * time.Hour,
+}
+
+type HotService struct {
+}
+
+// viewBuffer 浏览量先在内存中累计,定时写入数据库
+var viewBuffer = struct {
+	sync.Mutex
+	counts map[int]int
+}{counts: make(map[int]int)}
+
+type hotEntry struct {
+	ID     int
+	Type   int
+	Parent int // 父分类
+	Tags   []int
+	Score  float64
+}
+
+type hotRanking struct {
+	trending []hotEntry
+	periods  map[string][]hotEntry
+}
+
+// 最近一次计算的排行,未计算完成前为空
+var ranking atomic.Value
+
+func hotOptions() config.HotConfig {
+	if c := config.GetInstance(); c != nil {
+		return c.HotConfig
+	}
+	return config.HotConfig{ViewWindow: 30 * time.Minute, MaxAge: 30 * 24 * time.Hour}
+}
+
+// hotScore 互动越多分数越高,随发布时间衰减
+func hotScore(views, likes, comments, adoptions int, age time.Duration) float64 {
+	hours := age.Hours()
+	if hours < 0 {
+		hours = 0
+	}
+	return hotPoints(views, likes, comments, adoptions) / math.Pow(hours+2, hotGravity)
+}
+
+func hotPoints(views, likes, comments, adoptions int) float64 {
+	return float64(views*hotViewWeight + likes*hotLikeWeight + comments*hotCommentWeight + adoptions*hotAdoptionWeight)
+}
+
+// viewDay 浏览量按天统计的日期,如 20240101
+func viewDay(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
+
+// RecordView 记录一次浏览,同一用户、IP 在窗口时间内重复浏览只计一次
+func (*HotService) RecordView(ctx context.Context, articleId, userId int, ip string) {
+	key := fmt.Sprintf("%s%d:%d:%s", constant.ARTICLE_VIEW, articleId, userId, ip)
+	count, err := cache.GetInstance().Incr(key, hotOptions().ViewWindow)
+	if err != nil {
+		log.Ctx(ctx).Warnf("浏览量去重失败,文章id: %d,err: %s", articleId, err.Error())
+	} else if count > 1 {
+		return
+	}
+	viewBuffer.Lock()
+	viewBuffer.counts[articleId]++
+	viewBuffer.Unlock()
+}
+
+// FlushViews 把内存中累计的浏览量写入数据库,文章浏览量写入失败时放回内存等待下次写入
+func (*HotService) FlushViews(ctx context.Context) error {
+	viewBuffer.Lock()
+	counts := viewBuffer.counts
+	viewBuffer.counts = make(map[int]int)
+	viewBuffer.Unlock()
+
+	day := viewDay(time.Now())
+	var failed error
+	for articleId, n := range counts {
+		// 不修改 updated_at
+		err := model.Article(ctx).Where("id = ?", articleId).UpdateColumn("views", gorm.Expr("views + ?", n)).Error
+		if err != nil {
+			viewBuffer.Lock()
+			viewBuffer.counts[articleId] += n
+			viewBuffer.Unlock()
+			failed = err
+			continue
+		}
+		err = model.ArticleView(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "article_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]any{"views": gorm.Expr("article_views.views + ?", n)}),
+		}).Create(&model.ArticleViews{ArticleId: articleId, Day: day, Views: n}).Error
+		if err != nil {
+			log.Ctx(ctx).Warnf("记录文章每日浏览量失败,文章id: %d,err: %s", articleId, err.Error())
+		}
+	}
+	return failed
+}
+
+type hotCount struct {
+	Id    int
+	Count int
+}
+
+func hotCountMap(rows []hotCount) map[int]int {
+	m := make(map[int]int, len(rows))
+	for _, r := range rows {
+		m[r.Id] = r.Count
+	}
+	return m
+}
+
+// Refresh 重新计算热门排行
+func (s *HotService) Refresh(ctx context.Context) error {
+	now := time.Now()
+	trending, err := s.trending(ctx, now)
+	if err != nil {
+		return err
+	}
+	r := &hotRanking{trending: trending, periods: make(map[string][]hotEntry, len(hotPeriods))}
+	for period, d := range hotPeriods {
+		if r.periods[period], err = s.period(ctx, now, now.Add(-d)); err != nil {
+			return err
+		}
+	}
+	ranking.Store(r)
+	log.Ctx(ctx).Debugf("热门排行计算完成,文章数: %d,耗时: %s", len(trending), time.Since(now))
+	return nil
+}
+
+// trending 最近发布的公开文章按热门分数排序
+func (s *HotService) trending(ctx context.Context, now time.Time) ([]hotEntry, error) {
+	since := now.Add(-hotOptions().MaxAge)
+	var articles []model.Articles
+	err := model.Article(ctx).Select("id", "type", "like", "views", "created_at").
+		Where("state in ? and created_at >= ?", publicStates, since).Find(&articles).Error
+	if err != nil {
+		return nil, err
+	}
+	scope := model.Article(ctx).Select("id").Where("state in ? and created_at >= ?", publicStates, since)
+	var comments, adoptions []hotCount
+	model.Comment(ctx).Where("tenant_id = 0 and business_id in (?)", scope).
+		Select("business_id as id, count(*) as count").Group("business_id").Scan(&comments)
+	model.QaAdoption(ctx).Where("article_id in (?)", scope).
+		Select("article_id as id, count(*) as count").Group("article_id").Scan(&adoptions)
+	commentMap, adoptionMap := hotCountMap(comments), hotCountMap(adoptions)
+
+	entries := make([]hotEntry, 0, len(articles))
+	for _, a := range articles {
+		entries = append(entries, hotEntry{
+			ID:    a.ID,
+			Type:  a.Type,
+			Score: hotScore(a.Views, a.Like, commentMap[a.ID], adoptionMap[a.ID], now.Sub(time.Time(a.CreatedAt))),
+		})
+	}
+	s.fillTypeTags(ctx, entries)
+	sortHotEntries(entries)
+	return entries, nil
+}
+
+// period 时间段内的浏览、评论、采纳,点赞没有时间,只计算时间段内发布的文章的点赞;浏览量按天统计,起始日的浏览全部计入
+func (s *HotService) period(ctx context.Context, now, since time.Time) ([]hotEntry, error) {
+	var views, comments, adoptions []hotCount
+	err := model.ArticleView(ctx).Where("day >= ?", viewDay(since)).
+		Select("article_id as id, sum(views) as count").Group("article_id").Scan(&views).Error
+	if err != nil {
+		return nil, err
+	}
+	model.Comment(ctx).Where("tenant_id = 0 and created_at >= ?", since).
+		Select("business_id as id, count(*) as count").Group("business_id").Scan(&comments)
+	model.QaAdoption(ctx).Where("created_at >= ?", since).
+		Select("article_id as id, count(*) as count").Group("article_id").Scan(&adoptions)
+	viewMap, commentMap, adoptionMap := hotCountMap(views), hotCountMap(comments), hotCountMap(adoptions)
+
+	ids := make([]int, 0, len(viewMap)+len(commentMap))
+	for _, m := range []map[int]int{viewMap, commentMap, adoptionMap} {
+		for id := range m {
+			ids = append(ids, id)
+		}
+	}
+	var articles []model.Articles
+	model.Article(ctx).Select("id", "type", "like", "created_at").
+		Where("state in ? and (id in ? or created_at >= ?)", publicStates, ids, since).Find(&articles)
+
+	entries := make([]hotEntry, 0, len(articles))
+	for _, a := range articles {
+		likes := 0
+		if !time.Time(a.CreatedAt).Before(since) {
+			likes = a.Like
+		}
+		score := hotPoints(viewMap[a.ID], likes, commentMap[a.ID], adoptionMap[a.ID])
+		if score == 0 {
+			continue
+		}
+		entries = append(entries, hotEntry{ID: a.ID, Type: a.Type, Score: score})
+	}
+	sortHotEntries(entries)
+	if len(entries) > hotPeriodLimit {
+		entries = entries[:hotPeriodLimit]
+	}
+	return entries, nil
+}
+
+// fillTypeTags 填充父分类和标签,用于按分类、标签筛选
+func (s *HotService) fillTypeTags(ctx context.Context, entries []hotEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	var types []model.Types
+	model.Type(ctx).Select("id", "parent_id").Find(&types)
+	parents := make(map[int]int, len(types))
+	for _, t := range types {
+		parents[t.ID] = t.ParentId
+	}
+	ids := make([]int, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	var relations []model.ArticleTagRelations
+	model.ArticleTagRelation(ctx).Where("article_id in ?", ids).Select("article_id", "tag_id").Find(&relations)
+	tags := make(map[int][]int)
+	for _, r := range relations {
+		tags[r.ArticleId] = append(tags[r.ArticleId], r.TagId)
+	}
+	for i := range entries {
+		entries[i].Parent = parents[entries[i].Type]
+		entries[i].Tags = tags[entries[i].ID]
+	}
+}
+
+func sortHotEntries(entries []hotEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].ID > entries[j].ID
+	})
+}
+
+func currentRanking() (*hotRanking, error) {
+	r, _ := ranking.Load().(*hotRanking)
+	if r == nil {
+		return nil, errs.Unavailable.WithMsg("热门排行正在计算,请稍后再试")
+	}
+	return r, nil
+}
+
+// Trending 热门文章,typeId 可以是分类或父分类,tagId 为 0 时不按标签筛选
+func (s *HotService) Trending(ctx context.Context, typeId, tagId, page, limit int) ([]model.HotArticle, int64, error) {
+	r, err := currentRanking()
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := make([]hotEntry, 0)
+	for _, e := range r.trending {
+		if typeId != 0 && e.Type != typeId && e.Parent != typeId {
+			continue
+		}
+		if tagId != 0 && !containsInt(e.Tags, tagId) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	count := int64(len(entries))
+	start := (page - 1) * limit
+	if start >= len(entries) {
+		return make([]model.HotArticle, 0), count, nil
+	}
+	end := start + limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+	return s.articles(ctx, entries[start:end]), count, nil
+}
+
+// Hot 每日、每周热门
+func (s *HotService) Hot(ctx context.Context, period string, limit int) ([]model.HotArticle, error) {
+	if _, ok := hotPeriods[period]; !ok {
+		return nil, errs.BadRequest.WithMsg("热门周期只能是 day 或 week")
+	}
+	r, err := currentRanking()
+	if err != nil {
+		return nil, err
+	}
+	entries := r.periods[period]
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return s.articles(ctx, entries), nil
+}
+
+// articles 按排行顺序查询文章,排行计算后删除或转为私密的文章不返回
+func (s *HotService) articles(ctx context.Context, entries []hotEntry) []model.HotArticle {
+	list := make([]model.HotArticle, 0, len(entries))
+	if len(entries) == 0 {
+		return list
+	}
+	ids := make([]int, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	rows, err := articleDao.GetQueryArticleSql(ctx).
+		Where("articles.id in ? and articles.state in ?", ids, publicStates).Rows()
+	if err != nil {
+		log.Ctx(ctx).Warnf("查询热门文章失败,err: %s", err.Error())
+		return list
+	}
+	defer rows.Close()
+	articles := make(map[int]*model.ArticleData, len(entries))
+	for _, a := range buildResultArticles(ctx, rows) {
+		articles[a.ID] = a
+	}
+	for _, e := range entries {
+		if a, ok := articles[e.ID]; ok {
+			list = append(list, model.HotArticle{ArticleData: a, Score: e.Score})
+		}
+	}
+	return list
+}
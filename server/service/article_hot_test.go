@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"xhyovo.cn/community/pkg/cache"
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestHotScore(t *testing.T) {
+	if hotScore(10, 0, 0, 0, time.Hour) <= hotScore(10, 0, 0, 0, 48*time.Hour) {
+		t.Fatal("互动相同时新文章分数更高")
+	}
+	if hotScore(0, 0, 1, 0, time.Hour) <= hotScore(5, 0, 0, 0, time.Hour) {
+		t.Fatal("评论的权重高于浏览")
+	}
+}
+
+func TestHotRanking(t *testing.T) {
+	initSQLite(t)
+	cache.Init(config.CacheConfig{})
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1, FlagName: "go"})
+	db.Create(&model.Types{ID: 3, Title: "Java", ParentId: 1, FlagName: "java"})
+	db.Create(&model.ArticleTags{Id: 1, TagName: "并发", UserId: 1})
+	db.Create(&model.Articles{ID: 1, Title: "goroutine", Content: "a", UserId: 1, Type: 2, State: constant.Published})
+	db.Create(&model.Articles{ID: 2, Title: "jvm", Content: "b", UserId: 1, Type: 3, State: constant.Published})
+	db.Create(&model.Articles{ID: 3, Title: "草稿", Content: "c", UserId: 1, Type: 2, State: constant.Draft, Like: 10})
+	db.Create(&model.ArticleTagRelations{ArticleId: 1, TagId: 1, UserId: 1})
+
+	var s HotService
+	ranking.Store((*hotRanking)(nil))
+	if _, _, err := s.Trending(ctx, 0, 0, 1, 10); err == nil {
+		t.Fatal("排行计算前应返回错误")
+	}
+	// 同一用户、IP 重复浏览只计一次
+	for i := 0; i < 3; i++ {
+		s.RecordView(ctx, 1, 1, "127.0.0.1")
+	}
+	s.RecordView(ctx, 1, 1, "127.0.0.2")
+	s.RecordView(ctx, 1, 2, "127.0.0.1")
+	s.RecordView(ctx, 3, 1, "127.0.0.1")
+	if err := s.FlushViews(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.RecordView(ctx, 1, 3, "127.0.0.1")
+	if err := s.FlushViews(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var article model.Articles
+	db.First(&article, 1)
+	var daily model.ArticleViews
+	db.Where("article_id = ?", 1).First(&daily)
+	if article.Views != 4 || daily.Views != 4 || daily.Day != viewDay(time.Now()) {
+		t.Fatalf("浏览量: %d, 每日浏览量: %+v", article.Views, daily)
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	list, count, err := s.Trending(ctx, 0, 0, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || list[0].ID != 1 || list[1].ID != 2 || list[0].Views != 4 {
+		t.Fatalf("热门文章: %d, %+v", count, list)
+	}
+	if list, count, _ = s.Trending(ctx, 3, 0, 1, 10); count != 1 || list[0].ID != 2 {
+		t.Fatalf("按分类筛选: %d, %+v", count, list)
+	}
+	if list, count, _ = s.Trending(ctx, 1, 1, 1, 10); count != 1 || list[0].ID != 1 {
+		t.Fatalf("按父分类、标签筛选: %d, %+v", count, list)
+	}
+
+	day, err := s.Hot(ctx, HotDay, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有互动的文章不进入每日热门
+	if len(day) != 1 || day[0].ID != 1 {
+		t.Fatalf("每日热门: %+v", day)
+	}
+	if _, err = s.Hot(ctx, "month", 10); err == nil {
+		t.Fatal("不支持的热门周期")
+	}
+}
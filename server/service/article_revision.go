@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/diff"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/server/model"
+)
+
+// unified diff 每处修改前后保留的行数
+const revisionDiffContext = 3
+
+type ArticleRevisionService struct {
+}
+
+// Record 文章保存后记录一个版本,内容与上一个版本相同时不记录
+func (s *ArticleRevisionService) Record(ctx context.Context, articleId, userId int, summary string) {
+	var article model.Articles
+	model.Article(ctx).Select("id", "title", "abstract", "content", "type", "state").Where("id = ?", articleId).Limit(1).Find(&article)
+	if article.ID == 0 {
+		return
+	}
+	var prev model.ArticleRevisions
+	model.ArticleRevision(ctx).Where("article_id = ?", articleId).Order("version desc").Limit(1).Find(&prev)
+
+	revision := model.ArticleRevisions{
+		ArticleId: articleId,
+		Version:   prev.Version + 1,
+		UserId:    userId,
+		Title:     article.Title,
+		Abstract:  article.Abstract,
+		Content:   article.Content,
+		Type:      article.Type,
+		State:     article.State,
+		Summary:   summary,
+	}
+	revision.Added, revision.Deleted = diff.Stat(diff.Lines(diff.Split(prev.Content), diff.Split(article.Content)))
+	if prev.ID != 0 {
+		changed := changedFields(&prev, &revision)
+		if len(changed) == 0 {
+			return
+		}
+		if revision.Summary == "" {
+			revision.Summary = "修改了" + strings.Join(changed, "、")
+		}
+	} else if revision.Summary == "" {
+		revision.Summary = "创建文章"
+	}
+	if err := model.ArticleRevision(ctx).Create(&revision).Error; err != nil {
+		// 同时保存时版本号冲突,重新取版本号
+		var latest int
+		model.ArticleRevision(ctx).Where("article_id = ?", articleId).Select("coalesce(max(version), 0)").Scan(&latest)
+		revision.ID = 0
+		revision.Version = latest + 1
+		if err = model.ArticleRevision(ctx).Create(&revision).Error; err != nil {
+			log.Ctx(ctx).Warnf("记录文章版本失败,文章id: %d,err: %s", articleId, err.Error())
+		}
+	}
+}
+
+func changedFields(prev, cur *model.ArticleRevisions) []string {
+	var changed []string
+	if prev.Title != cur.Title {
+		changed = append(changed, "标题")
+	}
+	if prev.Abstract != cur.Abstract {
+		changed = append(changed, "摘要")
+	}
+	if prev.Content != cur.Content {
+		changed = append(changed, fmt.Sprintf("内容 (+%d -%d)", cur.Added, cur.Deleted))
+	}
+	if prev.Type != cur.Type {
+		changed = append(changed, "分类")
+	}
+	if prev.State != cur.State {
+		changed = append(changed, "状态")
+	}
+	return changed
+}
+
+// CheckAccess 作者和有文章查看权限的用户可以查看未删除文章的历史版本
+func (s *ArticleRevisionService) CheckAccess(ctx context.Context, articleId, userId int) error {
+	var article model.Articles
+	model.Article(ctx).Select("id", "user_id").Where("id = ?", articleId).Limit(1).Find(&article)
+	if article.ID == 0 {
+		return errs.NotFound.WithMsg("文章不存在")
+	}
+	if article.UserId == userId {
+		return nil
+	}
+	var roleS RoleService
+	ok, err := roleS.HasPermission(ctx, userId, constant.PermArticleView)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.Forbidden.WithMsg("只有作者可以查看文章的历史版本")
+	}
+	return nil
+}
+
+// PageRevisions 文章的版本列表,不包含内容,已删除文章的版本同样返回
+func (s *ArticleRevisionService) PageRevisions(ctx context.Context, articleId, page, limit int) (revisions []model.ArticleRevisions, count int64) {
+	db := model.ArticleRevision(ctx).Where("article_id = ?", articleId)
+	db.Count(&count)
+	if count == 0 {
+		return make([]model.ArticleRevisions, 0), 0
+	}
+	db.Omit("content", "abstract").Order("version desc").Limit(limit).Offset((page - 1) * limit).Find(&revisions)
+	userIds := make([]int, 0, len(revisions))
+	for _, r := range revisions {
+		userIds = append(userIds, r.UserId)
+	}
+	var users []model.Users
+	model.User(ctx).Where("id in ?", userIds).Select("id", "name").Find(&users)
+	names := make(map[int]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+	for i := range revisions {
+		revisions[i].UserName = names[revisions[i].UserId]
+	}
+	return
+}
+
+// GetRevision 获取某个版本的完整内容
+func (s *ArticleRevisionService) GetRevision(ctx context.Context, articleId, version int) (*model.ArticleRevisions, error) {
+	var revision model.ArticleRevisions
+	model.ArticleRevision(ctx).Where("article_id = ? and version = ?", articleId, version).Limit(1).Find(&revision)
+	if revision.ID == 0 {
+		return nil, errs.NotFound.WithMsg("版本不存在")
+	}
+	var user model.Users
+	model.User(ctx).Where("id = ?", revision.UserId).Select("id", "name").Limit(1).Find(&user)
+	revision.UserName = user.Name
+	return &revision, nil
+}
+
+// Diff 比较两个版本,from 可以大于 to
+func (s *ArticleRevisionService) Diff(ctx context.Context, articleId, from, to int) (*model.RevisionDiff, error) {
+	a, err := s.GetRevision(ctx, articleId, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.GetRevision(ctx, articleId, to)
+	if err != nil {
+		return nil, err
+	}
+	fromName, toName := fmt.Sprintf("v%d", from), fmt.Sprintf("v%d", to)
+	d := &model.RevisionDiff{
+		From:     from,
+		To:       to,
+		Title:    diff.Unified(fromName, toName, a.Title, b.Title, revisionDiffContext),
+		Abstract: diff.Unified(fromName, toName, a.Abstract, b.Abstract, revisionDiffContext),
+		Content:  diff.Unified(fromName, toName, a.Content, b.Content, revisionDiffContext),
+	}
+	d.Added, d.Deleted = diff.Stat(diff.Lines(diff.Split(a.Content), diff.Split(b.Content)))
+	return d, nil
+}
+
+// articleRestore 恢复的内容,需要人工审核时作为 payload 保存,审核通过后重新恢复
+type articleRestore struct {
+	ArticleId int    `json:"articleId"`
+	Version   int    `json:"version"`
+	UserId    int    `json:"userId"`
+	Title     string `json:"title"`
+	Abstract  string `json:"abstract"`
+	Content   string `json:"content"`
+}
+
+// Restore 作者把文章的标题、摘要、内容恢复为某个版本,并记录为新版本;分类和状态不变
+func (s *ArticleRevisionService) Restore(ctx context.Context, articleId, version, userId int) error {
+	var article model.Articles
+	model.Article(ctx).Select("id", "user_id", "state").Where("id = ?", articleId).Limit(1).Find(&article)
+	if article.ID == 0 {
+		return errs.NotFound.WithMsg("文章不存在")
+	}
+	if article.UserId != userId {
+		return errs.Forbidden.WithMsg("只有作者可以恢复文章的历史版本")
+	}
+	revision, err := s.GetRevision(ctx, articleId, version)
+	if err != nil {
+		return err
+	}
+	r := articleRestore{ArticleId: articleId, Version: version, UserId: userId, Title: revision.Title, Abstract: revision.Abstract, Content: revision.Content}
+	// 与发布文章一致,草稿只有作者可见,其他状态恢复的内容需要审核
+	if article.State != constant.Draft && article.State != constant.QADraft {
+		var moderationS ModerationService
+		if err = moderationS.Moderate(ctx, SceneArticleRestore, userId, articleId, &r, &r.Title, &r.Abstract, &r.Content); err != nil {
+			return err
+		}
+	}
+	return s.restore(ctx, r)
+}
+
+func (s *ArticleRevisionService) restore(ctx context.Context, r articleRestore) error {
+	err := model.Article(ctx).Where("id = ?", r.ArticleId).Select("title", "abstract", "content").
+		Updates(&model.Articles{Title: r.Title, Abstract: r.Abstract, Content: r.Content}).Error
+	if err != nil {
+		return err
+	}
+	log.Ctx(ctx).Infof("用户id: %d,恢复文章: %d 到版本: %d", r.UserId, r.ArticleId, r.Version)
+	s.Record(ctx, r.ArticleId, r.UserId, fmt.Sprintf("恢复到版本 %d", r.Version))
+	var searchS SearchService
+	searchS.IndexArticle(ctx, r.ArticleId)
+	return nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/moderation"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestArticleRevision(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Users{ID: 2, Name: "other"})
+	db.Create(&model.Articles{ID: 1, Title: "v1", Content: "a\nb\nc", UserId: 1, Type: 1, State: constant.Published})
+
+	var s ArticleRevisionService
+	s.Record(ctx, 1, 1, "")
+	s.Record(ctx, 1, 1, "")
+	db.Model(&model.Articles{}).Where("id = 1").Updates(map[string]any{"title": "v2", "content": "a\nB\nc\nd"})
+	s.Record(ctx, 1, 1, "")
+
+	list, count := s.PageRevisions(ctx, 1, 1, 10)
+	if count != 2 || list[0].Version != 2 || list[0].UserName != "xhy" {
+		t.Fatalf("内容未变化时不记录版本: %d, %+v", count, list)
+	}
+	if list[1].Summary != "创建文章" || list[0].Summary != "修改了标题、内容 (+2 -1)" {
+		t.Fatalf("修改说明: %q, %q", list[1].Summary, list[0].Summary)
+	}
+
+	d, err := s.Diff(ctx, 1, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(d.Content, "-b\n+B\n c\n+d\n") || d.Title == "" || d.Abstract != "" {
+		t.Fatalf("diff: %+v", d)
+	}
+
+	if err = s.CheckAccess(ctx, 1, 2); err == nil {
+		t.Fatal("非作者不能查看历史版本")
+	}
+	if err = s.Restore(ctx, 1, 1, 2); err == nil {
+		t.Fatal("非作者不能恢复")
+	}
+	if err = s.Restore(ctx, 1, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	var article model.Articles
+	db.First(&article, 1)
+	if article.Title != "v1" || article.Content != "a\nb\nc" {
+		t.Fatalf("恢复后: %+v", article)
+	}
+	latest, _ := s.GetRevision(ctx, 1, 3)
+	if latest == nil || latest.Summary != "恢复到版本 1" {
+		t.Fatalf("恢复应记录为新版本: %+v", latest)
+	}
+
+	// 文章删除后版本保留
+	var a ArticleService
+	a.Delete(ctx, 1)
+	if err = s.CheckAccess(ctx, 1, 1); err == nil {
+		t.Fatal("已删除的文章只有管理后台可以查看")
+	}
+	if _, count = s.PageRevisions(ctx, 1, 1, 10); count != 3 {
+		t.Fatalf("删除后的版本数: %d", count)
+	}
+}
+
+func TestArticleRevisionRestoreModerated(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Articles{ID: 1, Title: "旧标题", Content: "来赌博", UserId: 1, Type: 1, State: constant.Published})
+	var s ArticleRevisionService
+	s.Record(ctx, 1, 1, "")
+	db.Model(&model.Articles{}).Where("id = 1").Update("content", "干净的内容")
+	s.Record(ctx, 1, 1, "")
+
+	var m ModerationService
+	for _, w := range []model.SensitiveWords{{Word: "赌博", Action: moderation.ActionReject}, {Word: "加微信", Action: moderation.ActionReview}} {
+		if err := m.SaveWord(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Restore(ctx, 1, 1, 1); !errors.Is(err, errs.ContentRejected) {
+		t.Fatalf("恢复的内容需要审核: %v", err)
+	}
+	var article model.Articles
+	db.First(&article, 1)
+	if article.Content != "干净的内容" {
+		t.Fatalf("审核不通过时不恢复: %q", article.Content)
+	}
+
+	// 需要人工审核时,审核通过后再恢复
+	db.Model(&model.Articles{}).Where("id = 1").Update("content", "加微信")
+	s.Record(ctx, 1, 1, "")
+	db.Model(&model.Articles{}).Where("id = 1").Update("content", "干净的内容")
+	s.Record(ctx, 1, 1, "")
+	if err := s.Restore(ctx, 1, 3, 1); !errors.Is(err, errs.UnderReview) {
+		t.Fatalf("review: %v", err)
+	}
+	pending, _ := m.PageRecords(ctx, ReviewPending, "", 1, 10)
+	if len(pending) != 1 {
+		t.Fatalf("待审核: %+v", pending)
+	}
+	if err := m.Approve(ctx, pending[0].ID, 2); err != nil {
+		t.Fatal(err)
+	}
+	db.First(&article, 1)
+	if article.Content != "加微信" {
+		t.Fatalf("审核通过后恢复: %q", article.Content)
+	}
+
+	// 草稿只有作者可见,不审核
+	db.Model(&model.Articles{}).Where("id = 1").Update("state", constant.Draft)
+	if err := s.Restore(ctx, 1, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/delay"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
+	ltime "xhyovo.cn/community/pkg/time"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/service/event"
+)
+
+// 定时发布的延迟任务类型
+const jobArticlePublish = "article.publish"
+
+type articlePublishJob struct {
+	ArticleId   int   `json:"articleId"`
+	NoticeUsers []int `json:"noticeUsers"` // 发布时 @ 的用户
+}
+
+func init() {
+	delay.Register(jobArticlePublish, articleScheduledPublish)
+}
+
+func articlePublishJobKey(articleId int) string {
+	return fmt.Sprintf("%s:%d", jobArticlePublish, articleId)
+}
+
+// schedulePublishAt 校验定时发布时间;LocalTime 解析 json 时不带时区,按服务器时区解释
+func schedulePublishAt(t *ltime.LocalTime) (time.Time, error) {
+	if t == nil {
+		return time.Time{}, errs.BadRequest.WithMsg("定时发布时间不能为空")
+	}
+	v := time.Time(*t)
+	publishAt := time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), 0, time.Local)
+	if !publishAt.After(time.Now()) {
+		return time.Time{}, errs.BadRequest.WithMsg("定时发布时间必须晚于当前时间")
+	}
+	return publishAt, nil
+}
+
+// syncSchedule 文章保存后同步定时发布任务,非定时发布状态时取消任务
+func syncSchedule(ctx context.Context, articleId, state int, publishAt time.Time, noticeUsers []int) error {
+	if state == constant.Scheduled {
+		return delay.GetInstant().Schedule(articlePublishJobKey(articleId), jobArticlePublish, publishAt, articlePublishJob{ArticleId: articleId, NoticeUsers: noticeUsers})
+	}
+	model.Article(ctx).Where("id = ? and publish_at is not null", articleId).Update("publish_at", nil)
+	return delay.GetInstant().Cancel(articlePublishJobKey(articleId))
+}
+
+// scheduledState 到发布时间后的状态,QA 为待解决
+func scheduledState(ctx context.Context, typeId int) int {
+	var typeS TypeService
+	if typeS.GetById(ctx, typeS.GetById(ctx, typeId).ParentId).Title == "QA" {
+		return constant.Pending
+	}
+	return constant.Published
+}
+
+// articleScheduledPublish 到发布时间后发布文章,只有状态仍为定时发布时才修改,任务重复执行时不会重复通知
+func articleScheduledPublish(ctx context.Context, job articlePublishJob) error {
+	var article model.Articles
+	model.Article(ctx).Select("id", "user_id", "type").Where("id = ? and state = ?", job.ArticleId, constant.Scheduled).Limit(1).Find(&article)
+	if article.ID == 0 {
+		return nil
+	}
+	// 发布时间作为文章的创建时间,按时间排序时出现在最新的位置
+	state := scheduledState(ctx, article.Type)
+	tx := model.Article(ctx).Where("id = ? and state = ?", article.ID, constant.Scheduled).Updates(map[string]any{
+		"state":      state,
+		"publish_at": nil,
+		"created_at": time.Now(),
+	})
+	if tx.Error != nil || tx.RowsAffected == 0 {
+		return tx.Error
+	}
+	log.Ctx(ctx).Infof("定时发布文章,文章id: %d", article.ID)
+
+	var searchS SearchService
+	searchS.IndexArticle(ctx, article.ID)
+	var revisionS ArticleRevisionService
+	revisionS.Record(ctx, article.ID, article.UserId, "定时发布")
+	var seriesS SeriesService
+	seriesS.ArticlePublished(ctx, article.ID, constant.Scheduled, state)
+	noticeArticlePublished(ctx, article.ID, article.UserId, job.NoticeUsers)
+	return nil
+}
+
+// noticeArticlePublished 文章公开时通知关注作者的用户和 @ 的用户,直接发布和定时发布共用
+func noticeArticlePublished(ctx context.Context, articleId, userId int, noticeUsers []int) {
+	var subscriptionService SubscriptionService
+	var b SubscribeData
+	b.UserId = userId
+	b.ArticleId = articleId
+	b.CurrentBusinessId = articleId
+	b.SubscribeId = userId
+	subscriptionService.Do(ctx, event.UserFollowingEvent, b)
+	subscriptionService.NoticeUsers(ctx, event.ArticleAt, articleId, noticeUsers, b)
+}
+
+// scheduledNoticeUsers 定时发布任务中保存的 @ 用户,任务取消前读取
+func scheduledNoticeUsers(ctx context.Context, articleId int) []int {
+	var job articlePublishJob
+	if _, err := delay.GetInstant().Payload(articlePublishJobKey(articleId), &job); err != nil {
+		log.Ctx(ctx).Warnf("读取定时发布任务失败,文章id: %d,err: %s", articleId, err.Error())
+	}
+	return job.NoticeUsers
+}
+
+// InitArticleTasks 启动时为缺少任务的定时发布文章补上任务,已过发布时间的立即发布
+func InitArticleTasks(ctx context.Context) {
+	var articles []model.Articles
+	model.Article(ctx).Select("id", "publish_at").Where("state = ?", constant.Scheduled).Find(&articles)
+	for _, article := range articles {
+		exist, err := delay.GetInstant().Exists(articlePublishJobKey(article.ID))
+		if err != nil {
+			log.Ctx(ctx).Warnf("查询定时发布任务失败,文章id: %d,err: %s", article.ID, err.Error())
+			continue
+		}
+		if exist {
+			continue
+		}
+		runAt := time.Now()
+		if article.PublishAt != nil {
+			runAt = time.Time(*article.PublishAt)
+		}
+		if err = syncSchedule(ctx, article.ID, constant.Scheduled, runAt, nil); err != nil {
+			log.Ctx(ctx).Warnf("定时发布任务加入延迟队列失败,文章id: %d,err: %s", article.ID, err.Error())
+		}
+	}
+}
+
+// PageScheduled 用户定时发布的文章,按发布时间排序
+func (a *ArticleService) PageScheduled(ctx context.Context, userId, page, limit int) (articles []model.Articles, count int64) {
+	db := model.Article(ctx).Where("user_id = ? and state = ?", userId, constant.Scheduled)
+	db.Count(&count)
+	if count == 0 {
+		return make([]model.Articles, 0), 0
+	}
+	db.Select("id", "title", "abstract", "cover", "type", "state", "publish_at", "created_at", "updated_at").
+		Order("publish_at").Limit(limit).Offset((page - 1) * limit).Find(&articles)
+	return
+}
+
+func (a *ArticleService) checkScheduled(ctx context.Context, articleId, userId int) error {
+	var count int64
+	model.Article(ctx).Where("id = ? and user_id = ? and state = ?", articleId, userId, constant.Scheduled).Count(&count)
+	if count == 0 {
+		return errs.NotFound.WithMsg("定时发布的文章不存在")
+	}
+	return nil
+}
+
+// Reschedule 修改定时发布时间
+func (a *ArticleService) Reschedule(ctx context.Context, articleId, userId int, t *ltime.LocalTime) error {
+	publishAt, err := schedulePublishAt(t)
+	if err != nil {
+		return err
+	}
+	if err = a.checkScheduled(ctx, articleId, userId); err != nil {
+		return err
+	}
+	local := ltime.LocalTime(publishAt)
+	if err = model.Article(ctx).Where("id = ?", articleId).Update("publish_at", &local).Error; err != nil {
+		return err
+	}
+	ok, err := delay.GetInstant().Reschedule(articlePublishJobKey(articleId), publishAt)
+	if err == nil && !ok {
+		err = syncSchedule(ctx, articleId, constant.Scheduled, publishAt, nil)
+	}
+	if err != nil {
+		return err
+	}
+	log.Ctx(ctx).Infof("用户id: %d,修改文章: %d 定时发布时间为: %s", userId, articleId, publishAt.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// CancelSchedule 取消定时发布,文章转为草稿
+func (a *ArticleService) CancelSchedule(ctx context.Context, articleId, userId int) error {
+	if err := a.checkScheduled(ctx, articleId, userId); err != nil {
+		return err
+	}
+	tx := model.Article(ctx).Where("id = ? and state = ?", articleId, constant.Scheduled).Updates(map[string]any{
+		"state":      constant.Draft,
+		"publish_at": nil,
+	})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	// 任务已经执行,文章已发布
+	if tx.RowsAffected == 0 {
+		return errs.StateConflict.WithMsg("文章已发布,不能取消定时发布")
+	}
+	log.Ctx(ctx).Infof("用户id: %d,取消文章: %d 的定时发布", userId, articleId)
+	return delay.GetInstant().Cancel(articlePublishJobKey(articleId))
+}
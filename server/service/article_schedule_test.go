@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/delay"
+	"xhyovo.cn/community/pkg/lifecycle"
+	"xhyovo.cn/community/pkg/mysql"
+	ltime "xhyovo.cn/community/pkg/time"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+	"xhyovo.cn/community/server/service/event"
+)
+
+func TestScheduledPublish(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "公告", ParentId: 1, FlagName: "notice"})
+	db.Create(&model.Types{ID: 3, Title: "QA", FlagName: "qa"})
+	db.Create(&model.Types{ID: 4, Title: "Go", ParentId: 3, FlagName: "go"})
+
+	var a ArticleService
+	past := ltime.LocalTime(time.Now().Add(-time.Minute))
+	req := request.ReqArticle{Title: "公告", Content: "周五开会", UserId: 1, Type: 2, State: constant.Scheduled, PublishAt: &past}
+	if _, err := a.PublishArticle(ctx, req); err == nil {
+		t.Fatal("定时发布时间必须晚于当前时间")
+	}
+	future := ltime.LocalTime(time.Now().Add(time.Hour))
+	req.PublishAt = &future
+	article, err := a.PublishArticle(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exist, _ := delay.GetInstant().Exists(articlePublishJobKey(article.ID)); !exist {
+		t.Fatal("应加入定时发布任务")
+	}
+	if _, err = a.GetArticleData(ctx, article.ID, 2); err == nil {
+		t.Fatal("定时发布前其他用户不可见")
+	}
+	if list, count := a.PageScheduled(ctx, 1, 1, 10); count != 1 || list[0].PublishAt == nil {
+		t.Fatalf("定时发布列表: %d, %+v", count, list)
+	}
+	if err = a.Reschedule(ctx, article.ID, 1, &past); err == nil {
+		t.Fatal("修改的发布时间必须晚于当前时间")
+	}
+	later := ltime.LocalTime(time.Now().Add(2 * time.Hour))
+	if err = a.Reschedule(ctx, article.ID, 1, &later); err != nil {
+		t.Fatal(err)
+	}
+
+	// 任务重复执行时只发布一次
+	for i := 0; i < 2; i++ {
+		if err = articleScheduledPublish(ctx, articlePublishJob{ArticleId: article.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var published model.Articles
+	db.First(&published, article.ID)
+	if published.State != constant.Published || published.PublishAt != nil {
+		t.Fatalf("发布后: %+v", published)
+	}
+	if err = a.CancelSchedule(ctx, article.ID, 1); err == nil {
+		t.Fatal("已发布的文章不能取消定时发布")
+	}
+
+	// QA 到时间后为待解决,取消后转为草稿
+	qa, err := a.PublishArticle(ctx, request.ReqArticle{Title: "问题", Content: "如何调试", UserId: 1, Type: 4, State: constant.Scheduled, PublishAt: &future})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheduledState(ctx, 4) != constant.Pending {
+		t.Fatal("QA 定时发布后应为待解决")
+	}
+	if err = a.CancelSchedule(ctx, qa.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	var canceled model.Articles
+	db.First(&canceled, qa.ID)
+	if canceled.State != constant.Draft {
+		t.Fatalf("取消后: %+v", canceled)
+	}
+	if exist, _ := delay.GetInstant().Exists(articlePublishJobKey(qa.ID)); exist {
+		t.Fatal("取消后应删除任务")
+	}
+}
+
+func TestScheduledArticlePublishedEarly(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&[]model.Users{{ID: 1, Name: "xhy"}, {ID: 2, Name: "mentioned"}, {ID: 3, Name: "follower"}})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "公告", ParentId: 1, FlagName: "notice"})
+	db.Create(&model.Subscriptions{SubscriberId: 3, SendId: 1, EventId: event.UserFollowingEvent, BusinessId: 1})
+	notified := func(eventId, userId int) int64 {
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := lifecycle.Wait(waitCtx); err != nil {
+			t.Fatal(err)
+		}
+		var count int64
+		model.MessageState(ctx).Where("event_id = ? and "+mysql.Quote("to")+" = ?", eventId, userId).Count(&count)
+		return count
+	}
+
+	var a ArticleService
+	future := ltime.LocalTime(time.Now().Add(time.Hour))
+	req := request.ReqArticle{Title: "公告", Content: "周五开会", UserId: 1, Type: 2, State: constant.Scheduled, PublishAt: &future, NoticeUser: []int{2}}
+	article, err := a.PublishArticle(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notified(event.ArticleAt, 2) != 0 || notified(event.UserFollowingEvent, 3) != 0 {
+		t.Fatal("定时发布前不通知")
+	}
+
+	// 修改为直接发布时通知,@ 的用户来自取消的定时任务
+	req.ID = article.ID
+	req.State = constant.Published
+	req.PublishAt = nil
+	req.NoticeUser = nil
+	if _, err = a.PublishArticle(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if exist, _ := delay.GetInstant().Exists(articlePublishJobKey(article.ID)); exist {
+		t.Fatal("直接发布后应删除定时任务")
+	}
+	if n := notified(event.ArticleAt, 2); n != 1 {
+		t.Fatalf("@ 的用户收到的通知: %d", n)
+	}
+	if n := notified(event.UserFollowingEvent, 3); n != 1 {
+		t.Fatalf("关注作者的用户收到的通知: %d", n)
+	}
+
+	// 再次保存已发布的文章不重复通知
+	if _, err = a.PublishArticle(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if notified(event.ArticleAt, 2) != 1 || notified(event.UserFollowingEvent, 3) != 1 {
+		t.Fatal("已发布的文章不重复通知")
+	}
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
+	"xhyovo.cn/community/pkg/lifecycle"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/service/event"
+)
+
+const seriesUpdateTemp = "你订阅的系列 %s 发布了新的文章: %s"
+
+type SeriesService struct {
+}
+
+// 所有人可见的文章状态
+var publicStates = []int{constant.Published, constant.Pending, constant.Resolved}
+
+func isPublicState(state int) bool {
+	return containsInt(publicStates, state)
+}
+
+// Save 创建、修改系列
+func (s *SeriesService) Save(ctx context.Context, series *model.ArticleSeries) error {
+	if series.ID == 0 {
+		return model.Series(ctx).Create(series).Error
+	}
+	tx := model.Series(ctx).Where("id = ? and user_id = ?", series.ID, series.UserId).
+		Select("title", "description", "cover").Updates(series)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NotFound.WithMsg("系列不存在")
+	}
+	return nil
+}
+
+// Delete 删除系列,系列中的文章保留
+func (s *SeriesService) Delete(ctx context.Context, id, userId int) error {
+	tx := model.Series(ctx).Where("id = ? and user_id = ?", id, userId).Delete(&model.ArticleSeries{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NotFound.WithMsg("系列不存在")
+	}
+	log.Ctx(ctx).Infof("用户id: %d,删除系列: %d", userId, id)
+	return model.ArticleRelation(ctx).Unscoped().Where("root_id = ?", id).Delete(&model.ArticleRelations{}).Error
+}
+
+// Page 系列列表,userId 不为 0 时只查询该用户的系列
+func (s *SeriesService) Page(ctx context.Context, userId, page, limit int) (list []model.ArticleSeries, count int64) {
+	db := model.Series(ctx)
+	if userId != 0 {
+		db = db.Where("user_id = ?", userId)
+	}
+	db.Count(&count)
+	if count == 0 {
+		return make([]model.ArticleSeries, 0), 0
+	}
+	db.Order("id desc").Limit(limit).Offset((page - 1) * limit).Find(&list)
+
+	ids := make([]int, 0, len(list))
+	userIds := make([]int, 0, len(list))
+	for i := range list {
+		ids = append(ids, list[i].ID)
+		userIds = append(userIds, list[i].UserId)
+	}
+	var counts []struct {
+		RootId int
+		Count  int
+	}
+	model.ArticleRelation(ctx).Joins("JOIN articles ON articles.id = article_relations.article_id AND articles.deleted_at IS NULL").
+		Where("article_relations.root_id in ? and articles.state in ?", ids, publicStates).
+		Select("article_relations.root_id, count(*) as count").Group("article_relations.root_id").Scan(&counts)
+	countMap := make(map[int]int, len(counts))
+	for _, c := range counts {
+		countMap[c.RootId] = c.Count
+	}
+	users := s.users(ctx, userIds)
+	for i := range list {
+		list[i].Articles = countMap[list[i].ID]
+		list[i].User = users[list[i].UserId]
+	}
+	return
+}
+
+func (s *SeriesService) users(ctx context.Context, ids []int) map[int]model.UserSimple {
+	var users []model.UserSimple
+	model.User(ctx).Where("id in ?", ids).Select("id", "name", "avatar").Find(&users)
+	m := make(map[int]model.UserSimple, len(users))
+	for _, u := range users {
+		m[u.UId] = u
+	}
+	return m
+}
+
+// Detail 系列详情,作者可以看到系列中的草稿
+func (s *SeriesService) Detail(ctx context.Context, id, userId int) (*model.SeriesDetail, error) {
+	var series model.ArticleSeries
+	model.Series(ctx).Where("id = ?", id).Limit(1).Find(&series)
+	if series.ID == 0 {
+		return nil, errs.NotFound.WithMsg("系列不存在")
+	}
+	series.User = s.users(ctx, []int{series.UserId})[series.UserId]
+	articles := s.articles(ctx, id, series.UserId == userId)
+	for i := range articles {
+		articles[i].StateName = constant.GetArticleName(ctx, articles[i].State)
+	}
+	series.Articles = len(articles)
+	return &model.SeriesDetail{ArticleSeries: series, List: articles}, nil
+}
+
+// articles 系列中按顺序排列的文章,all 为 false 时只返回公开的文章
+func (s *SeriesService) articles(ctx context.Context, seriesId int, all bool) []model.SeriesArticle {
+	articles := make([]model.SeriesArticle, 0)
+	db := model.ArticleRelation(ctx).Joins("JOIN articles ON articles.id = article_relations.article_id AND articles.deleted_at IS NULL").
+		Where("article_relations.root_id = ?", seriesId)
+	if !all {
+		db = db.Where("articles.state in ?", publicStates)
+	}
+	db.Select("articles.id, articles.title, articles.abstract, articles.state, articles.created_at, article_relations.sort").
+		Order("article_relations.sort, article_relations.id").Scan(&articles)
+	return articles
+}
+
+func (s *SeriesService) checkOwner(ctx context.Context, seriesId, userId int) error {
+	var count int64
+	model.Series(ctx).Where("id = ? and user_id = ?", seriesId, userId).Count(&count)
+	if count == 0 {
+		return errs.NotFound.WithMsg("系列不存在")
+	}
+	return nil
+}
+
+// seriesOf 文章所在的系列 id,不在系列中时返回 0
+func (s *SeriesService) seriesOf(ctx context.Context, articleId int) int {
+	var relation model.ArticleRelations
+	model.ArticleRelation(ctx).Select("root_id").Where("article_id = ?", articleId).Limit(1).Find(&relation)
+	return relation.RootId
+}
+
+// CheckAdd 校验文章能否加入系列,一篇文章只能属于一个系列;articleId 为 0 时表示新文章
+func (s *SeriesService) CheckAdd(ctx context.Context, seriesId, articleId, userId int) error {
+	if err := s.checkOwner(ctx, seriesId, userId); err != nil {
+		return err
+	}
+	if articleId == 0 {
+		return nil
+	}
+	if current := s.seriesOf(ctx, articleId); current != 0 && current != seriesId {
+		return errs.Conflict.WithMsg("文章已在其他系列中")
+	}
+	return nil
+}
+
+// AddArticle 把自己的文章加到系列末尾,文章已公开时通知订阅了系列的用户
+func (s *SeriesService) AddArticle(ctx context.Context, seriesId, articleId, userId int) error {
+	state, added, err := s.add(ctx, seriesId, articleId, userId)
+	if err != nil {
+		return err
+	}
+	if added && isPublicState(state) {
+		s.notify(ctx, seriesId, articleId)
+	}
+	return nil
+}
+
+// add 把文章加到系列末尾,返回文章的状态和是否新加入;不通知,发布文章时由 ArticlePublished 通知
+func (s *SeriesService) add(ctx context.Context, seriesId, articleId, userId int) (int, bool, error) {
+	if err := s.CheckAdd(ctx, seriesId, articleId, userId); err != nil {
+		return 0, false, err
+	}
+	var article model.Articles
+	model.Article(ctx).Select("id", "state").Where("id = ? and user_id = ?", articleId, userId).Limit(1).Find(&article)
+	if article.ID == 0 {
+		return 0, false, errs.NotFound.WithMsg("文章不存在")
+	}
+	if s.seriesOf(ctx, articleId) == seriesId {
+		return article.State, false, nil
+	}
+	var last int
+	model.ArticleRelation(ctx).Where("root_id = ?", seriesId).Select("coalesce(max(sort), 0)").Scan(&last)
+	if err := model.ArticleRelation(ctx).Create(&model.ArticleRelations{RootId: seriesId, ArticleId: articleId, Sort: last + 1}).Error; err != nil {
+		return 0, false, err
+	}
+	log.Ctx(ctx).Infof("用户id: %d,文章: %d 加入系列: %d", userId, articleId, seriesId)
+	return article.State, true, nil
+}
+
+// RemoveArticle 把文章移出系列
+func (s *SeriesService) RemoveArticle(ctx context.Context, seriesId, articleId, userId int) error {
+	if err := s.checkOwner(ctx, seriesId, userId); err != nil {
+		return err
+	}
+	tx := model.ArticleRelation(ctx).Unscoped().Where("root_id = ? and article_id = ?", seriesId, articleId).Delete(&model.ArticleRelations{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NotFound.WithMsg("文章不在该系列中")
+	}
+	return nil
+}
+
+// RemoveArticleRelations 文章删除后移出系列
+func (s *SeriesService) RemoveArticleRelations(ctx context.Context, articleId int) {
+	model.ArticleRelation(ctx).Unscoped().Where("article_id = ?", articleId).Delete(&model.ArticleRelations{})
+}
+
+// Sort 调整系列中文章的顺序,articleIds 需要包含系列中的所有文章
+func (s *SeriesService) Sort(ctx context.Context, seriesId, userId int, articleIds []int) error {
+	if err := s.checkOwner(ctx, seriesId, userId); err != nil {
+		return err
+	}
+	var current []int
+	model.ArticleRelation(ctx).Where("root_id = ?", seriesId).Pluck("article_id", &current)
+	if !sameInts(current, articleIds) {
+		return errs.BadRequest.WithMsg("排序的文章与系列中的文章不一致")
+	}
+	return mysql.GetInstance().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range articleIds {
+			err := tx.Model(&model.ArticleRelations{}).Where("root_id = ? and article_id = ?", seriesId, id).Update("sort", i+1).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func sameInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x := append([]int(nil), a...)
+	y := append([]int(nil), b...)
+	sort.Ints(x)
+	sort.Ints(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Nav 文章在系列中的位置和上一篇、下一篇,只计算公开的文章
+func (s *SeriesService) Nav(ctx context.Context, articleId int) *model.SeriesNav {
+	seriesId := s.seriesOf(ctx, articleId)
+	if seriesId == 0 {
+		return nil
+	}
+	var series model.ArticleSeries
+	model.Series(ctx).Select("id", "title").Where("id = ?", seriesId).Limit(1).Find(&series)
+	if series.ID == 0 {
+		return nil
+	}
+	nav := &model.SeriesNav{ID: series.ID, Title: series.Title}
+	articles := s.articles(ctx, seriesId, false)
+	nav.Total = len(articles)
+	for i := range articles {
+		if articles[i].ID != articleId {
+			continue
+		}
+		nav.Index = i + 1
+		if i > 0 {
+			nav.Prev = &articles[i-1]
+		}
+		if i+1 < len(articles) {
+			nav.Next = &articles[i+1]
+		}
+	}
+	return nav
+}
+
+// ArticlePublished 系列中的文章从非公开变为公开时通知订阅了系列的用户,发布、修改、定时发布文章都从这里通知
+func (s *SeriesService) ArticlePublished(ctx context.Context, articleId, oldState, state int) {
+	if !isPublicState(state) || isPublicState(oldState) {
+		return
+	}
+	if seriesId := s.seriesOf(ctx, articleId); seriesId != 0 {
+		s.notify(ctx, seriesId, articleId)
+	}
+}
+
+func (s *SeriesService) notify(ctx context.Context, seriesId, articleId int) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
+		var series model.ArticleSeries
+		model.Series(ctx).Select("id", "title", "user_id").Where("id = ?", seriesId).Limit(1).Find(&series)
+		var article model.Articles
+		model.Article(ctx).Select("id", "title").Where("id = ?", articleId).Limit(1).Find(&article)
+		if series.ID == 0 || article.ID == 0 {
+			return
+		}
+		var subscriptionService SubscriptionService
+		subscriptions := subscriptionService.ListSubscriptionUserId(ctx, event.SeriesUpdate, seriesId)
+		if len(subscriptions) == 0 {
+			return
+		}
+		userIds := make([]int, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			userIds = append(userIds, subscription.SubscriberId)
+		}
+		var userS UserService
+		// 消息跳转到新发布的文章
+		sendToUsers(ctx, series.UserId, event.SeriesUpdate, constant.NOTICE, articleId, userS.ListByIdsToMap(ctx, userIds), i18n.M(seriesUpdateTemp, series.Title, article.Title))
+	})
+}
+
+func (s *SeriesService) ListByIdsSelectIdTitleMap(ctx context.Context, ids []int) map[int]string {
+	m := make(map[int]string)
+	if len(ids) == 0 {
+		return m
+	}
+	var list []model.ArticleSeries
+	model.Series(ctx).Where("id in ?", ids).Select("id", "title").Find(&list)
+	for _, series := range list {
+		m[series.ID] = series.Title
+	}
+	return m
+}
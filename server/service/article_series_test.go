@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/lifecycle"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+	"xhyovo.cn/community/server/service/event"
+)
+
+func TestArticleSeries(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1, FlagName: "go"})
+
+	var s SeriesService
+	series := model.ArticleSeries{Title: "Go 入门", UserId: 1}
+	if err := s.Save(ctx, &series); err != nil {
+		t.Fatal(err)
+	}
+	other := model.ArticleSeries{Title: "Go 进阶", UserId: 1}
+	if err := s.Save(ctx, &other); err != nil {
+		t.Fatal(err)
+	}
+
+	var a ArticleService
+	ids := make([]int, 0, 3)
+	for _, title := range []string{"第一篇", "第二篇", "第三篇"} {
+		article, err := a.PublishArticle(ctx, request.ReqArticle{Title: title, Content: title, UserId: 1, Type: 2, State: constant.Published, SeriesId: series.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, article.ID)
+	}
+
+	nav := s.Nav(ctx, ids[1])
+	if nav == nil || nav.Index != 2 || nav.Total != 3 || nav.Prev.ID != ids[0] || nav.Next.ID != ids[2] {
+		t.Fatalf("系列导航: %+v", nav)
+	}
+	if err := s.AddArticle(ctx, other.ID, ids[0], 1); err == nil {
+		t.Fatal("文章只能属于一个系列")
+	}
+	if err := s.AddArticle(ctx, series.ID, ids[0], 2); err == nil {
+		t.Fatal("只有作者可以修改系列")
+	}
+
+	if err := s.Sort(ctx, series.ID, 1, []int{ids[2], ids[0]}); err == nil {
+		t.Fatal("排序需要包含系列中的所有文章")
+	}
+	if err := s.Sort(ctx, series.ID, 1, []int{ids[2], ids[0], ids[1]}); err != nil {
+		t.Fatal(err)
+	}
+	if nav = s.Nav(ctx, ids[2]); nav.Index != 1 || nav.Prev != nil || nav.Next.ID != ids[0] {
+		t.Fatalf("排序后的导航: %+v", nav)
+	}
+
+	// 删除文章后移出系列,草稿只有作者能在详情中看到
+	if err := a.Delete(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.PublishArticle(ctx, request.ReqArticle{Title: "草稿", Content: "草稿", UserId: 1, Type: 2, State: constant.Draft, SeriesId: series.ID}); err != nil {
+		t.Fatal(err)
+	}
+	detail, err := s.Detail(ctx, series.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detail.List) != 2 || detail.List[0].ID != ids[2] || detail.List[1].ID != ids[1] {
+		t.Fatalf("系列详情: %+v", detail.List)
+	}
+	if detail, _ = s.Detail(ctx, series.ID, 1); len(detail.List) != 3 {
+		t.Fatalf("作者看到的系列详情: %+v", detail.List)
+	}
+	if list, count := s.Page(ctx, 1, 1, 10); count != 2 || list[1].Articles != 2 {
+		t.Fatalf("系列列表: %d, %+v", count, list)
+	}
+
+	if err = s.Delete(ctx, series.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if s.Nav(ctx, ids[1]) != nil {
+		t.Fatal("删除系列后文章不再属于系列")
+	}
+}
+
+func TestArticleSeriesNotify(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Users{ID: 2, Name: "reader"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1, FlagName: "go"})
+
+	var s SeriesService
+	series := model.ArticleSeries{Title: "Go 入门", UserId: 1}
+	if err := s.Save(ctx, &series); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&model.Subscriptions{SubscriberId: 2, EventId: event.SeriesUpdate, BusinessId: series.ID})
+	notified := func() int64 {
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := lifecycle.Wait(waitCtx); err != nil {
+			t.Fatal(err)
+		}
+		var count int64
+		model.MessageState(ctx).Where("event_id = ?", event.SeriesUpdate).Count(&count)
+		return count
+	}
+
+	// 新发布的文章加入系列只通知一次
+	var a ArticleService
+	article, err := a.PublishArticle(ctx, request.ReqArticle{Title: "第一篇", Content: "第一篇", UserId: 1, Type: 2, State: constant.Published, SeriesId: series.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := notified(); n != 1 {
+		t.Fatalf("新发布的文章通知次数: %d", n)
+	}
+	// 再次保存已公开的文章不通知
+	if _, err = a.PublishArticle(ctx, request.ReqArticle{ID: article.ID, Title: "第一篇", Content: "修改", UserId: 1, Type: 2, State: constant.Published, SeriesId: series.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if n := notified(); n != 1 {
+		t.Fatalf("修改已公开的文章不通知: %d", n)
+	}
+
+	// 草稿通过修改接口公开时通知
+	draft, err := a.PublishArticle(ctx, request.ReqArticle{Title: "第二篇", Content: "第二篇", UserId: 1, Type: 2, State: constant.Draft, SeriesId: series.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := notified(); n != 1 {
+		t.Fatalf("草稿不通知: %d", n)
+	}
+	if _, err = a.SaveArticle(ctx, request.ReqArticle{ID: draft.ID, Title: "第二篇", Content: "第二篇", UserId: 1, Type: 2, State: constant.Published}); err != nil {
+		t.Fatal(err)
+	}
+	if n := notified(); n != 2 {
+		t.Fatalf("草稿公开后的通知次数: %d", n)
+	}
+}
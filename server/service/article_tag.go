@@ -1,9 +1,10 @@
 package services
 
 import (
-	"errors"
+	"context"
 	mapset "github.com/deckarep/golang-set/v2"
 	"strings"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/server/model"
 )
@@ -12,16 +13,16 @@ type ArticleTagService struct {
 }
 
 // get hot top 10 item
-func (*ArticleTagService) QueryHotTags(limit int) (result []*model.ArticleTags, err error) {
+func (*ArticleTagService) QueryHotTags(ctx context.Context, limit int) (result []*model.ArticleTags, err error) {
 	result = make([]*model.ArticleTags, 0)
-	d := model.ArticleTag().Order("updated_at").Limit(limit).Find(&result)
+	d := model.ArticleTag(ctx).Order("updated_at").Limit(limit).Find(&result)
 	return result, d.Error
 }
 
-func (*ArticleTagService) QueryList(page, limit int, title string) (result map[string]interface{}, err error) {
+func (*ArticleTagService) QueryList(ctx context.Context, page, limit int, title string) (result map[string]interface{}, err error) {
 	var count int64
 	list := make([]*model.ArticleTags, 0)
-	err = model.ArticleTag().Where("tag_name like ?", "%"+title+"%").Count(&count).Offset((page - 1) * limit).Limit(limit).Find(&list).Error
+	err = model.ArticleTag(ctx).Where("tag_name like ?", "%"+title+"%").Count(&count).Offset((page - 1) * limit).Limit(limit).Find(&list).Error
 	result = map[string]interface{}{
 		"list":  list,
 		"total": count,
@@ -29,41 +30,41 @@ func (*ArticleTagService) QueryList(page, limit int, title string) (result map[s
 	return
 }
 
-func (*ArticleTagService) CreateTag(tag model.ArticleTags) (result *model.ArticleTags, err error) {
-	db := model.ArticleTag()
+func (*ArticleTagService) CreateTag(ctx context.Context, tag model.ArticleTags) (result *model.ArticleTags, err error) {
+	db := model.ArticleTag(ctx)
 	tagName := tag.TagName
 	tagName = strings.ToLower(tagName)
 	var tagId int
 	db.Where("tag_name = ?", tagName).Select("id").First(&tagId)
 	if tagId == 0 {
-		model.ArticleTag().Save(&tag)
+		model.ArticleTag(ctx).Save(&tag)
 		tagId = tag.Id
 	}
 	tag.Id = tagId
-	model.ArticleTagUserRelation().Create(&model.ArticleTagUserRelations{UserId: tag.UserId, TagId: tagId})
+	model.ArticleTagUserRelation(ctx).Create(&model.ArticleTagUserRelations{UserId: tag.UserId, TagId: tagId})
 	return &tag, nil
 }
 
-func (a *ArticleTagService) DeleteTag(tagId, userId int) error {
+func (a *ArticleTagService) DeleteTag(ctx context.Context, tagId, userId int) error {
 	// 被引用则不能删除
 	var count int64
-	model.ArticleTagRelation().Where("tag_id = ?", tagId).Count(&count)
+	model.ArticleTagRelation(ctx).Where("tag_id = ?", tagId).Count(&count)
 	if count > 0 {
-		return errors.New("标签已被引用,不可删除")
+		return errs.InUse.WithMsg("标签已被引用,不可删除")
 	}
-	mysql.GetInstance().Where("user_id = ? and tag_id = ?", userId, tagId).Delete(model.ArticleTagUserRelations{})
-	mysql.GetInstance().Where("id = ? and user_id = ?", tagId, userId).Delete(model.ArticleTags{})
+	mysql.GetInstance().WithContext(ctx).Where("user_id = ? and tag_id = ?", userId, tagId).Delete(model.ArticleTagUserRelations{})
+	mysql.GetInstance().WithContext(ctx).Where("id = ? and user_id = ?", tagId, userId).Delete(model.ArticleTags{})
 	return nil
 }
 
-func (a *ArticleTagService) GetTagArticleCount(userId int) []model.TagArticleCount {
+func (a *ArticleTagService) GetTagArticleCount(ctx context.Context, userId int) []model.TagArticleCount {
 	var tagsIds []int
-	model.ArticleTagUserRelation().Where("user_id = ?", userId).Select("tag_id").Find(&tagsIds)
+	model.ArticleTagUserRelation(ctx).Where("user_id = ?", userId).Select("tag_id").Find(&tagsIds)
 	if len(tagsIds) == 0 {
 		return []model.TagArticleCount{}
 	}
 	var tagAcount []model.TagArticleCount
-	db := mysql.GetInstance()
+	db := mysql.GetInstance().WithContext(ctx)
 	db.Raw("select tag_id,count(article_id) as article_count from article_tag_relations WHERE tag_id in(?) and user_id = ? GROUP BY tag_id", tagsIds, userId).Scan(&tagAcount)
 	if len(tagAcount) == 0 {
 		return []model.TagArticleCount{}
@@ -80,7 +81,7 @@ func (a *ArticleTagService) GetTagArticleCount(userId int) []model.TagArticleCou
 	}
 
 	var articleTags []model.ArticleTags
-	model.ArticleTag().Where("id in ?", tagsIds).Select("id", "tag_name").Find(&articleTags)
+	model.ArticleTag(ctx).Where("id in ?", tagsIds).Select("id", "tag_name").Find(&articleTags)
 
 	var m = make(map[int]string)
 	for i := range articleTags {
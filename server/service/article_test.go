@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/data"
+	"xhyovo.cn/community/pkg/delay"
+	"xhyovo.cn/community/pkg/lifecycle"
+	"xhyovo.cn/community/pkg/migrate"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+)
+
+// 使用 sqlite 验证文章列表的 sql 在非 mysql 数据库上的结果
+func initSQLite(t *testing.T) {
+	mysql.Init(config.DbConfig{Driver: mysql.DriverSQLite, Database: ":memory:"})
+	if _, err := migrate.Up(mysql.GetInstance()); err != nil {
+		t.Fatal(err)
+	}
+	delay.Init(mysql.GetInstance())
+	t.Cleanup(func() {
+		delay.GetInstant().Stop(context.Background())
+		// 等待通知等后台任务结束,避免写入下一个测试的数据库
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := lifecycle.Wait(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestArticleListSQLite(t *testing.T) {
+	initSQLite(t)
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Articles{ID: 1, Title: "Go Generics", Abstract: "摘要", Content: strings.Repeat("泛型", 60), UserId: 1, Type: 1, State: constant.Published})
+	db.Create(&model.Articles{ID: 2, Title: "Java", UserId: 1, Type: 1, State: constant.Published})
+	db.Create(&model.ArticleTags{Id: 1, TagName: "go", UserId: 1})
+	db.Create(&model.ArticleTags{Id: 2, TagName: "lang", UserId: 1})
+	db.Create(&[]model.ArticleTagRelations{{ArticleId: 1, TagId: 1}, {ArticleId: 1, TagId: 2}})
+	db.Create(&model.Comments{Content: "nice", FromUserId: 1, BusinessId: 1})
+
+	var a ArticleService
+	latest := a.LatestArticle(context.Background())
+	if len(latest) != 2 {
+		t.Fatalf("最新文章数量: %d", len(latest))
+	}
+	byId := map[int]*model.ArticleData{}
+	for _, item := range latest {
+		byId[item.ID] = item
+	}
+	first := byId[1]
+	tags, _ := first.Tags.(string)
+	if first.Comments != 1 || first.UserSimple.UName != "xhy" || first.TypeSimple.TypeFlag != "article" {
+		t.Fatalf("文章数据不正确: %+v", first)
+	}
+	if tags != "go, lang" && tags != "lang, go" {
+		t.Fatalf("标签: %q", tags)
+	}
+	if tags, _ := byId[2].Tags.(string); tags != "" {
+		t.Fatalf("没有标签的文章: %q", tags)
+	}
+
+	list, total, err := a.PageByClassfily(context.Background(), "article", nil, &model.Articles{State: constant.Published, Title: "go"},
+		data.QueryPage{Page: 1, Limit: 10}, data.ListSortStrategy{OrderBy: "created_at", DescOrder: true}, 1)
+	if err != nil || total != 1 || len(list) != 1 || list[0].ID != 1 {
+		t.Fatalf("分类查询不正确: %d, %v", total, err)
+	}
+	if tags, _ := list[0].Tags.(string); len(strings.Split(tags, ",")) != 2 {
+		t.Fatalf("标签: %q", tags)
+	}
+	// 分类列表的摘要为正文的前 100 个字
+	if list[0].Abstract != strings.Repeat("泛型", 50) {
+		t.Fatalf("列表摘要: %q", list[0].Abstract)
+	}
+}
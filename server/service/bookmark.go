@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+)
+
+// 默认收藏夹的名称,不保存在数据库中
+const defaultFolderName = "默认收藏夹"
+
+type BookmarkService struct {
+}
+
+// SaveFolder 创建、修改收藏夹
+func (s *BookmarkService) SaveFolder(ctx context.Context, folder *model.BookmarkFolders) error {
+	if folder.ID == 0 {
+		return model.BookmarkFolder(ctx).Create(folder).Error
+	}
+	tx := model.BookmarkFolder(ctx).Where("id = ? and user_id = ?", folder.ID, folder.UserId).
+		Select("name", "description", "public").Updates(folder)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NotFound.WithMsg("收藏夹不存在")
+	}
+	return nil
+}
+
+// DeleteFolder 删除收藏夹及其中的收藏
+func (s *BookmarkService) DeleteFolder(ctx context.Context, id, userId int) error {
+	tx := model.BookmarkFolder(ctx).Where("id = ? and user_id = ?", id, userId).Delete(&model.BookmarkFolders{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NotFound.WithMsg("收藏夹不存在")
+	}
+	log.Ctx(ctx).Infof("用户id: %d,删除收藏夹: %d", userId, id)
+	return model.Bookmark(ctx).Where("user_id = ? and folder_id = ?", userId, id).Delete(&model.Bookmarks{}).Error
+}
+
+// ListFolders 用户的收藏夹,本人可以看到私密收藏夹和默认收藏夹
+func (s *BookmarkService) ListFolders(ctx context.Context, ownerId, viewerId int) []model.BookmarkFolders {
+	folders := make([]model.BookmarkFolders, 0)
+	if ownerId == viewerId {
+		folders = append(folders, model.BookmarkFolders{Name: i18n.T(ctx, defaultFolderName), UserId: ownerId})
+	}
+	var list []model.BookmarkFolders
+	db := model.BookmarkFolder(ctx).Where("user_id = ?", ownerId)
+	if ownerId != viewerId {
+		db = db.Where("public = ?", true)
+	}
+	db.Order("id").Find(&list)
+	folders = append(folders, list...)
+
+	var counts []struct {
+		FolderId int
+		Count    int
+	}
+	model.Bookmark(ctx).Where("user_id = ?", ownerId).Select("folder_id, count(*) as count").Group("folder_id").Scan(&counts)
+	countMap := make(map[int]int, len(counts))
+	for _, c := range counts {
+		countMap[c.FolderId] = c.Count
+	}
+	for i := range folders {
+		folders[i].Count = countMap[folders[i].ID]
+	}
+	return folders
+}
+
+// folder 查询收藏夹,0 为默认收藏夹;不是本人的私密收藏夹返回不存在
+func (s *BookmarkService) folder(ctx context.Context, id, ownerId, viewerId int) (*model.BookmarkFolders, error) {
+	if id == 0 {
+		if ownerId != viewerId {
+			return nil, errs.NotFound.WithMsg("收藏夹不存在")
+		}
+		return &model.BookmarkFolders{Name: i18n.T(ctx, defaultFolderName), UserId: ownerId}, nil
+	}
+	var folder model.BookmarkFolders
+	db := model.BookmarkFolder(ctx).Where("id = ?", id)
+	if ownerId != 0 {
+		db = db.Where("user_id = ?", ownerId)
+	}
+	db.Limit(1).Find(&folder)
+	if folder.ID == 0 || (!folder.Public && folder.UserId != viewerId) {
+		return nil, errs.NotFound.WithMsg("收藏夹不存在")
+	}
+	return &folder, nil
+}
+
+// checkTarget 收藏的内容必须存在,文章需要所有人可见或是自己的
+func (s *BookmarkService) checkTarget(ctx context.Context, businessType, businessId, userId int) error {
+	switch businessType {
+	case model.BookmarkArticle:
+		var article model.Articles
+		model.Article(ctx).Select("id", "user_id", "state").Where("id = ?", businessId).Limit(1).Find(&article)
+		if article.ID == 0 || (article.UserId != userId && !isPublicState(article.State)) {
+			return errs.NotFound.WithMsg("文章不存在")
+		}
+	case model.BookmarkSection:
+		var count int64
+		model.CoursesSection(ctx).Where("id = ?", businessId).Count(&count)
+		if count == 0 {
+			return errs.NotFound.WithMsg("章节不存在")
+		}
+	default:
+		return errs.BadRequest.WithMsg("收藏类型只能是 1 文章 或 2 课程章节")
+	}
+	return nil
+}
+
+func (s *BookmarkService) exists(ctx context.Context, userId, folderId, businessType, businessId int) bool {
+	var count int64
+	model.Bookmark(ctx).Where("user_id = ? and folder_id = ? and business_type = ? and business_id = ?", userId, folderId, businessType, businessId).Count(&count)
+	return count > 0
+}
+
+// Add 收藏到收藏夹
+func (s *BookmarkService) Add(ctx context.Context, userId int, req request.ReqBookmark) (*model.Bookmarks, error) {
+	if _, err := s.folder(ctx, req.FolderId, userId, userId); err != nil {
+		return nil, err
+	}
+	if err := s.checkTarget(ctx, req.BusinessType, req.BusinessId, userId); err != nil {
+		return nil, err
+	}
+	if s.exists(ctx, userId, req.FolderId, req.BusinessType, req.BusinessId) {
+		return nil, errs.Conflict.WithMsg("已收藏到该收藏夹")
+	}
+	bookmark := model.Bookmarks{UserId: userId, FolderId: req.FolderId, BusinessType: req.BusinessType, BusinessId: req.BusinessId}
+	if err := model.Bookmark(ctx).Create(&bookmark).Error; err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// Remove 取消收藏
+func (s *BookmarkService) Remove(ctx context.Context, id, userId int) error {
+	tx := model.Bookmark(ctx).Where("id = ? and user_id = ?", id, userId).Delete(&model.Bookmarks{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NotFound.WithMsg("收藏不存在")
+	}
+	return nil
+}
+
+// Move 移动到其他收藏夹
+func (s *BookmarkService) Move(ctx context.Context, id, userId, folderId int) error {
+	var bookmark model.Bookmarks
+	model.Bookmark(ctx).Where("id = ? and user_id = ?", id, userId).Limit(1).Find(&bookmark)
+	if bookmark.ID == 0 {
+		return errs.NotFound.WithMsg("收藏不存在")
+	}
+	if bookmark.FolderId == folderId {
+		return nil
+	}
+	if _, err := s.folder(ctx, folderId, userId, userId); err != nil {
+		return err
+	}
+	if s.exists(ctx, userId, folderId, bookmark.BusinessType, bookmark.BusinessId) {
+		return errs.Conflict.WithMsg("已收藏到该收藏夹")
+	}
+	return model.Bookmark(ctx).Where("id = ?", id).Update("folder_id", folderId).Error
+}
+
+// FolderIds 内容所在的收藏夹
+func (s *BookmarkService) FolderIds(ctx context.Context, userId, businessType, businessId int) []int {
+	ids := make([]int, 0)
+	model.Bookmark(ctx).Where("user_id = ? and business_type = ? and business_id = ?", userId, businessType, businessId).Pluck("folder_id", &ids)
+	return ids
+}
+
+// Count 收藏了内容的用户数
+func (s *BookmarkService) Count(ctx context.Context, businessType, businessId int) int {
+	var count int64
+	model.Bookmark(ctx).Where("business_type = ? and business_id = ?", businessType, businessId).Distinct("user_id").Count(&count)
+	return int(count)
+}
+
+// Page 本人的收藏,可以按收藏夹、类型、标题筛选;已删除的内容不返回
+func (s *BookmarkService) Page(ctx context.Context, userId int, search request.ReqBookmarkSearch, page, limit int) (items []model.BookmarkItem, count int64) {
+	db := s.itemQuery(ctx, userId).Where("bookmarks.user_id = ?", userId)
+	if search.FolderId != nil {
+		db = db.Where("bookmarks.folder_id = ?", *search.FolderId)
+	}
+	if search.BusinessType != 0 {
+		db = db.Where("bookmarks.business_type = ?", search.BusinessType)
+	}
+	if search.Keyword != "" {
+		keyword := mysql.Contains(search.Keyword)
+		db = db.Where("("+mysql.Like("a.title")+" or "+mysql.Like("cs.title")+")", keyword, keyword)
+	}
+	return s.pageItems(ctx, db, userId, page, limit)
+}
+
+// FolderItems 收藏夹中的收藏,其他用户只能查看公开的收藏夹
+func (s *BookmarkService) FolderItems(ctx context.Context, folderId, viewerId, page, limit int) (*model.BookmarkFolders, []model.BookmarkItem, int64, error) {
+	folder, err := s.folder(ctx, folderId, 0, viewerId)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	db := s.itemQuery(ctx, viewerId).Where("bookmarks.user_id = ? and bookmarks.folder_id = ?", folder.UserId, folderId)
+	items, count := s.pageItems(ctx, db, folder.UserId, page, limit)
+	return folder, items, count, nil
+}
+
+// itemQuery 关联收藏的文章、章节,已删除的内容和查看者不可见的文章不返回
+func (s *BookmarkService) itemQuery(ctx context.Context, viewerId int) *gorm.DB {
+	return model.Bookmark(ctx).
+		Joins("LEFT JOIN articles a ON bookmarks.business_type = ? AND a.id = bookmarks.business_id AND a.deleted_at IS NULL AND (a.state IN ? OR a.user_id = ?)",
+			model.BookmarkArticle, publicStates, viewerId).
+		Joins("LEFT JOIN courses_sections cs ON bookmarks.business_type = ? AND cs.id = bookmarks.business_id AND cs.deleted_at IS NULL", model.BookmarkSection).
+		Where("(a.id IS NOT NULL OR cs.id IS NOT NULL)")
+}
+
+func (s *BookmarkService) pageItems(ctx context.Context, db *gorm.DB, ownerId, page, limit int) (items []model.BookmarkItem, count int64) {
+	db.Count(&count)
+	if count == 0 {
+		return make([]model.BookmarkItem, 0), 0
+	}
+	db.Select("bookmarks.id, bookmarks.folder_id, bookmarks.business_type, bookmarks.business_id, bookmarks.created_at, " +
+		"COALESCE(a.title, cs.title) as title, COALESCE(a.abstract, '') as abstract, COALESCE(cs.course_id, 0) as course_id").
+		Order("bookmarks.id desc").Limit(limit).Offset((page - 1) * limit).Scan(&items)
+
+	names := map[int]string{0: i18n.T(ctx, defaultFolderName)}
+	var folders []model.BookmarkFolders
+	model.BookmarkFolder(ctx).Where("user_id = ?", ownerId).Select("id", "name").Find(&folders)
+	for _, f := range folders {
+		names[f.ID] = f.Name
+	}
+	for i := range items {
+		items[i].FolderName = names[items[i].FolderId]
+	}
+	return
+}
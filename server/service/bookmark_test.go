@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+)
+
+func TestBookmark(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Users{ID: 2, Name: "reader"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Articles{ID: 1, Title: "Go 并发", Abstract: "goroutine", UserId: 1, Type: 1, State: constant.Published})
+	db.Create(&model.Articles{ID: 2, Title: "草稿", UserId: 1, Type: 1, State: constant.Draft})
+	db.Create(&model.CoursesSections{ID: 1, Title: "第一章 channel", Content: "c", UserId: 1, CourseId: 3})
+
+	var s BookmarkService
+	folder := model.BookmarkFolders{Name: "Go", UserId: 2, Public: true}
+	if err := s.SaveFolder(ctx, &folder); err != nil {
+		t.Fatal(err)
+	}
+	private := model.BookmarkFolders{Name: "稍后阅读", UserId: 2}
+	if err := s.SaveFolder(ctx, &private); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Add(ctx, 2, request.ReqBookmark{BusinessType: model.BookmarkArticle, BusinessId: 2}); err == nil {
+		t.Fatal("不能收藏其他用户的草稿")
+	}
+	if _, err := s.Add(ctx, 2, request.ReqBookmark{FolderId: folder.ID, BusinessType: model.BookmarkArticle, BusinessId: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(ctx, 2, request.ReqBookmark{FolderId: folder.ID, BusinessType: model.BookmarkArticle, BusinessId: 1}); err == nil {
+		t.Fatal("重复收藏到同一个收藏夹")
+	}
+	if _, err := s.Add(ctx, 1, request.ReqBookmark{FolderId: folder.ID, BusinessType: model.BookmarkArticle, BusinessId: 1}); err == nil {
+		t.Fatal("不能收藏到其他用户的收藏夹")
+	}
+	if _, err := s.Add(ctx, 2, request.ReqBookmark{BusinessType: model.BookmarkArticle, BusinessId: 1}); err != nil {
+		t.Fatal(err)
+	}
+	section, err := s.Add(ctx, 2, request.ReqBookmark{FolderId: private.ID, BusinessType: model.BookmarkSection, BusinessId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一用户收藏到多个收藏夹只计一次
+	if count := s.Count(ctx, model.BookmarkArticle, 1); count != 1 {
+		t.Fatalf("收藏数: %d", count)
+	}
+	var a ArticleService
+	data, err := a.GetArticleData(ctx, 1, 2)
+	if err != nil || data.Bookmarks != 1 || !data.Bookmarked {
+		t.Fatalf("文章收藏数: %+v, %v", data, err)
+	}
+	if latest := a.LatestArticle(ctx); len(latest) != 1 || latest[0].Bookmarks != 1 {
+		t.Fatalf("列表中的收藏数: %+v", latest)
+	}
+
+	items, count := s.Page(ctx, 2, request.ReqBookmarkSearch{Keyword: "channel"}, 1, 10)
+	if count != 1 || items[0].Title != "第一章 channel" || items[0].CourseId != 3 || items[0].FolderName != "稍后阅读" {
+		t.Fatalf("搜索收藏: %d, %+v", count, items)
+	}
+	defaultFolder := 0
+	if _, count = s.Page(ctx, 2, request.ReqBookmarkSearch{FolderId: &defaultFolder}, 1, 10); count != 1 {
+		t.Fatalf("默认收藏夹: %d", count)
+	}
+
+	// 其他用户只能看到公开的收藏夹
+	if folders := s.ListFolders(ctx, 2, 1); len(folders) != 1 || folders[0].ID != folder.ID || folders[0].Count != 1 {
+		t.Fatalf("其他用户看到的收藏夹: %+v", folders)
+	}
+	if folders := s.ListFolders(ctx, 2, 2); len(folders) != 3 {
+		t.Fatalf("自己的收藏夹: %+v", folders)
+	}
+	if _, _, _, err = s.FolderItems(ctx, private.ID, 1, 1, 10); err == nil {
+		t.Fatal("不能查看其他用户的私密收藏夹")
+	}
+
+	if err = s.Move(ctx, section.ID, 2, folder.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, items, count, err = s.FolderItems(ctx, folder.ID, 1, 1, 10); err != nil || count != 2 {
+		t.Fatalf("公开收藏夹: %d, %v", count, err)
+	}
+	if err = s.DeleteFolder(ctx, folder.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, count = s.Page(ctx, 2, request.ReqBookmarkSearch{}, 1, 10); count != 1 {
+		t.Fatalf("删除收藏夹后的收藏: %d", count)
+	}
+}
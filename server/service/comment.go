@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/gin-gonic/gin"
 	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/search"
 	"xhyovo.cn/community/server/model"
 	"xhyovo.cn/community/server/service/event"
 )
@@ -19,7 +21,13 @@ func NewCommentService(ctx *gin.Context) *CommentsService {
 }
 
 // 发布评论
-func (a *CommentsService) Comment(comment *model.Comments) error {
+func (a *CommentsService) Comment(ctx context.Context, comment *model.Comments) error {
+	// 点赞数只能通过表态修改
+	comment.Likes = 0
+	var moderationS ModerationService
+	if err := moderationS.Moderate(ctx, SceneComment, comment.FromUserId, comment.BusinessId, comment, &comment.Content); err != nil {
+		return err
+	}
 
 	parentId := comment.ParentId
 	var subscriptionService SubscriptionService
@@ -28,13 +36,15 @@ func (a *CommentsService) Comment(comment *model.Comments) error {
 	// 父评论是否存在
 
 	if parentId != 0 {
-		parentComment := commentDao.GetByParentId(parentId)
+		parentComment := commentDao.GetByParentId(ctx, parentId)
 		comment.ToUserId = parentComment.FromUserId
 		comment.RootId = parentComment.RootId
 		comment.BusinessId = parentComment.BusinessId
 		comment.RootId = parentComment.RootId
 	}
-	commentDao.AddComment(comment)
+	commentDao.AddComment(ctx, comment)
+	var searchS SearchService
+	searchS.IndexComment(ctx, comment.ID)
 	b.UserId = comment.FromUserId
 	b.ArticleId = comment.BusinessId
 	b.CurrentBusinessId = comment.BusinessId
@@ -45,53 +55,57 @@ func (a *CommentsService) Comment(comment *model.Comments) error {
 	eventId := event.CommentUpdateEvent
 	// 延迟发送评论事件
 	if parentId != 0 {
-		subscriptionService.Send(event.ReplyComment, constant.NOTICE, comment.FromUserId, comment.ToUserId, b)
+		subscriptionService.Send(ctx, event.ReplyComment, constant.NOTICE, comment.FromUserId, comment.ToUserId, b)
 	}
 	userId := 0
 	// 文章评论
 	if comment.TenantId == 0 {
 		var articles ArticleService
-		userId = articles.GetById(comment.BusinessId).UserId
+		userId = articles.GetById(ctx, comment.BusinessId).UserId
 	}
 	// 课程评论
 	if comment.TenantId == 1 {
 		var courS CourseService
-		userId = courS.GetCourseSectionDetail(comment.BusinessId).UserId
+		userId = courS.GetCourseSectionDetail(ctx, comment.BusinessId).UserId
 		eventId = event.SectionComment
 	} else if comment.TenantId == 2 {
 		// 章节评论
 		var courS CourseService
-		userId = courS.GetCourseDetail(comment.BusinessId).UserId
+		userId = courS.GetCourseDetail(ctx, comment.BusinessId).UserId
 		eventId = event.CourseComment
 	} else if comment.TenantId == 3 {
 		// 分享会评论
 		var meetingS MeetingService
-		userId = meetingS.GetById(comment.BusinessId).InitiatorId
+		userId = meetingS.GetById(ctx, comment.BusinessId).InitiatorId
 		eventId = event.Meeting
 	}
 
-	subscriptionService.ConstantAtSend(event.CommentAt, comment.FromUserId, comment.Content, b)
-	subscriptionService.Do(eventId, b)
+	subscriptionService.ConstantAtSend(ctx, event.CommentAt, comment.FromUserId, comment.Content, b)
+	subscriptionService.Do(ctx, eventId, b)
 	// 文章发布者收到消息
-	subscriptionService.Send(eventId, constant.NOTICE, comment.FromUserId, userId, b)
+	subscriptionService.Send(ctx, eventId, constant.NOTICE, comment.FromUserId, userId, b)
 	jsonBody, _ := json.Marshal(comment)
-	log.Infof("用户id: %d,发布评论: %s", comment.FromUserId, jsonBody)
+	log.Ctx(ctx).Infof("用户id: %d,发布评论: %s", comment.FromUserId, jsonBody)
 	return nil
 }
 
 // 删除评论
-func (a *CommentsService) DeleteComment(id, userId int) bool {
+func (a *CommentsService) DeleteComment(ctx context.Context, id, userId int) bool {
 
-	log.Infof("用户id: %d,删除评论: %d", userId, id)
-	return commentDao.Delete(id, userId) == 1
+	log.Ctx(ctx).Infof("用户id: %d,删除评论: %d", userId, id)
+	if commentDao.Delete(ctx, id, userId) != 1 {
+		return false
+	}
+	search.GetInstance().Delete(search.Key{Kind: search.KindComment, ID: id})
+	return true
 }
 
-// 查询文章下的评论
-func (*CommentsService) GetCommentsByArticleID(page, limit, businessId int) ([]*model.Comments, int64) {
+// 查询文章下的评论,order 为 likes 时按点赞数排序
+func (*CommentsService) GetCommentsByArticleID(ctx context.Context, page, limit, businessId int, order string) ([]*model.Comments, int64) {
 
 	var parentComments []*model.Comments
 	childCommentsMap := make(map[int][]*model.Comments)
-	comments, count := commentDao.GetCommentsByArticleID(page, limit, businessId)
+	comments, count := commentDao.GetCommentsByArticleID(ctx, page, limit, businessId, order)
 	if count == 0 {
 		return parentComments, 0
 	}
@@ -109,9 +123,10 @@ func (*CommentsService) GetCommentsByArticleID(page, limit, businessId int) ([]*
 		userIds = append(userIds, comment.FromUserId)
 	}
 
-	setCommentUserInfoAndArticleTitle(comments)
+	setCommentUserInfoAndArticleTitle(ctx, comments)
+	setCommentReactions(ctx, comments)
 
-	ChildCommentNumberMap := commentDao.GetCommentsCountByRootId(parentIds)
+	ChildCommentNumberMap := commentDao.GetCommentsCountByRootId(ctx, parentIds)
 	for i := range parentComments {
 		parentComments[i].ChildComments = childCommentsMap[parentComments[i].RootId]
 		parentComments[i].ChildCommentNumber = ChildCommentNumberMap[parentComments[i].RootId]
@@ -121,7 +136,7 @@ func (*CommentsService) GetCommentsByArticleID(page, limit, businessId int) ([]*
 }
 
 // 设置用户的昵称和头像
-func setCommentUserInfoAndArticleTitle(comments []*model.Comments) {
+func setCommentUserInfoAndArticleTitle(ctx context.Context, comments []*model.Comments) {
 	userIds := mapset.NewSetWithSize[int](len(comments))
 	articleIds := mapset.NewSetWithSize[int](len(comments))
 	for i := range comments {
@@ -135,10 +150,10 @@ func setCommentUserInfoAndArticleTitle(comments []*model.Comments) {
 		return
 	}
 	var u UserService
-	userNameMap := u.ListByIdsToMap(userIds.ToSlice())
+	userNameMap := u.ListByIdsToMap(ctx, userIds.ToSlice())
 
 	var a ArticleService
-	articleTitleMap := a.ListByIdsSelectIdTitleMap(articleIds.ToSlice())
+	articleTitleMap := a.ListByIdsSelectIdTitleMap(ctx, articleIds.ToSlice())
 
 	for i := range comments {
 		comment := comments[i]
@@ -153,53 +168,54 @@ func setCommentUserInfoAndArticleTitle(comments []*model.Comments) {
 }
 
 // 查询用户的评论(管理端)
-func (*CommentsService) GetAllCommentsByArticleID(page, limit, userId, businessId, tenantId int) ([]*model.Comments, int64) {
-	comments, count := commentDao.GetAllCommentsByArticleID(page, limit, userId, businessId, tenantId)
+func (*CommentsService) GetAllCommentsByArticleID(ctx context.Context, page, limit, userId, businessId, tenantId int) ([]*model.Comments, int64) {
+	comments, count := commentDao.GetAllCommentsByArticleID(ctx, page, limit, userId, businessId, tenantId)
 	if count == 0 {
 		return comments, count
 	}
-	setCommentUserInfoAndArticleTitle(comments)
+	setCommentUserInfoAndArticleTitle(ctx, comments)
 	return comments, count
 }
 
-// 查询根评论下的子评论
-func (*CommentsService) GetCommentsByRootID(page, limit, rootId int) (comments []*model.Comments, count int64) {
+// 查询根评论下的子评论,order 为 likes 时按点赞数排序
+func (*CommentsService) GetCommentsByRootID(ctx context.Context, page, limit, rootId int, order string) (comments []*model.Comments, count int64) {
 
-	model.Comment().Where("root_id = ? and id <> root_id", rootId).Count(&count)
+	model.Comment(ctx).Where("root_id = ? and id <> root_id", rootId).Count(&count)
 	if count == 0 {
 		return
 	}
-	comments = commentDao.GetCommentsByCommentID(page, limit, rootId)
+	comments = commentDao.GetCommentsByCommentID(ctx, page, limit, rootId, order)
 
-	setCommentUserInfoAndArticleTitle(comments)
+	setCommentUserInfoAndArticleTitle(ctx, comments)
+	setCommentReactions(ctx, comments)
 	return
 }
 
-func (a *CommentsService) PageComment(p, limit int) (comments []*model.Comments, count int64) {
-	db := model.Comment()
+func (a *CommentsService) PageComment(ctx context.Context, p, limit int) (comments []*model.Comments, count int64) {
+	db := model.Comment(ctx)
 	db.Count(&count)
 	if count == 0 {
 		return
 	}
 	db.Limit(limit).Offset((p - 1) * limit).Find(&comments)
 
-	setCommentUserInfoAndArticleTitle(comments)
+	setCommentUserInfoAndArticleTitle(ctx, comments)
 	return comments, count
 }
 
-func (a *CommentsService) Exist(commentId int) bool {
+func (a *CommentsService) Exist(ctx context.Context, commentId int) bool {
 	var count int64
-	model.Comment().Where("id = ?", commentId).Count(&count)
+	model.Comment(ctx).Where("id = ?", commentId).Count(&count)
 	return count == 1
 }
 
-func (a *CommentsService) GetById(id int) (comment model.Comments) {
-	model.Comment().Where("id = ?", id).Find(&comment)
+func (a *CommentsService) GetById(ctx context.Context, id int) (comment model.Comments) {
+	model.Comment(ctx).Where("id = ?", id).Find(&comment)
 	return
 }
 
-func (a *CommentsService) ListAdoptionsByArticleId(articleId, page, limit int) (comments []*model.Comments, count int64) {
-	db := model.Comment().
+func (a *CommentsService) ListAdoptionsByArticleId(ctx context.Context, articleId, page, limit int) (comments []*model.Comments, count int64) {
+	db := model.Comment(ctx).
 		Joins("JOIN qa_adoptions ON qa_adoptions.comment_id = comments.id").
 		Order("qa_adoptions.created_at DESC"). // 按照采纳时间降序排列
 		Where("qa_adoptions.article_id = ?", articleId)
@@ -209,13 +225,13 @@ func (a *CommentsService) ListAdoptionsByArticleId(articleId, page, limit int) (
 	for i := range comments {
 		comments[i].AdoptionState = true
 	}
-	setCommentUserInfoAndArticleTitle(comments)
+	setCommentUserInfoAndArticleTitle(ctx, comments)
 	return
 }
 
-func (a *CommentsService) ListCommentsByArticleIdNoTree(businessId, tenantId int) (comments []*model.Comments) {
+func (a *CommentsService) ListCommentsByArticleIdNoTree(ctx context.Context, businessId, tenantId int) (comments []*model.Comments) {
 
-	model.Comment().Where("business_id = ? and tenant_id = ? ", businessId, tenantId).Order("created_at desc").Find(&comments)
-	setCommentUserInfoAndArticleTitle(comments)
+	model.Comment(ctx).Where("business_id = ? and tenant_id = ? ", businessId, tenantId).Order("created_at desc").Find(&comments)
+	setCommentUserInfoAndArticleTitle(ctx, comments)
 	return comments
 }
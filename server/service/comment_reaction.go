@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/lifecycle"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/service/event"
+)
+
+// 后台没有配置消息模板时使用
+const commentReactionTemp = "${user.name} 对你的评论「${comment.content}」做出了表态"
+
+type CommentReactionService struct {
+}
+
+// React 对评论表态,已经表态过时取消,返回是否为表态
+func (s *CommentReactionService) React(ctx context.Context, commentId, userId int, reaction string) (bool, error) {
+	if !isReaction(reaction) {
+		return false, errs.BadRequest.WithMsg("不支持的表态")
+	}
+	var comment model.Comments
+	model.Comment(ctx).Where("id = ?", commentId).Select("id", "from_user_id", "business_id", "tenant_id").Limit(1).Find(&comment)
+	if comment.ID == 0 {
+		return false, errs.NotFound.WithMsg("评论不存在")
+	}
+	if err := s.checkVisible(ctx, comment, userId); err != nil {
+		return false, err
+	}
+
+	var count int64
+	model.CommentReaction(ctx).Where("comment_id = ? and user_id = ? and reaction = ?", commentId, userId, reaction).Count(&count)
+	added := count == 0
+	err := mysql.GetInstance().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		delta := 1
+		if added {
+			if err := tx.Create(&model.CommentReactions{CommentId: commentId, UserId: userId, Reaction: reaction}).Error; err != nil {
+				return err
+			}
+		} else {
+			res := tx.Where("comment_id = ? and user_id = ? and reaction = ?", commentId, userId, reaction).Delete(&model.CommentReactions{})
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			delta = -1
+		}
+		if reaction != model.ReactionLike {
+			return nil
+		}
+		// 点赞数用于排序,不更新评论的修改时间
+		return tx.Model(&model.Comments{}).Where("id = ?", commentId).UpdateColumn("likes", gorm.Expr("likes + ?", delta)).Error
+	})
+	if err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 评论表态失败,评论id: %d,err: %s", userId, commentId, err.Error())
+		return false, err
+	}
+
+	// 通知评论的作者,取消表态和自己的评论不通知
+	if added && comment.FromUserId != userId {
+		b := reactionSubscribeData(ctx, comment)
+		b.UserId = userId
+		lifecycle.Go(ctx, func(ctx context.Context) {
+			temp := messageDao.GetMessageTemplate(ctx, event.CommentReaction)
+			if temp == "" {
+				temp = commentReactionTemp
+			}
+			send(ctx, []int{comment.FromUserId}, event.CommentReaction, constant.NOTICE, userId, b, temp)
+		})
+	}
+	return added, nil
+}
+
+// ListUsers 对评论表态的用户,reaction 为空时查询所有表态
+func (s *CommentReactionService) ListUsers(ctx context.Context, commentId, userId int, reaction string, page, limit int) ([]*model.CommentReactionUser, int64, error) {
+	if reaction != "" && !isReaction(reaction) {
+		return nil, 0, errs.BadRequest.WithMsg("不支持的表态")
+	}
+	var comment model.Comments
+	model.Comment(ctx).Where("id = ?", commentId).Select("id", "business_id", "tenant_id").Limit(1).Find(&comment)
+	if comment.ID == 0 {
+		return nil, 0, errs.NotFound.WithMsg("评论不存在")
+	}
+	if err := s.checkVisible(ctx, comment, userId); err != nil {
+		return nil, 0, err
+	}
+	db := model.CommentReaction(ctx).Where("comment_id = ?", commentId)
+	if reaction != "" {
+		db = db.Where("reaction = ?", reaction)
+	}
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	users := []*model.CommentReactionUser{}
+	if count == 0 {
+		return users, 0, nil
+	}
+	err := db.Select("user_id", "reaction", "created_at").Order("id desc").
+		Limit(limit).Offset((page - 1) * limit).Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	userIds := make([]int, 0, len(users))
+	for _, u := range users {
+		userIds = append(userIds, u.UserId)
+	}
+	var userS UserService
+	userMap := userS.ListByIdsToMap(ctx, userIds)
+	for _, u := range users {
+		u.UserName = userMap[u.UserId].Name
+		u.UserAvatar = userMap[u.UserId].Avatar
+	}
+	return users, count, nil
+}
+
+// checkVisible 和文章详情一致,他人的草稿、私密提问和定时发布的文章只有作者和有文章查看权限的用户可以看到,
+// 课程、章节和分享会的评论不限制
+func (s *CommentReactionService) checkVisible(ctx context.Context, comment model.Comments, userId int) error {
+	if comment.TenantId != 0 {
+		return nil
+	}
+	var article model.Articles
+	model.Article(ctx).Select("id", "user_id", "state").Where("id = ?", comment.BusinessId).Limit(1).Find(&article)
+	if article.ID == 0 {
+		return errs.NotFound.WithMsg("评论不存在")
+	}
+	if article.UserId == userId || isPublicState(article.State) {
+		return nil
+	}
+	var roleS RoleService
+	ok, err := roleS.HasPermission(ctx, userId, constant.PermArticleView)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.NotFound.WithMsg("评论不存在")
+	}
+	return nil
+}
+
+// reactionSubscribeData 按评论所属的业务设置消息模板变量和跳转的业务id,
+// 课程和分享会没有对应的消息页面,不设置跳转
+func reactionSubscribeData(ctx context.Context, comment model.Comments) SubscribeData {
+	b := SubscribeData{CommentId: comment.ID}
+	switch comment.TenantId {
+	case 0:
+		b.ArticleId = comment.BusinessId
+		b.CurrentBusinessId = comment.BusinessId
+	case 1:
+		b.SectionId = comment.BusinessId
+		model.CoursesSection(ctx).Where("id = ?", comment.BusinessId).Select("course_id").Find(&b.CourseId)
+		b.CurrentBusinessId = comment.BusinessId
+	case 2:
+		b.CourseId = comment.BusinessId
+	}
+	return b
+}
+
+// SetUserReactions 设置当前用户对评论及其子评论的表态
+func (s *CommentReactionService) SetUserReactions(ctx context.Context, comments []*model.Comments, userId int) {
+	comments = flattenComments(comments)
+	if len(comments) == 0 {
+		return
+	}
+	ids := make([]int, 0, len(comments))
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	var reactions []model.CommentReactions
+	model.CommentReaction(ctx).Where("comment_id in ? and user_id = ?", ids, userId).Select("comment_id", "reaction").Find(&reactions)
+	mine := make(map[int][]string)
+	for _, r := range reactions {
+		mine[r.CommentId] = append(mine[r.CommentId], r.Reaction)
+	}
+	for _, c := range comments {
+		c.MyReactions = mine[c.ID]
+		if c.MyReactions == nil {
+			c.MyReactions = []string{}
+		}
+	}
+}
+
+// setCommentReactions 设置评论各表态的数量
+func setCommentReactions(ctx context.Context, comments []*model.Comments) {
+	if len(comments) == 0 {
+		return
+	}
+	ids := make([]int, 0, len(comments))
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	var rows []struct {
+		CommentId int
+		Reaction  string
+		Number    int
+	}
+	model.CommentReaction(ctx).Where("comment_id in ?", ids).
+		Select("comment_id, reaction, count(*) as number").Group("comment_id, reaction").Scan(&rows)
+	counts := make(map[int]map[string]int)
+	for _, r := range rows {
+		if counts[r.CommentId] == nil {
+			counts[r.CommentId] = make(map[string]int)
+		}
+		counts[r.CommentId][r.Reaction] = r.Number
+	}
+	for _, c := range comments {
+		c.Reactions = counts[c.ID]
+		if c.Reactions == nil {
+			c.Reactions = map[string]int{}
+		}
+	}
+}
+
+// flattenComments 根评论和已加载的子评论
+func flattenComments(comments []*model.Comments) []*model.Comments {
+	all := make([]*model.Comments, 0, len(comments))
+	for _, c := range comments {
+		all = append(all, c)
+		all = append(all, c.ChildComments...)
+	}
+	return all
+}
+
+func isReaction(reaction string) bool {
+	for _, r := range model.Reactions {
+		if r == reaction {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestCommentReaction(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&[]model.Users{{ID: 1, Name: "xhy"}, {ID: 2, Name: "helper"}, {ID: 3, Name: "reader"}})
+	db.Create(&model.Articles{ID: 1, Title: "问题", UserId: 1, State: constant.Pending})
+	var commentS CommentsService
+	for _, c := range []*model.Comments{
+		{Content: "第一个回答", FromUserId: 2, BusinessId: 1},
+		{Content: "第二个回答", FromUserId: 3, BusinessId: 1},
+	} {
+		// 直接写库,不经过审核和通知
+		commentDao.AddComment(ctx, c)
+	}
+	reply := &model.Comments{Content: "回复", FromUserId: 1, BusinessId: 1, ParentId: 1, RootId: 1}
+	commentDao.AddComment(ctx, reply)
+
+	var s CommentReactionService
+	if _, err := s.React(ctx, 1, 1, "angry"); !errors.Is(err, errs.BadRequest) {
+		t.Fatalf("不支持的表态: %v", err)
+	}
+	if _, err := s.React(ctx, 99, 1, "like"); !errors.Is(err, errs.NotFound) {
+		t.Fatalf("评论不存在: %v", err)
+	}
+	for _, r := range []struct {
+		commentId, userId int
+		reaction          string
+	}{{2, 1, "like"}, {2, 2, "like"}, {2, 1, "rocket"}, {1, 3, "like"}, {3, 2, "heart"}} {
+		if added, err := s.React(ctx, r.commentId, r.userId, r.reaction); err != nil || !added {
+			t.Fatalf("表态: %+v, %v", r, err)
+		}
+	}
+
+	comments, count := commentS.GetCommentsByArticleID(ctx, 1, 10, 1, model.CommentSortLikes)
+	if count != 2 || len(comments) != 2 || comments[0].ID != 2 || comments[0].Likes != 2 {
+		t.Fatalf("按点赞数排序: %d, %+v", count, comments)
+	}
+	if comments[0].Reactions["like"] != 2 || comments[0].Reactions["rocket"] != 1 || len(comments[1].ChildComments) != 1 {
+		t.Fatalf("表态数量: %+v", comments[0].Reactions)
+	}
+	if comments[1].ChildComments[0].Reactions["heart"] != 1 {
+		t.Fatalf("子评论的表态数量: %+v", comments[1].ChildComments[0].Reactions)
+	}
+	s.SetUserReactions(ctx, comments, 1)
+	if len(comments[0].MyReactions) != 2 || len(comments[1].MyReactions) != 0 {
+		t.Fatalf("当前用户的表态: %v, %v", comments[0].MyReactions, comments[1].MyReactions)
+	}
+
+	users, total, err := s.ListUsers(ctx, 2, 3, "like", 1, 10)
+	if err != nil || total != 2 || users[0].UserName != "helper" || users[1].UserName != "xhy" {
+		t.Fatalf("点赞的用户: %+v, %d, %v", users, total, err)
+	}
+
+	// 再次点赞为取消
+	if added, err := s.React(ctx, 2, 1, "like"); err != nil || added {
+		t.Fatalf("取消点赞: %v, %v", added, err)
+	}
+	if added, err := s.React(ctx, 2, 2, "like"); err != nil || added {
+		t.Fatalf("取消点赞: %v, %v", added, err)
+	}
+	comments, _ = commentS.GetCommentsByArticleID(ctx, 1, 10, 1, model.CommentSortLikes)
+	if comments[0].ID != 1 || comments[1].Likes != 0 || comments[1].Reactions["like"] != 0 {
+		t.Fatalf("取消后的排序: %+v", comments)
+	}
+}
+
+func TestCommentReactionVisible(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&[]model.Users{{ID: 1, Name: "xhy"}, {ID: 2, Name: "reader"}})
+	db.Create(&model.Articles{ID: 1, Title: "私密提问", UserId: 1, State: constant.PrivateQuestion})
+	db.Create(&model.CoursesSections{ID: 5, Title: "第一章", Content: "内容", UserId: 1, CourseId: 7})
+	commentDao.AddComment(ctx, &model.Comments{Content: "文章评论", FromUserId: 1, BusinessId: 1})
+	commentDao.AddComment(ctx, &model.Comments{Content: "章节评论", FromUserId: 1, BusinessId: 5, TenantId: 1})
+
+	// 看不到文章的用户不能表态,也不能查看表态的用户
+	var s CommentReactionService
+	if _, err := s.React(ctx, 1, 2, "like"); !errors.Is(err, errs.NotFound) {
+		t.Fatalf("私密提问下的评论: %v", err)
+	}
+	if _, _, err := s.ListUsers(ctx, 1, 2, "", 1, 10); !errors.Is(err, errs.NotFound) {
+		t.Fatalf("私密提问下的评论: %v", err)
+	}
+	if _, _, err := s.ListUsers(ctx, 1, 1, "", 1, 10); err != nil {
+		t.Fatalf("作者可以查看: %v", err)
+	}
+
+	// 章节评论的消息跳转到章节,而不是同 id 的文章
+	if _, err := s.React(ctx, 2, 2, "like"); err != nil {
+		t.Fatal(err)
+	}
+	b := reactionSubscribeData(ctx, model.Comments{ID: 2, BusinessId: 5, TenantId: 1})
+	if b.ArticleId != 0 || b.SectionId != 5 || b.CourseId != 7 || b.CurrentBusinessId != 5 {
+		t.Fatalf("章节评论的消息: %+v", b)
+	}
+	if b = reactionSubscribeData(ctx, model.Comments{ID: 3, BusinessId: 7, TenantId: 2}); b.CourseId != 7 || b.CurrentBusinessId != 0 {
+		t.Fatalf("课程评论的消息: %+v", b)
+	}
+}
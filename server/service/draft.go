@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"strconv"
 	"strings"
+	"xhyovo.cn/community/pkg/lifecycle"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/server/model"
 )
@@ -10,9 +12,9 @@ import (
 type Draft struct {
 }
 
-func (*Draft) Get(userId int) *model.Drafts {
+func (*Draft) Get(ctx context.Context, userId int) *model.Drafts {
 	draft := new(model.Drafts)
-	model.Draft().Where("user_id = ?", userId).Find(&draft)
+	model.Draft(ctx).Where("user_id = ?", userId).Find(&draft)
 	if len(draft.LabelIds) > 0 {
 		split := strings.Split(draft.LabelIds, ",")
 		var ids = make([]int, 0, len(split))
@@ -25,14 +27,13 @@ func (*Draft) Get(userId int) *model.Drafts {
 	return draft
 }
 
-func (*Draft) InitDraft(userId int) {
-	go func() {
-		mysql.GetInstance().Create([]model.Drafts{model.Drafts{UserId: userId, State: 1}, model.Drafts{UserId: userId, State: 2}})
-
-	}()
+func (*Draft) InitDraft(ctx context.Context, userId int) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
+		mysql.GetInstance().WithContext(ctx).Create([]model.Drafts{model.Drafts{UserId: userId, State: 1}, model.Drafts{UserId: userId, State: 2}})
+	})
 }
 
-func (*Draft) Save(draft model.Drafts) {
+func (*Draft) Save(ctx context.Context, draft model.Drafts) {
 	if len(draft.Labels) > 0 {
 		var ls = make([]string, 0, len(draft.Labels))
 		for i := range draft.Labels {
@@ -40,10 +41,10 @@ func (*Draft) Save(draft model.Drafts) {
 		}
 		draft.LabelIds = strings.Join(ls, ",")
 	}
-	model.Draft().Where("user_id = ? and state = ?", draft.UserId, 2).Updates(&draft)
+	model.Draft(ctx).Where("user_id = ? and state = ?", draft.UserId, 2).Updates(&draft)
 }
 
-func (*Draft) DelDraft(userId int) {
+func (*Draft) DelDraft(ctx context.Context, userId int) {
 
-	model.Draft().Where("user_id = ? and state = ?", userId, 1).Updates(map[string]interface{}{"content": "", "type": 0, "label_ids": ""})
+	model.Draft(ctx).Where("user_id = ? and state = ?", userId, 1).Updates(map[string]interface{}{"content": "", "type": 0, "label_ids": ""})
 }
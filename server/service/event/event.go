@@ -1,5 +1,11 @@
 package event
 
+import (
+	"context"
+
+	"xhyovo.cn/community/pkg/i18n"
+)
+
 const (
 	CommentUpdateEvent = iota + 1 // 文章下评论更新事件
 	UserFollowingEvent            // 用户关注的人事件
@@ -11,6 +17,8 @@ const (
 	CourseComment                 // 课程回复
 	CourseUpdate                  // 课程更新
 	Meeting                       // 会议
+	SeriesUpdate                  // 系列更新
+	CommentReaction               // 评论表态
 )
 
 var events []*event
@@ -31,6 +39,8 @@ func init() {
 	events = append(events, &event{Id: CourseComment, Msg: "课程回复"})
 	events = append(events, &event{Id: CourseUpdate, Msg: "课程更新"})
 	events = append(events, &event{Id: Meeting, Msg: "分享会"})
+	events = append(events, &event{Id: SeriesUpdate, Msg: "系列更新"})
+	events = append(events, &event{Id: CommentReaction, Msg: "评论表态"})
 
 	eventMap[CommentUpdateEvent] = "文章评论"
 	eventMap[UserFollowingEvent] = "用户更新"
@@ -42,6 +52,8 @@ func init() {
 	eventMap[CourseComment] = "课程回复"
 	eventMap[CourseUpdate] = "课程更新"
 	eventMap[Meeting] = "分享会"
+	eventMap[SeriesUpdate] = "系列更新"
+	eventMap[CommentReaction] = "评论表态"
 
 	eventPage[CommentUpdateEvent] = "articleView"
 	eventPage[UserFollowingEvent] = "articleView"
@@ -53,6 +65,8 @@ func init() {
 	eventPage[CourseComment] = ""
 	eventPage[CourseUpdate] = ""
 	eventPage[Meeting] = ""
+	eventPage[SeriesUpdate] = "articleView"
+	eventPage[CommentReaction] = "articleView"
 
 }
 
@@ -62,16 +76,28 @@ type event struct {
 	Msg string `json:"msg"`
 }
 
-func GetMsg(eventId int) string {
+// GetMsg 事件名称,按 ctx 中的语言翻译
+func GetMsg(ctx context.Context, eventId int) string {
 	v := events[eventId]
-	return v.Msg
+	return i18n.T(ctx, v.Msg)
 }
 
-func List() []*event {
-	return events
+// List 所有事件,名称按 ctx 中的语言翻译,返回副本
+func List(ctx context.Context) []*event {
+	list := make([]*event, len(events))
+	for i, e := range events {
+		if e != nil {
+			list[i] = &event{Id: e.Id, Msg: i18n.T(ctx, e.Msg)}
+		}
+	}
+	return list
 }
-func Map() map[int]string {
-	return eventMap
+func Map(ctx context.Context) map[int]string {
+	m := make(map[int]string, len(eventMap))
+	for id, msg := range eventMap {
+		m[id] = i18n.T(ctx, msg)
+	}
+	return m
 }
 
 func PageName() map[int]string {
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/oss"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/server/model"
+)
+
+// 每批导出的文章数
+const exportBatchSize = 100
+
+// 文件名中标题保留的最大字符数
+const exportTitleLength = 50
+
+type ExportService struct {
+}
+
+// ExportOptions 导出范围,为 0 时不限制;TypeId 可以是分类或父分类
+type ExportOptions struct {
+	UserId int
+	TypeId int
+}
+
+// exportFrontMatter markdown 文件头部的 yaml,字段兼容 hexo、hugo
+type exportFrontMatter struct {
+	ID        int       `yaml:"id"`
+	Title     string    `yaml:"title"`
+	Author    string    `yaml:"author"`
+	Type      string    `yaml:"type"` // 分类标识
+	Tags      []string  `yaml:"tags"`
+	State     int       `yaml:"state"`
+	StateName string    `yaml:"stateName"`
+	Draft     bool      `yaml:"draft"`
+	Date      time.Time `yaml:"date"`
+	Updated   time.Time `yaml:"updated"`
+	Likes     int       `yaml:"likes"`
+	Abstract  string    `yaml:"abstract,omitempty"`
+	Cover     string    `yaml:"cover,omitempty"`
+}
+
+// exportComment 评论单独保存在 .comments.json 中
+type exportComment struct {
+	ID        int       `json:"id"`
+	ParentId  int       `json:"parentId"`
+	RootId    int       `json:"rootId"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Adopted   bool      `json:"adopted"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// exporter 一次导出的状态,资源只打包一次
+type exporter struct {
+	ctx    context.Context
+	zw     *zip.Writer
+	assets map[string]string // fileKey -> 压缩包中的路径,读取失败时为空
+	types  map[int]model.Types
+	users  map[int]string
+}
+
+// Export 把文章、问答导出为 zip 写入 w: articles/ 下为 markdown 和评论,assets/ 下为引用的文件
+func (s *ExportService) Export(ctx context.Context, w io.Writer, opts ExportOptions) (int, error) {
+	e := &exporter{ctx: ctx, zw: zip.NewWriter(w), assets: make(map[string]string), types: make(map[int]model.Types), users: make(map[int]string)}
+	var types []model.Types
+	model.Type(ctx).Find(&types)
+	for _, t := range types {
+		e.types[t.ID] = t
+	}
+
+	db := model.Article(ctx).Select("id", "title", "abstract", "content", "user_id", "state", "type", "like", "cover", "created_at", "updated_at")
+	if opts.UserId != 0 {
+		db = db.Where("user_id = ?", opts.UserId)
+	}
+	if opts.TypeId != 0 {
+		db = db.Where("type = ? or type in (?)", opts.TypeId, model.Type(ctx).Select("id").Where("parent_id = ?", opts.TypeId))
+	}
+	total := 0
+	var articles []model.Articles
+	err := db.Order("id").FindInBatches(&articles, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		total += len(articles)
+		return e.batch(articles)
+	}).Error
+	if err != nil {
+		return total, err
+	}
+	return total, e.zw.Close()
+}
+
+func (e *exporter) batch(articles []model.Articles) error {
+	ids := make([]int, 0, len(articles))
+	userIds := make([]int, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ID)
+		userIds = append(userIds, a.UserId)
+	}
+
+	var tagRows []struct {
+		ArticleId int
+		TagName   string
+	}
+	model.ArticleTagRelation(e.ctx).Joins("JOIN article_tags ON article_tags.id = article_tag_relations.tag_id").
+		Where("article_tag_relations.article_id in ?", ids).Select("article_tag_relations.article_id, article_tags.tag_name").Scan(&tagRows)
+	tags := make(map[int][]string)
+	for _, t := range tagRows {
+		tags[t.ArticleId] = append(tags[t.ArticleId], t.TagName)
+	}
+
+	var comments []model.Comments
+	model.Comment(e.ctx).Where("business_id in ? and tenant_id = 0", ids).Order("id").Find(&comments)
+	var adopted []int
+	model.QaAdoption(e.ctx).Where("article_id in ?", ids).Pluck("comment_id", &adopted)
+	for _, c := range comments {
+		userIds = append(userIds, c.FromUserId)
+	}
+	e.loadUsers(userIds)
+	commentMap := make(map[int][]exportComment)
+	for _, c := range comments {
+		commentMap[c.BusinessId] = append(commentMap[c.BusinessId], exportComment{
+			ID:        c.ID,
+			ParentId:  c.ParentId,
+			RootId:    c.RootId,
+			Author:    e.users[c.FromUserId],
+			Content:   utils.ReplaceFileKeys(c.Content, e.asset),
+			Adopted:   containsInt(adopted, c.ID),
+			CreatedAt: time.Time(c.CreatedAt),
+		})
+	}
+
+	for i := range articles {
+		a := &articles[i]
+		name := exportFileName(a.ID, a.Title)
+		if err := e.writeArticle(name, a, tags[a.ID]); err != nil {
+			return err
+		}
+		if list := commentMap[a.ID]; len(list) > 0 {
+			if err := e.writeJson(name+".comments.json", list); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *exporter) loadUsers(ids []int) {
+	var users []model.Users
+	model.User(e.ctx).Where("id in ?", ids).Select("id", "name").Find(&users)
+	for _, u := range users {
+		e.users[u.ID] = u.Name
+	}
+}
+
+func (e *exporter) writeArticle(name string, a *model.Articles, tags []string) error {
+	content := utils.ReplaceFileKeys(a.Content, e.asset)
+	fm := exportFrontMatter{
+		ID:        a.ID,
+		Title:     a.Title,
+		Author:    e.users[a.UserId],
+		Type:      e.types[a.Type].FlagName,
+		Tags:      tags,
+		State:     a.State,
+		StateName: constant.GetArticleName(e.ctx, a.State),
+		Draft:     !isPublicState(a.State),
+		Date:      time.Time(a.CreatedAt),
+		Updated:   time.Time(a.UpdatedAt),
+		Likes:     a.Like,
+		Abstract:  a.Abstract,
+	}
+	if fm.Tags == nil {
+		fm.Tags = []string{}
+	}
+	if a.Cover != "" {
+		fm.Cover = a.Cover
+		if p := e.asset(coverFileKey(a.Cover)); p != "" {
+			fm.Cover = p
+		}
+	}
+	head, err := yaml.Marshal(fm)
+	if err != nil {
+		return err
+	}
+	f, err := e.zw.Create(name + ".md")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "---\n%s---\n\n%s\n", head, strings.TrimRight(content, "\n"))
+	return err
+}
+
+func (e *exporter) writeJson(name string, v any) error {
+	f, err := e.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// asset 把 fileKey 对应的文件打包到 assets/ 下,返回相对 articles/ 的路径;读取失败时返回空字符串,保留原地址
+func (e *exporter) asset(fileKey string) string {
+	if fileKey == "" {
+		return ""
+	}
+	if p, ok := e.assets[fileKey]; ok {
+		return p
+	}
+	e.assets[fileKey] = ""
+	cleaned := strings.TrimPrefix(path.Clean("/"+fileKey), "/")
+	if cleaned == "" {
+		return ""
+	}
+	reader, err := oss.GetInstance().Get(fileKey)
+	if err != nil {
+		log.Ctx(e.ctx).Warnf("导出时读取文件失败,fileKey: %s,err: %s", fileKey, err.Error())
+		return ""
+	}
+	defer reader.Close()
+	f, err := e.zw.Create("assets/" + cleaned)
+	if err == nil {
+		_, err = io.Copy(f, reader)
+	}
+	if err != nil {
+		log.Ctx(e.ctx).Warnf("导出时打包文件失败,fileKey: %s,err: %s", fileKey, err.Error())
+		return ""
+	}
+	e.assets[fileKey] = "../assets/" + cleaned
+	return e.assets[fileKey]
+}
+
+// coverFileKey 封面保存的是 fileKey,也可能是带 fileKey 参数的地址或外部地址
+func coverFileKey(cover string) string {
+	var key string
+	utils.ReplaceFileKeys("]("+cover+")", func(fileKey string) string {
+		key = fileKey
+		return ""
+	})
+	if key != "" {
+		return key
+	}
+	if strings.Contains(cover, "://") || strings.HasPrefix(cover, "/") {
+		return ""
+	}
+	return cover
+}
+
+// exportFileName articles/<id>-<标题>,去掉文件名中不能使用的字符
+func exportFileName(id int, title string) string {
+	var sb strings.Builder
+	n := 0
+	for _, r := range strings.TrimSpace(title) {
+		if n >= exportTitleLength {
+			break
+		}
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r), unicode.IsControl(r):
+			r = '_'
+		case unicode.IsSpace(r):
+			r = '-'
+		}
+		sb.WriteRune(r)
+		n++
+	}
+	if sb.Len() == 0 {
+		return fmt.Sprintf("articles/%d", id)
+	}
+	return fmt.Sprintf("articles/%d-%s", id, sb.String())
+}
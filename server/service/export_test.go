@@ -0,0 +1,77 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/oss"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestExportArticles(t *testing.T) {
+	initSQLite(t)
+	oss.Init(config.OssConfig{Driver: oss.DriverLocal, LocalPath: t.TempDir(), SecretKey: "secret"})
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Users{ID: 2, Name: "reader"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1, FlagName: "go"})
+	db.Create(&model.ArticleTags{Id: 1, TagName: "并发", UserId: 1})
+	db.Create(&model.ArticleTagRelations{ArticleId: 1, TagId: 1, UserId: 1})
+	content := "图片 ![a](/community/file/singUrl?fileKey=1/a.png \"title\")\n丢失 ![b](/community/file/singUrl?fileKey=1/missing.png)\n"
+	db.Create(&model.Articles{ID: 1, Title: "Go: 并发/channel", Content: content, UserId: 1, Type: 2, State: constant.Published, Like: 3, Cover: "1/a.png"})
+	db.Create(&model.Articles{ID: 2, Title: "草稿", Content: "draft", UserId: 1, Type: 2, State: constant.Draft})
+	db.Create(&model.Articles{ID: 3, Title: "别人的", Content: "other", UserId: 2, Type: 2, State: constant.Published})
+	db.Create(&model.Comments{Content: "好文", FromUserId: 2, BusinessId: 1})
+	if err := oss.GetInstance().Put("1/a.png", strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	var s ExportService
+	var buf bytes.Buffer
+	count, err := s.Export(ctx, &buf, ExportOptions{UserId: 1})
+	if err != nil || count != 2 {
+		t.Fatalf("导出: %d, %v", count, err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, _ := f.Open()
+		b, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(b)
+	}
+	md, ok := files["articles/1-Go_-并发_channel.md"]
+	if !ok {
+		t.Fatalf("文件: %v", files)
+	}
+	for _, want := range []string{"title: 'Go: 并发/channel'", "type: go", "- 并发", "draft: false", "likes: 3", "cover: ../assets/1/a.png",
+		"![a](../assets/1/a.png \"title\")", "![b](/community/file/singUrl?fileKey=1/missing.png)"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("缺少 %q:\n%s", want, md)
+		}
+	}
+	if files["assets/1/a.png"] != "png" {
+		t.Fatal("引用的文件应打包到 assets")
+	}
+	if !strings.Contains(files["articles/1-Go_-并发_channel.comments.json"], `"author": "reader"`) {
+		t.Fatalf("评论: %v", files)
+	}
+	if !strings.Contains(files["articles/2-草稿.md"], "draft: true") {
+		t.Fatal("草稿")
+	}
+	if len(zr.File) != 4 {
+		t.Fatalf("只导出自己的文章: %d", len(zr.File))
+	}
+}
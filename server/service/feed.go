@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/feed"
+	"xhyovo.cn/community/pkg/oss"
+	"xhyovo.cn/community/pkg/search"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/server/model"
+)
+
+const (
+	// 订阅源中的文章数
+	feedSize = 20
+	// 没有摘要时从正文截取的长度
+	feedSummaryLength = 200
+)
+
+type FeedService struct {
+}
+
+// FeedOptions 按分类标识、标签、作者筛选,都为空时为最新文章
+type FeedOptions struct {
+	TypeFlag string
+	TagId    int
+	AuthorId int
+	Token    string // 用户的订阅 token,会员分类需要
+}
+
+// Feed 最新公开的文章、问答,没有 token 时只包含公开分类
+func (s *FeedService) Feed(ctx context.Context, opts FeedOptions) (*feed.Feed, error) {
+	member, err := s.member(ctx, opts.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	site := siteConfig()
+	f := &feed.Feed{Title: site.Name, Link: siteUrl(), Description: site.Description}
+	db := model.Article(ctx).Select("id", "title", "abstract", "content", "cover", "user_id", "type", "created_at", "updated_at").
+		Where("state in ?", publicStates)
+	var typeS TypeService
+	var visible []int
+	if !member {
+		visible = typeS.PublicIds(ctx)
+	}
+	switch {
+	case opts.TypeFlag != "":
+		var t model.Types
+		model.Type(ctx).Where("flag_name = ?", opts.TypeFlag).Limit(1).Find(&t)
+		if t.ID == 0 {
+			return nil, errs.NotFound.WithMsg("分类不存在")
+		}
+		// 一级分类包含其下的二级分类
+		ids := []int{t.ID}
+		if t.ParentId == 0 {
+			ids = nil
+			model.Type(ctx).Where("parent_id = ?", t.ID).Pluck("id", &ids)
+		}
+		if !member {
+			ids = intersectInts(ids, visible)
+			if len(ids) == 0 {
+				return nil, errs.Unauthorized.WithMsg("会员分类需要使用订阅 token")
+			}
+		}
+		db = db.Where("type in ?", ids)
+		f.Title += " - " + t.Title
+	case opts.TagId != 0:
+		var tag model.ArticleTags
+		model.ArticleTag(ctx).Where("id = ?", opts.TagId).Limit(1).Find(&tag)
+		if tag.Id == 0 {
+			return nil, errs.NotFound.WithMsg("标签不存在")
+		}
+		db = db.Where("id in (?)", model.ArticleTagRelation(ctx).Select("article_id").Where("tag_id = ?", tag.Id))
+		f.Title += " - #" + tag.TagName
+	case opts.AuthorId != 0:
+		var user model.Users
+		model.User(ctx).Where("id = ?", opts.AuthorId).Select("id", "name").Limit(1).Find(&user)
+		if user.ID == 0 {
+			return nil, errs.NotFound.WithMsg("用户不存在")
+		}
+		db = db.Where("user_id = ?", user.ID)
+		f.Title += " - " + user.Name
+	}
+	if !member {
+		db = db.Where("type in ?", visible)
+	}
+
+	var articles []model.Articles
+	if err := db.Order("created_at desc").Limit(feedSize).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	f.Items = s.items(ctx, articles, opts.Token)
+	f.Updated = time.Now()
+	if len(articles) > 0 {
+		f.Updated = time.Time(articles[0].CreatedAt)
+	}
+	return f, nil
+}
+
+func (s *FeedService) items(ctx context.Context, articles []model.Articles, token string) []feed.Item {
+	ids := make([]int, 0, len(articles))
+	userIds := make([]int, 0, len(articles))
+	typeIds := make([]int, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ID)
+		userIds = append(userIds, a.UserId)
+		typeIds = append(typeIds, a.Type)
+	}
+	var typeS TypeService
+	types := typeS.ListByIdToMap(ctx, typeIds)
+	var users []model.Users
+	model.User(ctx).Where("id in ?", userIds).Select("id", "name").Find(&users)
+	names := make(map[int]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+	var tagRows []struct {
+		ArticleId int
+		TagName   string
+	}
+	model.ArticleTagRelation(ctx).Joins("JOIN article_tags ON article_tags.id = article_tag_relations.tag_id").
+		Where("article_tag_relations.article_id in ?", ids).Select("article_tag_relations.article_id, article_tags.tag_name").Scan(&tagRows)
+	tags := make(map[int][]string)
+	for _, t := range tagRows {
+		tags[t.ArticleId] = append(tags[t.ArticleId], t.TagName)
+	}
+
+	items := make([]feed.Item, 0, len(articles))
+	for _, a := range articles {
+		summary := a.Abstract
+		if summary == "" {
+			summary = utils.Limit(search.PlainText(a.Content), 0, feedSummaryLength, "...")
+		}
+		categories := tags[a.ID]
+		if t := types[a.Type]; t != "" {
+			categories = append([]string{t}, categories...)
+		}
+		image := ""
+		if a.Cover != "" {
+			image = coverUrl(a.ID, token)
+		}
+		items = append(items, feed.Item{
+			ID:         strconv.Itoa(a.ID),
+			Title:      a.Title,
+			Link:       articleUrl(a.ID),
+			Summary:    summary,
+			Image:      image,
+			Author:     names[a.UserId],
+			Categories: categories,
+			Published:  time.Time(a.CreatedAt),
+			Updated:    time.Time(a.UpdatedAt),
+		})
+	}
+	return items
+}
+
+// member token 有效时为会员,可以访问所有分类
+func (s *FeedService) member(ctx context.Context, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	userId := s.userId(ctx, token)
+	if userId == 0 {
+		return false, errs.TokenInvalid.WithMsg("订阅 token 无效")
+	}
+	var userS UserService
+	if black, _ := userS.GetAuthState(ctx, userId); black {
+		return false, errs.Banned
+	}
+	return true, nil
+}
+
+// Cover 订阅源中文章封面的访问地址,阅读器无法登录,会员分类的封面同样需要 token
+func (s *FeedService) Cover(ctx context.Context, articleId int, token string) (string, error) {
+	member, err := s.member(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	var article model.Articles
+	model.Article(ctx).Where("id = ? and state in ?", articleId, publicStates).Select("id", "type", "cover").Limit(1).Find(&article)
+	if article.ID == 0 || article.Cover == "" {
+		return "", errs.NotFound.WithMsg("封面不存在")
+	}
+	if !member {
+		var typeS TypeService
+		if !containsInt(typeS.PublicIds(ctx), article.Type) {
+			return "", errs.Unauthorized.WithMsg("会员分类需要使用订阅 token")
+		}
+	}
+	key := coverFileKey(article.Cover)
+	if key == "" {
+		// 外部图片
+		return article.Cover, nil
+	}
+	if u := oss.SingUrl(key); u != "" {
+		return u, nil
+	}
+	return "", errs.NotFound.WithMsg("封面不存在")
+}
+
+// Token 用户的订阅 token,没有时生成
+func (s *FeedService) Token(ctx context.Context, userId int) (string, error) {
+	var token sql.NullString
+	if err := model.User(ctx).Where("id = ?", userId).Select("feed_token").Row().Scan(&token); err != nil {
+		return "", err
+	}
+	if token.String != "" {
+		return token.String, nil
+	}
+	return s.ResetToken(ctx, userId)
+}
+
+// ResetToken 重新生成订阅 token,旧的订阅地址失效
+func (s *FeedService) ResetToken(ctx context.Context, userId int) (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := model.User(ctx).Where("id = ?", userId).Update("feed_token", token).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *FeedService) userId(ctx context.Context, token string) (id int) {
+	model.User(ctx).Where("feed_token = ?", token).Select("id").Limit(1).Find(&id)
+	return
+}
+
+func siteConfig() config.SiteConfig {
+	if c := config.GetInstance(); c != nil {
+		return c.SiteConfig
+	}
+	return config.SiteConfig{}
+}
+
+// siteUrl 站点地址,不带末尾的 /
+func siteUrl() string {
+	return strings.TrimRight(siteConfig().Url, "/")
+}
+
+// articleUrl 文章页面的地址
+func articleUrl(id int) string {
+	return siteUrl() + "/article/" + strconv.Itoa(id)
+}
+
+// coverUrl 订阅源中封面的地址,会员订阅需要带上 token
+func coverUrl(articleId int, token string) string {
+	u := siteUrl() + "/community/feed/cover/" + strconv.Itoa(articleId)
+	if token != "" {
+		u += "?token=" + url.QueryEscape(token)
+	}
+	return u
+}
+
+func intersectInts(a, b []int) []int {
+	result := make([]int, 0, len(a))
+	for _, v := range a {
+		if containsInt(b, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
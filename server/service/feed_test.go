@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestFeed(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1, FlagName: "go", Public: true})
+	db.Create(&model.Types{ID: 3, Title: "内部", ParentId: 1, FlagName: "internal"})
+	db.Create(&model.ArticleTags{Id: 1, TagName: "并发", UserId: 1})
+	db.Create(&model.Articles{ID: 1, Title: "公开", Abstract: "摘要", Content: "x", UserId: 1, Type: 2, State: constant.Published, Cover: "1/a.png"})
+	db.Create(&model.Articles{ID: 2, Title: "会员", Content: "会员 **正文**", UserId: 1, Type: 3, State: constant.Published})
+	db.Create(&model.Articles{ID: 3, Title: "草稿", Content: "x", UserId: 1, Type: 2, State: constant.Draft})
+	db.Create(&model.ArticleTagRelations{ArticleId: 2, TagId: 1, UserId: 1})
+
+	var s FeedService
+	f, err := s.Feed(ctx, FeedOptions{})
+	if err != nil || len(f.Items) != 1 || f.Items[0].Title != "公开" || f.Items[0].Summary != "摘要" {
+		t.Fatalf("未登录只能看到公开分类: %+v, %v", f, err)
+	}
+	if !strings.HasSuffix(f.Items[0].Image, "/community/feed/cover/1") || f.Items[0].Categories[0] != "Go" {
+		t.Fatalf("封面和分类: %+v", f.Items[0])
+	}
+	if _, err = s.Feed(ctx, FeedOptions{TypeFlag: "internal"}); !errors.Is(err, errs.Unauthorized) {
+		t.Fatalf("会员分类需要 token: %v", err)
+	}
+	if _, err = s.Feed(ctx, FeedOptions{Token: "bad"}); !errors.Is(err, errs.TokenInvalid) {
+		t.Fatalf("token 无效: %v", err)
+	}
+	if f, _ = s.Feed(ctx, FeedOptions{TagId: 1}); len(f.Items) != 0 {
+		t.Fatal("标签下只有会员文章")
+	}
+
+	token, err := s.Token(ctx, 1)
+	if err != nil || token == "" {
+		t.Fatal(err)
+	}
+	if again, _ := s.Token(ctx, 1); again != token {
+		t.Fatal("token 应保持不变")
+	}
+	f, err = s.Feed(ctx, FeedOptions{TypeFlag: "article", Token: token})
+	if err != nil || len(f.Items) != 2 {
+		t.Fatalf("会员可以看到所有分类: %+v, %v", f, err)
+	}
+	f, _ = s.Feed(ctx, FeedOptions{TagId: 1, Token: token})
+	if len(f.Items) != 1 || f.Items[0].Summary != "会员 正文" {
+		t.Fatalf("没有摘要时截取正文: %+v", f.Items)
+	}
+	if f, _ = s.Feed(ctx, FeedOptions{AuthorId: 1, Token: token}); len(f.Items) != 2 || !strings.HasSuffix(f.Title, " - xhy") {
+		t.Fatalf("作者: %+v", f)
+	}
+
+	if _, err = s.Cover(ctx, 2, ""); !errors.Is(err, errs.NotFound) {
+		t.Fatalf("没有封面: %v", err)
+	}
+	reset, _ := s.ResetToken(ctx, 1)
+	if _, err = s.Feed(ctx, FeedOptions{Token: token}); reset == token || !errors.Is(err, errs.TokenInvalid) {
+		t.Fatal("重置后旧 token 失效")
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/dustin/go-humanize"
@@ -10,24 +11,24 @@ import (
 
 type FileService struct{}
 
-func (*FileService) Save(file *model.Files) {
+func (*FileService) Save(ctx context.Context, file *model.Files) {
 	file.TenantId = 1
 	jsonBody, _ := json.Marshal(file)
-	log.Infof("用户id: %d,上传文件: %s", file.UserId, jsonBody)
-	fileDao.Save(file)
+	log.Ctx(ctx).Infof("用户id: %d,上传文件: %s", file.UserId, jsonBody)
+	fileDao.Save(ctx, file)
 }
 
-func (*FileService) Delete(userId, fileId, tenantId int) {
-	fileDao.Delete(userId, fileId, tenantId)
+func (*FileService) Delete(ctx context.Context, userId, fileId, tenantId int) {
+	fileDao.Delete(ctx, userId, fileId, tenantId)
 }
 
 func (*FileService) Deletes(userId, businessId, tenantcdId int) {
 
 }
 
-func (s *FileService) PageFiles(p, limit, userId int) (files []model.Files, count int64) {
+func (s *FileService) PageFiles(ctx context.Context, p, limit, userId int) (files []model.Files, count int64) {
 
-	files = fileDao.PageFiles(p, limit, userId)
+	files = fileDao.PageFiles(ctx, p, limit, userId)
 	if len(files) == 0 {
 		return []model.Files{}, 0
 	}
@@ -36,18 +37,18 @@ func (s *FileService) PageFiles(p, limit, userId int) (files []model.Files, coun
 	for i := range files {
 		userIds.Add(files[i].UserId)
 	}
-	nameMap := uS.ListByIdsToMap(userIds.ToSlice())
+	nameMap := uS.ListByIdsToMap(ctx, userIds.ToSlice())
 	for i := range files {
 		files[i].UserName = nameMap[files[i].UserId].Name
 		files[i].SizeName = humanize.Bytes(uint64(files[i].Size))
 	}
-	count = fileDao.Count()
+	count = fileDao.Count(ctx)
 
 	return files, count
 }
 
-func (s *FileService) ExistFile(key string) bool {
+func (s *FileService) ExistFile(ctx context.Context, key string) bool {
 	var count int64
-	model.File().Where("file_key = ?", key).Count(&count)
+	model.File(ctx).Where("file_key = ?", key).Count(&count)
 	return count == 1
 }
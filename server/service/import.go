@@ -0,0 +1,635 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/moderation"
+	"xhyovo.cn/community/pkg/oss"
+	ltime "xhyovo.cn/community/pkg/time"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+)
+
+const (
+	// ImportMaxSize 导入的压缩包大小上限
+	ImportMaxSize = 50 << 20
+	// 压缩包中单个文件解压后的大小上限
+	importMaxFileSize = 20 << 20
+	// 单次导入的文章数上限
+	importMaxArticles = 500
+	// 摘要字段长度
+	importAbstractLength = 500
+	// 标题字段长度
+	importTitleLength = 255
+)
+
+// 没有时区的日期按本地时间解析
+var importDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+type ImportService struct {
+}
+
+// ImportOptions TypeId 为没有匹配到分类的文章使用的默认分类,为 0 时这些文章不导入
+type ImportOptions struct {
+	UserId int
+	TypeId int
+	DryRun bool
+}
+
+// ImportReport 导入结果,预览时为将要执行的操作
+type ImportReport struct {
+	DryRun        bool          `json:"dryRun"`
+	Total         int           `json:"total"`
+	Imported      int           `json:"imported"`
+	Skipped       int           `json:"skipped"`
+	UnmappedTypes []string      `json:"unmappedTypes"` // 没有匹配到分类的 categories
+	NewTags       []string      `json:"newTags"`       // 需要新建的标签
+	Articles      []*ImportItem `json:"articles"`
+}
+
+type ImportItem struct {
+	File          string    `json:"file"`
+	Title         string    `json:"title"`
+	ArticleId     int       `json:"articleId,omitempty"` // 导入后的文章id
+	TypeId        int       `json:"typeId"`
+	TypeTitle     string    `json:"typeTitle"`
+	Categories    []string  `json:"categories"`
+	Tags          []string  `json:"tags"`
+	State         int       `json:"state"`
+	Date          time.Time `json:"date"`
+	Updated       time.Time `json:"updated"`
+	Assets        int       `json:"assets"`                  // 引用的本地图片数
+	MissingAssets []string  `json:"missingAssets,omitempty"` // 压缩包中找不到的本地图片
+	ConflictId    int       `json:"conflictId,omitempty"`    // 同名文章的id
+	Unmapped      bool      `json:"unmapped"`                // 没有匹配到分类
+	Error         string    `json:"error,omitempty"`         // 不导入的原因
+}
+
+// importPost 解析后的 markdown 文件
+type importPost struct {
+	item     *ImportItem
+	content  string
+	abstract string
+	cover    string
+	draft    bool
+}
+
+// importer 一次导入的状态,同一个图片只上传一次
+type importer struct {
+	ctx    context.Context
+	opts   ImportOptions
+	files  map[string]*zip.File
+	assets map[string]string // 压缩包中的路径 -> fileKey
+	types  []model.Types
+	tags   map[string]int // 小写标签名 -> 标签id
+}
+
+// Import 从 hexo、hugo 等博客的 markdown 压缩包导入文章,DryRun 时只返回报告
+// 分类按 categories、type 匹配分类的标识或名称,同名文章和没有匹配到分类的文章不导入
+func (s *ImportService) Import(ctx context.Context, r io.ReaderAt, size int64, opts ImportOptions) (*ImportReport, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errs.BadRequest.WithMsg("压缩包格式错误")
+	}
+	im := &importer{ctx: ctx, opts: opts, files: make(map[string]*zip.File), assets: make(map[string]string), tags: make(map[string]int)}
+	model.Type(ctx).Where("parent_id != 0").Order("sort").Find(&im.types)
+	if opts.TypeId != 0 {
+		if _, ok := im.typeById(opts.TypeId); !ok {
+			return nil, errs.BadRequest.WithMsg("默认分类不存在或为一级分类")
+		}
+	}
+
+	var names []string
+	for _, f := range zr.File {
+		name := path.Clean(strings.TrimPrefix(strings.ReplaceAll(f.Name, "\\", "/"), "/"))
+		if f.FileInfo().IsDir() || ignoredImportPath(name) {
+			continue
+		}
+		im.files[name] = f
+		if ext := strings.ToLower(path.Ext(name)); ext == ".md" || ext == ".markdown" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, errs.BadRequest.WithMsg("压缩包中没有 markdown 文件")
+	}
+	if len(names) > importMaxArticles {
+		return nil, errs.BadRequest.WithMsg(i18n.T(ctx, "单次最多导入 %d 篇文章", importMaxArticles))
+	}
+	sort.Strings(names)
+
+	report := &ImportReport{DryRun: opts.DryRun, Total: len(names), UnmappedTypes: []string{}, NewTags: []string{}, Articles: make([]*ImportItem, 0, len(names))}
+	titles := make(map[string]bool)
+	unmapped := make(map[string]bool)
+	newTags := make(map[string]bool)
+	for _, name := range names {
+		post := im.parse(name)
+		item := post.item
+		report.Articles = append(report.Articles, item)
+		if item.Error == "" {
+			im.check(post, titles)
+		}
+		if item.Unmapped {
+			for _, c := range item.Categories {
+				if !unmapped[c] {
+					unmapped[c] = true
+					report.UnmappedTypes = append(report.UnmappedTypes, c)
+				}
+			}
+		}
+		if item.Error != "" {
+			report.Skipped++
+			continue
+		}
+		for _, tag := range item.Tags {
+			key := strings.ToLower(tag)
+			if !newTags[key] && im.tagId(tag) == 0 {
+				newTags[key] = true
+				report.NewTags = append(report.NewTags, tag)
+			}
+		}
+		if opts.DryRun {
+			continue
+		}
+		if err := im.save(post); err != nil {
+			log.Ctx(ctx).Warnf("用户id: %d 导入文章失败,文件: %s,err: %s", opts.UserId, name, err.Error())
+			item.Error = err.Error()
+			report.Skipped++
+			continue
+		}
+		report.Imported++
+	}
+	return report, nil
+}
+
+// 跳过隐藏文件和 macOS 打包产生的文件
+func ignoredImportPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+func (im *importer) read(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > importMaxFileSize {
+		return nil, errs.BadRequest.WithMsg("文件过大")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, importMaxFileSize))
+}
+
+// parse 解析 markdown 文件,支持 yaml(---) 和 toml(+++) 格式的 front matter
+func (im *importer) parse(name string) *importPost {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	item := &ImportItem{File: name, Title: base, Categories: []string{}, Tags: []string{}}
+	post := &importPost{item: item}
+	raw, err := im.read(im.files[name])
+	if err != nil {
+		item.Error = err.Error()
+		return post
+	}
+	if !utf8.Valid(raw) {
+		item.Error = "文件不是 utf-8 编码"
+		return post
+	}
+	text := strings.ReplaceAll(strings.TrimPrefix(string(raw), "\ufeff"), "\r\n", "\n")
+
+	meta := make(map[string]any)
+	body := text
+	for _, delim := range []string{"---", "+++"} {
+		if !strings.HasPrefix(text, delim+"\n") {
+			continue
+		}
+		end := strings.Index(text[len(delim)+1:], "\n"+delim)
+		if end < 0 {
+			break
+		}
+		head := text[len(delim)+1 : len(delim)+1+end]
+		body = strings.TrimPrefix(text[len(delim)+1+end+len(delim)+1:], "\n")
+		if delim == "---" {
+			err = yaml.Unmarshal([]byte(head), &meta)
+		} else {
+			err = toml.Unmarshal([]byte(head), &meta)
+		}
+		if err != nil {
+			item.Error = "front matter 格式错误: " + err.Error()
+			return post
+		}
+		break
+	}
+
+	if title := strings.TrimSpace(metaString(meta, "title")); title != "" {
+		item.Title = title
+	}
+	if utf8.RuneCountInString(item.Title) > importTitleLength {
+		item.Title = string([]rune(item.Title)[:importTitleLength])
+	}
+	item.Date = metaTime(meta, "date", "created", "publishDate")
+	item.Updated = metaTime(meta, "updated", "lastmod", "modified")
+	if item.Date.IsZero() {
+		if f := im.files[name]; !f.Modified.IsZero() {
+			item.Date = f.Modified
+		} else {
+			item.Date = time.Now()
+		}
+	}
+	if item.Updated.IsZero() || item.Updated.Before(item.Date) {
+		item.Updated = item.Date
+	}
+	item.Tags = uniqueStrings(metaStrings(meta, "tags"))
+	item.Categories = uniqueStrings(append(metaStrings(meta, "categories", "category"), metaStrings(meta, "type")...))
+	post.draft = metaBool(meta, "draft") || meta["published"] == false || strings.HasPrefix(name, "_drafts/") || strings.Contains(name, "/_drafts/")
+	post.abstract = strings.TrimSpace(metaString(meta, "abstract", "description", "summary", "excerpt"))
+	if utf8.RuneCountInString(post.abstract) > importAbstractLength {
+		post.abstract = string([]rune(post.abstract)[:importAbstractLength])
+	}
+	post.cover = strings.TrimSpace(metaString(meta, "cover", "image", "thumbnail"))
+	post.content = strings.TrimSpace(body)
+	if post.content == "" {
+		item.Error = "文章内容为空"
+	}
+	return post
+}
+
+// check 匹配分类、检查同名文章和本地图片
+func (im *importer) check(post *importPost, titles map[string]bool) {
+	item := post.item
+	t, ok := im.matchType(item.Categories)
+	if !ok && im.opts.TypeId != 0 {
+		t, _ = im.typeById(im.opts.TypeId)
+	}
+	item.Unmapped = !ok && len(item.Categories) > 0
+	if t.ID == 0 {
+		item.Unmapped = true
+		item.Error = "没有匹配到分类"
+		return
+	}
+	item.TypeId, item.TypeTitle = t.ID, t.Title
+	item.State = im.state(t, post.draft)
+
+	var conflictId int
+	model.Article(im.ctx).Where("user_id = ? and title = ?", im.opts.UserId, item.Title).Select("id").Limit(1).Find(&conflictId)
+	if conflictId != 0 || titles[item.Title] {
+		item.ConflictId = conflictId
+		item.Error = "已存在同名文章"
+		return
+	}
+	titles[item.Title] = true
+
+	seen := make(map[string]bool)
+	count := func(link string) string {
+		if !localImportLink(link) || seen[link] {
+			return ""
+		}
+		seen[link] = true
+		if im.resolve(item.File, link) == "" {
+			item.MissingAssets = append(item.MissingAssets, link)
+		} else {
+			item.Assets++
+		}
+		return ""
+	}
+	utils.ReplaceImageLinks(post.content, count)
+	if post.cover != "" {
+		count(post.cover)
+	}
+}
+
+// matchType 按标识、名称匹配二级分类,不区分大小写
+func (im *importer) matchType(categories []string) (model.Types, bool) {
+	for _, c := range categories {
+		for _, t := range im.types {
+			if strings.EqualFold(t.FlagName, c) || strings.EqualFold(t.Title, c) {
+				return t, true
+			}
+		}
+	}
+	return model.Types{}, false
+}
+
+func (im *importer) typeById(id int) (model.Types, bool) {
+	for _, t := range im.types {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return model.Types{}, false
+}
+
+// state 草稿保持草稿,问答发布后为待解决
+func (im *importer) state(t model.Types, draft bool) int {
+	var parent model.Types
+	model.Type(im.ctx).Where("id = ?", t.ParentId).Select("title").Limit(1).Find(&parent)
+	qa := parent.Title == "QA"
+	switch {
+	case draft && qa:
+		return constant.QADraft
+	case draft:
+		return constant.Draft
+	case qa:
+		return constant.Pending
+	}
+	return constant.Published
+}
+
+// tagId 查询已存在的标签,不存在时返回 0
+func (im *importer) tagId(name string) int {
+	key := strings.ToLower(name)
+	if id, ok := im.tags[key]; ok {
+		return id
+	}
+	var id int
+	model.ArticleTag(im.ctx).Where("tag_name = ?", key).Select("id").Limit(1).Find(&id)
+	if id != 0 {
+		im.tags[key] = id
+	}
+	return id
+}
+
+// localImportLink 压缩包内的相对地址,排除网络地址和站内地址
+func localImportLink(link string) bool {
+	if link == "" || strings.HasPrefix(link, "#") || strings.HasPrefix(link, "//") || strings.HasPrefix(link, "data:") {
+		return false
+	}
+	if strings.Contains(link, "://") || strings.Contains(link, "fileKey=") {
+		return false
+	}
+	return true
+}
+
+// resolve 查找图片在压缩包中的路径,找不到时返回空字符串
+// 依次尝试: 相对 markdown 所在目录、hexo 资源文件夹、以 / 开头时匹配 source/、static/ 等目录下的同名路径
+func (im *importer) resolve(file, link string) string {
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		link = link[:i]
+	}
+	if l, err := url.PathUnescape(link); err == nil {
+		link = l
+	}
+	dir := path.Dir(file)
+	if !strings.HasPrefix(link, "/") {
+		stem := strings.TrimSuffix(path.Base(file), path.Ext(file))
+		for _, p := range []string{path.Join(dir, link), path.Join(dir, stem, link)} {
+			if _, ok := im.files[p]; ok && !strings.HasPrefix(p, "../") {
+				return p
+			}
+		}
+	}
+	suffix := "/" + strings.TrimPrefix(path.Clean("/"+link), "/")
+	found := ""
+	for p := range im.files {
+		if ("/"+p == suffix || strings.HasSuffix(p, suffix)) && (found == "" || len(p) < len(found)) {
+			found = p
+		}
+	}
+	return found
+}
+
+// upload 上传图片,返回 fileKey
+func (im *importer) upload(p string) (string, error) {
+	if key, ok := im.assets[p]; ok {
+		return key, nil
+	}
+	data, err := im.read(im.files[p])
+	if err != nil {
+		return "", err
+	}
+	contentType := mime.TypeByExtension(strings.ToLower(path.Ext(p)))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	key := utils.BuildFileKey(im.opts.UserId) + strings.ToLower(path.Ext(p))
+	if err = oss.GetInstance().Put(key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return "", err
+	}
+	var fileS FileService
+	fileS.Save(im.ctx, &model.Files{FileKey: key, Size: int64(len(data)), Format: contentType, UserId: im.opts.UserId})
+	im.assets[p] = key
+	return key, nil
+}
+
+func (im *importer) save(post *importPost) error {
+	item := post.item
+	// 先审核,会被拒绝的文章不上传图片、不创建标签;其他情况在内容替换后再正式审核
+	if isPublicState(item.State) {
+		var moderationS ModerationService
+		if moderationS.Action(im.ctx, item.Title, post.abstract, post.content) == moderation.ActionReject {
+			req := request.ReqArticle{Title: item.Title, Abstract: post.abstract, Content: post.content, UserId: im.opts.UserId}
+			return moderationS.Moderate(im.ctx, SceneArticle, im.opts.UserId, 0, &req, &req.Title, &req.Abstract, &req.Content)
+		}
+	}
+	var uploadErr error
+	rewrite := func(link string) string {
+		if !localImportLink(link) {
+			return ""
+		}
+		p := im.resolve(item.File, link)
+		if p == "" {
+			return ""
+		}
+		key, err := im.upload(p)
+		if err != nil {
+			uploadErr = err
+			return ""
+		}
+		return "/community/file/singUrl?fileKey=" + key
+	}
+	content := utils.ReplaceImageLinks(post.content, rewrite)
+	if uploadErr != nil {
+		return uploadErr
+	}
+	cover := post.cover
+	if cover != "" && localImportLink(cover) {
+		cover = ""
+		if p := im.resolve(item.File, post.cover); p != "" {
+			key, err := im.upload(p)
+			if err != nil {
+				return err
+			}
+			cover = key
+		}
+	}
+
+	tagIds := make([]int, 0, len(item.Tags))
+	for _, name := range item.Tags {
+		var articleTagS ArticleTagService
+		tag, err := articleTagS.CreateTag(im.ctx, model.ArticleTags{TagName: name, UserId: im.opts.UserId})
+		if err != nil {
+			return err
+		}
+		im.tags[strings.ToLower(name)] = tag.Id
+		tagIds = append(tagIds, tag.Id)
+	}
+
+	req := request.ReqArticle{
+		Title:    item.Title,
+		Content:  content,
+		UserId:   im.opts.UserId,
+		Abstract: post.abstract,
+		State:    item.State,
+		Type:     item.TypeId,
+		Tags:     tagIds,
+		Cover:    cover,
+		Summary:  "导入文章",
+	}
+	// 草稿只有作者可见,公开的文章需要审核,待审核的文章审核通过后按发布文章处理
+	if isPublicState(item.State) {
+		var moderationS ModerationService
+		if err := moderationS.Moderate(im.ctx, SceneArticle, im.opts.UserId, 0, &req, &req.Title, &req.Abstract, &req.Content); err != nil {
+			return err
+		}
+	}
+
+	article := &model.Articles{
+		Title:     req.Title,
+		Content:   req.Content,
+		UserId:    im.opts.UserId,
+		State:     item.State,
+		Type:      item.TypeId,
+		Abstract:  req.Abstract,
+		Cover:     cover,
+		CreatedAt: ltime.LocalTime(item.Date),
+		UpdatedAt: ltime.LocalTime(item.Updated),
+	}
+	if err := model.Article(im.ctx).Create(article).Error; err != nil {
+		return err
+	}
+	item.ArticleId = article.ID
+	log.Ctx(im.ctx).Infof("用户id: %d,导入文章: %d,文件: %s", im.opts.UserId, article.ID, item.File)
+	if len(tagIds) > 0 {
+		tags := make([]model.ArticleTagRelations, 0, len(tagIds))
+		for _, id := range tagIds {
+			tags = append(tags, model.ArticleTagRelations{ArticleId: article.ID, TagId: id, UserId: im.opts.UserId})
+		}
+		model.ArticleTagRelation(im.ctx).Create(&tags)
+	}
+	var searchS SearchService
+	searchS.IndexArticle(im.ctx, article.ID)
+	var revisionS ArticleRevisionService
+	revisionS.Record(im.ctx, article.ID, im.opts.UserId, req.Summary)
+	return nil
+}
+
+func metaValue(meta map[string]any, keys ...string) any {
+	for _, k := range keys {
+		if v, ok := meta[k]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func metaString(meta map[string]any, keys ...string) string {
+	switch v := metaValue(meta, keys...).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func metaBool(meta map[string]any, key string) bool {
+	switch v := meta[key].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true") || v == "yes"
+	}
+	return false
+}
+
+// metaStrings 兼容字符串、列表和 hexo 的多级分类列表
+func metaStrings(meta map[string]any, keys ...string) []string {
+	var result []string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					result = append(result, s)
+				}
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		default:
+			result = append(result, fmt.Sprint(v))
+		}
+	}
+	walk(metaValue(meta, keys...))
+	return result
+}
+
+// metaTime 解析日期,toml 的本地时间和 yaml 中的字符串按本地时区处理
+func metaTime(meta map[string]any, keys ...string) time.Time {
+	var s string
+	switch v := metaValue(meta, keys...).(type) {
+	case nil:
+		return time.Time{}
+	case time.Time:
+		return v
+	case fmt.Stringer:
+		s = v.String()
+	case string:
+		s = v
+	default:
+		return time.Time{}
+	}
+	s = strings.TrimSpace(s)
+	for _, layout := range importDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func uniqueStrings(list []string) []string {
+	result := make([]string, 0, len(list))
+	seen := make(map[string]bool)
+	for _, s := range list {
+		if key := strings.ToLower(s); !seen[key] {
+			seen[key] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/moderation"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/oss"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestImportArticles(t *testing.T) {
+	initSQLite(t)
+	oss.Init(config.OssConfig{Driver: oss.DriverLocal, LocalPath: t.TempDir(), SecretKey: "secret"})
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1, FlagName: "go"})
+	db.Create(&model.Types{ID: 3, Title: "随笔", ParentId: 1, FlagName: "essay"})
+	db.Create(&model.ArticleTags{Id: 1, TagName: "并发", UserId: 1})
+	db.Create(&model.Articles{ID: 1, Title: "已存在", Content: "old", UserId: 1, Type: 2, State: constant.Published})
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		// hexo
+		"source/_posts/hello.md": "---\ntitle: Hello\ndate: 2020-01-02 03:04:05\ntags: [并发, 新标签]\ncategories:\n  - [Go, 后端]\n---\n图片 ![a](/images/a.png \"t\")\n丢失 ![b](b.png)\n网络 ![c](https://x.com/c.png)\n",
+		"source/images/a.png":    "png",
+		// hugo
+		"content/posts/b/index.md": "+++\ntitle = \"草稿\"\ndate = 2021-05-06T07:08:09\ndraft = true\ncategories = [\"Misc\"]\n+++\n![](a.png)\n",
+		"content/posts/b/a.png":    "png2",
+		"content/posts/c.md":       "---\ntitle: 已存在\ncategories: go\n---\nbody\n",
+		"__MACOSX/._hello.md":      "x",
+	}
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	r := bytes.NewReader(buf.Bytes())
+
+	var s ImportService
+	report, err := s.Import(ctx, r, r.Size(), ImportOptions{UserId: 1, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 3 || report.Imported != 0 || report.Skipped != 2 {
+		t.Fatalf("预览: %+v", report)
+	}
+	byFile := make(map[string]*ImportItem)
+	for _, item := range report.Articles {
+		byFile[item.File] = item
+	}
+	hello := byFile["source/_posts/hello.md"]
+	if hello.TypeId != 2 || hello.Assets != 1 || len(hello.MissingAssets) != 1 || hello.Error != "" {
+		t.Fatalf("hello: %+v", hello)
+	}
+	if !byFile["content/posts/b/index.md"].Unmapped || byFile["content/posts/c.md"].ConflictId != 1 {
+		t.Fatalf("未匹配分类和冲突: %+v", report.Articles)
+	}
+	if len(report.UnmappedTypes) != 1 || report.UnmappedTypes[0] != "Misc" || len(report.NewTags) != 1 || report.NewTags[0] != "新标签" {
+		t.Fatalf("报告: %+v", report)
+	}
+	var count int64
+	model.Article(ctx).Count(&count)
+	if count != 1 {
+		t.Fatal("预览不能写入文章")
+	}
+
+	// 没有匹配到分类的文章使用默认分类
+	report, err = s.Import(ctx, r, r.Size(), ImportOptions{UserId: 1, TypeId: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 2 || report.Skipped != 1 {
+		t.Fatalf("导入: %+v", report)
+	}
+	var article model.Articles
+	model.Article(ctx).Where("title = ?", "Hello").First(&article)
+	if article.Type != 2 || article.State != constant.Published {
+		t.Fatalf("文章: %+v", article)
+	}
+	if want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local); !time.Time(article.CreatedAt).Equal(want) {
+		t.Fatalf("应保留原始日期: %v", time.Time(article.CreatedAt))
+	}
+	if !strings.Contains(article.Content, "![a](/community/file/singUrl?fileKey=1/") || !strings.Contains(article.Content, "![b](b.png)") ||
+		!strings.Contains(article.Content, "https://x.com/c.png") {
+		t.Fatalf("图片地址: %s", article.Content)
+	}
+	var tags []int
+	model.ArticleTagRelation(ctx).Where("article_id = ?", article.ID).Pluck("tag_id", &tags)
+	if len(tags) != 2 || !containsInt(tags, 1) {
+		t.Fatalf("标签: %v", tags)
+	}
+	var draft model.Articles
+	model.Article(ctx).Where("title = ?", "草稿").First(&draft)
+	if draft.Type != 3 || draft.State != constant.Draft || !strings.Contains(draft.Content, "fileKey=1/") {
+		t.Fatalf("草稿: %+v", draft)
+	}
+	var fileCount int64
+	model.File(ctx).Where("user_id = 1").Count(&fileCount)
+	if fileCount != 2 {
+		t.Fatalf("上传的文件: %d", fileCount)
+	}
+}
+
+func TestImportRejectedArticle(t *testing.T) {
+	initSQLite(t)
+	oss.Init(config.OssConfig{Driver: oss.DriverLocal, LocalPath: t.TempDir(), SecretKey: "secret"})
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1, FlagName: "go"})
+	var m ModerationService
+	if err := m.SaveWord(ctx, model.SensitiveWords{Word: "赌博", Action: moderation.ActionReject}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"source/_posts/bad.md": "---\ntitle: 来赌博\ntags: [新标签]\n---\n![a](/images/a.png)\n",
+		"source/images/a.png":  "png",
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	r := bytes.NewReader(buf.Bytes())
+
+	var s ImportService
+	report, err := s.Import(ctx, r, r.Size(), ImportOptions{UserId: 1, TypeId: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 0 || report.Articles[0].Error == "" {
+		t.Fatalf("被拒绝的文章: %+v", report.Articles[0])
+	}
+	// 审核不通过时不上传图片、不创建标签
+	var fileCount, tagCount int64
+	model.File(ctx).Count(&fileCount)
+	model.ArticleTag(ctx).Count(&tagCount)
+	if fileCount != 0 || tagCount != 0 {
+		t.Fatalf("上传的文件: %d, 标签: %d", fileCount, tagCount)
+	}
+}
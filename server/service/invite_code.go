@@ -1,8 +1,9 @@
 package services
 
 import (
-	"errors"
+	"context"
 	mapset "github.com/deckarep/golang-set/v2"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/utils"
 	"xhyovo.cn/community/server/model"
 )
@@ -10,9 +11,9 @@ import (
 type CodeService struct {
 }
 
-func (*CodeService) PageCodes(page, limit int, code string) (codes []*model.InviteCodes, count int64) {
-	codes = codeDao.PageCodes(page, limit, code)
-	count = codeDao.GetCount()
+func (*CodeService) PageCodes(ctx context.Context, page, limit int, code string) (codes []*model.InviteCodes, count int64) {
+	codes = codeDao.PageCodes(ctx, page, limit, code)
+	count = codeDao.GetCount(ctx)
 
 	if count == 0 {
 		return codes, count
@@ -25,7 +26,7 @@ func (*CodeService) PageCodes(page, limit int, code string) (codes []*model.Invi
 	}
 
 	var m MemberInfoService
-	idNameMap := m.ListNameByIds(memberIds.ToSlice())
+	idNameMap := m.ListNameByIds(ctx, memberIds.ToSlice())
 
 	for i := range codes {
 		codes[i].MemberName = idNameMap[codes[i].MemberId]
@@ -33,11 +34,11 @@ func (*CodeService) PageCodes(page, limit int, code string) (codes []*model.Invi
 
 	return codes, count
 }
-func (*CodeService) GenerateCode(m model.GenerateCode) error {
+func (*CodeService) GenerateCode(ctx context.Context, m model.GenerateCode) error {
 	memberId := m.MemberId
 	var mSer MemberInfoService
-	if !mSer.Exist(memberId) {
-		return errors.New("对应vip等级不存在")
+	if !mSer.Exist(ctx, memberId) {
+		return errs.NotFound.WithMsg("对应vip等级不存在")
 	}
 	number := m.Number
 	var flag bool = true
@@ -47,7 +48,7 @@ func (*CodeService) GenerateCode(m model.GenerateCode) error {
 	for ; number > 0; number-- {
 		for flag {
 			code = utils.GenerateCode(8)
-			flag = codeDao.Exist(code)
+			flag = codeDao.Exist(ctx, code)
 		}
 		codes = append(codes, code)
 		flag = true
@@ -64,27 +65,27 @@ func (*CodeService) GenerateCode(m model.GenerateCode) error {
 		codeList = append(codeList, c)
 	}
 
-	codeDao.SaveCodes(codeList)
+	codeDao.SaveCodes(ctx, codeList)
 	return nil
 }
 
-func (*CodeService) DestroyCode(code int) error {
-	if codeDao.Del(code) == 0 {
-		return errors.New("邀请码被使用，无法删除")
+func (*CodeService) DestroyCode(ctx context.Context, code int) error {
+	if codeDao.Del(ctx, code) == 0 {
+		return errs.InUse.WithMsg("邀请码被使用，无法删除")
 	}
 	return nil
 }
 
-func (*CodeService) SetState(code string) {
-	codeDao.SetState(code)
+func (*CodeService) SetState(ctx context.Context, code string) {
+	codeDao.SetState(ctx, code)
 }
 
-func (s *CodeService) CountByMemberId(id int) (count int64) {
-	model.InviteCode().Where("member_id = ?", id).Count(&count)
+func (s *CodeService) CountByMemberId(ctx context.Context, id int) (count int64) {
+	model.InviteCode(ctx).Where("member_id = ?", id).Count(&count)
 	return count
 }
 
-func (s *CodeService) GetByCode(code string) (codeObject model.InviteCodes) {
-	model.InviteCode().Where("code = ?", code).Find(&codeObject)
+func (s *CodeService) GetByCode(ctx context.Context, code string) (codeObject model.InviteCodes) {
+	model.InviteCode(ctx).Where("code = ?", code).Find(&codeObject)
 	return
 }
@@ -1,15 +1,17 @@
 package services
 
 import (
+	"context"
 	mapset "github.com/deckarep/golang-set/v2"
+	"xhyovo.cn/community/pkg/lifecycle"
 	"xhyovo.cn/community/server/model"
 )
 
 type LogServices struct {
 }
 
-func (*LogServices) GetPageOperLog(page, limit int, logSearch model.LogSearch, flag bool) (logs []model.OperLogs, count int64) {
-	db := model.OperLog()
+func (*LogServices) GetPageOperLog(ctx context.Context, page, limit int, logSearch model.LogSearch, flag bool) (logs []model.OperLogs, count int64) {
+	db := model.OperLog(ctx)
 	if logSearch.RequestMethod != "" {
 		db.Where("request_method = ?", logSearch.RequestMethod)
 	}
@@ -19,12 +21,15 @@ func (*LogServices) GetPageOperLog(page, limit int, logSearch model.LogSearch, f
 	if logSearch.Ip != "" {
 		db.Where("ip like ?", "%"+logSearch.Ip+"%")
 	}
+	if logSearch.RequestId != "" {
+		db.Where("request_id = ?", logSearch.RequestId)
+	}
 	if logSearch.StartTime != "" {
 		db.Where("created_at <= ? and ? >= created_at", logSearch.StartTime, logSearch.EndTime)
 	}
 	if logSearch.UserName != "" {
 		var userS UserService
-		ids := userS.SearchNameSelectId(logSearch.UserName)
+		ids := userS.SearchNameSelectId(ctx, logSearch.UserName)
 		db.Where("user_id in ?", ids)
 	}
 	if flag {
@@ -44,27 +49,27 @@ func (*LogServices) GetPageOperLog(page, limit int, logSearch model.LogSearch, f
 		set.Add(lo.UserId)
 	}
 	var u UserService
-	userMap := u.ListByIdsToMap(set.ToSlice())
+	userMap := u.ListByIdsToMap(ctx, set.ToSlice())
 	for i := range logs {
 		logs[i].UserName = userMap[logs[i].UserId].Name
 	}
 	return
 }
 
-func (*LogServices) InsertOperLog(log model.OperLogs) {
-	go func(log model.OperLogs) {
-		model.OperLog().Create(&log)
-	}(log)
+func (*LogServices) InsertOperLog(ctx context.Context, log model.OperLogs) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
+		model.OperLog(ctx).Create(&log)
+	})
 }
 
-func (*LogServices) InsertLoginLog(log model.LoginLogs) {
-	go func(log model.LoginLogs) {
-		model.LoginLog().Create(&log)
-	}(log)
+func (*LogServices) InsertLoginLog(ctx context.Context, log model.LoginLogs) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
+		model.LoginLog(ctx).Create(&log)
+	})
 }
 
-func (s *LogServices) GetPageLoginPage(page, limit int, logSearch model.LogSearch) (logs []model.LoginLogs, count int64) {
-	db := model.LoginLog()
+func (s *LogServices) GetPageLoginPage(ctx context.Context, page, limit int, logSearch model.LogSearch) (logs []model.LoginLogs, count int64) {
+	db := model.LoginLog(ctx)
 	if logSearch.Account != "" {
 		db.Where("account like ?", "%"+logSearch.Account+"%")
 	}
@@ -1,11 +1,13 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"time"
 	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/delay"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
 	"xhyovo.cn/community/pkg/log"
 	"xhyovo.cn/community/server/request"
 
@@ -15,7 +17,7 @@ import (
 
 const msgTemp = "用户: %s, 申请了会议，会议标题为: %s,会议描述为: %s"
 
-const msgSuccessTemp = "会议主题：%s 会议已通过审核, 会议截止报名时间为: %v, 开始时间为: %v"
+const msgSuccessTemp = "会议主题：%s 会议已通过审核, 会议截止报名时间为: %s, 开始时间为: %s"
 
 const signupEndTimeTemp = "你参与的 %s 会议报名已截止，你的参会邀请信息：%s"
 
@@ -24,79 +26,88 @@ const startTimeTemp = "你参与的 %s 会议已开始，请及时参会，会
 type MeetingService struct {
 }
 
-func init() {
-	// 等待 db 初始化
-	go func() {
-		time.Sleep(3 * time.Second)
-		initMeetingTasks()
-	}()
+// 会议状态流转的延迟任务类型
+const (
+	jobMeetingSignupEnd = "meeting.signupEnd"
+	jobMeetingStart     = "meeting.start"
+	jobMeetingEnd       = "meeting.end"
+)
 
+type meetingJob struct {
+	MeetingId int `json:"meetingId"`
+}
+
+func init() {
+	delay.Register(jobMeetingSignupEnd, meetingSignupEnd)
+	delay.Register(jobMeetingStart, meetingStart)
+	delay.Register(jobMeetingEnd, meetingEnd)
 }
 
 // 获取加入的用户
-func (m *MeetingService) GetJoinMeetingUserSelectAvatar(meetingId int) []string {
+func (m *MeetingService) GetJoinMeetingUserSelectAvatar(ctx context.Context, meetingId int) []string {
 
-	userIds := m.GetJoinUsers(meetingId)
+	userIds := m.GetJoinUsers(ctx, meetingId)
 	var avatar []string
-	model.User().Where("id in ?", userIds).Select("avatar").Find(&avatar)
+	model.User(ctx).Where("id in ?", userIds).Select("avatar").Find(&avatar)
 
 	return avatar
 }
 
 // 保存
-func (m *MeetingService) Save(meeting model.Meetings) error {
+func (m *MeetingService) Save(ctx context.Context, meeting model.Meetings) error {
 
 	// 会议申请时间不低于当前时间
 	if time.Time(meeting.InitiatorTime).Before(time.Now()) {
-		return errors.New("会议申请时间不可小于当前时间")
+		return errs.BadRequest.WithMsg("会议申请时间不可小于当前时间")
 	}
 
 	// 如果是修改
 	if meeting.Id > 0 {
 		// 会议状态只一旦非审核中则不可修改
-		meetingState := m.GetById(meeting.Id).State
+		meetingState := m.GetById(ctx, meeting.Id).State
 		if meetingState != constant.Reviewing {
-			return errors.New("会议已被锁定,不可修改")
+			return errs.StateConflict.WithMsg("会议已被锁定,不可修改")
 		}
 	}
 	meeting.State = constant.Reviewing
 
 	if meeting.Id == 0 {
 
-		if err := model.Meeting().Save(&meeting).Error; err != nil {
+		if err := model.Meeting(ctx).Save(&meeting).Error; err != nil {
 			return err
 		}
 	} else {
-		if err := model.Meeting().Where("id = ?", meeting.Id).Updates(&meeting).Error; err != nil {
+		if err := model.Meeting(ctx).Where("id = ?", meeting.Id).Updates(&meeting).Error; err != nil {
 			return err
 		}
 	}
 
 	var userS UserService
-	user := userS.GetUserById(meeting.InitiatorId)
+	user := userS.GetUserById(ctx, meeting.InitiatorId)
 
 	// 发送消息给订阅人
 	var subS SubscriptionService
-	message := fmt.Sprintf(msgTemp, user.Name, meeting.Title, meeting.Description)
-	subS.SendMsg(13, event.Meeting, constant.NOTICE, constant.MeetingId, message)
+	message := i18n.M(msgTemp, user.Name, meeting.Title, meeting.Description)
+	subS.SendMsg(ctx, 13, event.Meeting, constant.NOTICE, constant.MeetingId, message)
 	return nil
 }
 
 // 获取
-func (*MeetingService) GetById(id int) model.Meetings {
+func (*MeetingService) GetById(ctx context.Context, id int) model.Meetings {
 	var meeting model.Meetings
-	model.Meeting().Find(&meeting, id)
+	model.Meeting(ctx).Find(&meeting, id)
 	if meeting.Id == 0 {
 		return meeting
 	}
 
 	var userS UserService
-	name := userS.GetUserById(meeting.InitiatorId).Name
+	name := userS.GetUserById(ctx, meeting.InitiatorId).Name
 
 	meeting.InitiatorName = name
+	meeting.StateName = i18n.T(ctx, meeting.State)
 
 	var joinUsers []model.MeetingJoinUsers
-	model.MeetingJoinUser().Where("meeting_id = ?", meeting.Id).Find(&joinUsers)
+	model.MeetingJoinUser(ctx).Where("meeting_id = ?", meeting.Id).Find(&joinUsers)
 	// 如果会议已完成则显示昵称，否则只显示人数
 	if meeting.State == constant.Completed {
 		var userIds []int
@@ -104,7 +115,7 @@ func (*MeetingService) GetById(id int) model.Meetings {
 		for _, user := range joinUsers {
 			userIds = append(userIds, user.UserId)
 		}
-		nameMap := userS.ListByIdsToMap(userIds)
+		nameMap := userS.ListByIdsToMap(ctx, userIds)
 		for i, _ := range joinUsers {
 			joinUsers[i].UserName = nameMap[joinUsers[i].UserId].Name
 		}
@@ -116,10 +127,10 @@ func (*MeetingService) GetById(id int) model.Meetings {
 	return meeting
 }
 
-func (*MeetingService) Page(page, limit, userId int) ([]model.Meetings, int64) {
+func (*MeetingService) Page(ctx context.Context, page, limit, userId int) ([]model.Meetings, int64) {
 	var meetings []model.Meetings
 	var count int64
-	tx := model.Meeting()
+	tx := model.Meeting(ctx)
 	tx.Count(&count)
 
 	if count == 0 {
@@ -139,24 +150,25 @@ func (*MeetingService) Page(page, limit, userId int) ([]model.Meetings, int64) {
 	}
 
 	var userS UserService
-	nameMap := userS.ListByIdsToMap(userIds)
+	nameMap := userS.ListByIdsToMap(ctx, userIds)
 	for i := range meetings {
 		meetings[i].InitiatorName = nameMap[meetings[i].InitiatorId].Name
 		meetings[i].InitiatorAvatar = nameMap[meetings[i].InitiatorId].Avatar
+		meetings[i].StateName = i18n.T(ctx, meetings[i].State)
 	}
 
 	return meetings, count
 }
 
 // 管理员删除 传0 即可
-func (*MeetingService) DeleteById(id, userId int) error {
+func (*MeetingService) DeleteById(ctx context.Context, id, userId int) error {
 	// 只有在审核中可以删除
 	var meeting model.Meetings
-	model.Meeting().Where("id = ?", id).First(&meeting)
+	model.Meeting(ctx).Where("id = ?", id).First(&meeting)
 	if meeting.State != constant.Reviewing {
-		return errors.New("除了审核状态外均不可删除")
+		return errs.StateConflict.WithMsg("除了审核状态外均不可删除")
 	}
-	db := model.Meeting()
+	db := model.Meeting(ctx)
 	if userId != 0 {
 		db.Where("Initiator_id", userId)
 	}
@@ -166,61 +178,61 @@ func (*MeetingService) DeleteById(id, userId int) error {
 }
 
 // 加入会议
-func (m *MeetingService) JoinMeeting(id, userId int) error {
-	meeting := m.GeyByIdSample(id)
+func (m *MeetingService) JoinMeeting(ctx context.Context, id, userId int) error {
+	meeting := m.GeyByIdSample(ctx, id)
 	if meeting.Id == 0 {
-		return errors.New("会议不存在")
+		return errs.NotFound.WithMsg("会议不存在")
 	}
 	// 发起者不可申请加入，本身已在
 	if meeting.InitiatorId == userId {
-		return errors.New("会议发起者不可申请加入，你已在里面")
+		return errs.Conflict.WithMsg("会议发起者不可申请加入，你已在里面")
 	}
 
 	// 必须是报名中才可加入
 	if meeting.State != constant.Registering {
-		return errors.New("参与会议必须是报名中")
+		return errs.StateConflict.WithMsg("参与会议必须是报名中")
 	}
 
 	var joinUsers model.MeetingJoinUsers
 	joinUsers.UserId = userId
 	joinUsers.MeetingId = id
-	if err := model.MeetingJoinUser().Save(&joinUsers).Error; err != nil {
-		return errors.New("不可重复加入")
+	if err := model.MeetingJoinUser(ctx).Save(&joinUsers).Error; err != nil {
+		return errs.Conflict.WithMsg("不可重复加入")
 	}
 	return nil
 }
 
 // 退出会议
-func (m *MeetingService) QuitJoinMeeting(id, userId int) error {
-	meeting := m.GeyByIdSample(id)
+func (m *MeetingService) QuitJoinMeeting(ctx context.Context, id, userId int) error {
+	meeting := m.GeyByIdSample(ctx, id)
 	if meeting.Id == 0 {
-		return errors.New("会议不存在")
+		return errs.NotFound.WithMsg("会议不存在")
 	}
 
 	// 必须是报名中或者筹备中可以退出
 	if meeting.State != constant.Registering && meeting.State != constant.Preparing {
-		return errors.New("退出会议只能是报名中或者筹备中状态")
+		return errs.StateConflict.WithMsg("退出会议只能是报名中或者筹备中状态")
 	}
-	model.MeetingJoinUser().Where("meeting_id = ? and user_id = ?", id, userId).Delete(&model.MeetingJoinUsers{})
+	model.MeetingJoinUser(ctx).Where("meeting_id = ? and user_id = ?", id, userId).Delete(&model.MeetingJoinUsers{})
 	return nil
 }
 
-func (*MeetingService) GeyByIdSample(id int) model.Meetings {
+func (*MeetingService) GeyByIdSample(ctx context.Context, id int) model.Meetings {
 	var meeting model.Meetings
-	model.Meeting().Where("id = ?", id).First(&meeting)
+	model.Meeting(ctx).Where("id = ?", id).First(&meeting)
 	return meeting
 }
 
-func (*MeetingService) ExistById(id int) bool {
+func (*MeetingService) ExistById(ctx context.Context, id int) bool {
 	var count int64
-	model.Meeting().Where("id = ? ", id).Count(&count)
+	model.Meeting(ctx).Where("id = ? ", id).Count(&count)
 	return count == 1
 }
 
-func (m *MeetingService) Approve(reqApproveMeeting request.ReqApproveMeeting) error {
-	meeting := m.GetById(reqApproveMeeting.Id)
+func (m *MeetingService) Approve(ctx context.Context, reqApproveMeeting request.ReqApproveMeeting) error {
+	meeting := m.GetById(ctx, reqApproveMeeting.Id)
 	if meeting.Id == 0 {
-		return errors.New("操作会议不存在")
+		return errs.NotFound.WithMsg("操作会议不存在")
 	}
 
 	// 会议报名时间 不能 大于 开始时间，开始时间不能大于结束时间
@@ -229,22 +241,22 @@ func (m *MeetingService) Approve(reqApproveMeeting request.ReqApproveMeeting) er
 	endTime := time.Time(*reqApproveMeeting.MeetingEndTime)
 
 	if meeting.State != constant.Reviewing {
-		return errors.New("当前会议状态不可通过")
+		return errs.StateConflict.WithMsg("当前会议状态不可通过")
 	}
 
 	if signupEndTime.Before(time.Now()) {
-		return errors.New("会议报名时间不能小于当前时间")
+		return errs.BadRequest.WithMsg("会议报名时间不能小于当前时间")
 	}
 
 	if signupEndTime.After(startTime) {
-		return errors.New("会议报名时间不能大于会议开始时间")
+		return errs.BadRequest.WithMsg("会议报名时间不能大于会议开始时间")
 	}
 
 	if signupEndTime.After(endTime) {
-		return errors.New("会议报名时间不能大于会议结束时间")
+		return errs.BadRequest.WithMsg("会议报名时间不能大于会议结束时间")
 	}
 	if startTime.After(endTime) {
-		return errors.New("会议开始时间不能大于会议结束时间")
+		return errs.BadRequest.WithMsg("会议开始时间不能大于会议结束时间")
 	}
 
 	meeting.MeetingStartTime = reqApproveMeeting.MeetingStartTime
@@ -254,190 +266,179 @@ func (m *MeetingService) Approve(reqApproveMeeting request.ReqApproveMeeting) er
 
 	// {name} 会议已通过审核,截止报名时间为：{time}，开始时间为：{time}
 
-	signupMessage := fmt.Sprintf(msgSuccessTemp, meeting.Title, signupEndTime.Format("2006-01-02 15:04:05"), startTime.Format("2006-01-02 15:04:05"))
+	signupMessage := i18n.M(msgSuccessTemp, meeting.Title, signupEndTime.Format("2006-01-02 15:04:05"), startTime.Format("2006-01-02 15:04:05"))
 
 	// 给订阅人发送邮箱
 	var subS SubscriptionService
-	subS.SendMsg(13, event.Meeting, constant.NOTICE, constant.MeetingId, signupMessage)
-
-	approveAddTask(meeting)
+	subS.SendMsg(ctx, 13, event.Meeting, constant.NOTICE, constant.MeetingId, signupMessage)
 
 	// 修改状态
 	meeting.State = constant.Registering
 
-	model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
+	model.Meeting(ctx).Where("id = ?", meeting.Id).Save(&meeting)
+
+	// 状态修改后再加入任务,避免任务先于状态修改执行
+	approveAddTask(ctx, meeting)
 
 	return nil
 }
 
-func (m *MeetingService) Pass(reqPassMeeting request.ReqPassMeeting) error {
-	meeting := m.GetById(reqPassMeeting.Id)
+func (m *MeetingService) Pass(ctx context.Context, reqPassMeeting request.ReqPassMeeting) error {
+	meeting := m.GetById(ctx, reqPassMeeting.Id)
 	if meeting.Id == 0 {
-		return errors.New("操作会议不存在")
+		return errs.NotFound.WithMsg("操作会议不存在")
 	}
 
 	if meeting.State != constant.Reviewing {
-		return errors.New("会议状态只能是审核中才能被 PASSda∂")
+		return errs.StateConflict.WithMsg("会议状态只能是审核中才能被 PASSda∂")
 	}
 
 	meeting.State = constant.Pass
 	meeting.StateMessage = reqPassMeeting.PassMessage
-	model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
+	model.Meeting(ctx).Where("id = ?", meeting.Id).Save(&meeting)
 	return nil
 }
-func (m *MeetingService) GetJoinUsers(meetingId int) []int {
+func (m *MeetingService) GetJoinUsers(ctx context.Context, meetingId int) []int {
 	var userIds []int
-	model.MeetingJoinUser().Where("meeting_id = ?", meetingId).Select("user_id").Find(&userIds)
+	model.MeetingJoinUser(ctx).Where("meeting_id = ?", meetingId).Select("user_id").Find(&userIds)
 	return userIds
 }
 
-func (m *MeetingService) Record(reqRecordMeeting request.ReqRecordMeeting) error {
-	meeting := m.GetById(reqRecordMeeting.Id)
+func (m *MeetingService) Record(ctx context.Context, reqRecordMeeting request.ReqRecordMeeting) error {
+	meeting := m.GetById(ctx, reqRecordMeeting.Id)
 	if meeting.Id == 0 {
-		return errors.New("操作会议不存在")
+		return errs.NotFound.WithMsg("操作会议不存在")
 	}
 
 	// 必须是完成后才可填写
 	if meeting.State != constant.Completed {
-		return errors.New("会议必须是已完成才可填写会议记录")
+		return errs.StateConflict.WithMsg("会议必须是已完成才可填写会议记录")
 	}
 
 	meeting.Record = reqRecordMeeting.Record
-	model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
+	model.Meeting(ctx).Where("id = ?", meeting.Id).Save(&meeting)
 	return nil
 }
 
-func (m *MeetingService) InMeetingState(meetingId, userId int) (bool, error) {
-	meeting := m.GetById(meetingId)
+func (m *MeetingService) InMeetingState(ctx context.Context, meetingId, userId int) (bool, error) {
+	meeting := m.GetById(ctx, meetingId)
 	if meeting.Id == 0 {
-		return false, errors.New("操作会议不存在")
+		return false, errs.NotFound.WithMsg("操作会议不存在")
 	}
 	var count int64
-	model.MeetingJoinUser().Where("meeting_id = ? AND user_id = ?", meetingId, userId).Count(&count)
+	model.MeetingJoinUser(ctx).Where("meeting_id = ? AND user_id = ?", meetingId, userId).Count(&count)
 	return count == 1, nil
 }
 
-func (m *MeetingService) SendMsgToJoinMeeting(id int, content string) {
+func (m *MeetingService) SendMsgToJoinMeeting(ctx context.Context, id int, content i18n.Message) {
 	// 查出参会人
-	userIds := m.GetJoinUsers(id)
+	userIds := m.GetJoinUsers(ctx, id)
 	var subS SubscriptionService
-	subS.SendMsgByToIds(13, event.Meeting, constant.NOTICE, constant.MeetingId, userIds, content)
+	subS.SendMsgByToIds(ctx, 13, event.Meeting, constant.NOTICE, constant.MeetingId, userIds, content)
 }
 
 /*
 *
-初始化定时任务
-查出 报名中，筹备中，会议中 的会议状态
+补齐会议状态流转的任务,任务持久化在 delay_jobs 中,正常情况下这里不会有新增
+只有升级前已审核通过的会议需要补齐
 
 报名中状态：添加 报名截止，会议开始，会议结束定时任务
 筹备中：添加会议开始，会议结束定时任务
 会议中：添加会议结束定时任务
 */
-func initMeetingTasks() {
-	// 查出在 当前时间 < 报名时间的 数据
+func InitMeetingTasks(ctx context.Context) {
 	var meetings []model.Meetings
-	model.Meeting().Where("signup_end_time > ?", time.Now()).Find(&meetings)
-	// 存入队列中
+	model.Meeting(ctx).Where("state IN ?", []string{constant.Registering, constant.Preparing, constant.InMeeting}).Find(&meetings)
 	for _, meeting := range meetings {
-		if meeting.State == constant.Registering {
-			approveAddTask(meeting)
-		} else if meeting.State == constant.Preparing {
-			preparingAddTask(meeting)
-		} else if meeting.State == constant.InMeeting {
-			inMeetingAddTask(meeting)
+		var jobTypes []string
+		switch meeting.State {
+		case constant.Registering:
+			jobTypes = []string{jobMeetingSignupEnd, jobMeetingStart, jobMeetingEnd}
+		case constant.Preparing:
+			jobTypes = []string{jobMeetingStart, jobMeetingEnd}
+		case constant.InMeeting:
+			jobTypes = []string{jobMeetingEnd}
+		}
+		for _, jobType := range jobTypes {
+			exist, err := delay.GetInstant().Exists(meetingJobKey(jobType, meeting.Id))
+			if err != nil {
+				log.Ctx(ctx).Warnf("查询会议任务失败,会议id: %d,err: %s", meeting.Id, err.Error())
+				continue
+			}
+			if !exist {
+				scheduleMeetingJob(ctx, meeting, jobType)
+			}
 		}
-
 	}
 }
 
-func inMeetingAddTask(meeting model.Meetings) {
-	endTime := time.Time(*meeting.MeetingEndTime)
-	// 加入延迟队列
-	delayQueue := delay.GetInstant()
-
-	log.Infof("延迟队列加入任务：%s", meeting.PrintLog())
-	// 会议结束后状态改为会议完成
-	delayQueue.Add(meeting.Id, endTime, func() {
-		log.Infof("会议id:%d,会议标题:%s,会议状态:%s,修改会议状态:%s", meeting.Id, meeting.Title, meeting.State, constant.Completed)
-		meeting.State = constant.Completed
-		model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
-	})
+// 审核通过后加入报名截止、会议开始、会议结束三个任务
+func approveAddTask(ctx context.Context, meeting model.Meetings) {
+	log.Ctx(ctx).Infof("延迟队列加入任务：%s", meeting.PrintLog())
+	scheduleMeetingJob(ctx, meeting, jobMeetingSignupEnd)
+	scheduleMeetingJob(ctx, meeting, jobMeetingStart)
+	scheduleMeetingJob(ctx, meeting, jobMeetingEnd)
 }
 
-func preparingAddTask(meeting model.Meetings) {
-	startTime := time.Time(*meeting.MeetingStartTime)
-	endTime := time.Time(*meeting.MeetingEndTime)
+func meetingJobKey(jobType string, meetingId int) string {
+	return fmt.Sprintf("%s:%d", jobType, meetingId)
+}
 
-	var subS SubscriptionService
-	startMessage := fmt.Sprintf(startTimeTemp, meeting.Title, meeting.MeetingLink)
-	// 加入延迟队列
-	delayQueue := delay.GetInstant()
-
-	// 会议开始后状态改为会议中
-	log.Infof("延迟队列加入任务：%s", meeting.PrintLog())
-	delayQueue.Add(meeting.Id, startTime, func() {
-		log.Infof("会议id:%d,会议标题:%s,会议状态:%s,修改会议状态:%s", meeting.Id, meeting.Title, meeting.State, constant.InMeeting)
-		meeting.State = constant.InMeeting
-		model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
-		// 查出参与人
-		var meetingService MeetingService
-		userIds := meetingService.GetJoinUsers(meeting.Id)
-		subS.SendMsgByToIds(13, event.Meeting, constant.NOTICE, constant.MeetingId, userIds, startMessage)
-	})
-
-	// 会议结束后状态改为会议完成
-	log.Infof("延迟队列加入任务：%s", meeting.PrintLog())
-	delayQueue.Add(meeting.Id, endTime, func() {
-		log.Infof("会议id:%d,会议标题:%s,会议状态:%s,修改会议状态:%s", meeting.Id, meeting.Title, meeting.State, constant.Completed)
-		meeting.State = constant.Completed
-		model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
-	})
+func scheduleMeetingJob(ctx context.Context, meeting model.Meetings, jobType string) {
+	var runAt time.Time
+	switch jobType {
+	case jobMeetingSignupEnd:
+		runAt = time.Time(*meeting.SignupEndTime)
+	case jobMeetingStart:
+		runAt = time.Time(*meeting.MeetingStartTime)
+	case jobMeetingEnd:
+		runAt = time.Time(*meeting.MeetingEndTime)
+	}
+	err := delay.GetInstant().Schedule(meetingJobKey(jobType, meeting.Id), jobType, runAt, meetingJob{MeetingId: meeting.Id})
+	if err != nil {
+		log.Ctx(ctx).Warnf("会议任务加入延迟队列失败,会议id: %d,任务: %s,err: %s", meeting.Id, jobType, err.Error())
+	}
 }
 
-func approveAddTask(meeting model.Meetings) {
-	signupEndTime := time.Time(*meeting.SignupEndTime)
-	startTime := time.Time(*meeting.MeetingStartTime)
-	endTime := time.Time(*meeting.MeetingEndTime)
+// changeMeetingState 只有当前状态在 from 中时才修改,任务重复执行时不会重复通知
+func changeMeetingState(ctx context.Context, meetingId int, to string, from ...string) (bool, error) {
+	tx := model.Meeting(ctx).Where("id = ? AND state IN ?", meetingId, from).Update("state", to)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return false, nil
+	}
+	log.Ctx(ctx).Infof("会议id:%d,修改会议状态:%s", meetingId, to)
+	return true, nil
+}
 
-	var subS SubscriptionService
-	startMessage := fmt.Sprintf(startTimeTemp, meeting.Title, meeting.MeetingLink)
-	// 加入延迟队列
-	delayQueue := delay.GetInstant()
-	expireTime := signupEndTime
-
-	log.Infof("延迟队列加入任务：%s", meeting.PrintLog())
-	delayQueue.Add(meeting.Id, expireTime, func() {
-		log.Infof("会议id:%d,会议标题:%s,会议状态:%s,修改会议状态:%s", meeting.Id, meeting.Title, meeting.State, constant.Preparing)
-		// 再查一次，避免使用之前的状态
-		var meetingService MeetingService
-		meeting = meetingService.GetById(meeting.Id)
-		meeting.State = constant.Preparing
-		model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
-		// 发送报名信息
-		message := fmt.Sprintf(signupEndTimeTemp, meeting.Title, meeting.MeetingLink)
-		// 查出参与人
-		userIds := meetingService.GetJoinUsers(meeting.Id)
-		subS.SendMsgByToIds(13, event.Meeting, constant.NOTICE, constant.MeetingId, userIds, message)
-	})
-
-	// 会议开始后状态改为会议中
-	log.Infof("延迟队列加入任务：%s", meeting.PrintLog())
-	delayQueue.Add(meeting.Id, startTime, func() {
-		log.Infof("会议id:%d,会议标题:%s,会议状态:%s,修改会议状态:%s", meeting.Id, meeting.Title, meeting.State, constant.InMeeting)
-		var meetingService MeetingService
-		meeting = meetingService.GetById(meeting.Id)
-		meeting.State = constant.InMeeting
-		model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
-		// 查出参与人
-		userIds := meetingService.GetJoinUsers(meeting.Id)
-		subS.SendMsgByToIds(13, event.Meeting, constant.NOTICE, constant.MeetingId, userIds, startMessage)
-	})
-
-	// 会议结束后状态改为会议完成
-	log.Infof("延迟队列加入任务：%s", meeting.PrintLog())
-	delayQueue.Add(meeting.Id, endTime, func() {
-		log.Infof("会议id:%d,会议标题:%s,会议状态:%s,修改会议状态:%s", meeting.Id, meeting.Title, meeting.State, constant.Completed)
-		meeting.State = constant.Completed
-		model.Meeting().Where("id = ?", meeting.Id).Save(&meeting)
-	})
+// 报名截止后状态改为筹备中,并发送参会信息
+func meetingSignupEnd(ctx context.Context, job meetingJob) error {
+	changed, err := changeMeetingState(ctx, job.MeetingId, constant.Preparing, constant.Registering)
+	if err != nil || !changed {
+		return err
+	}
+	var meetingService MeetingService
+	meeting := meetingService.GeyByIdSample(ctx, job.MeetingId)
+	meetingService.SendMsgToJoinMeeting(ctx, meeting.Id, i18n.M(signupEndTimeTemp, meeting.Title, meeting.MeetingLink))
+	return nil
+}
+
+// 会议开始后状态改为会议中,并通知参会人
+func meetingStart(ctx context.Context, job meetingJob) error {
+	changed, err := changeMeetingState(ctx, job.MeetingId, constant.InMeeting, constant.Registering, constant.Preparing)
+	if err != nil || !changed {
+		return err
+	}
+	var meetingService MeetingService
+	meeting := meetingService.GeyByIdSample(ctx, job.MeetingId)
+	meetingService.SendMsgToJoinMeeting(ctx, meeting.Id, i18n.M(startTimeTemp, meeting.Title, meeting.MeetingLink))
+	return nil
+}
+
+// 会议结束后状态改为会议完成
+func meetingEnd(ctx context.Context, job meetingJob) error {
+	_, err := changeMeetingState(ctx, job.MeetingId, constant.Completed, constant.Registering, constant.Preparing, constant.InMeeting)
+	return err
 }
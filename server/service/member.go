@@ -1,15 +1,16 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/server/model"
 )
 
 type MemberInfoService struct {
 }
 
-func (*MemberInfoService) ListMembers(page, limit int) (members []*model.MemberInfos, count int64) {
-	db := model.MemberInfo()
+func (*MemberInfoService) ListMembers(ctx context.Context, page, limit int) (members []*model.MemberInfos, count int64) {
+	db := model.MemberInfo(ctx)
 	db.Count(&count)
 	if count == 0 {
 		return
@@ -18,27 +19,27 @@ func (*MemberInfoService) ListMembers(page, limit int) (members []*model.MemberI
 	return
 }
 
-func (*MemberInfoService) SaveMember(member *model.MemberInfos) {
-	memberDao.SaveMemberInfo(member)
+func (*MemberInfoService) SaveMember(ctx context.Context, member *model.MemberInfos) {
+	memberDao.SaveMemberInfo(ctx, member)
 }
-func (*MemberInfoService) DeleteMember(id int) error {
+func (*MemberInfoService) DeleteMember(ctx context.Context, id int) error {
 	// 是否被邀请码使用
 	var codeS CodeService
-	count := codeS.CountByMemberId(id)
+	count := codeS.CountByMemberId(ctx, id)
 	if count > 0 {
-		return errors.New("等级被引用,无法删除")
+		return errs.InUse.WithMsg("等级被引用,无法删除")
 	}
-	memberDao.DeleteMemberInfo(id)
+	memberDao.DeleteMemberInfo(ctx, id)
 	return nil
 }
 
-func (*MemberInfoService) Exist(id int) bool {
-	return memberDao.Count(id) == 1
+func (*MemberInfoService) Exist(ctx context.Context, id int) bool {
+	return memberDao.Count(ctx, id) == 1
 }
 
-func (s *MemberInfoService) ListNameByIds(ids []int) map[int]string {
+func (s *MemberInfoService) ListNameByIds(ctx context.Context, ids []int) map[int]string {
 
-	infos := memberDao.ListByIdsSelectIdAndName(ids)
+	infos := memberDao.ListByIdsSelectIdAndName(ctx, ids)
 
 	m := make(map[int]string, len(infos))
 
@@ -50,8 +51,8 @@ func (s *MemberInfoService) ListNameByIds(ids []int) map[int]string {
 	return m
 }
 
-func (s *MemberInfoService) GetById(id int) (member model.MemberInfos) {
-	model.MemberInfo().Where("id = ?", id).Find(&member)
+func (s *MemberInfoService) GetById(ctx context.Context, id int) (member model.MemberInfos) {
+	model.MemberInfo(ctx).Where("id = ?", id).Find(&member)
 	return
 
 }
@@ -1,9 +1,10 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"strings"
+	"xhyovo.cn/community/pkg/errs"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/server/model"
 	"xhyovo.cn/community/server/service/event"
@@ -55,11 +56,11 @@ type SubscribeData struct {
 type MessageService struct {
 }
 
-func (*MessageService) ListMessageTemplate(page, limit int) ([]*model.MessageTemplates, int64) {
+func (*MessageService) ListMessageTemplate(ctx context.Context, page, limit int) ([]*model.MessageTemplates, int64) {
 	var count int64
-	model.MessageTemplate().Count(&count)
-	templates := messageDao.ListMessageTemplate(page, limit)
-	eventMap := event.Map()
+	model.MessageTemplate(ctx).Count(&count)
+	templates := messageDao.ListMessageTemplate(ctx, page, limit)
+	eventMap := event.Map(ctx)
 	for i := range templates {
 		templates[i].EventName = eventMap[templates[i].EventId]
 	}
@@ -67,18 +68,18 @@ func (*MessageService) ListMessageTemplate(page, limit int) ([]*model.MessageTem
 	return templates, count
 }
 
-func (*MessageService) SaveMessageTemplate(template model.MessageTemplates) error {
-	if err := messageDao.SaveMessageTemplate(template); err != nil {
-		return errors.New("创建消息模板对应的事件已经存在")
+func (*MessageService) SaveMessageTemplate(ctx context.Context, template model.MessageTemplates) error {
+	if err := messageDao.SaveMessageTemplate(ctx, template); err != nil {
+		return errs.Conflict.WithMsg("创建消息模板对应的事件已经存在")
 	}
 	return nil
 }
 
-func (*MessageService) DeleteMessageTemplate(id int) {
-	messageDao.DeleteMessageTemplate(id)
+func (*MessageService) DeleteMessageTemplate(ctx context.Context, id int) {
+	messageDao.DeleteMessageTemplate(ctx, id)
 }
 
-func (*MessageService) AddMessageLogs(from, types, eventId, businessId int, to []int, content string) {
+func (*MessageService) AddMessageLogs(ctx context.Context, from, types, eventId, businessId int, to []int, content string) {
 	var messageLogs []*model.MessageLogs
 	for i := range to {
 		log := &model.MessageLogs{
@@ -91,51 +92,51 @@ func (*MessageService) AddMessageLogs(from, types, eventId, businessId int, to [
 		}
 		messageLogs = append(messageLogs, log)
 	}
-	messageDao.SaveMessageLogs(messageLogs)
+	messageDao.SaveMessageLogs(ctx, messageLogs)
 }
 
-func (*MessageService) DeleteMessageLogs(id []int) {
-	messageDao.DeleteMessageLogs(id)
+func (*MessageService) DeleteMessageLogs(ctx context.Context, id []int) {
+	messageDao.DeleteMessageLogs(ctx, id)
 }
 
-func (m *MessageService) SendMessage(from, to, types, eventId, businessId int, content string) {
+func (m *MessageService) SendMessage(ctx context.Context, from, to, types, eventId, businessId int, content string) {
 
-	messageDao.SaveMessage(from, types, eventId, businessId, []int{to}, content)
+	messageDao.SaveMessage(ctx, from, types, eventId, businessId, []int{to}, content)
 	// 添加记录
-	m.AddMessageLogs(from, types, eventId, businessId, []int{to}, content)
+	m.AddMessageLogs(ctx, from, types, eventId, businessId, []int{to}, content)
 }
 
-func (m *MessageService) SendMessages(from, types, eventId, businessId int, to []int, content string) {
+func (m *MessageService) SendMessages(ctx context.Context, from, types, eventId, businessId int, to []int, content string) {
 
-	messageDao.SaveMessage(from, types, eventId, businessId, to, content)
+	messageDao.SaveMessage(ctx, from, types, eventId, businessId, to, content)
 	// 添加记录
-	m.AddMessageLogs(from, types, eventId, businessId, to, content)
+	m.AddMessageLogs(ctx, from, types, eventId, businessId, to, content)
 }
 
-func (*MessageService) ReadMessage2(typee, eventId, businessId, userId int) int64 {
-	return messageDao.ReadMessage2(typee, eventId, businessId, userId)
+func (*MessageService) ReadMessage2(ctx context.Context, typee, eventId, businessId, userId int) int64 {
+	return messageDao.ReadMessage2(ctx, typee, eventId, businessId, userId)
 }
 
-func (*MessageService) ReadMessage(id []int, userId int) int64 {
-	return messageDao.ReadMessage(id, userId)
+func (*MessageService) ReadMessage(ctx context.Context, id []int, userId int) int64 {
+	return messageDao.ReadMessage(ctx, id, userId)
 }
 
-func (m *MessageService) PageMessage(page, limit, userId, types, state int) (msgs []*model.MessageStates, count int64) {
-	msgs = messageDao.ListMessage(page, limit, userId, types, state)
-	count = messageDao.CountMessage(userId, types, state)
+func (m *MessageService) PageMessage(ctx context.Context, page, limit, userId, types, state int) (msgs []*model.MessageStates, count int64) {
+	msgs = messageDao.ListMessage(ctx, page, limit, userId, types, state)
+	count = messageDao.CountMessage(ctx, userId, types, state)
 	return msgs, count
 }
 
 // 人：你订阅的 xxx 用户发布了文章，文章标题：xxx
 // 文章：你订阅的 xxx 文章，被xxx评论了，评论内容：xxx
-func (m *MessageService) GetMsg(template string, b SubscribeData) string {
+func (m *MessageService) GetMsg(ctx context.Context, template string, b SubscribeData) string {
 	BusinessIdMap := businessIdToMap(b)
 	for s, v := range messageTemplateVar {
 		// 拼接 ${ + s + "." 如果存在则找
 		str := fmt.Sprintf("${%s.", s)
 		if strings.Contains(template, str) {
 			var objet map[string]interface{}
-			mysql.GetInstance().Table(s+"s").Where("id = ?", BusinessIdMap[s]).Find(&objet)
+			mysql.GetInstance().WithContext(ctx).Table(s+"s").Where("id = ?", BusinessIdMap[s]).Find(&objet)
 			// 遍历 v 从key找template
 			for s2 := range v {
 				varTemlp := fmt.Sprintf("${%s}", s2)
@@ -164,11 +165,11 @@ func (*MessageService) GetMessageTemplateVar() map[string]map[string]string {
 	return messageTemplateVar
 }
 
-func (m *MessageService) ClearUnReadMessage(msgType, userId int) {
-	model.MessageState().Where("`to` = ? and type = ?", userId, msgType).Update("state", 0)
+func (m *MessageService) ClearUnReadMessage(ctx context.Context, msgType, userId int) {
+	model.MessageState(ctx).Where(mysql.Quote("to")+" = ? and type = ?", userId, msgType).Update("state", 0)
 }
 
-func (m *MessageService) GetUnReadMessageCountByUserId(userId int) (count int64) {
-	model.MessageState().Where("`to` = ? and state = 1", userId).Count(&count)
+func (m *MessageService) GetUnReadMessageCountByUserId(ctx context.Context, userId int) (count int64) {
+	model.MessageState(ctx).Where(mysql.Quote("to")+" = ? and state = 1", userId).Count(&count)
 	return
 }
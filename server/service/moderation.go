@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/moderation"
+	"xhyovo.cn/community/pkg/mysql"
+	ltime "xhyovo.cn/community/pkg/time"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+)
+
+// 用户内容的审核场景,review 的内容审核通过后按场景重新保存
+const (
+	SceneArticle        = "article"         // 发布文章
+	SceneArticleSave    = "article_save"    // 修改文章
+	SceneArticleRestore = "article_restore" // 恢复文章的历史版本
+	SceneComment        = "comment"
+	SceneRate           = "rate"
+)
+
+// review 的审核状态
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// 词库缓存时间,多实例部署时其他实例修改的敏感词最迟在该时间后生效
+const dictionaryTTL = 30 * time.Second
+
+type cachedDictionary struct {
+	dict     *moderation.Dictionary
+	loadedAt time.Time
+}
+
+var dictionary atomic.Value
+
+type skipModerationKey struct{}
+
+type ModerationService struct {
+}
+
+// Moderate 审核用户提交的文本,mask 直接修改 texts;review 时保存 payload,返回 errs.UnderReview;reject 返回 errs.ContentRejected
+// texts 需要指向 payload 中的字段,保证审核通过后重新保存的是 mask 之后的内容
+func (s *ModerationService) Moderate(ctx context.Context, scene string, userId, businessId int, payload any, texts ...*string) error {
+	if ctx.Value(skipModerationKey{}) != nil {
+		return nil
+	}
+	res, original := s.check(ctx, texts...)
+	if res.Action == "" {
+		return nil
+	}
+
+	hits, _ := json.Marshal(res.Hits)
+	record := model.ModerationRecords{
+		Scene:      scene,
+		BusinessId: businessId,
+		UserId:     userId,
+		Action:     res.Action,
+		Hits:       string(hits),
+		Content:    strings.Join(original, "\n"),
+	}
+	if res.Action == moderation.ActionReview {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		record.Payload = string(raw)
+		record.State = ReviewPending
+	}
+	if err := model.ModerationRecord(ctx).Create(&record).Error; err != nil {
+		log.Ctx(ctx).Warnf("保存审核记录失败,scene: %s,err: %s", scene, err.Error())
+		// 待审核的内容保存失败时不能放行
+		if res.Action == moderation.ActionReview {
+			return err
+		}
+	}
+	log.Ctx(ctx).Infof("用户id: %d,内容命中审核规则,scene: %s,action: %s,hits: %s", userId, scene, res.Action, hits)
+
+	switch res.Action {
+	case moderation.ActionReject:
+		details := make([]errs.FieldError, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			details = append(details, errs.FieldError{Field: hit.Rule, Msg: hit.Detail})
+		}
+		return errs.ContentRejected.WithDetails(details)
+	case moderation.ActionReview:
+		return errs.UnderReview
+	}
+	return nil
+}
+
+// Action 只检查文本会触发的处理方式,不修改文本也不记录,用于写入关联数据前提前发现会被拒绝的内容
+func (s *ModerationService) Action(ctx context.Context, texts ...string) string {
+	if ctx.Value(skipModerationKey{}) != nil {
+		return ""
+	}
+	ptrs := make([]*string, 0, len(texts))
+	for i := range texts {
+		ptrs = append(ptrs, &texts[i])
+	}
+	res, _ := s.check(ctx, ptrs...)
+	return res.Action
+}
+
+// check 按词库和启发式规则检查文本,mask 直接修改 texts,返回检查结果和修改前不为空的文本
+func (s *ModerationService) check(ctx context.Context, texts ...*string) (moderation.Result, []string) {
+	dict, err := s.dictionary(ctx)
+	if err != nil {
+		log.Ctx(ctx).Warnf("加载敏感词失败,只执行启发式规则,err: %s", err.Error())
+	}
+	opts := moderationOptions()
+	var res moderation.Result
+	original := make([]string, 0, len(texts))
+	for _, text := range texts {
+		if *text == "" {
+			continue
+		}
+		original = append(original, *text)
+		r := moderation.Check(*text, dict, opts)
+		res.Merge(r)
+		*text = r.Text
+	}
+	return res, original
+}
+
+func moderationOptions() moderation.Options {
+	c := config.GetInstance()
+	if c == nil {
+		return moderation.Options{}
+	}
+	m := c.ModerationConfig
+	return moderation.Options{
+		MaxLinks:       m.MaxLinks,
+		LinkAction:     m.LinkAction,
+		MaxRepeatChars: m.MaxRepeatChars,
+		MaxRepeatLines: m.MaxRepeatLines,
+		RepeatAction:   m.RepeatAction,
+	}
+}
+
+func (*ModerationService) dictionary(ctx context.Context) (*moderation.Dictionary, error) {
+	if c, ok := dictionary.Load().(cachedDictionary); ok && c.dict != nil && time.Since(c.loadedAt) < dictionaryTTL {
+		return c.dict, nil
+	}
+	var rows []model.SensitiveWords
+	if err := model.SensitiveWord(ctx).Select("word", "action").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	words := make([]moderation.Word, len(rows))
+	for i, row := range rows {
+		words[i] = moderation.Word{Word: row.Word, Action: row.Action}
+	}
+	dict := moderation.NewDictionary(words)
+	dictionary.Store(cachedDictionary{dict: dict, loadedAt: time.Now()})
+	return dict, nil
+}
+
+// 词库修改后下次审核时重新加载
+func resetDictionary() {
+	dictionary.Store(cachedDictionary{})
+}
+
+func (*ModerationService) PageWords(ctx context.Context, word string, page, limit int) (words []model.SensitiveWords, count int64) {
+	db := model.SensitiveWord(ctx)
+	if word != "" {
+		db = db.Where(mysql.Like("word"), mysql.Contains(word))
+	}
+	db.Count(&count)
+	if count == 0 {
+		return make([]model.SensitiveWords, 0), 0
+	}
+	db.Order("id desc").Limit(limit).Offset((page - 1) * limit).Find(&words)
+	return
+}
+
+func (*ModerationService) SaveWord(ctx context.Context, word model.SensitiveWords) error {
+	word.Word = strings.TrimSpace(word.Word)
+	if word.Word == "" {
+		return errs.BadRequest.WithMsg("敏感词不能为空")
+	}
+	var count int64
+	model.SensitiveWord(ctx).Where("word = ? and id != ?", word.Word, word.ID).Count(&count)
+	if count > 0 {
+		return errs.Conflict.WithMsg("敏感词已存在")
+	}
+	var err error
+	if word.ID != 0 {
+		err = model.SensitiveWord(ctx).Where("id = ?", word.ID).
+			Updates(map[string]any{"word": word.Word, "action": word.Action, "updated_at": time.Now()}).Error
+	} else {
+		err = model.SensitiveWord(ctx).Create(&word).Error
+	}
+	resetDictionary()
+	return err
+}
+
+func (*ModerationService) DeleteWord(ctx context.Context, id int) error {
+	err := model.SensitiveWord(ctx).Where("id = ?", id).Delete(&model.SensitiveWords{}).Error
+	resetDictionary()
+	return err
+}
+
+// PageRecords 命中记录,state、action 为空时不过滤
+func (*ModerationService) PageRecords(ctx context.Context, state, action string, page, limit int) (records []model.ModerationRecords, count int64) {
+	db := model.ModerationRecord(ctx)
+	if state != "" {
+		db = db.Where("state = ?", state)
+	}
+	if action != "" {
+		db = db.Where("action = ?", action)
+	}
+	db.Count(&count)
+	if count == 0 {
+		return make([]model.ModerationRecords, 0), 0
+	}
+	db.Order("id desc").Limit(limit).Offset((page - 1) * limit).Find(&records)
+	userIds := make([]int, 0, len(records))
+	for _, r := range records {
+		userIds = append(userIds, r.UserId)
+	}
+	var users []model.Users
+	model.User(ctx).Where("id in ?", userIds).Select("id", "name").Find(&users)
+	names := make(map[int]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+	for i := range records {
+		records[i].UserName = names[records[i].UserId]
+	}
+	return
+}
+
+// Approve 审核通过,按场景重新保存内容,保存失败时恢复为待审核
+func (s *ModerationService) Approve(ctx context.Context, id, reviewer int) error {
+	record, err := s.review(ctx, id, reviewer, ReviewApproved)
+	if err != nil {
+		return err
+	}
+	if err = replay(context.WithValue(ctx, skipModerationKey{}, true), record); err != nil {
+		model.ModerationRecord(ctx).Where("id = ?", id).
+			Updates(map[string]any{"state": ReviewPending, "reviewer": 0, "reviewed_at": nil})
+		return err
+	}
+	log.Ctx(ctx).Infof("用户id: %d,审核通过,记录id: %d", reviewer, id)
+	return nil
+}
+
+// Reject 审核不通过,内容不会保存
+func (s *ModerationService) Reject(ctx context.Context, id, reviewer int) error {
+	if _, err := s.review(ctx, id, reviewer, ReviewRejected); err != nil {
+		return err
+	}
+	log.Ctx(ctx).Infof("用户id: %d,审核不通过,记录id: %d", reviewer, id)
+	return nil
+}
+
+// review 将待审核的记录修改为 state,同一条记录只有一个管理员能审核成功
+func (*ModerationService) review(ctx context.Context, id, reviewer int, state string) (record model.ModerationRecords, err error) {
+	if err = model.ModerationRecord(ctx).Where("id = ?", id).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return record, errs.NotFound.WithMsg("审核记录不存在")
+		}
+		return record, err
+	}
+	now := ltime.Now()
+	tx := model.ModerationRecord(ctx).Where("id = ? and state = ?", id, ReviewPending).
+		Updates(map[string]any{"state": state, "reviewer": reviewer, "reviewed_at": &now})
+	if tx.Error != nil {
+		return record, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return record, errs.StateConflict.WithMsg("该内容不是待审核状态")
+	}
+	return record, nil
+}
+
+func replay(ctx context.Context, record model.ModerationRecords) error {
+	raw := []byte(record.Payload)
+	switch record.Scene {
+	case SceneArticle, SceneArticleSave:
+		var req request.ReqArticle
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return err
+		}
+		var a ArticleService
+		var err error
+		if record.Scene == SceneArticle {
+			_, err = a.PublishArticle(ctx, req)
+		} else {
+			_, err = a.SaveArticle(ctx, req)
+		}
+		return err
+	case SceneArticleRestore:
+		var r articleRestore
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return err
+		}
+		var revisionS ArticleRevisionService
+		return revisionS.restore(ctx, r)
+	case SceneComment:
+		var comment model.Comments
+		if err := json.Unmarshal(raw, &comment); err != nil {
+			return err
+		}
+		var c CommentsService
+		return c.Comment(ctx, &comment)
+	case SceneRate:
+		var rate model.Rates
+		if err := json.Unmarshal(raw, &rate); err != nil {
+			return err
+		}
+		var r RateService
+		return r.Comment(ctx, rate)
+	}
+	return errs.BadRequest.WithMsg("未知的审核场景")
+}
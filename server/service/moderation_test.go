@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/moderation"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestModerateRateSQLite(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	var s ModerationService
+	var r RateService
+	for _, w := range []model.SensitiveWords{{Word: "傻瓜", Action: moderation.ActionMask}, {Word: "加微信", Action: moderation.ActionReview}, {Word: "赌博", Action: moderation.ActionReject}} {
+		if err := s.SaveWord(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Comment(ctx, model.Rates{UserId: 1, Content: "你是傻瓜"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Comment(ctx, model.Rates{UserId: 1, Content: "来赌博"}); !errors.Is(err, errs.ContentRejected) {
+		t.Fatalf("reject: %v", err)
+	}
+	if err := r.Comment(ctx, model.Rates{UserId: 1, Content: "傻瓜加微信"}); !errors.Is(err, errs.UnderReview) {
+		t.Fatalf("review: %v", err)
+	}
+	var contents []string
+	model.Rate(ctx).Order("id").Pluck("content", &contents)
+	if len(contents) != 1 || contents[0] != "你是**" {
+		t.Fatalf("审核前保存的内容: %v", contents)
+	}
+
+	pending, count := s.PageRecords(ctx, ReviewPending, "", 1, 10)
+	if count != 1 {
+		t.Fatalf("待审核: %d", count)
+	}
+	if _, count = s.PageRecords(ctx, "", "", 1, 10); count != 3 {
+		t.Fatalf("命中记录: %d", count)
+	}
+	if err := s.Approve(ctx, pending[0].ID, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reject(ctx, pending[0].ID, 2); !errors.Is(err, errs.StateConflict) {
+		t.Fatalf("重复审核: %v", err)
+	}
+	contents = nil
+	model.Rate(ctx).Order("id").Pluck("content", &contents)
+	if len(contents) != 2 || contents[1] != "**加微信" {
+		t.Fatalf("审核通过后保存的内容: %v", contents)
+	}
+}
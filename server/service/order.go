@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	mapset "github.com/deckarep/golang-set/v2"
 	"xhyovo.cn/community/server/dao"
 	"xhyovo.cn/community/server/model"
@@ -9,10 +10,10 @@ import (
 type OrderServices struct {
 }
 
-func (*OrderServices) Page(page, limit int) ([]*model.Orders, int64) {
+func (*OrderServices) Page(ctx context.Context, page, limit int) ([]*model.Orders, int64) {
 
 	var orderDao = dao.OrderDao{}
-	orders, count := orderDao.Page(page, limit)
+	orders, count := orderDao.Page(ctx, page, limit)
 	// 收集创建者id
 
 	var userIds = mapset.NewSet[int]()
@@ -22,7 +23,7 @@ func (*OrderServices) Page(page, limit int) ([]*model.Orders, int64) {
 	}
 
 	var userService = UserService{}
-	nameMap := userService.ListByIdsToMap(userIds.ToSlice())
+	nameMap := userService.ListByIdsToMap(ctx, userIds.ToSlice())
 	for _, order := range orders {
 		order.PurchaserName = nameMap[order.Purchaser].Name
 		order.CreatorName = nameMap[order.Creator].Name
@@ -31,9 +32,9 @@ func (*OrderServices) Page(page, limit int) ([]*model.Orders, int64) {
 	return orders, count
 }
 
-func (*OrderServices) CalculateProfit() int64 {
+func (*OrderServices) CalculateProfit(ctx context.Context) int64 {
 	// 我现在有一个账单表，需要查出盈利多少，函数名如何取
 	var totalProfit int64
-	model.Order().Where("acquisition_type = ?", 2).Select("SUM(price)").Scan(&totalProfit)
+	model.Order(ctx).Where("acquisition_type = ?", 2).Select("SUM(price)").Scan(&totalProfit)
 	return totalProfit
 }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	mapset "github.com/deckarep/golang-set/v2"
 	"xhyovo.cn/community/server/model"
 )
@@ -9,7 +10,7 @@ type QAAdoption struct {
 }
 
 // 查询采纳评论
-func (*QAAdoption) SetAdoptionComment(comments []*model.Comments) {
+func (*QAAdoption) SetAdoptionComment(ctx context.Context, comments []*model.Comments) {
 
 	var ids = make([]int, 0, len(comments))
 	for i := range comments {
@@ -19,7 +20,7 @@ func (*QAAdoption) SetAdoptionComment(comments []*model.Comments) {
 		return
 	}
 	var qaCommentIds []int
-	model.QaAdoption().Where("comment_id in ?", ids).Select("comment_id").Find(&qaCommentIds)
+	model.QaAdoption(ctx).Where("comment_id in ?", ids).Select("comment_id").Find(&qaCommentIds)
 
 	set := mapset.NewSet[int](qaCommentIds...)
 
@@ -32,10 +33,10 @@ func (*QAAdoption) SetAdoptionComment(comments []*model.Comments) {
 }
 
 // 采纳/取消采纳 评论
-func (*QAAdoption) Adopt(articleId, commentId int) bool {
+func (*QAAdoption) Adopt(ctx context.Context, articleId, commentId int) bool {
 
-	if err := model.QaAdoption().Create(&model.QaAdoptions{ArticleId: articleId, CommentId: commentId}).Error; err != nil {
-		model.QaAdoption().Where("comment_id = ? ", commentId).Delete(&model.QaAdoptions{})
+	if err := model.QaAdoption(ctx).Create(&model.QaAdoptions{ArticleId: articleId, CommentId: commentId}).Error; err != nil {
+		model.QaAdoption(ctx).Where("comment_id = ? ", commentId).Delete(&model.QaAdoptions{})
 		return false
 	}
 
@@ -43,8 +44,8 @@ func (*QAAdoption) Adopt(articleId, commentId int) bool {
 }
 
 // QA 采纳状态 -> 已解决 or 未解决 变更,  > 0 解决  = 0 未解决
-func (*QAAdoption) QAAdoptState(articleId int) bool {
+func (*QAAdoption) QAAdoptState(ctx context.Context, articleId int) bool {
 	var count int64
-	model.QaAdoption().Where("article_id = ?", articleId).Count(&count)
+	model.QaAdoption(ctx).Where("article_id = ?", articleId).Count(&count)
 	return count > 0
 }
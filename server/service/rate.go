@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"xhyovo.cn/community/pkg/mysql"
 	"xhyovo.cn/community/server/model"
 )
@@ -8,21 +9,25 @@ import (
 type RateService struct {
 }
 
-func (RateService) Comment(notes model.Rates) {
-	mysql.GetInstance().Save(&notes)
+func (RateService) Comment(ctx context.Context, notes model.Rates) error {
+	var moderationS ModerationService
+	if err := moderationS.Moderate(ctx, SceneRate, notes.UserId, notes.ID, &notes, &notes.Content); err != nil {
+		return err
+	}
+	return mysql.GetInstance().WithContext(ctx).Save(&notes).Error
 }
 
-func (RateService) Delete(id, userId int) {
-	model.Rate().Where("id = ? and user_id = ?", id, userId).Delete(&model.Rates{})
+func (RateService) Delete(ctx context.Context, id, userId int) {
+	model.Rate(ctx).Where("id = ? and user_id = ?", id, userId).Delete(&model.Rates{})
 }
 
-func (RateService) GetById(userId int) (note model.Rates) {
-	model.Rate().Where("user_id = ?", userId).Find(&note)
+func (RateService) GetById(ctx context.Context, userId int) (note model.Rates) {
+	model.Rate(ctx).Where("user_id = ?", userId).Find(&note)
 	return
 }
 
-func (RateService) Page(page, limit int) (state bool, notes []model.Rates) {
-	model.Rate().Offset((page - 1) * limit).Limit(limit).Order("created_at desc").Find(&notes)
+func (RateService) Page(ctx context.Context, page, limit int) (state bool, notes []model.Rates) {
+	model.Rate(ctx).Offset((page - 1) * limit).Limit(limit).Order("created_at desc").Find(&notes)
 	if len(notes) == 0 {
 		notes = make([]model.Rates, 0)
 		return
@@ -38,7 +43,7 @@ func (RateService) Page(page, limit int) (state bool, notes []model.Rates) {
 	}
 	// 根据用户id获取用户昵称
 	users := make([]model.Users, 0)
-	model.User().Where("id in (?)", userIds).Select("id", "name").Find(&users)
+	model.User(ctx).Where("id in (?)", userIds).Select("id", "name").Find(&users)
 
 	for i, v := range notes {
 		for _, u := range users {
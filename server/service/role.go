@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+)
+
+type RoleService struct {
+}
+
+// Permissions 用户通过角色获得的所有权限
+func (*RoleService) Permissions(ctx context.Context, userId int) (permissions []string, err error) {
+	err = model.RolePermission(ctx).Distinct("role_permissions.permission").
+		Joins("join user_roles as ur on ur.role_id = role_permissions.role_id").
+		Where("ur.user_id = ?", userId).
+		Pluck("role_permissions.permission", &permissions).Error
+	return
+}
+
+// HasPermission 用户是否拥有权限,拥有 * 的用户拥有所有权限
+func (s *RoleService) HasPermission(ctx context.Context, userId int, permission string) (bool, error) {
+	var count int64
+	err := model.RolePermission(ctx).
+		Joins("join user_roles as ur on ur.role_id = role_permissions.role_id").
+		Where("ur.user_id = ? and role_permissions.permission in ?", userId, []string{permission, constant.PermAll}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// IsStaff 用户是否拥有任意后台权限
+func (s *RoleService) IsStaff(ctx context.Context, userId int) (bool, error) {
+	var count int64
+	err := model.UserRole(ctx).Where("user_id = ?", userId).Count(&count).Error
+	return count > 0, err
+}
+
+func (*RoleService) List(ctx context.Context) (roles []model.Roles, err error) {
+	if err = model.Role(ctx).Order("id").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	var rps []model.RolePermissions
+	if err = model.RolePermission(ctx).Order("id").Find(&rps).Error; err != nil {
+		return nil, err
+	}
+	m := make(map[int][]string)
+	for _, rp := range rps {
+		m[rp.RoleId] = append(m[rp.RoleId], rp.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = m[roles[i].ID]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+	return roles, nil
+}
+
+// Save 新增或修改角色,权限整体覆盖,内置角色不能修改
+func (*RoleService) Save(ctx context.Context, req request.ReqRole) (id int, err error) {
+	for _, p := range req.Permissions {
+		if !constant.ValidPermission(p) {
+			return 0, errs.Validation.WithMsg("权限不存在").WithDetails([]errs.FieldError{{Field: "permissions", Msg: p}})
+		}
+	}
+	var count int64
+	model.Role(ctx).Where("name = ? and id != ?", req.Name, req.ID).Count(&count)
+	if count > 0 {
+		return 0, errs.Conflict.WithMsg("角色名称已存在")
+	}
+	role := model.Roles{ID: req.ID, Name: req.Name, Description: req.Description}
+	if req.ID != 0 {
+		var old model.Roles
+		if err = model.Role(ctx).Where("id = ?", req.ID).First(&old).Error; err != nil {
+			return 0, roleErr(err)
+		}
+		if old.BuiltIn {
+			return 0, errs.StateConflict.WithMsg("内置角色不能修改")
+		}
+	}
+	err = mysql.GetInstance().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if role.ID != 0 {
+			if err := tx.Model(&model.Roles{}).Where("id = ?", role.ID).
+				Updates(map[string]any{"name": role.Name, "description": role.Description}).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Create(&role).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&model.RolePermissions{}).Error; err != nil {
+			return err
+		}
+		if len(req.Permissions) == 0 {
+			return nil
+		}
+		rps := make([]model.RolePermissions, 0, len(req.Permissions))
+		seen := make(map[string]bool)
+		for _, p := range req.Permissions {
+			if !seen[p] {
+				seen[p] = true
+				rps = append(rps, model.RolePermissions{RoleId: role.ID, Permission: p})
+			}
+		}
+		return tx.Create(&rps).Error
+	})
+	return role.ID, err
+}
+
+// Delete 删除角色及其分配关系,内置角色不能删除
+func (*RoleService) Delete(ctx context.Context, id int) error {
+	var role model.Roles
+	if err := model.Role(ctx).Where("id = ?", id).First(&role).Error; err != nil {
+		return roleErr(err)
+	}
+	if role.BuiltIn {
+		return errs.StateConflict.WithMsg("内置角色不能删除")
+	}
+	return mysql.GetInstance().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", id).Delete(&model.UserRoles{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&model.RolePermissions{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&model.Roles{}).Error
+	})
+}
+
+// UserRoles 用户的角色
+func (*RoleService) UserRoles(ctx context.Context, userId int) (roles []model.Roles, err error) {
+	err = model.Role(ctx).Joins("join user_roles as ur on ur.role_id = roles.id").
+		Where("ur.user_id = ?", userId).Order("roles.id").Find(&roles).Error
+	if roles == nil {
+		roles = []model.Roles{}
+	}
+	return
+}
+
+// SetUserRoles 覆盖用户的角色,至少保留一个 admin
+func (s *RoleService) SetUserRoles(ctx context.Context, userId int, roleIds []int) error {
+	if len(roleIds) > 0 {
+		var count int64
+		model.Role(ctx).Where("id in ?", roleIds).Count(&count)
+		if int(count) != len(uniqueInts(roleIds)) {
+			return errs.NotFound.WithMsg("角色不存在")
+		}
+	}
+	var admin model.Roles
+	if err := model.Role(ctx).Where("name = ?", constant.RoleAdmin).First(&admin).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return mysql.GetInstance().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&model.UserRoles{}).Error; err != nil {
+			return err
+		}
+		ids := uniqueInts(roleIds)
+		if len(ids) > 0 {
+			urs := make([]model.UserRoles, 0, len(ids))
+			for _, id := range ids {
+				urs = append(urs, model.UserRoles{UserId: userId, RoleId: id})
+			}
+			if err := tx.Create(&urs).Error; err != nil {
+				return err
+			}
+		}
+		if admin.ID == 0 {
+			return nil
+		}
+		var count int64
+		if err := tx.Model(&model.UserRoles{}).Where("role_id = ?", admin.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errs.StateConflict.WithMsg("至少保留一个管理员")
+		}
+		return nil
+	})
+}
+
+func roleErr(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errs.NotFound.WithMsg("角色不存在")
+	}
+	return err
+}
+
+func uniqueInts(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	res := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res
+}
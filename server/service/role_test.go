@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestRolePermissionSQLite(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	var s RoleService
+	roles, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]int{}
+	for _, r := range roles {
+		ids[r.Name] = r.ID
+		for _, p := range r.Permissions {
+			if !constant.ValidPermission(p) {
+				t.Fatalf("角色 %s 的权限未登记: %s", r.Name, p)
+			}
+		}
+	}
+	if len(ids) != 3 {
+		t.Fatalf("内置角色: %v", ids)
+	}
+
+	if err = s.SetUserRoles(ctx, 1, []int{ids[constant.RoleAdmin]}); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.SetUserRoles(ctx, 2, []int{ids[constant.RoleModerator]}); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		userId     int
+		permission string
+		want       bool
+	}{
+		{1, constant.PermRoleManage, true},
+		{2, constant.PermArticleDelete, true},
+		{2, constant.PermRoleManage, false},
+		{3, constant.PermArticleView, false},
+	}
+	for _, c := range cases {
+		if ok, err := s.HasPermission(ctx, c.userId, c.permission); err != nil || ok != c.want {
+			t.Fatalf("用户 %d 权限 %s: %v %v", c.userId, c.permission, ok, err)
+		}
+	}
+
+	if err = s.SetUserRoles(ctx, 1, nil); !errors.Is(err, errs.StateConflict) {
+		t.Fatalf("移除最后一个管理员: %v", err)
+	}
+	if ok, _ := s.HasPermission(ctx, 1, constant.PermRoleManage); !ok {
+		t.Fatal("移除失败后应该保留原有角色")
+	}
+	if err = s.Delete(ctx, ids[constant.RoleAdmin]); !errors.Is(err, errs.StateConflict) {
+		t.Fatalf("删除内置角色: %v", err)
+	}
+	if err = s.Delete(ctx, ids[constant.RoleModerator]); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	model.UserRole(ctx).Where("user_id = ?", 2).Count(&count)
+	if count != 0 {
+		t.Fatalf("删除角色后用户角色: %d", count)
+	}
+}
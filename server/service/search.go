@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/search"
+	ltime "xhyovo.cn/community/pkg/time"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+)
+
+// 公开的文章状态,只有这些状态的文章会被索引
+var searchableStates = []int{constant.Published, constant.Pending, constant.Resolved}
+
+// 全量构建时每批读取的行数
+const searchBatchSize = 500
+
+type SearchService struct {
+}
+
+// Rebuild 从数据库全量构建索引,构建期间的增量更新不会丢失
+func (s *SearchService) Rebuild(ctx context.Context) error {
+	index := search.GetInstance()
+	index.BeginRebuild()
+	start := time.Now()
+	docs, err := s.loadAll(ctx)
+	if err != nil {
+		index.AbortRebuild()
+		return err
+	}
+	index.Rebuild(docs)
+	log.Ctx(ctx).Infof("搜索索引构建完成,文档数: %d,耗时: %s", len(docs), time.Since(start))
+	return nil
+}
+
+func (s *SearchService) loadAll(ctx context.Context) ([]search.Doc, error) {
+	var docs []search.Doc
+	parents := typeParents(ctx)
+	var articles []model.Articles
+	err := model.Article(ctx).Select("id", "title", "abstract", "content", "type", "user_id", "state", "created_at").
+		Where("state in ?", searchableStates).
+		FindInBatches(&articles, searchBatchSize, func(tx *gorm.DB, batch int) error {
+			tags := articleTags(ctx, articleIds(articles))
+			for i := range articles {
+				docs = append(docs, articleDoc(&articles[i], parents, tags[articles[i].ID]))
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []model.Comments
+	err = model.Comment(ctx).Select("id", "content", "from_user_id", "business_id", "tenant_id", "created_at").
+		FindInBatches(&comments, searchBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range comments {
+				docs = append(docs, commentDoc(&comments[i]))
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []model.CoursesSections
+	err = model.CoursesSection(ctx).Select("id", "title", "content", "user_id", "course_id", "created_at").
+		FindInBatches(&sections, searchBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range sections {
+				docs = append(docs, sectionDoc(&sections[i]))
+			}
+			return nil
+		}).Error
+	return docs, err
+}
+
+// IndexArticle 文章保存、修改状态、删除后更新索引,非公开状态的文章从索引中删除
+func (s *SearchService) IndexArticle(ctx context.Context, id int) {
+	var article model.Articles
+	err := model.Article(ctx).Select("id", "title", "abstract", "content", "type", "user_id", "state", "created_at").
+		Where("id = ?", id).Limit(1).Find(&article).Error
+	if err != nil {
+		log.Ctx(ctx).Warnf("更新文章索引失败,文章id: %d,err: %s", id, err.Error())
+		return
+	}
+	key := search.Key{Kind: search.KindArticle, ID: id}
+	if article.ID == 0 || !containsInt(searchableStates, article.State) {
+		search.GetInstance().Delete(key)
+		return
+	}
+	search.GetInstance().Put(articleDoc(&article, typeParents(ctx), articleTags(ctx, []int{id})[id]))
+}
+
+// IndexComment 评论发布、删除后更新索引
+func (s *SearchService) IndexComment(ctx context.Context, id int) {
+	var comment model.Comments
+	err := model.Comment(ctx).Select("id", "content", "from_user_id", "business_id", "tenant_id", "created_at").
+		Where("id = ?", id).Limit(1).Find(&comment).Error
+	if err != nil {
+		log.Ctx(ctx).Warnf("更新评论索引失败,评论id: %d,err: %s", id, err.Error())
+		return
+	}
+	if comment.ID == 0 {
+		search.GetInstance().Delete(search.Key{Kind: search.KindComment, ID: id})
+		return
+	}
+	search.GetInstance().Put(commentDoc(&comment))
+}
+
+// IndexSection 章节发布、修改、删除后更新索引
+func (s *SearchService) IndexSection(ctx context.Context, id int) {
+	var section model.CoursesSections
+	err := model.CoursesSection(ctx).Select("id", "title", "content", "user_id", "course_id", "created_at").
+		Where("id = ?", id).Limit(1).Find(&section).Error
+	if err != nil {
+		log.Ctx(ctx).Warnf("更新章节索引失败,章节id: %d,err: %s", id, err.Error())
+		return
+	}
+	if section.ID == 0 {
+		search.GetInstance().Delete(search.Key{Kind: search.KindSection, ID: id})
+		return
+	}
+	search.GetInstance().Put(sectionDoc(&section))
+}
+
+// Search 搜索文章、评论、课程章节
+func (s *SearchService) Search(ctx context.Context, req request.ReqSearch, page, limit int) ([]model.SearchResult, int64, error) {
+	q := search.Query{
+		Text:     req.Keyword,
+		AuthorId: req.Author,
+		SortTime: req.Sort == "time",
+		Offset:   (page - 1) * limit,
+		Limit:    limit,
+	}
+	switch req.Kind {
+	case "":
+	case search.KindArticle, search.KindComment, search.KindSection:
+		q.Kinds = []string{req.Kind}
+	default:
+		return nil, 0, errs.BadRequest.WithMsg("搜索类型只支持 article / comment / section")
+	}
+	if req.Type != 0 {
+		q.Types = []int{req.Type}
+	}
+	q.Tags = req.Tags
+	if req.State != 0 {
+		q.States = []int{req.State}
+	}
+	var err error
+	if q.From, err = parseDate(req.From); err != nil {
+		return nil, 0, err
+	}
+	if q.To, err = parseDate(req.To); err != nil {
+		return nil, 0, err
+	}
+	if !q.To.IsZero() {
+		q.To = q.To.AddDate(0, 0, 1)
+	}
+
+	index := search.GetInstance()
+	if !index.Ready() {
+		return nil, 0, errs.Unavailable.WithMsg("搜索索引构建中,请稍后重试")
+	}
+	hits, total := index.Search(q)
+	results := make([]model.SearchResult, len(hits))
+	var userIds, articleIds, sectionIds []int
+	for i, h := range hits {
+		d := h.Doc
+		results[i] = model.SearchResult{
+			Kind:       d.Kind,
+			ID:         d.ID,
+			Title:      h.Title,
+			Snippet:    h.Snippet,
+			Score:      h.Score,
+			BusinessId: d.BusinessId,
+			TenantId:   d.TenantId,
+			CreatedAt:  toLocalTime(d.CreatedAt),
+		}
+		if d.Kind == search.KindArticle {
+			results[i].State = d.State
+			results[i].StateName = constant.GetArticleName(ctx, d.State)
+		}
+		userIds = append(userIds, d.AuthorId)
+		if d.Kind == search.KindComment && d.TenantId == 0 {
+			articleIds = append(articleIds, d.BusinessId)
+		} else if d.Kind == search.KindComment && d.TenantId == 1 {
+			sectionIds = append(sectionIds, d.BusinessId)
+		}
+	}
+
+	// 评论没有标题,使用所属文章、章节的标题
+	articleTitles := make(map[int]string)
+	if len(articleIds) > 0 {
+		for _, a := range articleDao.ListByIdsSelectIdTitle(ctx, articleIds) {
+			articleTitles[a.ID] = a.Title
+		}
+	}
+	var courseS CourseService
+	sectionTitles := make(map[int]string)
+	if len(sectionIds) > 0 {
+		sectionTitles = courseS.ListSectionByIds(ctx, sectionIds)
+	}
+	users := make(map[int]model.UserSimple)
+	if len(userIds) > 0 {
+		var list []model.UserSimple
+		model.User(ctx).Where("id in ?", userIds).Select("id", "name", "avatar").Find(&list)
+		for _, u := range list {
+			users[u.UId] = u
+		}
+	}
+	for i := range results {
+		r := &results[i]
+		r.User = users[hits[i].Doc.AuthorId]
+		if r.Kind == search.KindComment {
+			if r.TenantId == 0 {
+				r.Title = articleTitles[r.BusinessId]
+			} else if r.TenantId == 1 {
+				r.Title = sectionTitles[r.BusinessId]
+			}
+		}
+	}
+	return results, int64(total), nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return t, errs.BadRequest.WithMsg("日期格式错误,应为 2006-01-02")
+	}
+	return t, nil
+}
+
+func articleDoc(a *model.Articles, parents map[int]int, tags []int) search.Doc {
+	types := []int{a.Type}
+	if p := parents[a.Type]; p != 0 {
+		types = append(types, p)
+	}
+	body := a.Abstract
+	if content := search.PlainText(a.Content); content != "" {
+		if body != "" {
+			body += " "
+		}
+		body += content
+	}
+	return search.Doc{
+		Kind:      search.KindArticle,
+		ID:        a.ID,
+		Title:     a.Title,
+		Body:      body,
+		Types:     types,
+		Tags:      tags,
+		AuthorId:  a.UserId,
+		State:     a.State,
+		CreatedAt: time.Time(a.CreatedAt),
+	}
+}
+
+func commentDoc(c *model.Comments) search.Doc {
+	doc := search.Doc{
+		Kind:       search.KindComment,
+		ID:         c.ID,
+		Body:       search.PlainText(c.Content),
+		AuthorId:   c.FromUserId,
+		CreatedAt:  time.Time(c.CreatedAt),
+		BusinessId: c.BusinessId,
+		TenantId:   c.TenantId,
+	}
+	// 文章、章节下的评论随文章、章节一起隐藏
+	switch c.TenantId {
+	case 0:
+		doc.Parent = search.Key{Kind: search.KindArticle, ID: c.BusinessId}
+	case 1:
+		doc.Parent = search.Key{Kind: search.KindSection, ID: c.BusinessId}
+	}
+	return doc
+}
+
+func sectionDoc(s *model.CoursesSections) search.Doc {
+	return search.Doc{
+		Kind:       search.KindSection,
+		ID:         s.ID,
+		Title:      s.Title,
+		Body:       search.PlainText(s.Content),
+		AuthorId:   s.UserId,
+		CreatedAt:  time.Time(s.CreatedAt),
+		BusinessId: s.CourseId,
+	}
+}
+
+// typeParents 分类 id -> 父分类 id
+func typeParents(ctx context.Context) map[int]int {
+	var types []model.Types
+	model.Type(ctx).Select("id", "parent_id").Find(&types)
+	m := make(map[int]int, len(types))
+	for _, t := range types {
+		m[t.ID] = t.ParentId
+	}
+	return m
+}
+
+// articleTags 文章 id -> 标签 id
+func articleTags(ctx context.Context, ids []int) map[int][]int {
+	m := make(map[int][]int)
+	if len(ids) == 0 {
+		return m
+	}
+	var relations []model.ArticleTagRelations
+	model.ArticleTagRelation(ctx).Where("article_id in ?", ids).Select("article_id", "tag_id").Find(&relations)
+	for _, r := range relations {
+		m[r.ArticleId] = append(m[r.ArticleId], r.TagId)
+	}
+	return m
+}
+
+func articleIds(articles []model.Articles) []int {
+	ids := make([]int, len(articles))
+	for i := range articles {
+		ids[i] = articles[i].ID
+	}
+	return ids
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func toLocalTime(t time.Time) ltime.LocalTime {
+	return ltime.LocalTime(t)
+}
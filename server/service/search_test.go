@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/server/model"
+	"xhyovo.cn/community/server/request"
+)
+
+func TestSearchSQLite(t *testing.T) {
+	initSQLite(t)
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article"})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1})
+	db.Create(&model.Articles{ID: 1, Title: "Go 泛型入门", Content: "介绍 **类型参数** 的用法", UserId: 1, Type: 2, State: constant.Published})
+	db.Create(&model.Articles{ID: 2, Title: "泛型草稿", UserId: 1, Type: 2, State: constant.Draft})
+	db.Create(&model.Comments{ID: 1, Content: "类型参数讲得很清楚", FromUserId: 1, BusinessId: 1})
+	db.Create(&model.Courses{ID: 1, Title: "Go 课程"})
+	db.Create(&model.CoursesSections{ID: 1, Title: "第一章 类型参数", CourseId: 1, UserId: 1})
+
+	var s SearchService
+	if err := s.Rebuild(ctx); err != nil {
+		t.Fatal(err)
+	}
+	results, total, err := s.Search(ctx, request.ReqSearch{Keyword: "泛型"}, 1, 10)
+	if err != nil || total != 1 || results[0].ID != 1 || results[0].User.UName != "xhy" {
+		t.Fatalf("草稿不应被搜索到: %d, %+v, %v", total, results, err)
+	}
+	if _, total, _ = s.Search(ctx, request.ReqSearch{Keyword: "泛型", Type: 1}, 1, 10); total != 1 {
+		t.Fatal("一级分类应包含子分类")
+	}
+
+	results, total, _ = s.Search(ctx, request.ReqSearch{Keyword: "类型参数"}, 1, 10)
+	if total != 3 {
+		t.Fatalf("文章、评论、章节都应被搜索到: %d", total)
+	}
+	for _, r := range results {
+		if r.Kind == "comment" && r.Title != "Go 泛型入门" {
+			t.Fatalf("评论标题应为文章标题: %q", r.Title)
+		}
+	}
+
+	// 文章删除后,其下的评论也不再返回
+	var a ArticleService
+	if err = a.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	results, total, _ = s.Search(ctx, request.ReqSearch{Keyword: "类型参数"}, 1, 10)
+	if total != 1 || results[0].Kind != "section" {
+		t.Fatalf("删除文章后: %d, %+v", total, results)
+	}
+
+	// 草稿发布后增量加入索引
+	a.UpdateState(ctx, 2, constant.Published)
+	if _, total, _ = s.Search(ctx, request.ReqSearch{Keyword: "草稿"}, 1, 10); total != 1 {
+		t.Fatal("发布后应被搜索到")
+	}
+	if _, _, err = s.Search(ctx, request.ReqSearch{Keyword: "泛型", From: "2006/01/02"}, 1, 10); err == nil {
+		t.Fatal("日期格式错误应返回错误")
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"time"
+
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
+	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/oss"
+	"xhyovo.cn/community/pkg/search"
+	"xhyovo.cn/community/pkg/utils"
+	"xhyovo.cn/community/server/model"
+)
+
+const (
+	// 页面描述的长度
+	seoDescriptionLength = 160
+	// 单个 sitemap 最多 50000 个地址
+	sitemapMaxUrls = 50000
+)
+
+// 服务端渲染的文章状态: 已发布的文章和已解决的问答
+var seoStates = []int{constant.Published, constant.Resolved}
+
+type SeoService struct {
+}
+
+// ArticlePage 文章页面的数据,会员分类的文章只有摘要
+type ArticlePage struct {
+	Lang        string
+	SiteName    string
+	SiteUrl     string
+	ID          int
+	Title       string
+	Description string
+	Canonical   string
+	Image       string
+	Author      string
+	TypeTitle   string
+	Tags        []string
+	Published   time.Time
+	Modified    time.Time
+	Views       int
+	Likes       int
+	QA          bool
+	MembersOnly bool
+	Content     template.HTML
+	Answer      template.HTML // 问答被采纳的回答
+	AnswerBy    string
+	AnswerAt    time.Time
+	JsonLd      template.JS // schema.org 结构化数据
+	T           func(key string) string
+}
+
+// ArticlePage 文章页面,只有已发布的文章和已解决的问答可以访问
+func (s *SeoService) ArticlePage(ctx context.Context, id int) (*ArticlePage, error) {
+	var article model.Articles
+	model.Article(ctx).Where("id = ? and state in ?", id, seoStates).Limit(1).Find(&article)
+	if article.ID == 0 {
+		return nil, errs.NotFound.WithMsg("文章不存在")
+	}
+	var typeS TypeService
+	site := siteConfig()
+	page := &ArticlePage{
+		Lang:        i18n.FromContext(ctx),
+		SiteName:    site.Name,
+		SiteUrl:     siteUrl() + "/",
+		ID:          article.ID,
+		Title:       article.Title,
+		Description: article.Abstract,
+		Canonical:   articleUrl(article.ID),
+		TypeTitle:   typeS.ListByIdToMap(ctx, []int{article.Type})[article.Type],
+		Tags:        []string{},
+		Published:   time.Time(article.CreatedAt),
+		Modified:    time.Time(article.UpdatedAt),
+		Views:       article.Views,
+		Likes:       article.Like,
+		QA:          article.State == constant.Resolved,
+		MembersOnly: !containsInt(typeS.PublicIds(ctx), article.Type),
+		T: func(key string) string {
+			return i18n.T(ctx, key)
+		},
+	}
+	var author model.Users
+	model.User(ctx).Where("id = ?", article.UserId).Select("name").Limit(1).Find(&author)
+	page.Author = author.Name
+	model.ArticleTagRelation(ctx).Joins("JOIN article_tags ON article_tags.id = article_tag_relations.tag_id").
+		Where("article_tag_relations.article_id = ?", article.ID).Pluck("article_tags.tag_name", &page.Tags)
+
+	var answer model.Comments
+	if !page.MembersOnly {
+		if page.Description == "" {
+			page.Description = utils.Limit(search.PlainText(article.Content), 0, seoDescriptionLength, "...")
+		}
+		// 封面和正文中的图片需要登录才能访问,公开分类使用订阅源的封面地址和签名地址
+		if article.Cover != "" {
+			page.Image = coverUrl(article.ID, "")
+		}
+		page.Content = renderMarkdown(ctx, article.Content)
+		if page.QA {
+			var adoption model.QaAdoptions
+			model.QaAdoption(ctx).Where("article_id = ?", article.ID).Limit(1).Find(&adoption)
+			if adoption.ID != 0 {
+				model.Comment(ctx).Where("id = ?", adoption.CommentId).Limit(1).Find(&answer)
+			}
+			if answer.ID != 0 {
+				var u model.Users
+				model.User(ctx).Where("id = ?", answer.FromUserId).Select("name").Limit(1).Find(&u)
+				page.Answer = renderMarkdown(ctx, answer.Content)
+				page.AnswerBy = u.Name
+				page.AnswerAt = time.Time(answer.CreatedAt)
+			}
+		}
+	}
+	page.JsonLd = s.jsonLd(page, answer)
+	return page, nil
+}
+
+// renderMarkdown 渲染正文,fileKey 链接替换为签名地址
+func renderMarkdown(ctx context.Context, content string) template.HTML {
+	content = utils.ReplaceFileKeys(content, oss.SingUrl)
+	html, err := utils.MarkdownToHtml(content)
+	if err != nil {
+		log.Ctx(ctx).Warnf("渲染 markdown 失败,err: %s", err.Error())
+		return ""
+	}
+	return template.HTML(html)
+}
+
+// jsonLd 文章使用 Article,问答使用 QAPage;会员内容标记为非免费,不包含正文
+func (s *SeoService) jsonLd(page *ArticlePage, answer model.Comments) template.JS {
+	author := map[string]any{"@type": "Person", "name": page.Author}
+	data := map[string]any{"@context": "https://schema.org"}
+	if page.QA {
+		question := map[string]any{
+			"@type":       "Question",
+			"name":        page.Title,
+			"text":        page.Description,
+			"dateCreated": page.Published.Format(time.RFC3339),
+			"author":      author,
+			"answerCount": 0,
+		}
+		if answer.ID != 0 {
+			question["answerCount"] = 1
+			question["acceptedAnswer"] = map[string]any{
+				"@type":       "Answer",
+				"text":        search.PlainText(answer.Content),
+				"dateCreated": page.AnswerAt.Format(time.RFC3339),
+				"url":         page.Canonical + "#answer",
+				"author":      map[string]any{"@type": "Person", "name": page.AnswerBy},
+			}
+		}
+		data["@type"] = "QAPage"
+		data["mainEntity"] = question
+	} else {
+		data["@type"] = "Article"
+		data["headline"] = page.Title
+		data["description"] = page.Description
+		data["datePublished"] = page.Published.Format(time.RFC3339)
+		data["dateModified"] = page.Modified.Format(time.RFC3339)
+		data["author"] = author
+		data["mainEntityOfPage"] = page.Canonical
+		data["publisher"] = map[string]any{"@type": "Organization", "name": page.SiteName, "url": page.SiteUrl}
+		if page.Image != "" {
+			data["image"] = page.Image
+		}
+		if len(page.Tags) > 0 {
+			data["keywords"] = page.Tags
+		}
+	}
+	if page.MembersOnly {
+		data["isAccessibleForFree"] = false
+	}
+	// json.Marshal 会转义 <、>、&,可以直接放在 script 标签中
+	b, _ := json.Marshal(data)
+	return template.JS(b)
+}
+
+type sitemapUrlSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+type sitemapUrl struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap 首页和所有可以访问的文章页面,按更新时间倒序
+func (s *SeoService) Sitemap(ctx context.Context) ([]byte, error) {
+	var articles []model.Articles
+	err := model.Article(ctx).Select("id", "updated_at").Where("state in ?", seoStates).
+		Order("updated_at desc").Limit(sitemapMaxUrls - 1).Find(&articles).Error
+	if err != nil {
+		return nil, err
+	}
+	set := sitemapUrlSet{Urls: make([]sitemapUrl, 0, len(articles)+1)}
+	set.Urls = append(set.Urls, sitemapUrl{Loc: siteUrl() + "/"})
+	for _, a := range articles {
+		set.Urls = append(set.Urls, sitemapUrl{Loc: articleUrl(a.ID), LastMod: time.Time(a.UpdatedAt).Format(time.RFC3339)})
+	}
+	b, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// Robots 允许抓取文章页面和订阅源,不抓取其他接口
+func (s *SeoService) Robots() string {
+	return "User-agent: *\n" +
+		"Allow: /article/\n" +
+		"Allow: /community/feed/\n" +
+		"Disallow: /community/\n" +
+		"Sitemap: " + siteUrl() + "/sitemap.xml\n"
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"xhyovo.cn/community/pkg/config"
+	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/mysql"
+	"xhyovo.cn/community/pkg/oss"
+	"xhyovo.cn/community/server/model"
+)
+
+func TestSeoArticlePage(t *testing.T) {
+	initSQLite(t)
+	oss.Init(config.OssConfig{Driver: oss.DriverLocal, LocalPath: t.TempDir(), SecretKey: "secret"})
+	ctx := context.Background()
+	db := mysql.GetInstance()
+	db.Create(&model.Users{ID: 1, Name: "xhy"})
+	db.Create(&model.Users{ID: 2, Name: "helper"})
+	db.Create(&model.Types{ID: 1, Title: "文章", FlagName: "article", Public: true})
+	db.Create(&model.Types{ID: 2, Title: "Go", ParentId: 1, FlagName: "go"})
+	db.Create(&model.Types{ID: 3, Title: "QA", FlagName: "qa"})
+	db.Create(&model.Types{ID: 4, Title: "问答", ParentId: 3, FlagName: "question"})
+	content := "# 标题\n\n正文 <script>alert(1)</script> [x](javascript:alert(1))\n\n![a](/community/file/singUrl?fileKey=1/a.png)"
+	db.Create(&model.Articles{ID: 1, Title: "公开", Content: content, UserId: 1, Type: 2, State: constant.Published, Cover: "1/a.png"})
+	db.Create(&model.Articles{ID: 2, Title: "会员问题", Abstract: "问题摘要", Content: "会员正文", UserId: 1, Type: 4, State: constant.Resolved})
+	db.Create(&model.Articles{ID: 3, Title: "待解决", Content: "x", UserId: 1, Type: 4, State: constant.Pending})
+	db.Create(&model.Comments{ID: 1, Content: "采纳的回答", FromUserId: 2, BusinessId: 2})
+	db.Create(&model.QaAdoptions{ArticleId: 2, CommentId: 1})
+
+	var s SeoService
+	page, err := s.ArticlePage(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(page.Content)
+	if !strings.Contains(html, "<h1") || strings.Contains(html, "<script>") || strings.Contains(html, "javascript:") {
+		t.Fatalf("正文: %s", html)
+	}
+	if strings.Contains(html, "singUrl") || page.MembersOnly || page.Image == "" || !strings.HasPrefix(page.Description, "标题 正文") {
+		t.Fatalf("公开分类: %+v", page)
+	}
+	if !strings.Contains(string(page.JsonLd), `"@type":"Article"`) || strings.Contains(string(page.JsonLd), "isAccessibleForFree") {
+		t.Fatalf("结构化数据: %s", page.JsonLd)
+	}
+
+	page, err = s.ArticlePage(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !page.MembersOnly || page.Content != "" || page.Answer != "" || page.Image != "" || page.Description != "问题摘要" {
+		t.Fatalf("会员分类只有摘要: %+v", page)
+	}
+	ld := string(page.JsonLd)
+	if !strings.Contains(ld, `"@type":"QAPage"`) || !strings.Contains(ld, `"isAccessibleForFree":false`) || strings.Contains(ld, "采纳的回答") {
+		t.Fatalf("会员问答的结构化数据: %s", ld)
+	}
+
+	// 公开后显示采纳的回答
+	db.Model(&model.Types{}).Where("id = 3").Update("public", true)
+	page, _ = s.ArticlePage(ctx, 2)
+	if page.MembersOnly || !strings.Contains(string(page.Answer), "采纳的回答") || !strings.Contains(string(page.JsonLd), `"acceptedAnswer"`) {
+		t.Fatalf("公开问答: %+v", page)
+	}
+
+	if _, err = s.ArticlePage(ctx, 3); !errors.Is(err, errs.NotFound) {
+		t.Fatalf("待解决的问答不生成页面: %v", err)
+	}
+	sitemap, err := s.Sitemap(ctx)
+	if err != nil || !strings.Contains(string(sitemap), "/article/1</loc>") || !strings.Contains(string(sitemap), "/article/2</loc>") || strings.Contains(string(sitemap), "/article/3</loc>") {
+		t.Fatalf("sitemap: %s, %v", sitemap, err)
+	}
+}
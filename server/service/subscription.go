@@ -1,12 +1,17 @@
 package services
 
 import (
+	"context"
 	mapset "github.com/deckarep/golang-set/v2"
 	"regexp"
 	"strconv"
+	"xhyovo.cn/community/pkg/config"
 	"xhyovo.cn/community/pkg/constant"
 	"xhyovo.cn/community/pkg/email"
+	"xhyovo.cn/community/pkg/i18n"
+	"xhyovo.cn/community/pkg/lifecycle"
 	"xhyovo.cn/community/pkg/log"
+	"xhyovo.cn/community/pkg/metrics"
 	"xhyovo.cn/community/server/model"
 	"xhyovo.cn/community/server/service/event"
 )
@@ -15,12 +20,13 @@ type SubscriptionService struct {
 }
 
 // 查看订阅列表
-func (*SubscriptionService) ListSubscription(userId, eventId, page, limit int) ([]model.Subscriptions, int64) {
-	subscriptions, count := subscriptionDao.ListSubscription(userId, eventId, page, limit)
+func (*SubscriptionService) ListSubscription(ctx context.Context, userId, eventId, page, limit int) ([]model.Subscriptions, int64) {
+	subscriptions, count := subscriptionDao.ListSubscription(ctx, userId, eventId, page, limit)
 
 	var userIds []int
 	var articleIds []int
 	var courseIds []int
+	var seriesIds []int
 	for i := range subscriptions {
 		v := subscriptions[i]
 		if v.EventId == event.CommentUpdateEvent {
@@ -29,16 +35,21 @@ func (*SubscriptionService) ListSubscription(userId, eventId, page, limit int) (
 			userIds = append(userIds, v.BusinessId)
 		} else if v.EventId == event.CourseUpdate {
 			courseIds = append(courseIds, v.BusinessId)
+		} else if v.EventId == event.SeriesUpdate {
+			seriesIds = append(seriesIds, v.BusinessId)
 		}
 	}
 	var articleService ArticleService
-	articleMap := articleService.ListByIdsSelectIdTitleMap(articleIds)
+	articleMap := articleService.ListByIdsSelectIdTitleMap(ctx, articleIds)
 
 	var userService UserService
-	nameMap := userService.ListByIdsToMap(userIds)
+	nameMap := userService.ListByIdsToMap(ctx, userIds)
 
 	var courseService CourseService
-	courseMap := courseService.ListByIdsSelectIdTitleMap(courseIds)
+	courseMap := courseService.ListByIdsSelectIdTitleMap(ctx, courseIds)
+
+	var seriesService SeriesService
+	seriesMap := seriesService.ListByIdsSelectIdTitleMap(ctx, seriesIds)
 	for i := range subscriptions {
 		v := &subscriptions[i]
 		businessId := v.BusinessId
@@ -48,95 +59,99 @@ func (*SubscriptionService) ListSubscription(userId, eventId, page, limit int) (
 			v.BusinessName = nameMap[businessId].Name
 		} else if v.EventId == event.CourseUpdate {
 			v.BusinessName = courseMap[businessId]
+		} else if v.EventId == event.SeriesUpdate {
+			v.BusinessName = seriesMap[businessId]
 		}
-		v.EventName = event.GetMsg(v.EventId)
+		v.EventName = event.GetMsg(ctx, v.EventId)
 	}
 
 	return subscriptions, count
 }
 
 // 查看对应事件订阅状态
-func (*SubscriptionService) SubscriptionState(subscriptions *model.SubscriptionState) bool {
+func (*SubscriptionService) SubscriptionState(ctx context.Context, subscriptions *model.SubscriptionState) bool {
 
-	return subscriptionDao.SubscriptionState(subscriptions)
+	return subscriptionDao.SubscriptionState(ctx, subscriptions)
 }
 
 // 订阅/取消订阅
-func (*SubscriptionService) Subscribe(subscription *model.Subscriptions) bool {
+func (*SubscriptionService) Subscribe(ctx context.Context, subscription *model.Subscriptions) bool {
 	businessId := subscription.BusinessId
 	if event.CommentUpdateEvent == subscription.EventId {
-		model.Article().Where("id = ?", businessId).Select("user_id").First(&subscription.SendId)
+		model.Article(ctx).Where("id = ?", businessId).Select("user_id").First(&subscription.SendId)
 	} else if event.UserFollowingEvent == subscription.EventId {
-		model.User().Where("id = ?", businessId).Select("id").First(&subscription.SendId)
+		model.User(ctx).Where("id = ?", businessId).Select("id").First(&subscription.SendId)
 	} else if event.CourseUpdate == subscription.EventId {
-		model.Course().Where("id = ?", businessId).Select("user_id").First(&subscription.SendId)
+		model.Course(ctx).Where("id = ?", businessId).Select("user_id").First(&subscription.SendId)
 
+	} else if event.SeriesUpdate == subscription.EventId {
+		model.Series(ctx).Where("id = ?", businessId).Select("user_id").First(&subscription.SendId)
 	}
 
-	return subscriptionDao.Subscribe(subscription)
+	return subscriptionDao.Subscribe(ctx, subscription)
 }
 
 // 查询事件订阅的userid
-func (*SubscriptionService) ListSubscriptionUserId(event, businessId int) []model.Subscriptions {
-	return subscriptionDao.ListSubscriptions(event, businessId)
+func (*SubscriptionService) ListSubscriptionUserId(ctx context.Context, event, businessId int) []model.Subscriptions {
+	return subscriptionDao.ListSubscriptions(ctx, event, businessId)
 }
 
 // 触发订阅事件
-func (s *SubscriptionService) Do(eventId int, b SubscribeData) {
-	go func(eventId int, b SubscribeData) {
-		subscriptions := s.ListSubscriptionUserId(eventId, b.SubscribeId)
+func (s *SubscriptionService) Do(ctx context.Context, eventId int, b SubscribeData) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
+		subscriptions := s.ListSubscriptionUserId(ctx, eventId, b.SubscribeId)
 		if len(subscriptions) > 0 {
 			var userIds []int
 			for i := range subscriptions {
 				userIds = append(userIds, subscriptions[i].SubscriberId)
 			}
 			sendId := subscriptions[0].SendId
-			send(userIds, eventId, constant.NOTICE, sendId, b, "")
+			send(ctx, userIds, eventId, constant.NOTICE, sendId, b, "")
 		}
 
-	}(eventId, b)
+	})
 }
 
-func (s *SubscriptionService) DoWithMessageTempl(eventId int, b SubscribeData, messageTempl string) {
-	go func(eventId int, b SubscribeData) {
-		subscriptions := s.ListSubscriptionUserId(eventId, b.SubscribeId)
+func (s *SubscriptionService) DoWithMessageTempl(ctx context.Context, eventId int, b SubscribeData, messageTempl string) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
+		subscriptions := s.ListSubscriptionUserId(ctx, eventId, b.SubscribeId)
 		if len(subscriptions) > 0 {
 			var userIds []int
 			for i := range subscriptions {
 				userIds = append(userIds, subscriptions[i].SubscriberId)
 			}
 			sendId := subscriptions[0].SendId
-			send(userIds, eventId, constant.NOTICE, sendId, b, messageTempl)
+			send(ctx, userIds, eventId, constant.NOTICE, sendId, b, messageTempl)
 		}
 
-	}(eventId, b)
+	})
 }
 
 // 触发 @ 事件
-func (s *SubscriptionService) ConstantAtSend(eventId, triggerId int, content string, b SubscribeData) {
-	go func(eventId, triggerId int, content string, b SubscribeData) {
+func (s *SubscriptionService) ConstantAtSend(ctx context.Context, eventId, triggerId int, content string, b SubscribeData) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
 
-		ids := findAtUser(content)
-		send(ids, eventId, constant.MENTION, triggerId, b, "")
-	}(eventId, triggerId, content, b)
+		ids := findAtUser(ctx, content)
+		send(ctx, ids, eventId, constant.MENTION, triggerId, b, "")
+	})
 
 }
 
 // 触发 @ 事件，直接通知用户
-func (s *SubscriptionService) NoticeUsers(eventId, triggerId int, userIds []int, b SubscribeData) {
-	go func(eventId, triggerId int, userIds []int, b SubscribeData) {
-		send(userIds, eventId, constant.MENTION, triggerId, b, "")
-	}(eventId, triggerId, userIds, b)
+func (s *SubscriptionService) NoticeUsers(ctx context.Context, eventId, triggerId int, userIds []int, b SubscribeData) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
+		send(ctx, userIds, eventId, constant.MENTION, triggerId, b, "")
+	})
 
 }
 
-func (s *SubscriptionService) Send(eventId, eventType, fromId, toId int, b SubscribeData) {
-	go func(eventId, fromId, toId int, b SubscribeData) {
-		send([]int{toId}, eventId, eventType, fromId, b, "")
-	}(eventId, fromId, toId, b)
+func (s *SubscriptionService) Send(ctx context.Context, eventId, eventType, fromId, toId int, b SubscribeData) {
+	lifecycle.Go(ctx, func(ctx context.Context) {
+		send(ctx, []int{toId}, eventId, eventType, fromId, b, "")
+	})
 }
 
-func findAtUser(content string) []int {
+func findAtUser(ctx context.Context, content string) []int {
 
 	// 使用正则表达式匹配文本中的 @ 符号
 	re := regexp.MustCompile(`@\((.*?)\)\[(.*?)\]`)
@@ -145,7 +160,7 @@ func findAtUser(content string) []int {
 	for _, match := range matches {
 		id, err := strconv.Atoi(match[2])
 		if err != nil {
-			log.Warnf("解析 @ 失败,err: %s", err.Error())
+			log.Ctx(ctx).Warnf("解析 @ 失败,err: %s", err.Error())
 			continue
 		}
 		ids.Add(id)
@@ -153,29 +168,67 @@ func findAtUser(content string) []int {
 	return ids.ToSlice()
 }
 
-func send(userIds []int, eventId, eventType, sendId int, b SubscribeData, messageTemp string) {
+func send(ctx context.Context, userIds []int, eventId, eventType, sendId int, b SubscribeData, messageTemp string) {
 	if len(userIds) == 0 {
 		return
 	}
 	var m MessageService
 
 	var users []model.Users
-	model.User().Where("id in ?", userIds).Select("account", "id", "subscribe").Find(&users)
+	model.User(ctx).Where("id in ?", userIds).Select("account", "id", "subscribe", "locale").Find(&users)
+
+	if messageTemp == "" {
+		messageTemp = messageDao.GetMessageTemplate(ctx, eventId)
+	}
+	// 按接收人的语言分别渲染模板,后台配置的模板没有翻译时原样使用
+	for locale, group := range groupByLocale(users) {
+		var ids []int
+		var emails []string
+		for i := range group {
+			ids = append(ids, group[i].ID)
+			if group[i].Subscribe == 2 {
+				emails = append(emails, group[i].Account)
+			}
+		}
+		msg := m.GetMsg(ctx, i18n.Tr(locale, messageTemp), b)
+		m.SendMessages(ctx, sendId, eventType, eventId, b.CurrentBusinessId, ids, msg)
+		email.Send(emails, msg, config.GetInstance().SiteConfig.Name)
+	}
+	metrics.Notified(eventId, len(users))
+}
 
-	var ids []int
-	var emails []string
+// groupByLocale 按接收人的语言分组,未设置语言的使用默认语言
+func groupByLocale(users []model.Users) map[string][]model.Users {
+	groups := make(map[string][]model.Users)
 	for i := range users {
-		ids = append(ids, users[i].ID)
-		if users[i].Subscribe == 2 {
-			emails = append(emails, users[i].Account)
+		locale := users[i].Locale
+		if locale == "" {
+			locale = i18n.Default
 		}
+		groups[locale] = append(groups[locale], users[i])
 	}
-	if messageTemp == "" {
-		messageTemp = messageDao.GetMessageTemplate(eventId)
+	return groups
+}
+
+// sendToUsers 站内信和邮件按接收人的语言发送
+func sendToUsers(ctx context.Context, userId, eventId, messageType, subscribeId int, userMap map[int]model.Users, message i18n.Message) {
+	users := make([]model.Users, 0, len(userMap))
+	for _, v := range userMap {
+		users = append(users, v)
 	}
-	msg := m.GetMsg(messageTemp, b)
-	m.SendMessages(sendId, eventType, eventId, b.CurrentBusinessId, ids, msg)
-	email.Send(emails, msg, "技术鸭社区")
+	var m MessageService
+	for locale, group := range groupByLocale(users) {
+		var ids []int
+		var emails []string
+		for i := range group {
+			ids = append(ids, group[i].ID)
+			emails = append(emails, group[i].Account)
+		}
+		content := message.In(locale)
+		m.SendMessages(ctx, userId, messageType, eventId, subscribeId, ids, content)
+		email.Send(emails, content, config.GetInstance().SiteConfig.Name)
+	}
+	metrics.Notified(eventId, len(users))
 }
 
 /*
@@ -184,11 +237,11 @@ userId： 发送者
 eventId：事件
 messageType：消息类型
 subscribeId：业务id
-message：消息
+message：消息,按接收人的语言翻译
 */
-func (s *SubscriptionService) SendMsg(userId, eventId, messageType, subscribeId int, message string) {
+func (s *SubscriptionService) SendMsg(ctx context.Context, userId, eventId, messageType, subscribeId int, message i18n.Message) {
 	// 查出所有订阅人
-	subscriptions := s.ListSubscriptionUserId(eventId, subscribeId)
+	subscriptions := s.ListSubscriptionUserId(ctx, eventId, subscribeId)
 	if len(subscriptions) == 0 {
 		return
 	}
@@ -197,27 +250,10 @@ func (s *SubscriptionService) SendMsg(userId, eventId, messageType, subscribeId
 		userIds = append(userIds, subscription.SubscriberId)
 	}
 	var userS UserService
-	userMap := userS.ListByIdsToMap(userIds)
-	var emails []string
-	for _, v := range userMap {
-		emails = append(emails, v.Account)
-	}
-	var m MessageService
-
-	m.SendMessages(userId, messageType, eventId, subscribeId, userIds, message)
-	email.Send(emails, message, "技术鸭社区")
+	sendToUsers(ctx, userId, eventId, messageType, subscribeId, userS.ListByIdsToMap(ctx, userIds), message)
 }
 
-func (s *SubscriptionService) SendMsgByToIds(userId, eventId, messageType, subscribeId int, toUserIds []int, message string) {
-
+func (s *SubscriptionService) SendMsgByToIds(ctx context.Context, userId, eventId, messageType, subscribeId int, toUserIds []int, message i18n.Message) {
 	var userS UserService
-	userMap := userS.ListByIdsToMap(toUserIds)
-	var emails []string
-	for _, v := range userMap {
-		emails = append(emails, v.Account)
-	}
-	var m MessageService
-
-	m.SendMessages(userId, messageType, eventId, subscribeId, toUserIds, message)
-	email.Send(emails, message, "技术鸭社区")
+	sendToUsers(ctx, userId, eventId, messageType, subscribeId, userS.ListByIdsToMap(ctx, toUserIds), message)
 }
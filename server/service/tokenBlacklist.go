@@ -1,42 +1,45 @@
 package services
 
 import (
+	"context"
 	"strconv"
 	"xhyovo.cn/community/pkg/cache"
 	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/log"
 )
 
 type BlacklistService struct {
 }
 
 // token 黑名单，如果在 ttl 期间超过 x 次，则将该用户永久封禁小黑屋
-func (*BlacklistService) Add(userId int, token string) {
+func (*BlacklistService) Add(ctx context.Context, userId int, token string) {
 	cache := cache.GetInstance()
-	cache.Set(constant.BLACK_LIST+strconv.Itoa(userId), 1, constant.Token_TTl)
+	if err := cache.Set(constant.BLACK_LIST+strconv.Itoa(userId), "1", constant.Token_TTl); err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 加入黑名单失败,err: %s", userId, err.Error())
+	}
 	key := constant.BLACK_LIST_COUNT + strconv.Itoa(userId)
-	v, b := cache.Get(key)
-	if !b {
-		cache.Set(key, 1, constant.Token_TTl)
-	} else {
-		// 如果缓存中有该键，将其转换为int并加1
-		intValue, _ := v.(int)
-		intValue = intValue + 1
-		cache.Set(key, intValue, constant.Token_TTl)
-		// 超过 5 则永久关闭小黑屋 todo 先写死
-		if intValue > 5 {
-			var userService UserService
-			userService.BanByUserId(userId)
-
-		}
+	count, err := cache.Incr(key, constant.Token_TTl)
+	if err != nil {
+		log.Ctx(ctx).Warnf("用户id: %d 黑名单计数失败,err: %s", userId, err.Error())
+		return
+	}
+	// 超过 5 则永久关闭小黑屋 todo 先写死
+	if count > 5 {
+		var userService UserService
+		userService.BanByUserId(ctx, userId)
 	}
 }
 
-func (*BlacklistService) AddBlackByToken(token string) {
-	cache := cache.GetInstance()
-	cache.Set(constant.BLACK_LIST+token, 1, constant.Token_TTl)
+func (*BlacklistService) AddBlackByToken(ctx context.Context, token string) {
+	if err := cache.GetInstance().Set(constant.BLACK_LIST+token, "1", constant.Token_TTl); err != nil {
+		log.Ctx(ctx).Warnf("token 加入黑名单失败,err: %s", err.Error())
+	}
 }
 
-func (*BlacklistService) ExistToken(token string) bool {
-	_, b := cache.GetInstance().Get(constant.BLACK_LIST + token)
+func (*BlacklistService) ExistToken(ctx context.Context, token string) bool {
+	_, b, err := cache.GetInstance().Get(constant.BLACK_LIST + token)
+	if err != nil {
+		log.Ctx(ctx).Warnf("查询 token 黑名单失败,err: %s", err.Error())
+	}
 	return b
 }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"strings"
 	"xhyovo.cn/community/server/model"
 )
@@ -8,8 +9,8 @@ import (
 type TypeService struct {
 }
 
-func (s *TypeService) List(parentId int) []model.Types {
-	types := typeDao.List(parentId)
+func (s *TypeService) List(ctx context.Context, parentId int) []model.Types {
+	types := typeDao.List(ctx, parentId)
 	for i := range types {
 		typeObject := types[i]
 		typeObject.ArticleStates = strings.Split(typeObject.ArticleState, ",")
@@ -18,8 +19,8 @@ func (s *TypeService) List(parentId int) []model.Types {
 	return types
 }
 
-func (s *TypeService) PageTypes(page, limit int) (types []model.Types, count int64) {
-	model.Type().Limit(limit).Offset((page - 1) * limit).Find(&types)
+func (s *TypeService) PageTypes(ctx context.Context, page, limit int) (types []model.Types, count int64) {
+	model.Type(ctx).Limit(limit).Offset((page - 1) * limit).Find(&types)
 	if len(types) == 0 {
 		return make([]model.Types, 0), 0
 	}
@@ -40,45 +41,45 @@ func (s *TypeService) PageTypes(page, limit int) (types []model.Types, count int
 			}
 		}
 	}
-	model.Type().Where("parent_id = 0").Count(&count)
+	model.Type(ctx).Where("parent_id = 0").Count(&count)
 	return parentIds, count
 }
 
-func (s *TypeService) Save(types *model.Types) (int, error) {
-	return typeDao.Save(types)
+func (s *TypeService) Save(ctx context.Context, types *model.Types) (int, error) {
+	return typeDao.Save(ctx, types)
 }
 
-func (s *TypeService) Update(types *model.Types) error {
-	return typeDao.Update(types)
+func (s *TypeService) Update(ctx context.Context, types *model.Types) error {
+	return typeDao.Update(ctx, types)
 }
 
-func (s *TypeService) Delete(id int) error {
-	return typeDao.Delete(id)
+func (s *TypeService) Delete(ctx context.Context, id int) error {
+	return typeDao.Delete(ctx, id)
 }
 
-func (s *TypeService) GetById(id int) (types model.Types) {
+func (s *TypeService) GetById(ctx context.Context, id int) (types model.Types) {
 
-	model.Type().Where("id = ?", id).Find(&types)
+	model.Type(ctx).Where("id = ?", id).Find(&types)
 	return
 }
 
-func (s *TypeService) Exist(id int) bool {
+func (s *TypeService) Exist(ctx context.Context, id int) bool {
 	var c int64
-	model.Type().Where("id = ?", id).Count(&c)
+	model.Type(ctx).Where("id = ?", id).Count(&c)
 	return c == 1
 }
 
-func (s *TypeService) ListParentTypes() []model.Types {
+func (s *TypeService) ListParentTypes(ctx context.Context) []model.Types {
 	var types []model.Types
-	model.Type().Where("parent_id = 0").Find(&types)
+	model.Type(ctx).Where("parent_id = 0").Find(&types)
 	return types
 
 }
 
-func (s *TypeService) ListByIdToMap(ids []int) (m map[int]string) {
+func (s *TypeService) ListByIdToMap(ctx context.Context, ids []int) (m map[int]string) {
 	m = make(map[int]string)
 	var types []model.Types
-	model.Type().Where("id in ?", ids).Select("id", "title").Find(&types)
+	model.Type(ctx).Where("id in ?", ids).Select("id", "title").Find(&types)
 	for i := range types {
 		m[types[i].ID] = types[i].Title
 	}
@@ -86,8 +87,15 @@ func (s *TypeService) ListByIdToMap(ids []int) (m map[int]string) {
 	return
 }
 
-func (s *TypeService) GetByParentId(id int) (types model.Types) {
+func (s *TypeService) GetByParentId(ctx context.Context, id int) (types model.Types) {
 
-	model.Type().Where("parent_id = ?", id).Find(&types)
+	model.Type(ctx).Where("parent_id = ?", id).Find(&types)
+	return
+}
+
+// PublicIds 公开的二级分类,一级分类公开时其下的二级分类都公开
+func (s *TypeService) PublicIds(ctx context.Context) (ids []int) {
+	parents := model.Type(ctx).Select("id").Where("parent_id = 0 and public = ?", true)
+	model.Type(ctx).Where("parent_id != 0 and (public = ? or parent_id in (?))", true, parents).Pluck("id", &ids)
 	return
 }
@@ -1,11 +1,13 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"math/rand"
 	"time"
 	"xhyovo.cn/community/pkg/cache"
 	"xhyovo.cn/community/pkg/constant"
+	"xhyovo.cn/community/pkg/errs"
+	"xhyovo.cn/community/pkg/i18n"
 	"xhyovo.cn/community/server/dao"
 
 	"golang.org/x/crypto/bcrypt"
@@ -20,16 +22,16 @@ import (
 type UserService struct {
 }
 
-func (s UserService) DeleteUser(id int) {
+func (s UserService) DeleteUser(ctx context.Context, id int) {
 	var user model.Users
-	model.User().Where("id = ?", id).Find(&user)
-	mysql.GetInstance().Where("id = ?", id).Delete(&model.Users{})
-	mysql.GetInstance().Delete(&model.InviteCodes{}, user.InviteCode)
+	model.User(ctx).Where("id = ?", id).Find(&user)
+	mysql.GetInstance().WithContext(ctx).Where("id = ?", id).Delete(&model.Users{})
+	mysql.GetInstance().WithContext(ctx).Delete(&model.InviteCodes{}, user.InviteCode)
 
 }
 
-func (s UserService) ActiveUsers(page, limit int) (users []*model.UserSimple, count int64) {
-	tx := model.User().Select("users.id", "users.name", "users.avatar,users.desc").Joins("left join articles a ON users.id = a.user_id").
+func (s UserService) ActiveUsers(ctx context.Context, page, limit int) (users []*model.UserSimple, count int64) {
+	tx := model.User(ctx).Select("users.id", "users.name", "users.avatar,users.desc").Joins("left join articles a ON users.id = a.user_id").
 		Group("users.id, users.name").
 		Having("COUNT(a.id) > 0").
 		Order("COUNT(a.id) DESC")
@@ -41,56 +43,34 @@ func (s UserService) ActiveUsers(page, limit int) (users []*model.UserSimple, co
 	return
 }
 
-func (s UserService) IsAdmin(userId int) (bool, error) {
-
-	query := mysql.GetInstance().Table("users as u").Select("m.name").
-		Joins("join invite_codes as inv on u.invite_code = inv.code").
-		Joins("join member_infos as m on m.id = inv.member_id").
-		Where("u.id = ? and u.deleted_at is NULL", userId)
-	rows, err := query.Rows()
-	if err != nil {
-		return false, err
-	}
-	defer rows.Close()
-	var name string
-	if rows.Next() {
-		rows.Scan(&name)
-		if name == "admin" {
-			return true, nil
-		}
-	}
-	defer rows.Close()
-	return false, nil
-}
-
-func (s UserService) ListUsers(name string) (users []model.Users) {
-	model.User().Where("name like ?", "%"+name+"%").Select("name", "id").Limit(10).Find(&users)
+func (s UserService) ListUsers(ctx context.Context, name string) (users []model.Users) {
+	model.User(ctx).Where(mysql.Like("name"), mysql.Contains(name)).Select("name", "id").Limit(10).Find(&users)
 	return
 }
 
 // get user information
-func (*UserService) GetUserById(id int) *model.Users {
+func (*UserService) GetUserById(ctx context.Context, id int) *model.Users {
 
-	user := userDao.QueryUser(&model.Users{ID: id})
+	user := userDao.QueryUser(ctx, &model.Users{ID: id})
 	user.InviteCode = ""
 	return user
 }
 
 // get user information
-func (*UserService) GetUserSimpleById(id int) *model.UserSimple {
-	user, _ := userDao.QueryUserSimple(&model.Users{ID: id})
+func (*UserService) GetUserSimpleById(ctx context.Context, id int) *model.UserSimple {
+	user, _ := userDao.QueryUserSimple(ctx, &model.Users{ID: id})
 
 	return &user
 }
 
 // update user information
-func (*UserService) UpdateUser(user *model.Users) {
+func (*UserService) UpdateUser(ctx context.Context, user *model.Users) {
 
-	userDao.UpdateUser(user)
+	userDao.UpdateUser(ctx, user)
 
 }
 
-func (*UserService) ResetPwd(account string) bool {
+func (*UserService) ResetPwd(ctx context.Context, account string) bool {
 	characters := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()")
 	length := 10
 	password := make([]rune, length)
@@ -101,22 +81,29 @@ func (*UserService) ResetPwd(account string) bool {
 	}
 	newPwd := string(password)
 	newPwdHash, _ := GetPwd(newPwd)
-	tx := model.User().Where("account = ?", account).Limit(1).Update("password", newPwdHash)
+	tx := model.User(ctx).Where("account = ?", account).Limit(1).Update("password", newPwdHash)
 	if tx.RowsAffected == 0 {
 		return false
 	}
-	email.Send([]string{account}, "您的密码已重置为: "+newPwd, "重置密码")
+	// 使用用户自己的语言,而不是操作人的语言
+	var user model.Users
+	model.User(ctx).Where("account = ?", account).Select("locale").Limit(1).Find(&user)
+	locale := user.Locale
+	if locale == "" {
+		locale = i18n.Default
+	}
+	email.Send([]string{account}, i18n.Tr(locale, "您的密码已重置为: %s", newPwd), i18n.Tr(locale, "重置密码"))
 	return true
 }
 
-func (*UserService) ListByIdsSelectEmail(id ...int) []string {
-	return userDao.ListByIds(id...)
+func (*UserService) ListByIdsSelectEmail(ctx context.Context, id ...int) []string {
+	return userDao.ListByIds(ctx, id...)
 }
 
-func (s *UserService) ListByIdsToMap(ids []int) map[int]model.Users {
+func (s *UserService) ListByIdsToMap(ctx context.Context, ids []int) map[int]model.Users {
 
 	m := make(map[int]model.Users)
-	users := userDao.ListByIdsSelectIdName(ids)
+	users := userDao.ListByIdsSelectIdName(ctx, ids)
 	for i := range users {
 		user := users[i]
 		user.Password = ""
@@ -125,36 +112,36 @@ func (s *UserService) ListByIdsToMap(ids []int) map[int]model.Users {
 	return m
 }
 
-func Register(account, pswd, name, inviteCode string) (int, error) {
+func Register(ctx context.Context, account, pswd, name, inviteCode string) (int, error) {
 
 	if err := utils.NotBlank(account, pswd, name, inviteCode); err != nil {
 		return 0, err
 	}
 
 	// query codeDao
-	if !codeDao.Exist(inviteCode) {
-		return 0, errors.New("验证码不存在")
+	if !codeDao.Exist(ctx, inviteCode) {
+		return 0, errs.BadRequest.WithMsg("验证码不存在")
 	}
 
 	// 查询账户
-	user := userDao.QueryUser(&model.Users{Account: account})
+	user := userDao.QueryUser(ctx, &model.Users{Account: account})
 	if user.ID > 0 {
-		return 0, errors.New("账户已存在,换一个吧")
+		return 0, errs.Conflict.WithMsg("账户已存在,换一个吧")
 	}
 
-	user = userDao.QueryUser(&model.Users{Name: name})
+	user = userDao.QueryUser(ctx, &model.Users{Name: name})
 	if user.ID > 0 {
-		return 0, errors.New("用户昵称已存在,换一个吧")
+		return 0, errs.Conflict.WithMsg("用户昵称已存在,换一个吧")
 	}
 	pwd, err := GetPwd(pswd)
 	if err != nil {
 		return 0, err
 	}
 	// 保存用户
-	id := userDao.CreateUser(account, name, string(pwd), inviteCode)
+	id := userDao.CreateUser(ctx, account, name, string(pwd), inviteCode)
 	// 修改code状态
 	var c CodeService
-	c.SetState(inviteCode)
+	c.SetState(ctx, inviteCode)
 
 	// 自动订阅 xhyovo
 	var subscription model.Subscriptions
@@ -162,21 +149,21 @@ func Register(account, pswd, name, inviteCode string) (int, error) {
 	subscription.EventId = event.UserFollowingEvent
 	subscription.BusinessId = 13
 	var su SubscriptionService
-	su.Subscribe(&subscription)
+	su.Subscribe(ctx, &subscription)
 
 	// 自动订阅会议
 	var subscription2 model.Subscriptions
 	subscription2.SubscriberId = id
 	subscription2.EventId = event.Meeting
 	subscription2.BusinessId = constant.MeetingId
-	su.Subscribe(&subscription2)
+	su.Subscribe(ctx, &subscription2)
 
 	// 生成账单
 	var mS = MemberInfoService{}
 
 	// 查出邀请码
-	codeObject := c.GetByCode(inviteCode)
-	member := mS.GetById(codeObject.MemberId)
+	codeObject := c.GetByCode(ctx, inviteCode)
+	member := mS.GetById(ctx, codeObject.MemberId)
 	order := model.Orders{
 		InviteCode:      inviteCode,
 		Price:           member.Money,
@@ -185,7 +172,7 @@ func Register(account, pswd, name, inviteCode string) (int, error) {
 		Creator:         codeObject.Creator,
 	}
 	orderDao := dao.OrderDao{}
-	orderDao.Save(order)
+	orderDao.Save(ctx, order)
 
 	return id, nil
 }
@@ -199,8 +186,8 @@ type UserMenu struct {
 	Children   []UserMenu             `json:"children,omitempty"`
 }
 
-func (t *UserService) GetUserMenu() []*UserMenu {
-	rootMenu := typeDao.List(0)
+func (t *UserService) GetUserMenu(ctx context.Context) []*UserMenu {
+	rootMenu := typeDao.List(ctx, 0)
 	parentIds := make([]int, len(rootMenu))
 	userMenu := make(map[int]*UserMenu)
 	for i, item := range rootMenu {
@@ -223,7 +210,7 @@ func (t *UserService) GetUserMenu() []*UserMenu {
 		}
 	}
 	children := []model.Types{}
-	model.Type().Where("parent_id in (?)", parentIds).Find(&children)
+	model.Type(ctx).Where("parent_id in (?)", parentIds).Find(&children)
 	for _, item := range children {
 		um := userMenu[int(item.ParentId)]
 		um.Children = append(um.Children, UserMenu{
@@ -245,22 +232,22 @@ func (t *UserService) GetUserMenu() []*UserMenu {
 	return result
 }
 
-func (s *UserService) CheckCodeUsed(code int) bool {
+func (s *UserService) CheckCodeUsed(ctx context.Context, code int) bool {
 	var count int64
-	model.User().Where("invite_code = ?", code).Count(&count)
+	model.User(ctx).Where("invite_code = ?", code).Count(&count)
 	return count == 1
 }
 
-func (s *UserService) PageUsers(p, limit int, condition model.UserSimple) (users []model.Users, count int64) {
-	tx := model.User().Where("account like ?", condition.Account)
+func (s *UserService) PageUsers(ctx context.Context, p, limit int, condition model.UserSimple) (users []model.Users, count int64) {
+	tx := model.User(ctx).Where("account like ?", condition.Account)
 	tx.Count(&count)
 	tx.Offset((p - 1) * limit).Limit(limit).Find(&users)
 	return users, count
 }
 
-func (s *UserService) ListByNameSelectEmailAndId(usernames []string) (emails []string, id []int) {
+func (s *UserService) ListByNameSelectEmailAndId(ctx context.Context, usernames []string) (emails []string, id []int) {
 	var users []model.Users
-	model.User().Where("name in ? ", usernames).Select("account", "id").Find(&users)
+	model.User(ctx).Where("name in ? ", usernames).Select("account", "id").Find(&users)
 
 	for i := range users {
 		u := users[i]
@@ -271,23 +258,23 @@ func (s *UserService) ListByNameSelectEmailAndId(usernames []string) (emails []s
 	return emails, id
 }
 
-func (s *UserService) ListBySelect(user model.Users) (users []model.Users) {
-	model.User().Where(user).Find(&users)
+func (s *UserService) ListBySelect(ctx context.Context, user model.Users) (users []model.Users) {
+	model.User(ctx).Where(user).Find(&users)
 	return
 }
 
-func (s *UserService) Statistics(userId, types int) (m map[string]interface{}) {
+func (s *UserService) Statistics(ctx context.Context, userId, types int) (m map[string]interface{}) {
 	m = make(map[string]interface{})
 	var articleS ArticleService
 	// 获取被点赞次数,获取用户发布的所有文章
 	var articleCount int64
 	if types == 1 {
-		articleCount = articleS.PublishArticleCount(userId)
+		articleCount = articleS.PublishArticleCount(ctx, userId)
 	} else {
-		articleCount = articleS.QAArticleCount(userId)
+		articleCount = articleS.QAArticleCount(ctx, userId)
 	}
-	ids := articleS.PublishArticlesSelectId(userId)
-	likeCount := articleS.ArticlesLikeCount(ids)
+	ids := articleS.PublishArticlesSelectId(ctx, userId)
+	likeCount := articleS.ArticlesLikeCount(ctx, ids)
 	// 获取发布文章
 
 	m["articleCount"] = articleCount
@@ -295,25 +282,25 @@ func (s *UserService) Statistics(userId, types int) (m map[string]interface{}) {
 	return
 }
 
-func (s *UserService) SearchNameSelectId(name string) (ids []int) {
+func (s *UserService) SearchNameSelectId(ctx context.Context, name string) (ids []int) {
 
-	model.User().Where("name like ?", "%"+name+"%").Select("id").Find(&ids)
+	model.User(ctx).Where(mysql.Like("name"), mysql.Contains(name)).Select("id").Find(&ids)
 	return
 }
 
-func Login(login model.LoginForm) (*model.Users, error) {
+func Login(ctx context.Context, login model.LoginForm) (*model.Users, error) {
 	key := constant.LIMIT_LOGIN + login.Account
 	if !cache.CountLimit(key, 5, constant.TTL_LIMIT_lOGIN) {
-		return &model.Users{}, errors.New("操作次数过多,请稍后重试")
+		return &model.Users{}, errs.TooManyRequests.WithMsg("操作次数过多,请稍后重试")
 	}
 	var users []model.Users
-	model.User().Find(&users)
-	user := userDao.QueryUser(&model.Users{Account: login.Account})
+	model.User(ctx).Find(&users)
+	user := userDao.QueryUser(ctx, &model.Users{Account: login.Account})
 	if user.ID == 0 {
-		return &model.Users{}, errors.New("登录失败！账号不存在")
+		return &model.Users{}, errs.LoginFailed.WithMsg("登录失败！账号不存在")
 	}
 	if !ComparePswd(user.Password, login.Password) {
-		return &model.Users{}, errors.New("登录失败！密码错误")
+		return &model.Users{}, errs.LoginFailed.WithMsg("登录失败！密码错误")
 
 	}
 	return user, nil
@@ -332,38 +319,55 @@ func GetPwd(pwd string) ([]byte, error) {
 	return hash, err
 }
 
-func (s *UserService) ListBlackUser(p, limit int) ([]model.Users, int64) {
+func (s *UserService) ListBlackUser(ctx context.Context, p, limit int) ([]model.Users, int64) {
 	var users []model.Users
 	var count int64
-	tx := model.User().Where("state = 2").Select("id", "name", "account")
+	tx := model.User(ctx).Where("state = 2").Select("id", "name", "account")
 	tx.Offset((p - 1) * limit).Limit(limit).Find(&users)
 	tx.Count(&count)
 	return users, count
 }
 
 // 是否被拉黑
-func (s *UserService) IsBlack(userId int) bool {
+// GetAuthState 鉴权时使用,一次查询取出是否被封禁和用户设置的语言
+func (s *UserService) GetAuthState(ctx context.Context, userId int) (black bool, locale string) {
+	var user model.Users
+	model.User(ctx).Where("id = ?", userId).Select("state", "locale").Limit(1).Find(&user)
+	return user.State == 2, user.Locale
+}
+
+// UpdateLocale 修改用户的语言设置,为空时按请求头协商
+func (s *UserService) UpdateLocale(ctx context.Context, userId int, locale string) error {
+	if locale != "" {
+		if locale = i18n.Normalize(locale); locale == "" {
+			return errs.BadRequest.WithMsg("不支持的语言")
+		}
+	}
+	return model.User(ctx).Where("id = ?", userId).Update("locale", locale).Error
+}
+
+func (s *UserService) IsBlack(ctx context.Context, userId int) bool {
 	var count int64
-	model.User().Where("id = ? and state = 2", userId).Count(&count)
+	model.User(ctx).Where("id = ? and state = 2", userId).Count(&count)
 	return count == 1
 }
 
-func (s *UserService) BanByUserId(id int) {
-	model.User().Where("id = ?", id).Update("state", 2)
+func (s *UserService) BanByUserId(ctx context.Context, id int) {
+	model.User(ctx).Where("id = ?", id).Update("state", 2)
 }
 
 // ban 用户
-func (s *UserService) BanByUserAccount(account string) {
-	model.User().Where("account = ?", account).Update("state", 2)
+func (s *UserService) BanByUserAccount(ctx context.Context, account string) {
+	model.User(ctx).Where("account = ?", account).Update("state", 2)
 }
 
-func (s *UserService) UnBanByUserId(id int) {
-	model.User().Where("id = ?", id).Update("state", 1)
+func (s *UserService) UnBanByUserId(ctx context.Context, id int) {
+	model.User(ctx).Where("id = ?", id).Update("state", 1)
 }
 
-func (s UserService) ExistUserByAccount(account string) bool {
+func (s UserService) ExistUserByAccount(ctx context.Context, account string) bool {
 	var count int64
-	model.User().Where("account", account).Count(&count)
+	model.User(ctx).Where("account", account).Count(&count)
 	return count > 0
 
 }
@@ -1,14 +1,15 @@
 package services
 
 import (
+	"context"
 	"xhyovo.cn/community/server/model"
 )
 
 type UserTag struct {
 }
 
-func (*UserTag) Page(page, limit int) (userTags []model.UserTags, count int64) {
-	db := model.UserTag()
+func (*UserTag) Page(ctx context.Context, page, limit int) (userTags []model.UserTags, count int64) {
+	db := model.UserTag(ctx)
 	db.Count(&count)
 	if count == 0 {
 		return make([]model.UserTags, 0), 0
@@ -17,41 +18,41 @@ func (*UserTag) Page(page, limit int) (userTags []model.UserTags, count int64) {
 	return
 }
 
-func (*UserTag) Save(userTag model.UserTags) {
+func (*UserTag) Save(ctx context.Context, userTag model.UserTags) {
 	if userTag.ID != 0 {
-		model.UserTag().Where("id = ?", userTag.ID).Updates(&userTag)
+		model.UserTag(ctx).Where("id = ?", userTag.ID).Updates(&userTag)
 	} else {
-		model.UserTag().Create(&userTag)
+		model.UserTag(ctx).Create(&userTag)
 	}
 }
 
-func (*UserTag) DeleteById(id int) {
-	model.UserTag().Where("id = ?", id).Delete(&model.UserTags{})
-	model.UserTagRelation().Where("user_tag_id = ?", id).Delete(&model.UserTagRelations{})
+func (*UserTag) DeleteById(ctx context.Context, id int) {
+	model.UserTag(ctx).Where("id = ?", id).Delete(&model.UserTags{})
+	model.UserTagRelation(ctx).Where("user_tag_id = ?", id).Delete(&model.UserTagRelations{})
 }
 
-func (*UserTag) AssignUserLabel(userId int, tags []int) (okIds []int) {
+func (*UserTag) AssignUserLabel(ctx context.Context, userId int, tags []int) (okIds []int) {
 
 	// 删除用户的标签
-	model.UserTagRelation().Where("user_id = ?", userId).Delete(&model.UserTagRelations{})
+	model.UserTagRelation(ctx).Where("user_id = ?", userId).Delete(&model.UserTagRelations{})
 	if len(tags) != 0 {
 		var userTags []model.UserTagRelations
 		for i := range tags {
 			userTags = append(userTags, model.UserTagRelations{UserId: userId, UserTagId: tags[i]})
 		}
 		// 分配标签
-		model.UserTagRelation().Create(userTags)
-		model.UserTag().Where("id in ?", tags).Select("id").Find(&okIds)
+		model.UserTagRelation(ctx).Create(userTags)
+		model.UserTag(ctx).Where("id in ?", tags).Select("id").Find(&okIds)
 	}
 	return
 }
 
-func (*UserTag) GetTagsByUserId(userId int) (tagNames []model.UserTags) {
+func (*UserTag) GetTagsByUserId(ctx context.Context, userId int) (tagNames []model.UserTags) {
 	var tagsIds []int
-	model.UserTagRelation().Where("user_id = ?", userId).Select("user_tag_id").Find(&tagsIds)
+	model.UserTagRelation(ctx).Where("user_id = ?", userId).Select("user_tag_id").Find(&tagsIds)
 	if len(tagsIds) == 0 {
 		return make([]model.UserTags, 0)
 	}
-	model.UserTag().Where("id in ?", tagsIds).Find(&tagNames)
+	model.UserTag(ctx).Where("id in ?", tagsIds).Find(&tagNames)
 	return tagNames
 }